@@ -88,7 +88,7 @@ func (h *Handler) AdminLogin(c *gin.Context) {
 // 	// 	handleResponseLog(c, h.log, "error email does not exist" + loginReq.MobilePhone, http.StatusBadRequest, err.Error())
 // 	// }
 
-// 	err := h.service.AdminAuth().AdminRegister(c.Request.Context(), loginReq)
+// 	err := h.service.AdminAuth().AdminRegister(c.Request.Context(), loginReq, requestLocale(c))
 // 	if err != nil {
 // 		handleResponseLog(c, h.log, "", http.StatusInternalServerError, err)
 // 		return
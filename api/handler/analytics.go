@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"food/api/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	// analyticsDefaultDays is the report range when from is not given.
+	analyticsDefaultDays = 30
+	// analyticsMaxDays caps the report range.
+	analyticsMaxDays = 366
+)
+
+// @ID 			analytics_revenue
+// @Router 		/food/api/v1/admin/analytics/revenue [GET]
+// @Summary 	Revenue Report
+// @Description Order count, revenue, average basket and cancellation rate per day, week or month, optionally per branch. Revenue and the average basket leave out cancelled orders.
+// @Tags 		analytics
+// @Accept 		json
+// @Produce 	json
+// @Param 		from      query string false "First day, 2006-01-02 (default 30 days ago)"
+// @Param 		to        query string false "Last day, 2006-01-02 (default today)"
+// @Param 		branch_id query string false "Only orders of this branch"
+// @Param 		group_by  query string false "day, week or month (default day)"
+// @Param 		by_branch query bool   false "Split every period by branch"
+// @Success 	200 {object} models.RevenueReport
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetRevenueReport(c *gin.Context) {
+	req, msg := analyticsRequest(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+
+	report, err := h.storage.Analytics().Revenue(c.Request.Context(), req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting revenue report")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// @ID 			analytics_top_products
+// @Router 		/food/api/v1/admin/analytics/top-products [GET]
+// @Summary 	Top Products Report
+// @Description Best selling products of orders that were not cancelled, and the best selling combos ranked the same way. The limit applies to each list.
+// @Tags 		analytics
+// @Accept 		json
+// @Produce 	json
+// @Param 		from      query string false "First day, 2006-01-02 (default 30 days ago)"
+// @Param 		to        query string false "Last day, 2006-01-02 (default today)"
+// @Param 		branch_id query string false "Only orders of this branch"
+// @Param 		sort_by   query string false "quantity or revenue (default quantity)"
+// @Param 		limit     query int    false "Number of products and of combos, at most 100 (default 10)"
+// @Success 	200 {object} models.TopProductsReport
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetTopProductsReport(c *gin.Context) {
+	req, msg := analyticsRequest(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+
+	req.SortBy = c.DefaultQuery("sort_by", "quantity")
+	if req.SortBy != "quantity" && req.SortBy != "revenue" {
+		c.JSON(http.StatusBadRequest, "sort_by must be quantity or revenue")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 || limit > 100 {
+		c.JSON(http.StatusBadRequest, "limit must be between 1 and 100")
+		return
+	}
+	req.Limit = limit
+
+	report, err := h.storage.Analytics().TopProducts(c.Request.Context(), req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting top products report")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// @ID 			analytics_branches
+// @Router 		/food/api/v1/admin/analytics/branches [GET]
+// @Summary 	Branch Performance Report
+// @Description Orders, revenue, average basket, cancellation rate and average delivery time of every branch, best revenue first
+// @Tags 		analytics
+// @Accept 		json
+// @Produce 	json
+// @Param 		from      query string false "First day, 2006-01-02 (default 30 days ago)"
+// @Param 		to        query string false "Last day, 2006-01-02 (default today)"
+// @Param 		branch_id query string false "Only this branch"
+// @Success 	200 {object} models.BranchPerformanceReport
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetBranchPerformanceReport(c *gin.Context) {
+	req, msg := analyticsRequest(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+
+	report, err := h.storage.Analytics().Branches(c.Request.Context(), req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting branch performance report")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// @ID 			analytics_hourly
+// @Router 		/food/api/v1/admin/analytics/hourly [GET]
+// @Summary 	Orders By Hour Report
+// @Description Orders that were not cancelled and their revenue by the hour of day they were placed, always 24 hours
+// @Tags 		analytics
+// @Accept 		json
+// @Produce 	json
+// @Param 		from      query string false "First day, 2006-01-02 (default 30 days ago)"
+// @Param 		to        query string false "Last day, 2006-01-02 (default today)"
+// @Param 		branch_id query string false "Only orders of this branch"
+// @Success 	200 {object} models.HourlyReport
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetHourlyReport(c *gin.Context) {
+	req, msg := analyticsRequest(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+
+	report, err := h.storage.Analytics().Hourly(c.Request.Context(), req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting hourly report")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// @ID 			analytics_customers
+// @Router 		/food/api/v1/admin/analytics/customers [GET]
+// @Summary 	New And Returning Customers Report
+// @Description Customers who ordered per day, week or month, split into those placing their first order and those who ordered before
+// @Tags 		analytics
+// @Accept 		json
+// @Produce 	json
+// @Param 		from      query string false "First day, 2006-01-02 (default 30 days ago)"
+// @Param 		to        query string false "Last day, 2006-01-02 (default today)"
+// @Param 		branch_id query string false "Only orders of this branch"
+// @Param 		group_by  query string false "day, week or month (default day)"
+// @Success 	200 {object} models.CustomerReport
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetCustomerReport(c *gin.Context) {
+	req, msg := analyticsRequest(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+
+	report, err := h.storage.Analytics().Customers(c.Request.Context(), req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting customer report")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// analyticsRequest reads the filters shared by the reports. It returns a
+// message for the client when they are not valid.
+func analyticsRequest(c *gin.Context) (*models.AnalyticsRequest, string) {
+	from, to, msg := dateRangeQuery(c)
+	if msg != "" {
+		return nil, msg
+	}
+
+	req := &models.AnalyticsRequest{
+		From:     from,
+		To:       to,
+		BranchId: c.Query("branch_id"),
+		GroupBy:  c.DefaultQuery("group_by", "day"),
+		ByBranch: c.Query("by_branch") == "true",
+	}
+
+	if req.BranchId != "" {
+		if err := uuid.Validate(req.BranchId); err != nil {
+			return nil, "please enter a valid branch_id"
+		}
+	}
+	switch req.GroupBy {
+	case "day", "week", "month":
+	default:
+		return nil, "group_by must be day, week or month"
+	}
+
+	return req, ""
+}
+
+// dateRangeQuery reads the from and to days of a report. to defaults to
+// today and from to analyticsDefaultDays before it.
+func dateRangeQuery(c *gin.Context) (string, string, string) {
+	const layout = "2006-01-02"
+
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err != nil {
+			return "", "", "to must look like " + layout
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -(analyticsDefaultDays - 1))
+	if value := c.Query("from"); value != "" {
+		t, err := time.ParseInLocation(layout, value, time.Local)
+		if err != nil {
+			return "", "", "from must look like " + layout
+		}
+		from = t
+	}
+
+	if from.Format(layout) > to.Format(layout) {
+		return "", "", "from must not be after to"
+	}
+	if from.AddDate(0, 0, analyticsMaxDays).Format(layout) <= to.Format(layout) {
+		return "", "", "a report covers at most " + strconv.Itoa(analyticsMaxDays) + " days"
+	}
+
+	return from.Format(layout), to.Format(layout), ""
+}
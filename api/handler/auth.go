@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"food/api/models"
+	"food/pkg/i18n"
 	check "food/pkg/validation"
+	"food/service"
 
 	// check "food/pkg/validation"
 	"net/http"
@@ -71,7 +74,7 @@ func (h *Handler) UserRegister(c *gin.Context) {
 		return
 	}
 
-	err := h.service.Auth().UserRegister(c.Request.Context(), loginReq)
+	err := h.service.Auth().UserRegister(c.Request.Context(), loginReq, requestLocale(c))
 	if err != nil {
 		handleResponseLog(c, h.log, "error while sending sms code to "+loginReq.MobilePhone, http.StatusInternalServerError, err)
 		return
@@ -153,11 +156,15 @@ func (h *Handler) UserLoginByPhoneConfirm(c *gin.Context) {
 	resp, err := h.service.Auth().UserLoginByPhoneConfirm(c.Request.Context(), req)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
-		message := "INTERNAL_SERVER_ERROR"
+		message := i18n.Message(requestLocale(c), i18n.MsgSystemError)
 
-		if err.Error() == "OTP code not found or expired time" || err.Error() == "Incorrect OTP code" {
+		switch {
+		case errors.Is(err, service.ErrOtpNotFound):
 			statusCode = http.StatusUnauthorized
-			message = err.Error()
+			message = i18n.Message(requestLocale(c), i18n.MsgOtpExpired)
+		case errors.Is(err, service.ErrOtpIncorrect):
+			statusCode = http.StatusUnauthorized
+			message = i18n.Message(requestLocale(c), i18n.MsgOtpIncorrect)
 		}
 
 		h.log.Error("error in UserLoginByPhoneConfirm: " + err.Error())
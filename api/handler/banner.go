@@ -2,35 +2,67 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"food/api/models"
+	"food/storage"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 )
 
+// bannerTargetTypes are the deep-link targets a banner can open.
+var bannerTargetTypes = map[string]bool{
+	"product":   true,
+	"combo":     true,
+	"category":  true,
+	"promotion": true,
+}
+
 // @ID 			create_banner
 // @Router 		/food/api/v1/createbanner [POST]
 // @Summary 	Create Banner
-// @Description Create a new banner
+// @Description Creates a banner. target_type is product, combo, category or promotion; target_id is the id of the product, combo or category, or the promotion code. starts_at and ends_at are RFC 3339 times and may be left empty. An empty branch_ids shows the banner at every branch.
 // @Tags 		banner
 // @Accept 		json
 // @Produce 	json
 // @Param 		Banner body models.CreateBanner true "Banner"
-// @Success 	200 {object} models.Banner
+// @Success 	201 {object} models.Banner
 // @Response 	400 {object} Response{data=string} "Bad Request"
 // @Failure 	500 {object} Response{data=string} "Server error"
 func (h *Handler) CreateBanner(c *gin.Context) {
-	var banner models.Banner
+	var req models.CreateBanner
 
-	if err := c.ShouldBindJSON(&banner); err != nil {
+	if err := c.ShouldBindJSON(&req); err != nil {
 		h.log.Error(err.Error() + " : " + "error Banner Should Bind Json!")
 		c.JSON(http.StatusBadRequest, "Please, enter valid data!")
 		return
 	}
 
+	banner := models.Banner{
+		Title:      req.Title,
+		ImageUrl:   req.ImageUrl,
+		TargetType: req.TargetType,
+		TargetId:   req.TargetId,
+		SortOrder:  req.SortOrder,
+		IsActive:   req.IsActive == nil || *req.IsActive,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+		BranchIds:  req.BranchIds,
+	}
+	if msg := validateBanner(&banner); msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+
 	resp, err := h.storage.Banner().Create(c.Request.Context(), &banner)
+	if errors.Is(err, storage.ErrInvalidBranch) {
+		c.JSON(http.StatusBadRequest, "branch_ids contains an unknown branch")
+		return
+	}
 	if err != nil {
 		h.log.Error(err.Error() + ":" + "Error Banner Create")
 		c.JSON(http.StatusInternalServerError, "Server error!")
@@ -41,14 +73,114 @@ func (h *Handler) CreateBanner(c *gin.Context) {
 	c.JSON(http.StatusCreated, resp)
 }
 
+// @ID 			update_banner
+// @Router 		/food/api/v1/banners/{id} [PUT]
+// @Summary 	Update Banner
+// @Description Replaces a banner, including its branch targeting. Counters are kept.
+// @Tags 		banner
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Banner ID"
+// @Param 		Banner body models.UpdateBanner true "Banner"
+// @Success 	200 {object} models.Banner
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) UpdateBanner(c *gin.Context) {
+	var req models.UpdateBanner
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Banner Should Bind Json!")
+		c.JSON(http.StatusBadRequest, "Please, enter valid data!")
+		return
+	}
+
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating id")
+		c.JSON(http.StatusBadRequest, "please enter a valid id")
+		return
+	}
+
+	banner := models.Banner{
+		Id:         id,
+		Title:      req.Title,
+		ImageUrl:   req.ImageUrl,
+		TargetType: req.TargetType,
+		TargetId:   req.TargetId,
+		SortOrder:  req.SortOrder,
+		IsActive:   req.IsActive,
+		StartsAt:   req.StartsAt,
+		EndsAt:     req.EndsAt,
+		BranchIds:  req.BranchIds,
+	}
+	if msg := validateBanner(&banner); msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+
+	resp, err := h.storage.Banner().Update(c.Request.Context(), &banner)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, "Banner not found!")
+		return
+	}
+	if errors.Is(err, storage.ErrInvalidBranch) {
+		c.JSON(http.StatusBadRequest, "branch_ids contains an unknown branch")
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "Error Banner Update")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
+
+	h.log.Info("Banner updated successfully!")
+	c.JSON(http.StatusOK, resp)
+}
+
+// @ID 			get_banner
+// @Router 		/food/api/v1/banners/{id} [GET]
+// @Summary 	Get Banner by ID
+// @Description Retrieve a banner with its counters
+// @Tags 		banner
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Banner ID"
+// @Success 	200 {object} models.Banner
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetBannerByID(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating id")
+		c.JSON(http.StatusBadRequest, "please enter a valid id")
+		return
+	}
+
+	banner, err := h.storage.Banner().GetByID(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, "Banner not found!")
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "Error while getting banner by ID")
+		c.JSON(http.StatusInternalServerError, "Server Error")
+		return
+	}
+
+	h.log.Info("Banner retrieved successfully by ID")
+	c.JSON(http.StatusOK, banner)
+}
+
 // @ID 			get_all_banners
 // @Router 		/food/api/v1/getallbanners [GET]
 // @Summary 	Get All Banners
-// @Description Retrieve all banners
+// @Description Lists banners in display order. By default only active banners inside their campaign window are returned.
 // @Tags 		banner
 // @Accept 		json
 // @Produce 	json
-// @Param 		search query string false "Search banners by image_url"
+// @Param 		search query string false "Search banners by title"
+// @Param 		branch_id query string false "Only banners shown at this branch"
+// @Param 		include_inactive query bool false "Include inactive, scheduled and expired banners (admin)"
 // @Param 		page   query uint64 false "Page number"
 // @Param 		limit  query uint64 false "Limit number of results per page"
 // @Success 	200 {object} models.GetAllBannerResponse
@@ -58,10 +190,20 @@ func (h *Handler) GetAllBanners(c *gin.Context) {
 	var req = &models.GetAllBannerRequest{}
 
 	req.Search = c.Query("search")
+	req.BranchId = c.Query("branch_id")
+	req.IncludeInactive = c.Query("include_inactive") == "true"
+
+	if req.BranchId != "" {
+		if err := uuid.Validate(req.BranchId); err != nil {
+			h.log.Error(err.Error() + ":" + "error while validating branch id")
+			c.JSON(http.StatusBadRequest, "please enter a valid branch_id")
+			return
+		}
+	}
 
 	page, err := strconv.ParseUint(c.DefaultQuery("page", "1"), 10, 64)
-	if err != nil {
-		h.log.Error(err.Error() + ":" + "error while parsing page")
+	if err != nil || page == 0 {
+		h.log.Error("error while parsing page")
 		c.JSON(http.StatusBadRequest, "BadRequest at paging")
 		return
 	}
@@ -115,6 +257,10 @@ func (h *Handler) DeleteBanner(c *gin.Context) {
 	}
 
 	err = h.storage.Banner().Delete(context.Background(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, "Banner not found!")
+		return
+	}
 	if err != nil {
 		h.log.Error(err.Error() + ":" + "error while deleting banner")
 		c.JSON(http.StatusBadRequest, "please input valid data")
@@ -124,3 +270,90 @@ func (h *Handler) DeleteBanner(c *gin.Context) {
 	h.log.Info("Banner deleted successfully!")
 	c.JSON(http.StatusOK, id)
 }
+
+// @ID 			track_banners
+// @Router 		/food/api/v1/banners/track [POST]
+// @Summary 	Track Banner Events
+// @Description Counts one impression or click for each listed banner. Clients send impressions for the banners they displayed and a click when one is tapped.
+// @Tags 		banner
+// @Accept 		json
+// @Produce 	json
+// @Param 		event body models.TrackBannerRequest true "event is impression or click"
+// @Success 	200 {object} Response{data=int} "Number of banners counted"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) TrackBanners(c *gin.Context) {
+	var req models.TrackBannerRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Banner Track Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+	if req.Event != "impression" && req.Event != "click" {
+		c.JSON(http.StatusBadRequest, Response{Data: "event must be impression or click"})
+		return
+	}
+	if len(req.BannerIds) == 0 || len(req.BannerIds) > 50 {
+		c.JSON(http.StatusBadRequest, Response{Data: "banner_ids must list between 1 and 50 banners"})
+		return
+	}
+	for _, id := range req.BannerIds {
+		if err := uuid.Validate(id); err != nil {
+			c.JSON(http.StatusBadRequest, Response{Data: "please enter valid banner_ids"})
+			return
+		}
+	}
+
+	counted, err := h.storage.Banner().Track(c.Request.Context(), req.Event, req.BannerIds)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while tracking banners")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: counted})
+}
+
+// validateBanner returns a message for the client when the banner is not
+// valid, or an empty string.
+func validateBanner(banner *models.Banner) string {
+	if banner.ImageUrl == "" {
+		return "image_url is required!"
+	}
+	if banner.TargetType != "" || banner.TargetId != "" {
+		if !bannerTargetTypes[banner.TargetType] {
+			return "target_type must be product, combo, category or promotion"
+		}
+		if banner.TargetId == "" {
+			return "target_id is required with target_type"
+		}
+		if banner.TargetType != "promotion" {
+			if err := uuid.Validate(banner.TargetId); err != nil {
+				return "target_id must be a valid " + banner.TargetType + " id"
+			}
+		}
+	}
+
+	var window []time.Time
+	for _, value := range []string{banner.StartsAt, banner.EndsAt} {
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return "starts_at and ends_at must be RFC 3339 times"
+		}
+		window = append(window, t)
+	}
+	if banner.StartsAt != "" && banner.EndsAt != "" && !window[0].Before(window[1]) {
+		return "starts_at must be before ends_at"
+	}
+
+	for _, id := range banner.BranchIds {
+		if err := uuid.Validate(id); err != nil {
+			return "please enter valid branch_ids"
+		}
+	}
+	return ""
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"food/api/models"
+	"food/config"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -75,6 +76,21 @@ func (h *Handler) UpdateBranch(c *gin.Context) {
 	branch.Address = updateBranch.Address
 	branch.Latitude = updateBranch.Latitude
 	branch.Longitude = updateBranch.Longitude
+	if updateBranch.OpeningTime != "" {
+		branch.OpeningTime = updateBranch.OpeningTime
+	}
+	if updateBranch.ClosingTime != "" {
+		branch.ClosingTime = updateBranch.ClosingTime
+	}
+	if updateBranch.SlotMinutes > 0 {
+		branch.SlotMinutes = updateBranch.SlotMinutes
+	}
+	if updateBranch.SlotCapacity > 0 {
+		branch.SlotCapacity = updateBranch.SlotCapacity
+	}
+	if updateBranch.LeadMinutes > 0 {
+		branch.LeadMinutes = updateBranch.LeadMinutes
+	}
 
 	resp, err := h.storage.Branch().Update(c.Request.Context(), branch)
 	if err != nil {
@@ -203,3 +219,49 @@ func (h *Handler) DeleteBranch(c *gin.Context) {
 	h.log.Info("Branch deleted successfully!")
 	c.JSON(http.StatusOK, id)
 }
+
+// @ID 			get_branch_slots
+// @Router		/food/api/v1/branches/{id}/slots [GET]
+// @Summary		Get delivery slots of a branch
+// @Description Lists the pre-order delivery slots of a branch with their remaining capacity
+// @Tags		branch
+// @Accept		json
+// @Produce		json
+// @Param		id path string true "Branch ID"
+// @Param		days query int false "Number of days to look ahead, including today"
+// @Success 	200 {object} models.GetBranchSlotsResponse
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetBranchSlots(c *gin.Context) {
+	id := c.Param("id")
+
+	err := uuid.Validate(id)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating id")
+		c.JSON(http.StatusBadRequest, "please enter a valid id")
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "3"))
+	if err != nil || days <= 0 {
+		h.log.Error("error while parsing days")
+		c.JSON(http.StatusBadRequest, "days must be a positive number")
+		return
+	}
+	if days > config.MaxScheduleDays {
+		days = config.MaxScheduleDays
+	}
+
+	slots, err := h.storage.Branch().GetSlots(c.Request.Context(), &models.GetBranchSlotsRequest{
+		BranchId: id,
+		Days:     days,
+	})
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting branch slots")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
+
+	h.log.Info("Branch slots retrieved successfully")
+	c.JSON(http.StatusOK, slots)
+}
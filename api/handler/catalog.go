@@ -0,0 +1,432 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"food/api/models"
+	"food/config"
+	"food/pkg/i18n"
+	"food/pkg/spreadsheet"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	catalogCSV  = spreadsheet.CSV
+	catalogJSON = "json"
+
+	// catalogMaxSKU caps the length of product and category SKUs.
+	catalogMaxSKU = 64
+)
+
+// catalogColumns are the CSV columns of the catalog, followed by a name and a
+// description column for every translated locale.
+var catalogColumns = func() []spreadsheet.Column {
+	columns := []spreadsheet.Column{
+		{Name: "sku"}, {Name: "name"}, {Name: "description"}, {Name: "price", Numeric: true}, {Name: "image_url"},
+		{Name: "category_sku"}, {Name: "category_name"}, {Name: "parent_category_sku"},
+	}
+	for _, locale := range i18n.Supported {
+		if locale != i18n.Default {
+			columns = append(columns, spreadsheet.Column{Name: "name_" + locale}, spreadsheet.Column{Name: "description_" + locale})
+		}
+	}
+	return columns
+}()
+
+// @ID 			import_catalog
+// @Router 		/food/api/v1/admin/catalog/import [POST]
+// @Summary 	Import Catalog
+// @Description Creates or updates categories and products from CSV or JSON in the format of the catalog export, matching them by SKU, or by id when they have no SKU yet. Every row is checked first and nothing is saved unless all of them can be imported. The file is sent as the body or as the file field of a form.
+// @Tags 		catalog
+// @Accept 		text/csv
+// @Accept 		json
+// @Accept 		multipart/form-data
+// @Produce 	json
+// @Param 		format  query    string false "csv or json (default from the content type or file name)"
+// @Param 		dry_run query    bool   false "Only check the rows and report what would change"
+// @Param 		file    formData file   false "Catalog file"
+// @Success 	200 {object} models.CatalogImportResult
+// @Response 	400 {object} models.CatalogImportResult "Rows that cannot be imported"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) ImportCatalog(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.MaxCatalogBytes)
+	dryRun := c.Query("dry_run") == "true"
+
+	body, format, msg := catalogUpload(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+	defer body.Close()
+
+	var rows []models.CatalogRow
+	if format == catalogJSON {
+		rows, msg = parseCatalogJSON(body)
+	} else {
+		rows, msg = parseCatalogCSV(body)
+	}
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, "the file has no rows")
+		return
+	}
+	if len(rows) > config.MaxCatalogRows {
+		c.JSON(http.StatusBadRequest, "a catalog import has at most "+strconv.Itoa(config.MaxCatalogRows)+" rows")
+		return
+	}
+
+	if rowErrs := validateCatalogRows(rows); len(rowErrs) > 0 {
+		c.JSON(http.StatusBadRequest, &models.CatalogImportResult{
+			DryRun: dryRun,
+			Rows:   len(rows),
+			Errors: rowErrs,
+		})
+		return
+	}
+
+	result, err := h.storage.Catalog().Import(c.Request.Context(), rows, dryRun)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while importing catalog")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
+	if len(result.Errors) > 0 {
+		c.JSON(http.StatusBadRequest, result)
+		return
+	}
+
+	h.log.Info("Catalog imported successfully!")
+	c.JSON(http.StatusOK, result)
+}
+
+// @ID 			export_catalog
+// @Router 		/food/api/v1/admin/catalog/export [GET]
+// @Summary 	Export Catalog
+// @Description Every product with its category as CSV or JSON, in the format the catalog import reads. Records without a SKU are exported with their id as SKU.
+// @Tags 		catalog
+// @Produce 	text/csv
+// @Produce 	json
+// @Param 		format query string false "csv or json (default csv)"
+// @Success 	200 {array} models.CatalogRow
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) ExportCatalog(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	switch format := c.DefaultQuery("format", catalogCSV); format {
+	case catalogCSV:
+		h.stream(c, "catalog.csv", spreadsheet.ContentType(format), func(w io.Writer) error {
+			return writeSpreadsheet(w, "catalog", format, catalogColumns, func(sw spreadsheet.Writer) error {
+				return h.storage.Catalog().Export(ctx, func(row *models.CatalogRow) error {
+					return sw.Write(catalogCells(row))
+				})
+			})
+		})
+	case catalogJSON:
+		h.stream(c, "catalog.json", "application/json; charset=utf-8", func(w io.Writer) error {
+			return writeCatalogJSON(w, func(fn func(*models.CatalogRow) error) error {
+				return h.storage.Catalog().Export(ctx, fn)
+			})
+		})
+	default:
+		c.JSON(http.StatusBadRequest, "format must be csv or json")
+	}
+}
+
+// catalogUpload returns the uploaded file and its format. The file is the
+// file field of a multipart form or else the request body.
+func catalogUpload(c *gin.Context) (io.ReadCloser, string, string) {
+	format := c.Query("format")
+
+	var (
+		body     io.ReadCloser = c.Request.Body
+		detected               = catalogCSV
+	)
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", "please upload the catalog as the file field"
+		}
+		f, err := file.Open()
+		if err != nil {
+			return nil, "", "File error"
+		}
+		body = f
+		if strings.EqualFold(path.Ext(file.Filename), ".json") {
+			detected = catalogJSON
+		}
+	} else if strings.Contains(c.ContentType(), "json") {
+		detected = catalogJSON
+	}
+
+	switch format {
+	case "":
+		format = detected
+	case catalogCSV, catalogJSON:
+	default:
+		body.Close()
+		return nil, "", "format must be csv or json"
+	}
+	return body, format, ""
+}
+
+func parseCatalogJSON(r io.Reader) ([]models.CatalogRow, string) {
+	var rows []models.CatalogRow
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rows); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, catalogReadError(err)
+		}
+		return nil, "please send a JSON array of catalog rows: " + err.Error()
+	}
+	return rows, ""
+}
+
+// parseCatalogCSV reads the rows of a CSV catalog. The header names the
+// columns in any order; only sku, name, price, category_sku and
+// category_name are required.
+func parseCatalogCSV(r io.Reader) ([]models.CatalogRow, string) {
+	reader := spreadsheet.NewCSVReader(r)
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, "the file has no header row"
+	}
+	if err != nil {
+		return nil, catalogReadError(err)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		column, ok := catalogColumn(name)
+		if !ok {
+			return nil, fmt.Sprintf("unknown column %q", name)
+		}
+		index[column] = i
+	}
+	for _, name := range []string{"sku", "name", "price", "category_sku", "category_name"} {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Sprintf("the %s column is missing", name)
+		}
+	}
+	translated := hasTranslationColumn(index)
+
+	var rows []models.CatalogRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, catalogReadError(err)
+		}
+		if len(rows) == config.MaxCatalogRows {
+			return nil, "a catalog import has at most " + strconv.Itoa(config.MaxCatalogRows) + " rows"
+		}
+
+		cell := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := models.CatalogRow{
+			SKU:               cell("sku"),
+			Name:              cell("name"),
+			Description:       cell("description"),
+			ImageURL:          cell("image_url"),
+			CategorySKU:       cell("category_sku"),
+			CategoryName:      cell("category_name"),
+			ParentCategorySKU: cell("parent_category_sku"),
+		}
+		// A price that is not a number is reported by validateCatalogRow.
+		row.Price, err = strconv.ParseFloat(cell("price"), 64)
+		if err != nil {
+			row.Price = math.NaN()
+		}
+
+		if translated {
+			row.Translations = map[string]models.Translation{}
+			for _, locale := range i18n.Supported {
+				if locale == i18n.Default {
+					continue
+				}
+				t := models.Translation{Name: cell("name_" + locale), Description: cell("description_" + locale)}
+				if t.Name != "" || t.Description != "" {
+					row.Translations[locale] = t
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, ""
+}
+
+func catalogReadError(err error) string {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return "the file must be at most " + strconv.Itoa(config.MaxCatalogBytes>>20) + " MB"
+	}
+	return "the file is not valid CSV: " + err.Error()
+}
+
+// validateCatalogRows checks every row and that the rows agree on the
+// categories they share.
+func validateCatalogRows(rows []models.CatalogRow) []models.CatalogRowError {
+	var (
+		errs       []models.CatalogRowError
+		skus       = make(map[string]int, len(rows))
+		categories = make(map[string]int)
+	)
+	for i := range rows {
+		row := &rows[i]
+		fail := func(msg string) {
+			errs = append(errs, models.CatalogRowError{Row: i + 1, SKU: row.SKU, Message: msg})
+		}
+
+		if msg := validateCatalogRow(row); msg != "" {
+			fail(msg)
+			continue
+		}
+
+		if n, ok := skus[row.SKU]; ok {
+			fail(fmt.Sprintf("sku %q is also on row %d", row.SKU, n))
+			continue
+		}
+		skus[row.SKU] = i + 1
+
+		n, ok := categories[row.CategorySKU]
+		if !ok {
+			categories[row.CategorySKU] = i + 1
+			continue
+		}
+		first := rows[n-1]
+		if first.CategoryName != row.CategoryName {
+			fail(fmt.Sprintf("category %q is named %q on row %d", row.CategorySKU, first.CategoryName, n))
+		} else if first.ParentCategorySKU != row.ParentCategorySKU {
+			fail(fmt.Sprintf("category %q has parent %q on row %d", row.CategorySKU, first.ParentCategorySKU, n))
+		}
+	}
+	return errs
+}
+
+// validateCatalogRow trims and checks one row and moves uz-Latn translations
+// into the base fields.
+func validateCatalogRow(row *models.CatalogRow) string {
+	row.SKU = strings.TrimSpace(row.SKU)
+	row.Name = strings.TrimSpace(row.Name)
+	row.CategorySKU = strings.TrimSpace(row.CategorySKU)
+	row.CategoryName = strings.TrimSpace(row.CategoryName)
+	row.ParentCategorySKU = strings.TrimSpace(row.ParentCategorySKU)
+	row.ImageURL = strings.TrimSpace(row.ImageURL)
+
+	if row.Translations != nil {
+		translations, err := normalizeTranslations(&row.Name, &row.Description, row.Translations)
+		if err != nil {
+			return err.Error()
+		}
+		row.Translations = translations
+	}
+
+	switch {
+	case row.SKU == "":
+		return "sku is required"
+	case len(row.SKU) > catalogMaxSKU || len(row.CategorySKU) > catalogMaxSKU || len(row.ParentCategorySKU) > catalogMaxSKU:
+		return "SKUs must be at most " + strconv.Itoa(catalogMaxSKU) + " characters"
+	case row.Name == "":
+		return "name is required"
+	case math.IsNaN(row.Price) || math.IsInf(row.Price, 0):
+		return "price must be a number"
+	case row.Price < 0:
+		return "price must not be negative"
+	case row.CategorySKU == "":
+		return "category_sku is required"
+	case row.CategoryName == "":
+		return "category_name is required"
+	case row.ParentCategorySKU == row.CategorySKU:
+		return "a category cannot be its own parent"
+	}
+
+	if row.ImageURL != "" {
+		u, err := url.Parse(row.ImageURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "image_url must be an http or https URL"
+		}
+	}
+	return ""
+}
+
+// catalogColumn returns the column a CSV header names, ignoring case.
+func catalogColumn(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	for _, column := range catalogColumns {
+		if strings.EqualFold(column.Name, name) {
+			return column.Name, true
+		}
+	}
+	return "", false
+}
+
+func hasTranslationColumn(index map[string]int) bool {
+	for name := range index {
+		if strings.HasPrefix(name, "name_") || strings.HasPrefix(name, "description_") {
+			return true
+		}
+	}
+	return false
+}
+
+// catalogCells lays a row out in the order of catalogColumns.
+func catalogCells(row *models.CatalogRow) []string {
+	cells := []string{
+		row.SKU, row.Name, row.Description, strconv.FormatFloat(row.Price, 'f', -1, 64), row.ImageURL,
+		row.CategorySKU, row.CategoryName, row.ParentCategorySKU,
+	}
+	for _, locale := range i18n.Supported {
+		if locale != i18n.Default {
+			t := row.Translations[locale]
+			cells = append(cells, t.Name, t.Description)
+		}
+	}
+	return cells
+}
+
+// writeCatalogJSON writes the rows produced by export as a JSON array, one
+// row per line.
+func writeCatalogJSON(w io.Writer, export func(func(*models.CatalogRow) error) error) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	sep := "\n"
+	err := export(func(row *models.CatalogRow) error {
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+		sep = ","
+		return enc.Encode(row)
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "]\n")
+	return err
+}
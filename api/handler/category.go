@@ -2,11 +2,14 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"food/api/models"
+	"food/storage"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -33,9 +36,32 @@ func (h *Handler) CreateCategory(c *gin.Context) {
 	}
 
 	category := &models.Category{
-		Name: categoryCreate.Name,
+		Name:        categoryCreate.Name,
+		ParentId:    categoryCreate.ParentId,
+		SortOrder:   categoryCreate.SortOrder,
+		IconURL:     categoryCreate.IconURL,
+		IsActive:    categoryCreate.IsActive == nil || *categoryCreate.IsActive,
+		VisibleFrom: categoryCreate.VisibleFrom,
+		VisibleTo:   categoryCreate.VisibleTo,
 	}
+
+	if msg := validateCategory(category); msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+
+	translations, err := normalizeTranslations(&category.Name, nil, categoryCreate.Translations)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	category.Translations = translations
+
 	resp, err := h.storage.Category().Create(c.Request.Context(), category)
+	if errors.Is(err, storage.ErrInvalidParent) {
+		c.JSON(http.StatusBadRequest, "Parent category not found!")
+		return
+	}
 	if err != nil {
 		h.log.Error(err.Error() + ":" + "Error Category Create")
 		c.JSON(http.StatusInternalServerError, "Server error!")
@@ -75,8 +101,46 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 		return
 	}
 
-	category.Name = updateCategory.Name
+	if updateCategory.Name != "" {
+		category.Name = updateCategory.Name
+	}
+	if updateCategory.ParentId != nil {
+		category.ParentId = *updateCategory.ParentId
+	}
+	if updateCategory.SortOrder != nil {
+		category.SortOrder = *updateCategory.SortOrder
+	}
+	if updateCategory.IconURL != nil {
+		category.IconURL = *updateCategory.IconURL
+	}
+	if updateCategory.IsActive != nil {
+		category.IsActive = *updateCategory.IsActive
+	}
+	if updateCategory.VisibleFrom != nil {
+		category.VisibleFrom = *updateCategory.VisibleFrom
+	}
+	if updateCategory.VisibleTo != nil {
+		category.VisibleTo = *updateCategory.VisibleTo
+	}
+
+	if msg := validateCategory(category); msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+
+	if updateCategory.Translations != nil {
+		category.Translations, err = normalizeTranslations(&category.Name, nil, updateCategory.Translations)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
 	resp, err := h.storage.Category().Update(c.Request.Context(), category)
+	if errors.Is(err, storage.ErrInvalidParent) {
+		c.JSON(http.StatusBadRequest, "A category cannot be moved under itself or its subcategories!")
+		return
+	}
 	if err != nil {
 		h.log.Error(err.Error() + ":" + "Error Category Update")
 		c.JSON(http.StatusInternalServerError, "Server error!")
@@ -95,6 +159,7 @@ func (h *Handler) UpdateCategory(c *gin.Context) {
 // @Accept		 json
 // @Produce		 json
 // @Param		 id path string true "id"
+// @Param		 lang query string false "uz-Latn, uz-Cyrl, ru or en (default from Accept-Language); all returns every translation"
 // @Success		 200  {object}  models.Category
 // @Response     400 {object} Response{data=string} "Bad Request"
 // @Failure      500 {object} Response{data=string} "Server error"
@@ -114,6 +179,8 @@ func (h *Handler) GetCategoryByID(c *gin.Context) {
 		return
 	}
 
+	localizeCategory(requestLocale(c), category)
+
 	h.log.Info("Category was successfully gotten by Id")
 	c.JSON(http.StatusOK, category)
 }
@@ -121,13 +188,15 @@ func (h *Handler) GetCategoryByID(c *gin.Context) {
 // @ID 			    getall_category
 // @Router 			/food/api/v1/getallcategory [GET]
 // @Summary 		Get all category
-// @Description		Retrieves information about all categories
+// @Description		Returns the category tree ordered by sort_order with product counts that include subcategories. Paging applies to top-level categories. Inactive categories and those outside their visibility window are left out unless include_hidden is true.
 // @Tags 			category
 // @Accept 			json
 // @Produce 		json
 // @Param 			search query string false "categories"
+// @Param 			include_hidden query bool false "Include inactive and currently hidden categories (admin)"
 // @Param 			page query uint64 false "page"
 // @Param 			limit query uint64 false "limit"
+// @Param 			lang query string false "uz-Latn, uz-Cyrl, ru or en (default from Accept-Language); all returns every translation"
 // @Success 		200 {object} models.GetAllCategoriesResponse
 // @Response     400 {object} Response{data=string} "Bad Request"
 // @Failure      500 {object} Response{data=string} "Server error"
@@ -137,10 +206,11 @@ func (h *Handler) GetAllCategories(c *gin.Context) {
 	)
 
 	req.Search = c.Query("search")
+	req.IncludeHidden = c.Query("include_hidden") == "true"
 
 	page, err := strconv.ParseUint(c.DefaultQuery("page", "1"), 10, 64)
-	if err != nil {
-		h.log.Error(err.Error() + ":" + "error while parsing page")
+	if err != nil || page == 0 {
+		h.log.Error("error while parsing page")
 		c.JSON(http.StatusBadRequest, "BadRequest at paging")
 		return
 	}
@@ -162,6 +232,11 @@ func (h *Handler) GetAllCategories(c *gin.Context) {
 		return
 	}
 
+	locale := requestLocale(c)
+	for i := range customers.Categories {
+		localizeCategory(locale, &customers.Categories[i])
+	}
+
 	h.log.Info("Category was successfully gotten by Id")
 	c.JSON(http.StatusOK, customers)
 }
@@ -204,3 +279,34 @@ func (h Handler) DeleteCustomer(c *gin.Context) {
 	h.log.Info("Category deleted succesfully!")
 	c.JSON(http.StatusOK, id)
 }
+
+// validateCategory returns a message for the client when the category is not
+// valid, or an empty string.
+func validateCategory(category *models.Category) string {
+	if category.Name == "" {
+		return "Category name is required!"
+	}
+	if category.ParentId != "" {
+		if err := uuid.Validate(category.ParentId); err != nil {
+			return "please enter a valid parent_id"
+		}
+		if category.ParentId == category.Id {
+			return "A category cannot be its own parent!"
+		}
+	}
+	if (category.VisibleFrom == "") != (category.VisibleTo == "") {
+		return "visible_from and visible_to must be set together"
+	}
+	for _, value := range []string{category.VisibleFrom, category.VisibleTo} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", value); err != nil {
+			return "visible_from and visible_to must look like 15:04"
+		}
+	}
+	if category.VisibleFrom != "" && category.VisibleFrom == category.VisibleTo {
+		return "visible_from and visible_to must differ"
+	}
+	return ""
+}
@@ -3,12 +3,17 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"food/api/models"
+	"food/storage"
 	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 )
 
 // Create Combo godoc
@@ -38,6 +43,7 @@ func (h *Handler) CreateCombo(c *gin.Context) {
 	h.log.Info("Incoming JSON: " + string(body))
 
 	// Unmarshal the request body into the ComboCreateRequest struct
+	request.Combo.IsActive = true
 	err = json.Unmarshal(body, &request)
 	if err != nil {
 		h.log.Error("error unmarshalling JSON: " + err.Error())
@@ -45,34 +51,28 @@ func (h *Handler) CreateCombo(c *gin.Context) {
 		return
 	}
 
-	// Validate the Combo data
-	if request.Combo.Name == "" {
-		h.log.Error("Combo name is empty!")
-		c.JSON(http.StatusBadRequest, Response{Data: "Combo name is required!"})
+	translations, err := normalizeTranslations(&request.Combo.Name, &request.Combo.Description, request.Combo.Translations)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: err.Error()})
 		return
 	}
-	if request.Combo.Price <= 0 {
-		h.log.Error("Invalid combo price!")
-		c.JSON(http.StatusBadRequest, Response{Data: "Valid combo price is required!"})
+	request.Combo.Translations = translations
+
+	if msg := validateCombo(&request.Combo); msg != "" {
+		c.JSON(http.StatusBadRequest, Response{Data: msg})
 		return
 	}
-
-	// Validate each item in the combo
-	for _, item := range request.Combo.ComboItems {
-		if item.ProductId == "" {
-			h.log.Error("Product ID is empty for one of the items!")
-			c.JSON(http.StatusBadRequest, Response{Data: "Product ID is required for each item!"})
-			return
-		}
-		if item.Quantity <= 0 {
-			h.log.Error("Invalid quantity for product: " + item.ProductId)
-			c.JSON(http.StatusBadRequest, Response{Data: "Valid quantity is required for each item!"})
-			return
-		}
+	if msg := validateComboItems(request.Items); msg != "" {
+		c.JSON(http.StatusBadRequest, Response{Data: msg})
+		return
 	}
 
 	// Call the Create method in the repository to insert the combo into the database
 	combo, err := h.storage.Combo().Create(c.Request.Context(), &request)
+	if errors.Is(err, storage.ErrInvalidProduct) || errors.Is(err, storage.ErrInvalidQuantity) {
+		c.JSON(http.StatusBadRequest, Response{Data: err.Error()})
+		return
+	}
 	if err != nil {
 		h.log.Error("error in Combo.Create: " + err.Error())
 		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
@@ -87,13 +87,15 @@ func (h *Handler) CreateCombo(c *gin.Context) {
 // @ID 			   get_all_combos
 // @Router 		   /food/api/v1/getallcombos [GET]
 // @Summary 	   Get All Combos
-// @Description    Retrieve all Combo
+// @Description    Retrieve combos newest first. Inactive, archived and out-of-window combos are left out unless include_inactive is true.
 // @Tags 		   combo
 // @Accept 		   json
 // @Produce 	   json
 // @Param 		   search query string false "Search combos by name or description"
+// @Param 		   include_inactive query bool false "Include inactive, archived and currently unavailable combos (admin)"
 // @Param 		   page   query uint64 false "Page number"
 // @Param 		   limit  query uint64 false "Limit number of results per page"
+// @Param 		   lang   query string false "uz-Latn, uz-Cyrl, ru or en (default from Accept-Language); all returns every translation"
 // @Success 	   200 {object} Response{data=string} "Success"
 // @Response 	   400 {object} Response{data=string} "Bad Request"
 // @Failure 	   500 {object} Response{data=string} "Server error"
@@ -101,6 +103,7 @@ func (h *Handler) GetAllCombos(c *gin.Context) {
 	var req = &models.GetAllCombosRequest{}
 
 	req.Search = c.Query("search")
+	req.IncludeInactive = c.Query("include_inactive") == "true"
 
 	page, err := strconv.ParseUint(c.DefaultQuery("page", "1"), 10, 64)
 	if err != nil {
@@ -126,6 +129,11 @@ func (h *Handler) GetAllCombos(c *gin.Context) {
 		return
 	}
 
+	locale := requestLocale(c)
+	for i := range *products {
+		localizeCombo(locale, &(*products)[i].Combo)
+	}
+
 	h.log.Info("Combos retrieved successfully")
 	c.JSON(http.StatusOK, Response{Data: products})
 }
@@ -133,13 +141,15 @@ func (h *Handler) GetAllCombos(c *gin.Context) {
 // @ID             get_combo
 // @Router         /food/api/v1/getcombo/{id} [GET]
 // @Summary        get_combo
-// @Description    get_combo by its id
+// @Description    get_combo by its id, also when archived. items_total is what the items cost bought separately at current prices and savings how much less the combo costs.
 // @Tags           combo
 // @Accept         json
 // @Produces 	   json
 // @Param          id path string true "Combo Id"
+// @Param          lang query string false "uz-Latn, uz-Cyrl, ru or en (default from Accept-Language); all returns every translation"
 // @Success 	   200 {object}  Response{data=string} "Successfully retrieved combo"
 // @Response 	   400 {object} Response{data=string} "Bad Request"
+// @Response 	   404 {object} Response{data=string} "Not Found"
 // @Failure 	   500 {object} Response{data=string} "Server error"
 func (h *Handler) GetCombo(c *gin.Context) {
 	id := c.Param("id")
@@ -151,12 +161,18 @@ func (h *Handler) GetCombo(c *gin.Context) {
 	}
 
 	combo, err := h.storage.Combo().GetCombo(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Combo not found!"})
+		return
+	}
 	if err != nil {
 		h.log.Error("error in Combo.GetByID: " + err.Error())
 		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
 		return
 	}
 
+	localizeCombo(requestLocale(c), &combo.Combo)
+
 	h.log.Info("Order retrieved successfully!")
 	c.JSON(http.StatusOK, Response{Data: combo})
 }
@@ -164,33 +180,295 @@ func (h *Handler) GetCombo(c *gin.Context) {
 // @ID 			update_combo
 // @Router 		/food/api/v1/updatecombo/{id} [PUT]
 // @Summary 	Update Combo
-// @Description Update an existing combo
+// @Description Update an existing combo. Omitted fields keep their values; combo_items, when sent, replaces every item.
 // @Tags 		combo
 // @Accept 		json
 // @Produce 	json
 // @Param 		id path string true "Combo ID"
-// @Param 		Combo body models.ComboUpdateS true "UpdateOrderRequest"
+// @Param 		Combo body models.ComboUpdateS true "UpdateComboRequest"
 // @Success 	200 {object} Response{data=string} "Successfully"
 // @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Not Found"
 // @Failure 	500 {object} Response{data=string} "Server error"
 func (h *Handler) UpdateCombo(c *gin.Context) {
-	var updateCombo *models.Combo
+	var updateCombo models.ComboUpdateS
 
 	if err := c.ShouldBindJSON(&updateCombo); err != nil {
-		h.log.Error(err.Error() + " : " + "error Order Should Bind Json!")
+		h.log.Error(err.Error() + " : " + "error Combo Should Bind Json!")
 		c.JSON(http.StatusBadRequest, "Please, enter valid data!")
 		return
 	}
 
 	id := c.Param("id")
+	current, err := h.storage.Combo().GetCombo(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, "Combo not found!")
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "Error Combo Get")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
 
-	resp, err := h.storage.Combo().Update(c.Request.Context(), id, updateCombo)
+	combo := &current.Combo
+	combo.Translations = nil
+	if updateCombo.Name != "" {
+		combo.Name = updateCombo.Name
+	}
+	if updateCombo.Description != nil {
+		combo.Description = *updateCombo.Description
+	}
+	if updateCombo.Price != 0 {
+		combo.Price = updateCombo.Price
+	}
+	if updateCombo.IsActive != nil {
+		combo.IsActive = *updateCombo.IsActive
+	}
+	if updateCombo.AvailableFrom != nil {
+		combo.AvailableFrom = *updateCombo.AvailableFrom
+	}
+	if updateCombo.AvailableTo != nil {
+		combo.AvailableTo = *updateCombo.AvailableTo
+	}
+
+	if msg := validateCombo(combo); msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+
+	if updateCombo.ComboItems != nil {
+		combo.ComboItems = comboItems(updateCombo.ComboItems)
+		if msg := validateComboItems(combo.ComboItems); msg != "" {
+			c.JSON(http.StatusBadRequest, msg)
+			return
+		}
+	}
+
+	if updateCombo.Translations != nil {
+		combo.Translations, err = normalizeTranslations(&combo.Name, &combo.Description, updateCombo.Translations)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	resp, err := h.storage.Combo().Update(c.Request.Context(), id, combo)
+	if errors.Is(err, storage.ErrInvalidProduct) || errors.Is(err, storage.ErrInvalidQuantity) {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, "Combo not found!")
+		return
+	}
 	if err != nil {
-		h.log.Error(err.Error() + ":" + "Error Order Update")
+		h.log.Error(err.Error() + ":" + "Error Combo Update")
 		c.JSON(http.StatusInternalServerError, "Server error!")
 		return
 	}
 
-	h.log.Info("Order updated successfully!")
+	h.log.Info("Combo updated successfully!")
 	c.JSON(http.StatusOK, Response{Data: resp})
 }
+
+// @ID 			delete_combo
+// @Router		/food/api/v1/deletecombo/{id} [DELETE]
+// @Summary		Delete Combo
+// @Description Archives the combo so customers no longer see it while past orders keep it. permanent=true deletes it with its items and images instead, which is only possible for combos that were never ordered.
+// @Tags		combo
+// @Accept		json
+// @Produce		json
+// @Param		id path string true "Combo ID"
+// @Param		permanent query bool false "Delete instead of archiving"
+// @Success 	200 {object} Response{data=string} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Not Found"
+// @Response 	409 {object} Response{data=string} "Combo has been ordered"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) DeleteCombo(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter valid id"})
+		return
+	}
+
+	var err error
+	if c.Query("permanent") == "true" {
+		err = h.storage.Combo().Delete(c.Request.Context(), id)
+	} else {
+		err = h.storage.Combo().Archive(c.Request.Context(), id)
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Combo not found!"})
+		return
+	}
+	if errors.Is(err, storage.ErrComboOrdered) {
+		c.JSON(http.StatusConflict, Response{Data: "Combo has been ordered and cannot be deleted; archive it instead!"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "Error Combo Delete")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server error!"})
+		return
+	}
+
+	h.log.Info("Combo deleted successfully!")
+	c.JSON(http.StatusOK, Response{Data: id})
+}
+
+// @ID 			add_combo_item
+// @Router		/food/api/v1/combos/{id}/items [POST]
+// @Summary		Add Combo Item
+// @Description Adds a product to the combo at its current price, or raises the quantity when the combo already has it
+// @Tags		combo
+// @Accept		json
+// @Produce		json
+// @Param		id path string true "Combo ID"
+// @Param		item body models.SwaggerComboItems true "Item"
+// @Success 	200 {object} Response{data=models.ComboCreateRequest} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Not Found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) AddComboItem(c *gin.Context) {
+	var request models.SwaggerComboItems
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.log.Error(err.Error() + " : " + "error Combo Item Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Please, enter valid data!"})
+		return
+	}
+
+	items := comboItems([]models.SwaggerComboItems{request})
+	if msg := validateComboItems(items); msg != "" {
+		c.JSON(http.StatusBadRequest, Response{Data: msg})
+		return
+	}
+
+	resp, err := h.storage.Combo().AddItem(c.Request.Context(), c.Param("id"), &items[0])
+	h.comboItemsResponse(c, resp, err)
+}
+
+// @ID 			remove_combo_item
+// @Router		/food/api/v1/combos/{id}/items/{item_id} [DELETE]
+// @Summary		Remove Combo Item
+// @Description Removes one item from the combo. The last item cannot be removed.
+// @Tags		combo
+// @Accept		json
+// @Produce		json
+// @Param		id path string true "Combo ID"
+// @Param		item_id path string true "Combo item ID"
+// @Success 	200 {object} Response{data=models.ComboCreateRequest} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Not Found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) RemoveComboItem(c *gin.Context) {
+	if err := uuid.Validate(c.Param("item_id")); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid item_id"})
+		return
+	}
+
+	resp, err := h.storage.Combo().RemoveItem(c.Request.Context(), c.Param("id"), c.Param("item_id"))
+	h.comboItemsResponse(c, resp, err)
+}
+
+// @ID 			replace_combo_items
+// @Router		/food/api/v1/combos/{id}/items [PUT]
+// @Summary		Replace Combo Items
+// @Description Replaces every item of the combo in one transaction
+// @Tags		combo
+// @Accept		json
+// @Produce		json
+// @Param		id path string true "Combo ID"
+// @Param		items body models.ReplaceComboItemsRequest true "Items"
+// @Success 	200 {object} Response{data=models.ComboCreateRequest} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Not Found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) ReplaceComboItems(c *gin.Context) {
+	var request models.ReplaceComboItemsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.log.Error(err.Error() + " : " + "error Combo Items Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Please, enter valid data!"})
+		return
+	}
+
+	items := comboItems(request.Items)
+	if msg := validateComboItems(items); msg != "" {
+		c.JSON(http.StatusBadRequest, Response{Data: msg})
+		return
+	}
+
+	resp, err := h.storage.Combo().ReplaceItems(c.Request.Context(), c.Param("id"), items)
+	h.comboItemsResponse(c, resp, err)
+}
+
+// comboItemsResponse writes the result of an item change.
+func (h *Handler) comboItemsResponse(c *gin.Context, combo *models.ComboCreateRequest, err error) {
+	if errors.Is(err, storage.ErrInvalidProduct) || errors.Is(err, storage.ErrInvalidQuantity) || errors.Is(err, storage.ErrEmptyCombo) {
+		c.JSON(http.StatusBadRequest, Response{Data: err.Error()})
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Combo or item not found!"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "Error Combo Items Update")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server error!"})
+		return
+	}
+
+	h.log.Info("Combo items updated successfully!")
+	c.JSON(http.StatusOK, Response{Data: combo})
+}
+
+func comboItems(request []models.SwaggerComboItems) []models.ComboItem {
+	items := make([]models.ComboItem, 0, len(request))
+	for _, item := range request {
+		items = append(items, models.ComboItem{ProductId: item.ProductId, Quantity: item.Quantity})
+	}
+	return items
+}
+
+// validateCombo returns a message for the client when the combo is not
+// valid, or an empty string.
+func validateCombo(combo *models.Combo) string {
+	if combo.Name == "" {
+		return "Combo name is required!"
+	}
+	if combo.Price <= 0 {
+		return "Valid combo price is required!"
+	}
+	if (combo.AvailableFrom == "") != (combo.AvailableTo == "") {
+		return "available_from and available_to must be set together"
+	}
+	for _, value := range []string{combo.AvailableFrom, combo.AvailableTo} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", value); err != nil {
+			return "available_from and available_to must look like 15:04"
+		}
+	}
+	if combo.AvailableFrom != "" && combo.AvailableFrom == combo.AvailableTo {
+		return "available_from and available_to must differ"
+	}
+	return ""
+}
+
+// validateComboItems returns a message for the client when the items are not
+// valid, or an empty string.
+func validateComboItems(items []models.ComboItem) string {
+	if len(items) == 0 {
+		return "A combo needs at least one item!"
+	}
+	for _, item := range items {
+		if err := uuid.Validate(item.ProductId); err != nil {
+			return "A valid product_id is required for each item!"
+		}
+		if item.Quantity <= 0 {
+			return "Valid quantity is required for each item!"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,647 @@
+package handler
+
+import (
+	"errors"
+	"food/api/models"
+	"food/storage"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// @ID 			get_courier_rates
+// @Router 		/food/api/v1/admin/courier-rates [GET]
+// @Summary 	Get Courier Rates
+// @Description Lists how couriers are paid: the default rate first, then the branch rates that replace it
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Success 	200 {object} Response{data=[]models.CourierRate} "Success Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetCourierRates(c *gin.Context) {
+	rates, err := h.storage.CourierPay().GetRates(c.Request.Context())
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting courier rates")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: rates})
+}
+
+// @ID 			set_courier_rate
+// @Router 		/food/api/v1/admin/courier-rates [PUT]
+// @Summary 	Set Courier Rate
+// @Description Replaces the default rate, or the rate of a branch when branch_id is given. A delivery earns the base rate, the per-km rate for the distance from the branch to the drop-off, the peak surcharge when delivered in the peak window, and tip_share percent of the order's tip. Deliveries already made keep their earnings.
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		rate body models.SetCourierRate true "Courier rate"
+// @Success 	200 {object} Response{data=models.CourierRate} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) SetCourierRate(c *gin.Context) {
+	var req models.SetCourierRate
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Courier Rate Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	if msg := validateCourierRate(&req); msg != "" {
+		c.JSON(http.StatusBadRequest, Response{Data: msg})
+		return
+	}
+
+	rate, err := h.storage.CourierPay().SetRate(c.Request.Context(), &req)
+	if errors.Is(err, storage.ErrInvalidBranch) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Branch not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while setting courier rate")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Courier rate set successfully")
+	c.JSON(http.StatusOK, Response{Data: rate})
+}
+
+// @ID 			assign_courier
+// @Router 		/food/api/v1/admin/courier-assignments [POST]
+// @Summary 	Assign Courier
+// @Description Assigns an order to a courier and notifies the customer
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		assignment body models.AssignCourierRequest true "Order and courier"
+// @Success 	201 {object} Response{data=models.CourierAssignment} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Order not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) AssignCourier(c *gin.Context) {
+	var req models.AssignCourierRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Courier Assignment Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	for _, id := range []string{req.OrderId, req.CourierId} {
+		if err := uuid.Validate(id); err != nil {
+			c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid order_id and courier_id"})
+			return
+		}
+	}
+
+	assignment, err := h.service.Courier().Assign(c.Request.Context(), &models.CourierAssignment{
+		OrderId:   req.OrderId,
+		CourierId: req.CourierId,
+		Status:    "assigned",
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Order not found"})
+		return
+	}
+	if errors.Is(err, storage.ErrInvalidCourier) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Courier not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while assigning courier")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Courier assigned successfully")
+	c.JSON(http.StatusCreated, Response{Data: assignment})
+}
+
+// @ID 			change_courier_assignment_status
+// @Router 		/food/api/v1/admin/courier-assignments/{id}/status [PATCH]
+// @Summary 	Change Courier Assignment Status
+// @Description Moves an assignment forward, skipping steps if needed: assigned, picked_up, en_route, delivered, payment_collected. The order follows: picked_up, en_route, then delivered. Delivered records the delivery and its earnings. payment_collected is only for 'naxt pul' orders and puts the cash on the courier's account until it is reconciled. Unlike the courier workflow, no proof of delivery is needed, but orders with a handover code are only delivered through the handover override.
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		id     path string                                true "Assignment ID"
+// @Param 		status body models.CourierAssignmentStatusRequest true "New status"
+// @Success 	200 {object} Response{data=models.CourierAssignment} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Assignment not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) ChangeCourierAssignmentStatus(c *gin.Context) {
+	var req models.CourierAssignmentStatusRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Courier Assignment Status Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	assignment, err := h.service.Courier().ChangeStatus(c.Request.Context(), &models.CourierStatusChange{
+		Id:     id,
+		Status: req.Status,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Assignment not found"})
+		return
+	}
+	if errors.Is(err, storage.ErrInvalidStatus) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Assignment cannot be moved to " + req.Status})
+		return
+	}
+	if errors.Is(err, storage.ErrInvalidProof) {
+		c.JSON(http.StatusBadRequest, Response{Data: "The order has a handover code, use the handover override"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while changing courier assignment status")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Courier assignment status changed successfully")
+	c.JSON(http.StatusOK, Response{Data: assignment})
+}
+
+// @ID 			override_courier_handover
+// @Router 		/food/api/v1/admin/courier-assignments/{id}/handover-override [POST]
+// @Summary 	Override Handover
+// @Description Marks an assignment delivered without the customer's handover code, for example when the customer lost it or the order is locked after too many wrong codes. The note is kept with the order's handover attempts for audit.
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		id       path string                         true "Assignment ID"
+// @Param 		override body models.HandoverOverrideRequest true "Reason for the override"
+// @Success 	200 {object} Response{data=models.CourierAssignment} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Assignment not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) OverrideCourierHandover(c *gin.Context) {
+	var req models.HandoverOverrideRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Handover Override Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if req.Note == "" || len(req.Note) > 500 {
+		c.JSON(http.StatusBadRequest, Response{Data: "note is required and must be at most 500 characters"})
+		return
+	}
+
+	assignment, err := h.service.Courier().ChangeStatus(c.Request.Context(), &models.CourierStatusChange{
+		Id:     id,
+		Status: "delivered",
+		Proof:  &models.DeliveryProof{Type: "override", Note: req.Note},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Assignment not found"})
+		return
+	}
+	if errors.Is(err, storage.ErrInvalidStatus) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Assignment is already delivered or the order is cancelled"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while overriding handover")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Warn("Handover of assignment " + id + " overridden: " + req.Note)
+	c.JSON(http.StatusOK, Response{Data: assignment})
+}
+
+// @ID 			get_handover_attempts
+// @Router 		/food/api/v1/admin/orders/{id}/handover-attempts [GET]
+// @Summary 	Get Handover Attempts
+// @Description Handover codes entered by couriers for an order and admin overrides, latest first. The order is locked after 5 wrong codes.
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Order ID"
+// @Success 	200 {object} Response{data=models.GetHandoverAttemptsResponse} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Order not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetHandoverAttempts(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	resp, err := h.storage.CourierAssignment().HandoverAttempts(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Order not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting handover attempts")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: resp})
+}
+
+// @ID 			get_courier_earnings
+// @Router 		/food/api/v1/couriers/{id}/earnings [GET]
+// @Summary 	Get Courier Earnings
+// @Description Totals and a page of the deliveries a courier made in the range, latest first, with the unpaid earnings and the cash the courier holds
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		id    path  string true  "Courier ID"
+// @Param 		from  query string false "First day, 2006-01-02 (default 30 days ago)"
+// @Param 		to    query string false "Last day, 2006-01-02 (default today)"
+// @Param 		page  query uint64 false "Page number"
+// @Param 		limit query uint64 false "Limit number of results per page"
+// @Success 	200 {object} Response{data=models.CourierEarningsResponse} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetCourierEarnings(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	from, to, msg := dateRangeQuery(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, Response{Data: msg})
+		return
+	}
+
+	page, limit, msg := courierPaging(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, Response{Data: msg})
+		return
+	}
+
+	resp, err := h.storage.CourierPay().Earnings(c.Request.Context(), &models.CourierEarningsRequest{
+		CourierId: id,
+		From:      from,
+		To:        to,
+		Page:      page,
+		Limit:     limit,
+	})
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting courier earnings")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: resp})
+}
+
+// @ID 			get_courier_cash
+// @Router 		/food/api/v1/couriers/{id}/cash [GET]
+// @Summary 	Get Courier Cash
+// @Description Cash the courier collected on 'naxt pul' orders and has not handed in yet
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Courier ID"
+// @Success 	200 {object} Response{data=models.CourierCashResponse} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetCourierCash(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	resp, err := h.storage.CourierPay().Cash(c.Request.Context(), id)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting courier cash")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: resp})
+}
+
+// @ID 			get_courier_own_payouts
+// @Router 		/food/api/v1/couriers/{id}/payouts [GET]
+// @Summary 	Get Courier Payouts
+// @Description Payouts of a courier, latest first
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		id     path  string true  "Courier ID"
+// @Param 		status query string false "pending or paid"
+// @Param 		page   query uint64 false "Page number"
+// @Param 		limit  query uint64 false "Limit number of results per page"
+// @Success 	200 {object} Response{data=models.GetCourierPayoutsResponse} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetCourierOwnPayouts(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	h.listCourierPayouts(c, id)
+}
+
+// @ID 			reconcile_courier_cash
+// @Router 		/food/api/v1/admin/couriers/{id}/reconciliations [POST]
+// @Summary 	Reconcile Courier Cash
+// @Description Records the cash a courier hands in at the end of a shift against all the cash collected since the last reconciliation. A negative difference is a shortage; admins get an alert email about it.
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		id             path string                      true "Courier ID"
+// @Param 		reconciliation body models.ReconcileCashRequest true "Cash handed in"
+// @Success 	201 {object} Response{data=models.CourierReconciliation} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) ReconcileCourierCash(c *gin.Context) {
+	var req models.ReconcileCashRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Reconciliation Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+	if req.Received < 0 {
+		c.JSON(http.StatusBadRequest, Response{Data: "received must not be negative"})
+		return
+	}
+
+	resp, err := h.service.Courier().Reconcile(c.Request.Context(), id, &req)
+	if errors.Is(err, storage.ErrInvalidCourier) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Courier not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while reconciling courier cash")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Courier cash reconciled successfully")
+	c.JSON(http.StatusCreated, Response{Data: resp})
+}
+
+// @ID 			get_courier_reconciliations
+// @Router 		/food/api/v1/admin/courier-reconciliations [GET]
+// @Summary 	Get Courier Reconciliations
+// @Description Lists cash reconciliations, latest first
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		courier_id query string false "Courier ID"
+// @Param 		page       query uint64 false "Page number"
+// @Param 		limit      query uint64 false "Limit number of results per page"
+// @Success 	200 {object} Response{data=models.GetCourierReconciliationsResponse} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetCourierReconciliations(c *gin.Context) {
+	req := &models.GetCourierReconciliationsRequest{CourierId: c.Query("courier_id")}
+	if req.CourierId != "" {
+		if err := uuid.Validate(req.CourierId); err != nil {
+			c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid courier_id"})
+			return
+		}
+	}
+
+	var msg string
+	req.Page, req.Limit, msg = courierPaging(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, Response{Data: msg})
+		return
+	}
+
+	resp, err := h.storage.CourierPay().GetReconciliations(c.Request.Context(), req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting courier reconciliations")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: resp})
+}
+
+// @ID 			create_courier_payouts
+// @Router 		/food/api/v1/admin/courier-payouts [POST]
+// @Summary 	Create Courier Payouts
+// @Description Puts the unpaid earnings of deliveries made through until into one pending payout per courier, or only for courier_id
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		payouts body models.CreatePayoutsRequest true "Last day and courier"
+// @Success 	201 {object} Response{data=[]models.CourierPayout} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) CreateCourierPayouts(c *gin.Context) {
+	var req models.CreatePayoutsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Payouts Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	until, err := time.ParseInLocation("2006-01-02", req.Until, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "until must look like 2006-01-02"})
+		return
+	}
+	if until.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, Response{Data: "until must not be in the future"})
+		return
+	}
+	if req.CourierId != "" {
+		if err := uuid.Validate(req.CourierId); err != nil {
+			c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid courier_id"})
+			return
+		}
+	}
+
+	payouts, err := h.storage.CourierPay().CreatePayouts(c.Request.Context(), &req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while creating courier payouts")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Courier payouts created successfully")
+	c.JSON(http.StatusCreated, Response{Data: payouts})
+}
+
+// @ID 			get_courier_payouts
+// @Router 		/food/api/v1/admin/courier-payouts [GET]
+// @Summary 	Get All Courier Payouts
+// @Description Lists payouts, latest first
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		courier_id query string false "Courier ID"
+// @Param 		status     query string false "pending or paid"
+// @Param 		page       query uint64 false "Page number"
+// @Param 		limit      query uint64 false "Limit number of results per page"
+// @Success 	200 {object} Response{data=models.GetCourierPayoutsResponse} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetCourierPayouts(c *gin.Context) {
+	courierId := c.Query("courier_id")
+	if courierId != "" {
+		if err := uuid.Validate(courierId); err != nil {
+			c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid courier_id"})
+			return
+		}
+	}
+
+	h.listCourierPayouts(c, courierId)
+}
+
+// @ID 			mark_courier_payout_paid
+// @Router 		/food/api/v1/admin/courier-payouts/{id}/paid [PATCH]
+// @Summary 	Mark Courier Payout Paid
+// @Description Closes a pending payout with the reference of the transfer
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		id     path string                   true "Payout ID"
+// @Param 		payout body models.PayoutPaidRequest true "Transfer reference"
+// @Success 	200 {object} Response{data=models.CourierPayout} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Payout not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) MarkCourierPayoutPaid(c *gin.Context) {
+	var req models.PayoutPaidRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Payout Paid Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	payout, err := h.storage.CourierPay().MarkPayoutPaid(c.Request.Context(), id, req.Reference)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Payout not found"})
+		return
+	}
+	if errors.Is(err, storage.ErrInvalidStatus) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Payout is already paid"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while marking courier payout paid")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Courier payout marked paid successfully")
+	c.JSON(http.StatusOK, Response{Data: payout})
+}
+
+func (h *Handler) listCourierPayouts(c *gin.Context, courierId string) {
+	req := &models.GetCourierPayoutsRequest{
+		CourierId: courierId,
+		Status:    c.Query("status"),
+	}
+	if req.Status != "" && req.Status != "pending" && req.Status != "paid" {
+		c.JSON(http.StatusBadRequest, Response{Data: "status must be pending or paid"})
+		return
+	}
+
+	var msg string
+	req.Page, req.Limit, msg = courierPaging(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, Response{Data: msg})
+		return
+	}
+
+	resp, err := h.storage.CourierPay().GetPayouts(c.Request.Context(), req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting courier payouts")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: resp})
+}
+
+// courierPaging reads page, default 1, and limit, default 10.
+func courierPaging(c *gin.Context) (uint64, uint64, string) {
+	page, err := strconv.ParseUint(c.DefaultQuery("page", "1"), 10, 64)
+	if err != nil || page == 0 {
+		return 0, 0, "BadRequest at paging"
+	}
+
+	limit, err := strconv.ParseUint(c.DefaultQuery("limit", "10"), 10, 64)
+	if err != nil {
+		return 0, 0, "BadRequest at limit"
+	}
+
+	return page, limit, ""
+}
+
+func validateCourierRate(req *models.SetCourierRate) string {
+	if req.BranchId != "" {
+		if err := uuid.Validate(req.BranchId); err != nil {
+			return "please enter a valid branch_id"
+		}
+	}
+	if req.BaseRate < 0 || req.PerKmRate < 0 || req.PeakSurcharge < 0 {
+		return "base_rate, per_km_rate and peak_surcharge must not be negative"
+	}
+	if req.TipShare < 0 || req.TipShare > 100 {
+		return "tip_share must be between 0 and 100"
+	}
+	if (req.PeakFrom == "") != (req.PeakTo == "") {
+		return "peak_from and peak_to must be set together"
+	}
+	for _, value := range []string{req.PeakFrom, req.PeakTo} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", value); err != nil {
+			return "peak_from and peak_to must look like 15:04"
+		}
+	}
+	if req.PeakFrom != "" && req.PeakFrom == req.PeakTo {
+		return "peak_from and peak_to must differ"
+	}
+	return ""
+}
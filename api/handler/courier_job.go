@@ -0,0 +1,269 @@
+package handler
+
+import (
+	"errors"
+	"food/api/models"
+	"food/config"
+	"food/pkg/blob"
+	"food/storage"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// @ID 			get_courier_jobs
+// @Router 		/food/api/v1/couriers/{id}/jobs [GET]
+// @Summary 	Get Courier Jobs
+// @Description Assignments the courier still has to work on, oldest first, with the drop-off, the customer and the branch: those not delivered yet and delivered 'naxt pul' orders whose cash is not collected
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Courier ID"
+// @Success 	200 {object} Response{data=models.GetCourierJobsResponse} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetCourierJobs(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	resp, err := h.storage.CourierAssignment().Jobs(c.Request.Context(), id)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting courier jobs")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: resp})
+}
+
+// @ID 			accept_courier_job
+// @Router 		/food/api/v1/couriers/{id}/jobs/{assignment_id}/accept [POST]
+// @Summary 	Accept Courier Job
+// @Description The courier takes an assigned order. Jobs must be accepted before pickup.
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		id            path string true "Courier ID"
+// @Param 		assignment_id path string true "Assignment ID"
+// @Success 	200 {object} Response{data=models.CourierAssignment} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Assignment not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) AcceptCourierJob(c *gin.Context) {
+	courierId, id, ok := courierJobParams(c)
+	if !ok {
+		return
+	}
+
+	assignment, err := h.storage.CourierAssignment().Accept(c.Request.Context(), id, courierId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Assignment not found"})
+		return
+	}
+	if errors.Is(err, storage.ErrInvalidStatus) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Assignment is already accepted or the order is cancelled"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while accepting courier job")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Courier job accepted successfully")
+	c.JSON(http.StatusOK, Response{Data: assignment})
+}
+
+// @ID 			change_courier_job_status
+// @Router 		/food/api/v1/couriers/{id}/jobs/{assignment_id}/status [PATCH]
+// @Summary 	Change Courier Job Status
+// @Description Moves an accepted job one step forward: picked_up once the kitchen marked the order ready, then en_route, and payment_collected after delivering a 'naxt pul' order. The order status follows. The customer gets the handover code by SMS at pickup. Deliveries go through /deliver.
+// @Tags 		courier
+// @Accept 		json
+// @Produce 	json
+// @Param 		id            path string                                true "Courier ID"
+// @Param 		assignment_id path string                                true "Assignment ID"
+// @Param 		status        body models.CourierAssignmentStatusRequest true "New status"
+// @Success 	200 {object} Response{data=models.CourierAssignment} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Assignment not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) ChangeCourierJobStatus(c *gin.Context) {
+	var req models.CourierAssignmentStatusRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Courier Job Status Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	courierId, id, ok := courierJobParams(c)
+	if !ok {
+		return
+	}
+	if req.Status == "delivered" {
+		c.JSON(http.StatusBadRequest, Response{Data: "Deliveries need proof, use /deliver"})
+		return
+	}
+
+	h.changeCourierJobStatus(c, &models.CourierStatusChange{
+		Id:        id,
+		CourierId: courierId,
+		Status:    req.Status,
+	}, "")
+}
+
+// @ID 			deliver_courier_job
+// @Router 		/food/api/v1/couriers/{id}/jobs/{assignment_id}/deliver [POST]
+// @Summary 	Deliver Courier Job
+// @Description Completes an en-route job with proof of delivery. Orders with a handover code need the code the customer received; after 5 wrong codes only an admin override can complete the delivery. Older orders take a photo of the handover or the courier's position within 150 meters of the drop-off. A photo and the position are recorded whenever they are sent. The order becomes delivered and the earnings are recorded.
+// @Tags 		courier
+// @Accept 		multipart/form-data
+// @Produce 	json
+// @Param 		id            path     string true  "Courier ID"
+// @Param 		assignment_id path     string true  "Assignment ID"
+// @Param 		photo         formData file   false "Photo of the handover"
+// @Param 		code          formData string false "Customer's handover code"
+// @Param 		latitude      formData number false "Courier latitude"
+// @Param 		longitude     formData number false "Courier longitude"
+// @Success 	200 {object} Response{data=models.CourierAssignment} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Assignment not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) DeliverCourierJob(c *gin.Context) {
+	courierId, id, ok := courierJobParams(c)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.MaxUploadBytes+1<<20)
+	proof := &models.DeliveryProof{Code: strings.TrimSpace(c.PostForm("code"))}
+
+	lat, lng := c.PostForm("latitude"), c.PostForm("longitude")
+	if lat != "" || lng != "" {
+		var err1, err2 error
+		proof.Latitude, err1 = strconv.ParseFloat(lat, 64)
+		proof.Longitude, err2 = strconv.ParseFloat(lng, 64)
+		if err1 != nil || err2 != nil || proof.Latitude < -90 || proof.Latitude > 90 || proof.Longitude < -180 || proof.Longitude > 180 {
+			c.JSON(http.StatusBadRequest, Response{Data: "latitude and longitude must be valid coordinates"})
+			return
+		}
+	}
+
+	file, err := c.FormFile("photo")
+	if err != nil && !errors.Is(err, http.ErrMissingFile) {
+		h.log.Error(err.Error() + "  :  " + "File error")
+		c.JSON(http.StatusBadRequest, Response{Data: "File error"})
+		return
+	}
+	switch {
+	case proof.Code != "":
+		proof.Type = "pin"
+	case file != nil:
+		proof.Type = "photo"
+	case lat != "":
+		proof.Type = "geofence"
+	default:
+		c.JSON(http.StatusBadRequest, Response{Data: "send a photo, the handover code or your location as proof of delivery"})
+		return
+	}
+
+	var key string
+	if file != nil {
+		f, err := file.Open()
+		if err != nil {
+			h.log.Error(err.Error() + "  :  " + "File error")
+			c.JSON(http.StatusBadRequest, Response{Data: "File error"})
+			return
+		}
+		data, err := io.ReadAll(io.LimitReader(f, config.MaxUploadBytes+1))
+		f.Close()
+		if err != nil {
+			h.log.Error(err.Error() + "  :  " + "File error")
+			c.JSON(http.StatusBadRequest, Response{Data: "File error"})
+			return
+		}
+
+		contentType, err := blob.Check(data, config.MaxUploadBytes, uploadAllowedTypes...)
+		if errors.Is(err, blob.ErrTooLarge) {
+			c.JSON(http.StatusBadRequest, Response{Data: "photo must be at most " + strconv.Itoa(config.MaxUploadBytes>>20) + " MB"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, Response{Data: "only " + strings.Join(uploadAllowedTypes, ", ") + " photos are allowed"})
+			return
+		}
+
+		key = blob.NewKey("deliveries", contentType)
+		proof.PhotoURL, err = h.blob.Put(c.Request.Context(), key, contentType, data)
+		if err != nil {
+			h.log.Error(err.Error() + "  :  " + "Upload error")
+			c.JSON(http.StatusInternalServerError, Response{Data: "Upload error"})
+			return
+		}
+	}
+
+	h.changeCourierJobStatus(c, &models.CourierStatusChange{
+		Id:        id,
+		CourierId: courierId,
+		Status:    "delivered",
+		Proof:     proof,
+	}, key)
+}
+
+// changeCourierJobStatus applies a courier's status change. photoKey is the
+// uploaded proof photo, removed again when the change is refused.
+func (h *Handler) changeCourierJobStatus(c *gin.Context, req *models.CourierStatusChange, photoKey string) {
+	assignment, err := h.service.Courier().ChangeStatus(c.Request.Context(), req)
+	if err != nil && photoKey != "" {
+		if err := h.blob.Delete(c.Request.Context(), photoKey); err != nil {
+			h.log.Error(err.Error() + ":" + "error while deleting delivery photo")
+		}
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Assignment not found"})
+		return
+	}
+	if errors.Is(err, storage.ErrInvalidStatus) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Assignment cannot be moved to " + req.Status})
+		return
+	}
+	if errors.Is(err, storage.ErrInvalidProof) {
+		if req.Proof != nil && req.Proof.Type == "pin" {
+			h.log.Warn("Wrong handover code for assignment " + req.Id + " by courier " + req.CourierId)
+		}
+		c.JSON(http.StatusBadRequest, Response{Data: "Proof of delivery does not match the order; orders with a handover code need the customer's code"})
+		return
+	}
+	if errors.Is(err, storage.ErrHandoverLocked) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Too many wrong handover codes, ask an admin to confirm the delivery"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while changing courier job status")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Courier job moved to " + req.Status)
+	c.JSON(http.StatusOK, Response{Data: assignment})
+}
+
+// courierJobParams validates the courier and assignment ids of the path. It
+// writes the error response itself.
+func courierJobParams(c *gin.Context) (string, string, bool) {
+	courierId, id := c.Param("id"), c.Param("assignment_id")
+	if uuid.Validate(courierId) != nil || uuid.Validate(id) != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return "", "", false
+	}
+	return courierId, id, true
+}
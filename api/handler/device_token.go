@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"food/api/models"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// deviceTokenMaxLen caps push tokens; FCM tokens are about 160 characters.
+const deviceTokenMaxLen = 4096
+
+// @ID 			register_device
+// @Router 		/food/api/v1/users/{id}/devices [POST]
+// @Summary 	Register Device
+// @Description Registers the push token of the app the user is signed in to, or refreshes it. Apps call it on every start and whenever the token changes. A token that belonged to another user moves to this one.
+// @Tags 		notification
+// @Accept 		json
+// @Produce 	json
+// @Param 		id     path string                       true "User ID"
+// @Param 		device body models.RegisterDeviceRequest true "Device"
+// @Success 	200 {object} Response{data=models.DeviceToken} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "User not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) RegisterDevice(c *gin.Context) {
+	var req models.RegisterDeviceRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Device Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+	req.Token = strings.TrimSpace(req.Token)
+	if req.Token == "" || len(req.Token) > deviceTokenMaxLen {
+		c.JSON(http.StatusBadRequest, Response{Data: "token is required"})
+		return
+	}
+	if req.Platform != "android" && req.Platform != "ios" && req.Platform != "web" {
+		c.JSON(http.StatusBadRequest, Response{Data: "platform must be android, ios or web"})
+		return
+	}
+
+	device, err := h.storage.DeviceToken().Register(c.Request.Context(), id, &req)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "User not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while registering device")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Device registered successfully")
+	c.JSON(http.StatusOK, Response{Data: device})
+}
+
+// @ID 			unregister_device
+// @Router 		/food/api/v1/users/{id}/devices [DELETE]
+// @Summary 	Unregister Device
+// @Description Removes a push token, for example when the user signs out of the app
+// @Tags 		notification
+// @Accept 		json
+// @Produce 	json
+// @Param 		id     path string                         true "User ID"
+// @Param 		device body models.UnregisterDeviceRequest true "Device"
+// @Success 	200 {object} Response{data=string} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Device not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) UnregisterDevice(c *gin.Context) {
+	var req models.UnregisterDeviceRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Device Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+	req.Token = strings.TrimSpace(req.Token)
+	if req.Token == "" {
+		c.JSON(http.StatusBadRequest, Response{Data: "token is required"})
+		return
+	}
+
+	err := h.storage.DeviceToken().Unregister(c.Request.Context(), id, req.Token)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Device not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while unregistering device")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Device unregistered successfully")
+	c.JSON(http.StatusOK, Response{Data: "Device unregistered"})
+}
+
+// @ID 			get_devices
+// @Router 		/food/api/v1/users/{id}/devices [GET]
+// @Summary 	Get Devices
+// @Description Lists the devices the user gets push notifications on, most recently registered first
+// @Tags 		notification
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "User ID"
+// @Success 	200 {object} Response{data=models.GetDeviceTokensResponse} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetDevices(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	resp, err := h.storage.DeviceToken().GetByUser(c.Request.Context(), id)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting devices")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: resp})
+}
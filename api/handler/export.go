@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"bufio"
+	"food/api/models"
+	"food/pkg/spreadsheet"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// exportBufferSize is how much of an export is buffered before it is sent.
+const exportBufferSize = 32 << 10
+
+var (
+	orderExportColumns = []spreadsheet.Column{
+		{Name: "Order ID"}, {Name: "Created At"}, {Name: "User ID"}, {Name: "Customer"}, {Name: "Phone"},
+		{Name: "Branch"}, {Name: "Status"}, {Name: "Delivery Status"}, {Name: "Address"}, {Name: "Courier ID"},
+		{Name: "Order Total", Numeric: true}, {Name: "Item ID"}, {Name: "Product ID"}, {Name: "Combo ID"}, {Name: "Product"},
+		{Name: "Quantity", Numeric: true}, {Name: "Price", Numeric: true}, {Name: "Item Total", Numeric: true},
+		{Name: "Modifiers"},
+	}
+
+	paymentExportColumns = []spreadsheet.Column{
+		{Name: "Payment ID"}, {Name: "Created At"}, {Name: "Order ID"}, {Name: "User ID"}, {Name: "Customer"},
+		{Name: "Branch"}, {Name: "Order Status"}, {Name: "Kind"}, {Name: "Payment Method"}, {Name: "Paid"},
+		{Name: "Amount", Numeric: true}, {Name: "Tip", Numeric: true},
+	}
+
+	courierEarningExportColumns = []spreadsheet.Column{
+		{Name: "Delivery ID"}, {Name: "Delivered At"}, {Name: "Kind"}, {Name: "Courier ID"}, {Name: "Courier"},
+		{Name: "Order ID"}, {Name: "Branch"}, {Name: "Order Total", Numeric: true}, {Name: "Tip", Numeric: true},
+		{Name: "Earnings", Numeric: true},
+	}
+)
+
+// @ID 			export_orders
+// @Router 		/food/api/v1/admin/export/orders [GET]
+// @Summary 	Export Orders
+// @Description Orders placed in the range with one row per item, as CSV or XLSX. Items ordered as a combo have a combo ID instead of a product ID and the name of the combo. The courier is the one the order was last assigned to.
+// @Tags 		export
+// @Produce 	text/csv
+// @Produce 	application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param 		format     query string false "csv or xlsx (default csv)"
+// @Param 		from       query string false "First day, 2006-01-02 (default 30 days ago)"
+// @Param 		to         query string false "Last day, 2006-01-02 (default today)"
+// @Param 		branch_id  query string false "Only orders of this branch"
+// @Param 		status     query string false "Only orders with this status"
+// @Param 		courier_id query string false "Only orders of this courier"
+// @Success 	200 {file} file
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) ExportOrders(c *gin.Context) {
+	format, req, msg := exportRequest(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+
+	h.export(c, "orders", format, req, orderExportColumns, func(w spreadsheet.Writer) error {
+		return h.storage.Export().Orders(c.Request.Context(), req, func(row *models.OrderExportRow) error {
+			return w.Write([]string{
+				row.OrderId, row.CreatedAt, row.UserId, row.CustomerName, row.CustomerPhone,
+				row.BranchName, row.Status, row.DeliveryStatus, row.Address, row.CourierId,
+				formatAmount(row.OrderTotal), row.ItemId, row.ProductId, row.ComboId, row.ProductName,
+				formatQuantity(row), formatItemAmount(row, row.Price), formatItemAmount(row, row.ItemTotal),
+				strings.Join(row.Modifiers, ", "),
+			})
+		})
+	})
+}
+
+// @ID 			export_payments
+// @Router 		/food/api/v1/admin/export/payments [GET]
+// @Summary 	Export Payments
+// @Description Payments made in the range with their order, as CSV or XLSX. Order payments cover the order total and the tip given at checkout; tip payments are tips added after delivery. Tip is the part of the amount that goes to the courier, not the restaurant.
+// @Tags 		export
+// @Produce 	text/csv
+// @Produce 	application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param 		format     query string false "csv or xlsx (default csv)"
+// @Param 		from       query string false "First day, 2006-01-02 (default 30 days ago)"
+// @Param 		to         query string false "Last day, 2006-01-02 (default today)"
+// @Param 		branch_id  query string false "Only orders of this branch"
+// @Param 		status     query string false "Only orders with this status"
+// @Param 		courier_id query string false "Only orders this courier was assigned to"
+// @Success 	200 {file} file
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) ExportPayments(c *gin.Context) {
+	format, req, msg := exportRequest(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+
+	h.export(c, "payments", format, req, paymentExportColumns, func(w spreadsheet.Writer) error {
+		return h.storage.Export().Payments(c.Request.Context(), req, func(row *models.PaymentExportRow) error {
+			return w.Write([]string{
+				row.PaymentId, row.CreatedAt, row.OrderId, row.UserId, row.CustomerName,
+				row.BranchName, row.OrderStatus, row.Kind, row.PaymentMethod, strconv.FormatBool(row.IsPaid),
+				formatAmount(row.Amount), formatAmount(row.Tip),
+			})
+		})
+	})
+}
+
+// @ID 			export_courier_earnings
+// @Router 		/food/api/v1/admin/export/courier-earnings [GET]
+// @Summary 	Export Courier Earnings
+// @Description Deliveries made in the range and what the courier earned for them, as CSV or XLSX. Tips added after delivery are rows of kind tip.
+// @Tags 		export
+// @Produce 	text/csv
+// @Produce 	application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param 		format     query string false "csv or xlsx (default csv)"
+// @Param 		from       query string false "First day, 2006-01-02 (default 30 days ago)"
+// @Param 		to         query string false "Last day, 2006-01-02 (default today)"
+// @Param 		branch_id  query string false "Only orders of this branch"
+// @Param 		status     query string false "Only orders with this status"
+// @Param 		courier_id query string false "Only deliveries of this courier"
+// @Success 	200 {file} file
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) ExportCourierEarnings(c *gin.Context) {
+	format, req, msg := exportRequest(c)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, msg)
+		return
+	}
+
+	h.export(c, "courier-earnings", format, req, courierEarningExportColumns, func(w spreadsheet.Writer) error {
+		return h.storage.Export().CourierEarnings(c.Request.Context(), req, func(row *models.CourierEarningExportRow) error {
+			return w.Write([]string{
+				row.Id, row.DeliveredAt, row.Kind, row.CourierId, row.CourierName,
+				row.OrderId, row.BranchName, formatAmount(row.OrderTotal), formatAmount(row.TipAmount),
+				formatAmount(row.Earnings),
+			})
+		})
+	})
+}
+
+// export streams the rows written by fn to the client as a spreadsheet named
+// after the report and its range.
+func (h *Handler) export(c *gin.Context, name, format string, req *models.ExportRequest, columns []spreadsheet.Column, fn func(spreadsheet.Writer) error) {
+	filename := name + "-" + req.From + "-" + req.To + "." + format
+	h.stream(c, filename, spreadsheet.ContentType(format), func(w io.Writer) error {
+		return writeSpreadsheet(w, name, format, columns, fn)
+	})
+}
+
+// stream sends what write produces to the client as a file download. Once
+// the first bytes are sent the status can no longer change, so later errors
+// only end the download early.
+func (h *Handler) stream(c *gin.Context, filename, contentType string, write func(io.Writer) error) {
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	buf := bufio.NewWriterSize(c.Writer, exportBufferSize)
+	err := write(buf)
+	if err == nil {
+		err = buf.Flush()
+	}
+	if err == nil {
+		return
+	}
+
+	h.log.Error(err.Error() + ":" + "error while streaming " + filename)
+	if !c.Writer.Written() {
+		c.Header("Content-Type", "")
+		c.Header("Content-Disposition", "")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+	}
+}
+
+func writeSpreadsheet(w io.Writer, sheet, format string, columns []spreadsheet.Column, fn func(spreadsheet.Writer) error) error {
+	sw, err := spreadsheet.New(format, w, sheet, columns)
+	if err != nil {
+		return err
+	}
+	if err := fn(sw); err != nil {
+		return err
+	}
+	return sw.Close()
+}
+
+// exportRequest reads the format and filters of an export. It returns a
+// message for the client when they are not valid.
+func exportRequest(c *gin.Context) (string, *models.ExportRequest, string) {
+	format := c.DefaultQuery("format", spreadsheet.CSV)
+	if format != spreadsheet.CSV && format != spreadsheet.XLSX {
+		return "", nil, "format must be csv or xlsx"
+	}
+
+	from, to, msg := dateRangeQuery(c)
+	if msg != "" {
+		return "", nil, msg
+	}
+
+	req := &models.ExportRequest{
+		From:      from,
+		To:        to,
+		BranchId:  c.Query("branch_id"),
+		Status:    c.Query("status"),
+		CourierId: c.Query("courier_id"),
+	}
+	if req.BranchId != "" {
+		if err := uuid.Validate(req.BranchId); err != nil {
+			return "", nil, "please enter a valid branch_id"
+		}
+	}
+	if req.CourierId != "" {
+		if err := uuid.Validate(req.CourierId); err != nil {
+			return "", nil, "please enter a valid courier_id"
+		}
+	}
+
+	return format, req, ""
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// formatQuantity and formatItemAmount leave the item cells of an order
+// without items empty.
+func formatQuantity(row *models.OrderExportRow) string {
+	if row.ItemId == "" {
+		return ""
+	}
+	return strconv.Itoa(row.Quantity)
+}
+
+func formatItemAmount(row *models.OrderExportRow, amount float64) string {
+	if row.ItemId == "" {
+		return ""
+	}
+	return formatAmount(amount)
+}
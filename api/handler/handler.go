@@ -1,17 +1,16 @@
 package handler
 
 import (
-	// "errors"
-	// "food/api/models"
+	"errors"
 	"food/api/models"
 	"food/config"
-	"food/service"
-
-
-	// "food/pkg/jwt"
+	"food/pkg/blob"
+	"food/pkg/jwt"
 	"food/pkg/logger"
+	"food/service"
 	"food/storage"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,6 +20,7 @@ type Handler struct {
 	storage storage.IStorage
 	service service.IServiceManager
 	cfg     *config.Config
+	blob    blob.BlobStore
 }
 
 type Response struct {
@@ -34,12 +34,13 @@ type ErrorResponse struct {
 	Error interface{} `json:"error"`
 }
 
-func NewStrg(log logger.LoggerI, strg storage.IStorage, cfg *config.Config, service service.IServiceManager) *Handler {
+func NewStrg(log logger.LoggerI, strg storage.IStorage, cfg *config.Config, service service.IServiceManager, blobs blob.BlobStore) *Handler {
 	return &Handler{
 		log:     log,
 		storage: strg,
 		service: service,
 		cfg:     cfg,
+		blob:    blobs,
 	}
 }
 
@@ -104,24 +105,27 @@ func ParseLimitQueryParam(c *gin.Context) (uint64, error) {
 	return limit, nil
 }
 
-// func getAuthInfo(c *gin.Context) (models.AuthInfo, error) {
-// 	accessToken := c.GetHeader("Authorization")
-// 	if accessToken == "" {
-// 		return models.AuthInfo{}, errors.New("unauthorized")
-// 	}
-
-// 	m, err := jwt.ExtractClaims(accessToken)
-// 	if err != nil {
-// 		return models.AuthInfo{}, err
-// 	}
-
-// 	role := m["user_role"].(string)
-// 	if !(role == config.ADMIN_ROLE || role == config.USER_ROLE) {
-// 		return models.AuthInfo{}, errors.New("unauthorized")
-// 	}
-
-// 	return models.AuthInfo{
-// 		UserID:   m["user_id"].(string),
-// 		UserRole: role,
-// 	}, nil
-// }
+// getAuthInfo returns the user or admin of the request's access token, sent
+// in the Authorization header with or without the Bearer prefix.
+func getAuthInfo(c *gin.Context) (models.AuthInfo, error) {
+	accessToken := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if accessToken == "" {
+		return models.AuthInfo{}, errors.New("unauthorized")
+	}
+
+	m, err := jwt.ExtractClaims(accessToken)
+	if err != nil {
+		return models.AuthInfo{}, err
+	}
+
+	userId, _ := m["user_id"].(string)
+	role, _ := m["user_role"].(string)
+	if userId == "" || !(role == config.ADMIN_ROLE || role == config.USER_ROLE) {
+		return models.AuthInfo{}, errors.New("unauthorized")
+	}
+
+	return models.AuthInfo{
+		UserID:   userId,
+		UserRole: role,
+	}, nil
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"errors"
+	"food/api/models"
+	"food/config"
+	"food/pkg/imageproc"
+	"food/storage"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// @ID 			upload_product_images
+// @Router 		/food/api/v1/products/{id}/images [POST]
+// @Summary 	Upload Product Images
+// @Description Adds photos to the end of the product gallery. Every JPEG, PNG or GIF file is checked by content, stripped of EXIF data and re-encoded as JPEG and WebP in thumb, card and full sizes; the WebP URLs and webp_srcset are set for images uploaded since WebP was added. If any file is rejected or cannot be saved, none of the files are added. The first gallery image also becomes the product image_url.
+// @Tags 		image
+// @Accept 		multipart/form-data
+// @Produce 	json
+// @Param 		id path string true "Product ID"
+// @Param 		file formData []file true "Images to upload"
+// @Success 	201 {object} models.UploadImagesResponse
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) UploadProductImages(c *gin.Context) {
+	h.uploadImages(c, c.Param("id"), "")
+}
+
+// @ID 			upload_combo_images
+// @Router 		/food/api/v1/combos/{id}/images [POST]
+// @Summary 	Upload Combo Images
+// @Description Adds photos to the end of the combo gallery. Every JPEG, PNG or GIF file is checked by content, stripped of EXIF data and re-encoded as JPEG and WebP in thumb, card and full sizes; the WebP URLs and webp_srcset are set for images uploaded since WebP was added. If any file is rejected or cannot be saved, none of the files are added.
+// @Tags 		image
+// @Accept 		multipart/form-data
+// @Produce 	json
+// @Param 		id path string true "Combo ID"
+// @Param 		file formData []file true "Images to upload"
+// @Success 	201 {object} models.UploadImagesResponse
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) UploadComboImages(c *gin.Context) {
+	h.uploadImages(c, "", c.Param("id"))
+}
+
+func (h *Handler) uploadImages(c *gin.Context, productId, comboId string) {
+	if err := uuid.Validate(productId + comboId); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating id")
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, config.MaxGalleryImages*config.MaxImageUploadBytes)
+	form, err := c.MultipartForm()
+	if err != nil {
+		h.log.Error(err.Error() + "  :  " + "File error")
+		c.JSON(http.StatusBadRequest, Response{Data: "File error"})
+		return
+	}
+
+	files := form.File["file"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, Response{Data: "no file uploaded"})
+		return
+	}
+
+	// Every file is checked and processed before anything is stored, so a
+	// bad file rejects the whole upload.
+	photos := make([][]imageproc.Rendition, 0, len(files))
+	for _, file := range files {
+		if file.Size > config.MaxImageUploadBytes {
+			c.JSON(http.StatusBadRequest, Response{Data: file.Filename + ": image must be at most " + strconv.Itoa(config.MaxImageUploadBytes>>20) + " MB"})
+			return
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			h.log.Error(err.Error() + "  :  " + "File error")
+			c.JSON(http.StatusBadRequest, Response{Data: "File error"})
+			return
+		}
+		data, err := io.ReadAll(io.LimitReader(f, config.MaxImageUploadBytes))
+		f.Close()
+		if err != nil {
+			h.log.Error(err.Error() + "  :  " + "File error")
+			c.JSON(http.StatusBadRequest, Response{Data: "File error"})
+			return
+		}
+
+		renditions, err := imageproc.Process(data)
+		if errors.Is(err, imageproc.ErrUnsupported) {
+			c.JSON(http.StatusBadRequest, Response{Data: file.Filename + ": only JPEG, PNG and GIF images are allowed"})
+			return
+		}
+		if err != nil {
+			h.log.Error(err.Error() + ":" + "error while processing image")
+			c.JSON(http.StatusInternalServerError, Response{Data: "Upload error"})
+			return
+		}
+		photos = append(photos, renditions)
+	}
+
+	images, err := h.service.Image().Upload(c.Request.Context(), productId, comboId, photos)
+	if errors.Is(err, storage.ErrGalleryFull) {
+		c.JSON(http.StatusBadRequest, Response{Data: "a gallery can have at most " + strconv.Itoa(config.MaxGalleryImages) + " images"})
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while uploading image")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Upload error"})
+		return
+	}
+
+	h.log.Info("Images uploaded successfully")
+	c.JSON(http.StatusCreated, models.UploadImagesResponse{Images: images})
+}
+
+// @ID 			reorder_product_images
+// @Router 		/food/api/v1/products/{id}/images [PUT]
+// @Summary 	Reorder Product Images
+// @Description Sets the order of the product gallery. image_ids must list every image of the product once; the first one becomes the product image_url.
+// @Tags 		image
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Product ID"
+// @Param 		order body models.ReorderImagesRequest true "Image ids in display order"
+// @Success 	200 {object} []models.ProductImage
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) ReorderProductImages(c *gin.Context) {
+	h.reorderImages(c, c.Param("id"), "")
+}
+
+// @ID 			reorder_combo_images
+// @Router 		/food/api/v1/combos/{id}/images [PUT]
+// @Summary 	Reorder Combo Images
+// @Description Sets the order of the combo gallery. image_ids must list every image of the combo once.
+// @Tags 		image
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Combo ID"
+// @Param 		order body models.ReorderImagesRequest true "Image ids in display order"
+// @Success 	200 {object} []models.ProductImage
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) ReorderComboImages(c *gin.Context) {
+	h.reorderImages(c, "", c.Param("id"))
+}
+
+func (h *Handler) reorderImages(c *gin.Context, productId, comboId string) {
+	var req models.ReorderImagesRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Image Order Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	for _, id := range append([]string{productId + comboId}, req.ImageIds...) {
+		if err := uuid.Validate(id); err != nil {
+			h.log.Error(err.Error() + ":" + "error while validating id")
+			c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+			return
+		}
+	}
+
+	images, err := h.storage.Image().Reorder(c.Request.Context(), productId, comboId, req.ImageIds)
+	if errors.Is(err, storage.ErrInvalidImageOrder) {
+		c.JSON(http.StatusBadRequest, Response{Data: err.Error()})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while reordering images")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Images reordered successfully")
+	c.JSON(http.StatusOK, images)
+}
+
+// @ID 			delete_image
+// @Router 		/food/api/v1/images/{id} [DELETE]
+// @Summary 	Delete Image
+// @Description Removes an image from its gallery and deletes its files
+// @Tags 		image
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Image ID"
+// @Success 	200 {object} Response{data=string} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) DeleteImage(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating id")
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	err := h.service.Image().Delete(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Image not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while deleting image")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Image deleted successfully!")
+	c.JSON(http.StatusOK, Response{Data: id})
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"food/api/models"
+	"food/storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// @ID 			get_jobs
+// @Router 		/food/api/v1/admin/jobs [GET]
+// @Summary 	Get All Jobs
+// @Description Lists the background jobs, the ones due last first. Pending jobs wait for run_at, running ones are held by a worker, and failed ones used all their attempts or cannot succeed; last_error tells why.
+// @Tags 		job
+// @Accept 		json
+// @Produce 	json
+// @Param 		type   query string false "Job type, e.g. order.notify"
+// @Param 		status query string false "pending, running, done or failed"
+// @Param 		page   query uint64 false "Page number"
+// @Param 		limit  query uint64 false "Limit number of results per page"
+// @Success 	200 {object} Response{data=models.GetAllJobsResponse} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetJobs(c *gin.Context) {
+	req := &models.GetAllJobsRequest{
+		Type:   c.Query("type"),
+		Status: c.Query("status"),
+	}
+	switch req.Status {
+	case "", "pending", "running", "done", "failed":
+	default:
+		c.JSON(http.StatusBadRequest, Response{Data: "status must be pending, running, done or failed"})
+		return
+	}
+
+	var err error
+	req.Page, err = ParsePageQueryParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "BadRequest at paging"})
+		return
+	}
+	req.Limit, err = ParseLimitQueryParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "BadRequest at limit"})
+		return
+	}
+
+	resp, err := h.storage.Job().GetAll(c.Request.Context(), req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting jobs")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: resp})
+}
+
+// @ID 			retry_job
+// @Router 		/food/api/v1/admin/jobs/{id}/retry [POST]
+// @Summary 	Retry Job
+// @Description Runs a failed job again now with a fresh set of attempts, unless a job with the same unique key is already queued
+// @Tags 		job
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Job ID"
+// @Success 	200 {object} Response{data=models.Job} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Job not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) RetryJob(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	job, err := h.storage.Job().Requeue(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Job not found"})
+		return
+	}
+	if errors.Is(err, storage.ErrInvalidStatus) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Only failed jobs can be retried"})
+		return
+	}
+	if errors.Is(err, storage.ErrJobQueued) {
+		c.JSON(http.StatusBadRequest, Response{Data: "A job with the same unique key is queued"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while retrying job")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Job queued again")
+	c.JSON(http.StatusOK, Response{Data: job})
+}
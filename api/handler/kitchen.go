@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"errors"
+	"food/api/models"
+	"food/storage"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// kitchenFeedRefresh is how often the live feed resends the queue even when
+// nothing was bumped on this instance, so confirmations made elsewhere show up.
+const kitchenFeedRefresh = 10 * time.Second
+
+// @ID 			get_kitchen_queue
+// @Router 		/food/api/v1/kitchen/{branch_id}/queue [GET]
+// @Summary 	Get Kitchen Queue
+// @Description Lists the confirmed and in-progress orders of a branch with their items, sorted by due time
+// @Tags 		kitchen
+// @Accept 		json
+// @Produce 	json
+// @Param 		branch_id path string true "Branch ID"
+// @Success 	200 {object} models.KitchenQueueResponse
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetKitchenQueue(c *gin.Context) {
+	branchId := c.Param("branch_id")
+
+	if err := uuid.Validate(branchId); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating branch id")
+		c.JSON(http.StatusBadRequest, "please enter a valid branch id")
+		return
+	}
+
+	queue, err := h.storage.Kitchen().GetQueue(c.Request.Context(), branchId)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting kitchen queue")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
+
+	h.log.Info("Kitchen queue retrieved successfully")
+	c.JSON(http.StatusOK, queue)
+}
+
+// @ID 			kitchen_feed
+// @Router 		/food/api/v1/kitchen/{branch_id}/feed [GET]
+// @Summary 	Kitchen Live Feed
+// @Description Streams the kitchen queue of a branch as server-sent "queue" events whenever it changes
+// @Tags 		kitchen
+// @Produce 	text/event-stream
+// @Param 		branch_id path string true "Branch ID"
+// @Success 	200 {object} models.KitchenQueueResponse
+// @Response 	400 {object} Response{data=string} "Bad Request"
+func (h *Handler) KitchenFeed(c *gin.Context) {
+	branchId := c.Param("branch_id")
+
+	if err := uuid.Validate(branchId); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating branch id")
+		c.JSON(http.StatusBadRequest, "please enter a valid branch id")
+		return
+	}
+
+	updates, unsubscribe := h.service.Kitchen().Subscribe(branchId)
+	defer unsubscribe()
+
+	ticker := time.NewTicker(kitchenFeedRefresh)
+	defer ticker.Stop()
+
+	send := func() bool {
+		queue, err := h.storage.Kitchen().GetQueue(c.Request.Context(), branchId)
+		if err != nil {
+			h.log.Error(err.Error() + ":" + "error while getting kitchen queue")
+			return false
+		}
+		c.SSEvent("queue", queue)
+		c.Writer.Flush()
+		return true
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	for send() {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-updates:
+		case <-ticker.C:
+		}
+	}
+}
+
+// @ID 			bump_kitchen_order
+// @Router 		/food/api/v1/kitchen/{branch_id}/orders/{id}/status [PATCH]
+// @Summary 	Bump Kitchen Order
+// @Description Moves a whole order and its items to preparing or ready
+// @Tags 		kitchen
+// @Accept 		json
+// @Produce 	json
+// @Param 		branch_id path string true "Branch ID"
+// @Param 		id path string true "Order ID"
+// @Param 		status body models.KitchenStatusRequest true "preparing or ready"
+// @Success 	200 {object} Response{data=string} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) BumpKitchenOrder(c *gin.Context) {
+	var req models.KitchenStatusRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Kitchen Status Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	err := h.service.Kitchen().BumpOrder(c.Request.Context(), c.Param("branch_id"), c.Param("id"), req.Status)
+	if errors.Is(err, storage.ErrInvalidStatus) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Order cannot be moved to " + req.Status})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Kitchen order bumped successfully")
+	c.JSON(http.StatusOK, Response{Data: req.Status})
+}
+
+// @ID 			bump_kitchen_item
+// @Router 		/food/api/v1/kitchen/{branch_id}/items/{id}/status [PATCH]
+// @Summary 	Bump Kitchen Item
+// @Description Moves a single order item to preparing or ready and rolls the order status up
+// @Tags 		kitchen
+// @Accept 		json
+// @Produce 	json
+// @Param 		branch_id path string true "Branch ID"
+// @Param 		id path string true "Order item ID"
+// @Param 		status body models.KitchenStatusRequest true "preparing or ready"
+// @Success 	200 {object} Response{data=string} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) BumpKitchenItem(c *gin.Context) {
+	var req models.KitchenStatusRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Kitchen Status Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	err := h.service.Kitchen().BumpItem(c.Request.Context(), c.Param("branch_id"), c.Param("id"), req.Status)
+	if errors.Is(err, storage.ErrInvalidStatus) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Item cannot be moved to " + req.Status})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Kitchen item bumped successfully")
+	c.JSON(http.StatusOK, Response{Data: req.Status})
+}
+
+// @ID 			get_kitchen_stats
+// @Router 		/food/api/v1/kitchen/{branch_id}/stats [GET]
+// @Summary 	Get Kitchen Stats
+// @Description Average and longest preparation time per product for a branch
+// @Tags 		kitchen
+// @Accept 		json
+// @Produce 	json
+// @Param 		branch_id path string true "Branch ID"
+// @Param 		from query string false "From date (YYYY-MM-DD), defaults to 30 days ago"
+// @Param 		to   query string false "To date (YYYY-MM-DD), defaults to today"
+// @Success 	200 {object} models.KitchenStatsResponse
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetKitchenStats(c *gin.Context) {
+	req := &models.KitchenStatsRequest{
+		BranchId: c.Param("branch_id"),
+		From:     c.DefaultQuery("from", time.Now().AddDate(0, 0, -30).Format("2006-01-02")),
+		To:       c.DefaultQuery("to", time.Now().Format("2006-01-02")),
+	}
+
+	if err := uuid.Validate(req.BranchId); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating branch id")
+		c.JSON(http.StatusBadRequest, "please enter a valid branch id")
+		return
+	}
+	for _, date := range []string{req.From, req.To} {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			h.log.Error(err.Error() + ":" + "error while parsing date")
+			c.JSON(http.StatusBadRequest, "dates must look like 2006-01-02")
+			return
+		}
+	}
+
+	stats, err := h.storage.Kitchen().GetStats(c.Request.Context(), req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting kitchen stats")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
+
+	h.log.Info("Kitchen stats retrieved successfully")
+	c.JSON(http.StatusOK, stats)
+}
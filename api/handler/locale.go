@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"fmt"
+	"food/api/models"
+	"food/pkg/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestLocale resolves the language of a request from the lang query
+// parameter, then Accept-Language.
+func requestLocale(c *gin.Context) string {
+	return i18n.Parse(c.Query("lang"), c.GetHeader("Accept-Language"))
+}
+
+// localize replaces the base name and description with their translation in
+// locale, falling back to the base uz-Latn text for missing fields, and drops
+// the translation set from the response. With i18n.All everything is kept.
+func localize(locale string, name, description *string, translations *map[string]models.Translation) {
+	if locale == i18n.All {
+		return
+	}
+
+	if t, ok := (*translations)[locale]; ok {
+		if t.Name != "" {
+			*name = t.Name
+		}
+		if t.Description != "" && description != nil {
+			*description = t.Description
+		}
+	}
+	*translations = nil
+}
+
+func localizeProduct(locale string, product *models.Product) {
+	localize(locale, &product.Name, &product.Description, &product.Translations)
+}
+
+func localizeCategory(locale string, category *models.Category) {
+	localize(locale, &category.Name, nil, &category.Translations)
+	for i := range category.Children {
+		localizeCategory(locale, &category.Children[i])
+	}
+}
+
+func localizeCombo(locale string, combo *models.Combo) {
+	localize(locale, &combo.Name, &combo.Description, &combo.Translations)
+}
+
+// normalizeTranslations validates the locales of a translation set sent by an
+// admin and canonicalizes their tags. A uz-Latn entry updates the base name
+// and description instead, since those columns hold the uz-Latn text.
+func normalizeTranslations(name, description *string, translations map[string]models.Translation) (map[string]models.Translation, error) {
+	out := make(map[string]models.Translation, len(translations))
+	for tag, t := range translations {
+		locale, ok := i18n.Match(tag)
+		if !ok {
+			return nil, fmt.Errorf("unsupported locale %q, use one of %v", tag, i18n.Supported)
+		}
+
+		if locale == i18n.Default {
+			if t.Name != "" {
+				*name = t.Name
+			}
+			if t.Description != "" && description != nil {
+				*description = t.Description
+			}
+			continue
+		}
+
+		if description == nil {
+			t.Description = ""
+		}
+		if t.Name == "" && t.Description == "" {
+			continue
+		}
+		out[locale] = t
+	}
+	return out, nil
+}
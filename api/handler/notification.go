@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"errors"
+	"food/api/models"
+	"food/pkg/i18n"
+	"food/storage"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// @ID 			get_notification_preferences
+// @Router 		/food/api/v1/users/{id}/notification-preferences [GET]
+// @Summary 	Get Notification Preferences
+// @Description Returns the language of the user's notifications and the channels they are sent on besides the in-app inbox. Users who never set them get SMS and push in uz-Latn.
+// @Tags 		notification
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "User ID"
+// @Success 	200 {object} Response{data=models.NotificationPreferences} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "User not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetNotificationPreferences(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	recipient, err := h.storage.Notification().Recipient(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "User not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting notification preferences")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: recipient.Preferences})
+}
+
+// @ID 			set_notification_preferences
+// @Router 		/food/api/v1/users/{id}/notification-preferences [PUT]
+// @Summary 	Set Notification Preferences
+// @Description Replaces the language of the user's notifications (uz-Latn, uz-Cyrl, ru or en) and the channels they are sent on. The in-app inbox always receives them.
+// @Tags 		notification
+// @Accept 		json
+// @Produce 	json
+// @Param 		id          path string                            true "User ID"
+// @Param 		preferences body models.SetNotificationPreferences true "Preferences"
+// @Success 	200 {object} Response{data=models.NotificationPreferences} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "User not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) SetNotificationPreferences(c *gin.Context) {
+	var req models.SetNotificationPreferences
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Notification Preferences Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+	locale, ok := i18n.Match(req.Locale)
+	if !ok {
+		c.JSON(http.StatusBadRequest, Response{Data: "locale must be uz-Latn, uz-Cyrl, ru or en"})
+		return
+	}
+	req.Locale = locale
+
+	prefs, err := h.storage.Notification().SetPreferences(c.Request.Context(), id, &req)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "User not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while setting notification preferences")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Notification preferences set successfully")
+	c.JSON(http.StatusOK, Response{Data: prefs})
+}
+
+// @ID 			get_notification_deliveries
+// @Router 		/food/api/v1/admin/notifications/{id}/deliveries [GET]
+// @Summary 	Get Notification Deliveries
+// @Description Shows how a notification was delivered on each channel: sent, pending with the time of the next retry, failed with the last error, or skipped when the channel is not configured
+// @Tags 		notification
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Notification ID"
+// @Success 	200 {object} Response{data=models.GetNotificationDeliveriesResponse} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetNotificationDeliveries(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	resp, err := h.storage.Notification().Deliveries(c.Request.Context(), id)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting notification deliveries")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: resp})
+}
+
+// inboxMaxLimit caps the page size of the inbox.
+const inboxMaxLimit = 100
+
+// @ID 			get_my_notifications
+// @Router 		/food/api/v1/me/notifications [GET]
+// @Summary 	Get My Notifications
+// @Description Lists the signed-in user's notifications newest first. Pass next_cursor of a page as cursor to get the next one; the last page has none. unread=true lists only unread notifications.
+// @Tags 		notification
+// @Accept 		json
+// @Produce 	json
+// @Security 	ApiKeyAuth
+// @Param 		cursor query string false "Cursor of the next page"
+// @Param 		limit  query uint64 false "Page size, at most 100"
+// @Param 		unread query bool   false "Only unread notifications"
+// @Success 	200 {object} Response{data=models.GetInboxResponse} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	401 {object} Response{data=string} "Unauthorized"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetMyNotifications(c *gin.Context) {
+	auth, err := getAuthInfo(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, Response{Data: "Unauthorized"})
+		return
+	}
+
+	limit, err := strconv.ParseUint(c.DefaultQuery("limit", "20"), 10, 64)
+	if err != nil || limit == 0 || limit > inboxMaxLimit {
+		c.JSON(http.StatusBadRequest, Response{Data: "limit must be between 1 and " + strconv.Itoa(inboxMaxLimit)})
+		return
+	}
+	unread, err := strconv.ParseBool(c.DefaultQuery("unread", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "unread must be true or false"})
+		return
+	}
+
+	resp, err := h.storage.Notification().Inbox(c.Request.Context(), &models.GetInboxRequest{
+		UserId: auth.UserID,
+		Unread: unread,
+		Cursor: c.Query("cursor"),
+		Limit:  limit,
+	})
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, Response{Data: "invalid cursor"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting inbox")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: resp})
+}
+
+// @ID 			get_my_unread_count
+// @Router 		/food/api/v1/me/notifications/unread-count [GET]
+// @Summary 	Get My Unread Count
+// @Description Counts the signed-in user's unread notifications, for the badge
+// @Tags 		notification
+// @Accept 		json
+// @Produce 	json
+// @Security 	ApiKeyAuth
+// @Success 	200 {object} Response{data=models.UnreadCountResponse} "Success Request"
+// @Response 	401 {object} Response{data=string} "Unauthorized"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetMyUnreadCount(c *gin.Context) {
+	auth, err := getAuthInfo(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, Response{Data: "Unauthorized"})
+		return
+	}
+
+	count, err := h.storage.Notification().UnreadCount(c.Request.Context(), auth.UserID)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while counting unread notifications")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: models.UnreadCountResponse{Unread: count}})
+}
+
+// @ID 			mark_my_notifications_read
+// @Router 		/food/api/v1/me/notifications/read [POST]
+// @Summary 	Mark My Notifications Read
+// @Description Marks the listed notifications of the signed-in user read, or all of them with all=true. Ids of other users' notifications are ignored. Returns how many were marked and how many are still unread.
+// @Tags 		notification
+// @Accept 		json
+// @Produce 	json
+// @Security 	ApiKeyAuth
+// @Param 		request body models.MarkNotificationsReadRequest true "Notifications"
+// @Success 	200 {object} Response{data=models.MarkNotificationsReadResponse} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	401 {object} Response{data=string} "Unauthorized"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) MarkMyNotificationsRead(c *gin.Context) {
+	auth, err := getAuthInfo(c)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, Response{Data: "Unauthorized"})
+		return
+	}
+
+	var req models.MarkNotificationsReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Mark Read Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+	if !req.All && len(req.Ids) == 0 {
+		c.JSON(http.StatusBadRequest, Response{Data: "give the ids to mark read or all=true"})
+		return
+	}
+	if len(req.Ids) > inboxMaxLimit {
+		c.JSON(http.StatusBadRequest, Response{Data: "at most " + strconv.Itoa(inboxMaxLimit) + " ids at once, use all=true"})
+		return
+	}
+	for _, id := range req.Ids {
+		if err := uuid.Validate(id); err != nil {
+			c.JSON(http.StatusBadRequest, Response{Data: "please enter valid ids"})
+			return
+		}
+	}
+
+	updated, err := h.storage.Notification().MarkRead(c.Request.Context(), auth.UserID, &req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while marking notifications read")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+	unread, err := h.storage.Notification().UnreadCount(c.Request.Context(), auth.UserID)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while counting unread notifications")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: models.MarkNotificationsReadResponse{Updated: updated, Unread: unread}})
+}
@@ -3,20 +3,24 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"food/api/models"
+	"food/storage"
 	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 )
 
 // Create Order godoc
 // @ID          create_order
 // @Router      /food/api/v1/order [POST]
 // @Summary     Create Order
-// @Description Create Order
+// @Description Create Order. Each item is either a product (product_id) or a combo (combo_id), which is sold at the combo price while it is available. A tip for the courier is given as an amount (tip) or as a percentage of the total (tip_percent); it is paid with the order.
 // @Tags        order
 // @Accept      json
 // @Order       json
@@ -50,14 +54,39 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 		return
 	}
 	for _, item := range request.Items {
-		if item.ProductId == "" {
-			h.log.Error("Product ID is empty for one of the items!")
-			c.JSON(http.StatusBadRequest, Response{Data: "Product ID is required for each item!"})
+		if (item.ProductId == "") == (item.ComboId == "") {
+			h.log.Error("Product ID or combo ID is missing for one of the items!")
+			c.JSON(http.StatusBadRequest, Response{Data: "Each item needs either a product ID or a combo ID!"})
+			return
+		}
+	}
+	if request.Order.Tip < 0 || request.Order.TipPercent < 0 || request.Order.TipPercent > 100 {
+		c.JSON(http.StatusBadRequest, Response{Data: "tip must not be negative and tip_percent must be between 0 and 100!"})
+		return
+	}
+	if request.Order.Tip > 0 && request.Order.TipPercent > 0 {
+		c.JSON(http.StatusBadRequest, Response{Data: "give either tip or tip_percent!"})
+		return
+	}
+	if request.Order.ScheduledAt != "" {
+		if request.Order.BranchId == "" {
+			h.log.Error("Branch ID is empty for a scheduled order!")
+			c.JSON(http.StatusBadRequest, Response{Data: "Branch ID is required for a scheduled order!"})
+			return
+		}
+		if _, err := time.Parse(time.RFC3339, request.Order.ScheduledAt); err != nil {
+			h.log.Error("error parsing scheduled_at: " + err.Error())
+			c.JSON(http.StatusBadRequest, Response{Data: "scheduled_at must be an RFC 3339 time!"})
 			return
 		}
 	}
 
 	order, err := h.storage.Order().Create(c.Request.Context(), &request)
+	if errors.Is(err, storage.ErrSlotUnavailable) {
+		h.log.Error("error in Order.CreateOrder: " + err.Error())
+		c.JSON(http.StatusBadRequest, Response{Data: "The chosen delivery slot is not available!"})
+		return
+	}
 	if err != nil {
 		h.log.Error("error in Order.CreateOrder: " + err.Error())
 		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
@@ -220,7 +249,7 @@ func (h *Handler) DeleteOrder(c *gin.Context) {
 // @ID             change_order_status
 // @Router         /food/api/v1/orderStatus/{id} [PATCH]
 // @Summary        Change Order Status
-// @Description    Change the status of an order
+// @Description    Moves an order forward or cancels it; delivered and cancelled orders are final, and courier deliveries are completed through the courier endpoints. The customer is notified on confirmation and delivery.
 // @Tags           order
 // @Accept         json
 // @Produces       json
@@ -228,6 +257,7 @@ func (h *Handler) DeleteOrder(c *gin.Context) {
 // @Param          status body models.PatchOrderStatusRequest true "New Order Status"
 // @Success        200 {object} Response{data=string} "Order status updated successfully"
 // @Response       400 {object} Response{data=string} "Bad Request"
+// @Response       404 {object} Response{data=string} "Order not found"
 // @Response       500 {object} Response{data=string} "Server error"
 func (h *Handler) ChangeOrderStatus(c *gin.Context) {
 	// Get order ID from the URL
@@ -252,12 +282,22 @@ func (h *Handler) ChangeOrderStatus(c *gin.Context) {
 
 	// Call the repository method to change the order status
 	resp, err := h.storage.Order().ChangeOrderStatus(c.Request.Context(), &req, orderId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Order not found"})
+		return
+	}
+	if errors.Is(err, storage.ErrInvalidStatus) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Order cannot be moved to " + req.Status})
+		return
+	}
 	if err != nil {
 		h.log.Error("failed to update order status: " + err.Error())
 		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
 		return
 	}
 
+	h.service.Kitchen().OrderChanged(c.Request.Context(), orderId)
+
 	h.log.Info("Order status updated successfully")
 	c.JSON(http.StatusOK, Response{Data: resp})
 }
@@ -2,12 +2,15 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"food/api/models"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 )
 
 // @ID 			create_product
@@ -30,6 +33,13 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 		return
 	}
 
+	translations, err := normalizeTranslations(&product.Name, &product.Description, product.Translations)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	product.Translations = translations
+
 	resp, err := h.storage.Product().Create(c.Request.Context(), &product)
 	if err != nil {
 		h.log.Error(err.Error() + ":" + "Error Product Create")
@@ -44,7 +54,7 @@ func (h *Handler) CreateProduct(c *gin.Context) {
 // @ID 			update_product
 // @Router 		/food/api/v1/updateproduct/{id} [PUT]
 // @Summary 	Update Product
-// @Description Update an existing product
+// @Description Update an existing product. A changed price takes effect at once and is added to the price history; scheduled price changes stay planned.
 // @Tags 		product
 // @Accept 		json
 // @Produce 	json
@@ -73,9 +83,20 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 	product.Name = updateProduct.Name
 	product.Description = updateProduct.Description
 	product.Price = updateProduct.Price
-	product.ImageURL = updateProduct.ImageURL
+	// A product with a gallery keeps its first image as image_url.
+	if len(product.Images) == 0 {
+		product.ImageURL = updateProduct.ImageURL
+	}
 	product.CategoryId = updateProduct.CategoryId
 
+	if updateProduct.Translations != nil {
+		product.Translations, err = normalizeTranslations(&product.Name, &product.Description, updateProduct.Translations)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
 	resp, err := h.storage.Product().Update(c.Request.Context(), product)
 	if err != nil {
 		h.log.Error(err.Error() + ":" + "Error Product Update")
@@ -95,6 +116,7 @@ func (h *Handler) UpdateProduct(c *gin.Context) {
 // @Accept 		json
 // @Produce 	json
 // @Param 		id path string true "Product ID"
+// @Param 		lang query string false "uz-Latn, uz-Cyrl, ru or en (default from Accept-Language); all returns every translation"
 // @Success 	200 {object} models.Product
 // @Response 	400 {object} Response{data=string} "Bad Request"
 // @Failure 	500 {object} Response{data=string} "Server error"
@@ -114,6 +136,8 @@ func (h *Handler) GetProductByID(c *gin.Context) {
 		return
 	}
 
+	localizeProduct(requestLocale(c), product)
+
 	h.log.Info("Product retrieved successfully by ID")
 	c.JSON(http.StatusOK, product)
 }
@@ -127,8 +151,10 @@ func (h *Handler) GetProductByID(c *gin.Context) {
 // @Produce 	json
 // @Param       category_id query string false "get by category_id"
 // @Param 		search query string false "Search products by name or description"
+// @Param 		include_hidden query bool false "Include products of inactive and currently hidden categories (admin)"
 // @Param 		page   query uint64 false "Page number"
 // @Param 		limit  query uint64 false "Limit number of results per page"
+// @Param 		lang query string false "uz-Latn, uz-Cyrl, ru or en (default from Accept-Language); all returns every translation"
 // @Success 	200 {object} models.GetAllProductsResponse
 // @Response 	400 {object} Response{data=string} "Bad Request"
 // @Failure 	500 {object} Response{data=string} "Server error"
@@ -137,6 +163,7 @@ func (h *Handler) GetAllProducts(c *gin.Context) {
 
 	req.Search = c.Query("search")
 	req.CategoryId = c.Query("category_id")
+	req.IncludeHidden = c.Query("include_hidden") == "true"
 
 	page, err := strconv.ParseUint(c.DefaultQuery("page", "1"), 10, 64)
 	if err != nil {
@@ -162,6 +189,11 @@ func (h *Handler) GetAllProducts(c *gin.Context) {
 		return
 	}
 
+	locale := requestLocale(c)
+	for i := range products.Products {
+		localizeProduct(locale, &products.Products[i])
+	}
+
 	h.log.Info("Products retrieved successfully")
 	c.JSON(http.StatusOK, products)
 }
@@ -203,3 +235,134 @@ func (h *Handler) DeleteProduct(c *gin.Context) {
 	h.log.Info("Product deleted successfully!")
 	c.JSON(http.StatusOK, id)
 }
+
+// @ID 			schedule_product_price
+// @Router 		/food/api/v1/products/{id}/prices [POST]
+// @Summary 	Schedule Product Price
+// @Description Plans a price change that a background job applies once effective_from (RFC 3339, in the future) has passed. Orders keep the price that was in effect when they were placed.
+// @Tags 		product
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Product ID"
+// @Param 		price body models.SchedulePriceRequest true "Price change"
+// @Success 	201 {object} models.ProductPrice
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Not Found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) ScheduleProductPrice(c *gin.Context) {
+	var request models.SchedulePriceRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.log.Error(err.Error() + " : " + "error Price Should Bind Json!")
+		c.JSON(http.StatusBadRequest, "Please, enter valid data!")
+		return
+	}
+
+	if request.Price <= 0 {
+		c.JSON(http.StatusBadRequest, "Valid price is required!")
+		return
+	}
+	effectiveFrom, err := time.Parse(time.RFC3339, request.EffectiveFrom)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, "effective_from must be an RFC 3339 time")
+		return
+	}
+	if !effectiveFrom.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, "effective_from must be in the future")
+		return
+	}
+
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, "please enter a valid id")
+		return
+	}
+
+	price, err := h.storage.Product().SchedulePrice(c.Request.Context(), id, request.Price, effectiveFrom)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, "Product not found!")
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "Error Price Schedule")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
+
+	h.log.Info("Price scheduled successfully!")
+	c.JSON(http.StatusCreated, price)
+}
+
+// @ID 			get_product_prices
+// @Router 		/food/api/v1/products/{id}/prices [GET]
+// @Summary 	Get Product Price Timeline
+// @Description Lists every price the product had or is scheduled to have, oldest first. Each entry is in effect from effective_from until effective_to.
+// @Tags 		product
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Product ID"
+// @Success 	200 {object} models.PriceTimelineResponse
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Not Found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetProductPrices(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, "please enter a valid id")
+		return
+	}
+
+	prices, err := h.storage.Product().PriceHistory(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, "Product not found!")
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "Error while getting price history")
+		c.JSON(http.StatusInternalServerError, "Server Error")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.PriceTimelineResponse{ProductId: id, Prices: prices})
+}
+
+// @ID 			cancel_product_price
+// @Router 		/food/api/v1/products/{id}/prices/{price_id} [DELETE]
+// @Summary 	Cancel Scheduled Product Price
+// @Description Removes a price change that has not been applied yet
+// @Tags 		product
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Product ID"
+// @Param 		price_id path string true "Price ID"
+// @Success 	200 {object} Response{data=string} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Not Found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) CancelProductPrice(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, "please enter a valid id")
+		return
+	}
+
+	priceId := c.Param("price_id")
+	if err := uuid.Validate(priceId); err != nil {
+		c.JSON(http.StatusBadRequest, "please enter a valid price_id")
+		return
+	}
+
+	err := h.storage.Product().CancelScheduledPrice(c.Request.Context(), id, priceId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, "Scheduled price not found!")
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "Error Price Cancel")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
+
+	h.log.Info("Scheduled price cancelled successfully!")
+	c.JSON(http.StatusOK, priceId)
+}
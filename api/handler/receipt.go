@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"errors"
+	"food/pkg/receipt"
+	"food/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// @ID 			get_order_receipt
+// @Router 		/food/api/v1/orders/{id}/receipt [GET]
+// @Summary 	Download Order Receipt
+// @Description Downloads the customer receipt of an order as a PDF
+// @Tags 		order
+// @Produce 	application/pdf
+// @Param 		id path string true "Order ID"
+// @Success 	200 {file} file "PDF receipt"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Order not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetOrderReceipt(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := uuid.Validate(id); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating id")
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	data, err := h.service.Receipt().Build(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Order not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="receipt-`+id+`.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", receipt.PDF(data))
+}
+
+// @ID 			get_order_kitchen_ticket
+// @Router 		/food/api/v1/orders/{id}/ticket [GET]
+// @Summary 	Download Kitchen Ticket
+// @Description Downloads the kitchen ticket of an order as a raw ESC/POS stream for thermal printers
+// @Tags 		order
+// @Produce 	application/octet-stream
+// @Param 		id path string true "Order ID"
+// @Success 	200 {file} file "ESC/POS ticket"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Order not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetOrderKitchenTicket(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := uuid.Validate(id); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating id")
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	data, err := h.service.Receipt().Build(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Order not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="ticket-`+id+`.bin"`)
+	c.Data(http.StatusOK, "application/octet-stream", receipt.KitchenTicket(data))
+}
+
+// @ID 			email_order_receipt
+// @Router 		/food/api/v1/orders/{id}/receipt/email [POST]
+// @Summary 	Email Order Receipt
+// @Description Emails the receipt of an order to the customer's email address, with the PDF receipt attached
+// @Tags 		order
+// @Produce 	json
+// @Param 		id path string true "Order ID"
+// @Success 	200 {object} Response{data=string} "Address the receipt was sent to"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Order not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) EmailOrderReceipt(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := uuid.Validate(id); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating id")
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	to, err := h.service.Receipt().Email(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Order not found"})
+		return
+	}
+	if errors.Is(err, service.ErrNoEmail) {
+		c.JSON(http.StatusBadRequest, Response{Data: "The customer has no email address"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Receipt emailed successfully")
+	c.JSON(http.StatusOK, Response{Data: to})
+}
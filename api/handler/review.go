@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"errors"
+	"food/api/models"
+	"food/storage"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// reviewMaxPhotos caps how many uploaded photos one review may reference.
+const reviewMaxPhotos = 5
+
+// @ID 			create_review
+// @Router 		/food/api/v1/orders/{id}/review [POST]
+// @Summary 	Review Order
+// @Description Rates the food and the courier of a delivered order. Photos are URLs returned by /uploadfiles. Each order can be reviewed once, within a few days of delivery.
+// @Tags 		review
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Order ID"
+// @Param 		review body models.CreateReview true "Review"
+// @Success 	201 {object} models.Review
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) CreateReview(c *gin.Context) {
+	var req models.CreateReview
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Review Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	orderId := c.Param("id")
+	if err := uuid.Validate(orderId); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating order id")
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid order id"})
+		return
+	}
+	if err := uuid.Validate(req.UserId); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating user id")
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid user_id"})
+		return
+	}
+	if req.FoodRating < 1 || req.FoodRating > 5 {
+		c.JSON(http.StatusBadRequest, Response{Data: "food_rating must be between 1 and 5"})
+		return
+	}
+	if req.CourierRating < 0 || req.CourierRating > 5 {
+		c.JSON(http.StatusBadRequest, Response{Data: "courier_rating must be between 1 and 5"})
+		return
+	}
+	if len(req.PhotoUrls) > reviewMaxPhotos {
+		c.JSON(http.StatusBadRequest, Response{Data: "at most " + strconv.Itoa(reviewMaxPhotos) + " photos are allowed"})
+		return
+	}
+
+	review, err := h.storage.Review().Create(c.Request.Context(), orderId, &req)
+	if errors.Is(err, storage.ErrReviewNotAllowed) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Only delivered orders can be reviewed, by their customer, within a few days"})
+		return
+	}
+	if errors.Is(err, storage.ErrAlreadyReviewed) {
+		c.JSON(http.StatusBadRequest, Response{Data: "This order is already reviewed"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while creating review")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Review created successfully")
+	c.JSON(http.StatusCreated, review)
+}
+
+// @ID 			get_product_reviews
+// @Router 		/food/api/v1/products/{id}/reviews [GET]
+// @Summary 	Get Product Reviews
+// @Description Lists the published reviews of orders that contained the product, newest first
+// @Tags 		review
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Product ID"
+// @Param 		page   query uint64 false "Page number"
+// @Param 		limit  query uint64 false "Limit number of results per page"
+// @Success 	200 {object} models.GetAllReviewsResponse
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetProductReviews(c *gin.Context) {
+	req := &models.GetAllReviewsRequest{
+		ProductId: c.Param("id"),
+		Status:    "published",
+	}
+
+	if err := uuid.Validate(req.ProductId); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating product id")
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid product id"})
+		return
+	}
+
+	h.listReviews(c, req)
+}
+
+// @ID 			get_all_reviews
+// @Router 		/food/api/v1/reviews [GET]
+// @Summary 	Get All Reviews
+// @Description Lists reviews for moderation, newest first
+// @Tags 		review
+// @Accept 		json
+// @Produce 	json
+// @Param 		status query string false "published or hidden"
+// @Param 		product_id query string false "Product ID"
+// @Param 		courier_id query string false "Courier ID"
+// @Param 		page   query uint64 false "Page number"
+// @Param 		limit  query uint64 false "Limit number of results per page"
+// @Success 	200 {object} models.GetAllReviewsResponse
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetAllReviews(c *gin.Context) {
+	req := &models.GetAllReviewsRequest{
+		Status:    c.Query("status"),
+		ProductId: c.Query("product_id"),
+		CourierId: c.Query("courier_id"),
+	}
+
+	for _, id := range []string{req.ProductId, req.CourierId} {
+		if id == "" {
+			continue
+		}
+		if err := uuid.Validate(id); err != nil {
+			h.log.Error(err.Error() + ":" + "error while validating id")
+			c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+			return
+		}
+	}
+
+	h.listReviews(c, req)
+}
+
+func (h *Handler) listReviews(c *gin.Context, req *models.GetAllReviewsRequest) {
+	page, err := strconv.ParseUint(c.DefaultQuery("page", "1"), 10, 64)
+	if err != nil || page == 0 {
+		c.JSON(http.StatusBadRequest, "BadRequest at paging")
+		return
+	}
+
+	limit, err := strconv.ParseUint(c.DefaultQuery("limit", "10"), 10, 30)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, "BadRequest at limit")
+		return
+	}
+	if limit == 0 {
+		limit = 10
+	}
+
+	req.Page = page
+	req.Limit = limit
+
+	reviews, err := h.storage.Review().GetAll(c.Request.Context(), req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting reviews")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
+
+	h.log.Info("Reviews retrieved successfully")
+	c.JSON(http.StatusOK, reviews)
+}
+
+// @ID 			moderate_review
+// @Router 		/food/api/v1/reviews/{id}/status [PATCH]
+// @Summary 	Moderate Review
+// @Description Publishes or hides a review. Hidden reviews are left out of product ratings.
+// @Tags 		review
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Review ID"
+// @Param 		status body models.ModerateReviewRequest true "published or hidden"
+// @Success 	200 {object} models.Review
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) ModerateReview(c *gin.Context) {
+	var req models.ModerateReviewRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Review Status Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+	if req.Status != "published" && req.Status != "hidden" {
+		c.JSON(http.StatusBadRequest, Response{Data: "status must be published or hidden"})
+		return
+	}
+
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating id")
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	review, err := h.storage.Review().Moderate(c.Request.Context(), id, req.Status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Review not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while moderating review")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Review moderated successfully")
+	c.JSON(http.StatusOK, review)
+}
+
+// @ID 			reply_review
+// @Router 		/food/api/v1/reviews/{id}/reply [POST]
+// @Summary 	Reply To Review
+// @Description Sets the public admin reply of a review, replacing any earlier reply
+// @Tags 		review
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Review ID"
+// @Param 		reply body models.ReplyReviewRequest true "Reply"
+// @Success 	200 {object} models.Review
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) ReplyReview(c *gin.Context) {
+	var req models.ReplyReviewRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil || req.Reply == "" {
+		c.JSON(http.StatusBadRequest, Response{Data: "Please, enter a reply"})
+		return
+	}
+
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating id")
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	review, err := h.storage.Review().Reply(c.Request.Context(), id, req.Reply)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Review not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while replying to review")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Review reply saved successfully")
+	c.JSON(http.StatusOK, review)
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"food/api/models"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	searchDefaultLimit = 20
+	searchMaxLimit     = 50
+)
+
+// @ID 			search_menu
+// @Router 		/food/api/v1/search [GET]
+// @Summary 	Search Menu
+// @Description Searches products, combos and categories by name and description. The query may be typed in Latin or Cyrillic Uzbek and tolerates small typos. Snippets are escaped HTML with the matches wrapped in <mark> tags.
+// @Tags 		search
+// @Accept 		json
+// @Produce 	json
+// @Param 		q     query string true  "Search text"
+// @Param 		type  query string false "product, combo or category"
+// @Param 		limit query int    false "Maximum number of results (default 20, at most 50)"
+// @Param 		lang  query string false "uz-Latn, uz-Cyrl, ru or en (default from Accept-Language)"
+// @Success 	200 {object} models.SearchResponse
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) Search(c *gin.Context) {
+	req := &models.SearchRequest{
+		Query: strings.TrimSpace(c.Query("q")),
+		Type:  c.Query("type"),
+		Lang:  requestLocale(c),
+	}
+
+	if req.Query == "" {
+		c.JSON(http.StatusBadRequest, Response{Data: "q is required"})
+		return
+	}
+	if req.Type != "" && req.Type != "product" && req.Type != "combo" && req.Type != "category" {
+		c.JSON(http.StatusBadRequest, Response{Data: "type must be product, combo or category"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(searchDefaultLimit)))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, Response{Data: "limit must be a positive number"})
+		return
+	}
+	if limit > searchMaxLimit {
+		limit = searchMaxLimit
+	}
+	req.Limit = limit
+
+	resp, err := h.storage.Search().Search(c.Request.Context(), req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while searching menu")
+		c.JSON(http.StatusInternalServerError, "Server error!")
+		return
+	}
+
+	h.log.Info("Search completed successfully")
+	c.JSON(http.StatusOK, resp)
+}
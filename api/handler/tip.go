@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"errors"
+	"food/api/models"
+	"food/config"
+	"food/storage"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @ID 			create_tip
+// @Router 		/food/api/v1/orders/{id}/tip [POST]
+// @Summary 	Tip Courier
+// @Description Tips the courier of a delivered order, as an amount or as a percentage of the order total, within 24 hours of delivery. The tip is paid with click or payme and the courier's share is added to their earnings. Each order can be tipped once this way.
+// @Tags 		order
+// @Accept 		json
+// @Produce 	json
+// @Param 		id  path string                  true "Order ID"
+// @Param 		tip body models.CreateTipRequest true "Tip"
+// @Success 	201 {object} Response{data=models.OrderTip} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) CreateTip(c *gin.Context) {
+	var req models.CreateTipRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Tip Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	orderId := c.Param("id")
+	if err := uuid.Validate(orderId); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating order id")
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid order id"})
+		return
+	}
+	if err := uuid.Validate(req.UserId); err != nil {
+		h.log.Error(err.Error() + ":" + "error while validating user id")
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid user_id"})
+		return
+	}
+	if (req.Amount > 0) == (req.Percent > 0) || req.Amount < 0 || req.Percent < 0 || req.Percent > 100 {
+		c.JSON(http.StatusBadRequest, Response{Data: "give either a positive amount or a percent between 0 and 100"})
+		return
+	}
+	if req.PaymentMethod != "click" && req.PaymentMethod != "payme" {
+		c.JSON(http.StatusBadRequest, Response{Data: "payment_method must be click or payme"})
+		return
+	}
+
+	tip, err := h.storage.Tip().Create(c.Request.Context(), orderId, &req)
+	if errors.Is(err, storage.ErrTipNotAllowed) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Only orders delivered by a courier can be tipped, by their customer, within " + strconv.Itoa(config.TipWindowHours) + " hours"})
+		return
+	}
+	if errors.Is(err, storage.ErrAlreadyTipped) {
+		c.JSON(http.StatusBadRequest, Response{Data: "This order is already tipped"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while creating tip")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Tip created successfully")
+	c.JSON(http.StatusCreated, Response{Data: tip})
+}
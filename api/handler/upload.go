@@ -1,24 +1,38 @@
 package handler
 
 import (
-	"food/pkg/helper"
+	"errors"
+	"food/api/models"
+	"food/config"
+	"food/pkg/blob"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// uploadAllowedTypes are the content types accepted by /uploadfiles.
+var uploadAllowedTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// uploadMaxFiles caps the number of files in one /uploadfiles request.
+const uploadMaxFiles = 10
+
 // UploadFiles godoc
 // @ID upload_multiple_files
 // @Router /food/api/v1/uploadfiles [post]
 // @Summary Upload Multiple Files
-// @Description Upload Multiple Files
+// @Description Uploads JPEG, PNG, GIF or WebP images of up to 10 MB each. Every file gets a unique id, which is its storage key. The url is signed and expires when signed URLs are enabled.
 // @Tags Upload File
 // @Accept multipart/form-data
 // @Produce json
-// @Param file formData []file true "File to upload" 
-// @Success 200 {object} Response{data=string} "Success Request"
+// @Param file formData []file true "File to upload"
+// @Success 200 {object} models.MultipleFileUploadResponse "Success Request"
 // @Failure 400 {object} Response{data=string} "Bad Request"
 // @Failure 500 {object} Response{data=string} "Server error"
 func (h *Handler) UploadFiles(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, uploadMaxFiles*config.MaxUploadBytes)
 	form, err := c.MultipartForm()
 	if err != nil {
 		h.log.Error(err.Error() + "  :  " + "File error")
@@ -26,19 +40,62 @@ func (h *Handler) UploadFiles(c *gin.Context) {
 		return
 	}
 
-	resp, err := helper.UploadFiles(form)
-	if err != nil {
-		h.log.Error(err.Error() + "  :  " + "Upload error")
-		c.JSON(500, Response{Data: "Upload error"})
+	files := form.File["file"]
+	if len(files) == 0 || len(files) > uploadMaxFiles {
+		c.JSON(400, Response{Data: "upload between 1 and " + strconv.Itoa(uploadMaxFiles) + " files"})
 		return
 	}
 
+	var resp models.MultipleFileUploadResponse
+	for _, file := range files {
+		f, err := file.Open()
+		if err != nil {
+			h.log.Error(err.Error() + "  :  " + "File error")
+			c.JSON(400, Response{Data: "File error"})
+			return
+		}
+		// Reading one byte past the limit is enough to detect larger files.
+		data, err := io.ReadAll(io.LimitReader(f, config.MaxUploadBytes+1))
+		f.Close()
+		if err != nil {
+			h.log.Error(err.Error() + "  :  " + "File error")
+			c.JSON(400, Response{Data: "File error"})
+			return
+		}
+
+		contentType, err := blob.Check(data, config.MaxUploadBytes, uploadAllowedTypes...)
+		if errors.Is(err, blob.ErrTooLarge) {
+			c.JSON(400, Response{Data: file.Filename + ": file must be at most " + strconv.Itoa(config.MaxUploadBytes>>20) + " MB"})
+			return
+		}
+		if err != nil {
+			c.JSON(400, Response{Data: file.Filename + ": only " + strings.Join(uploadAllowedTypes, ", ") + " files are allowed"})
+			return
+		}
+
+		key := blob.NewKey("uploads", contentType)
+		url, err := h.blob.Put(c.Request.Context(), key, contentType, data)
+		if err == nil && h.cfg.BlobSignedURLs {
+			url, err = h.blob.SignedURL(c.Request.Context(), key, h.cfg.BlobSignedURLTTL)
+		}
+		if err != nil {
+			h.log.Error(err.Error() + "  :  " + "Upload error")
+			c.JSON(500, Response{Data: "Upload error"})
+			return
+		}
+
+		resp.Url = append(resp.Url, &models.Url{
+			Id:  key,
+			Url: url,
+		})
+	}
+
 	c.JSON(200, resp)
 }
 
 // DeleteFile godoc
 // @ID delete_file
-// @Router /food/api/v1/deletefile [delete]
+// @Router /food/api/v1/deletefiles [delete]
 // @Summary Delete File
 // @Description Delete File
 // @Tags Upload File
@@ -47,9 +104,18 @@ func (h *Handler) UploadFiles(c *gin.Context) {
 // @Param id query string true "ID of the file to delete"
 // @Success 204 {string} string "Success Request"
 // @Failure 400 {object} Response{data=string} "Bad Request"
+// @Failure 404 {object} Response{data=string} "Not Found"
 // @Failure 500 {object} Response{data=string} "Server error"
 func (h *Handler) DeleteFile(c *gin.Context) {
-	err := helper.DeleteFile(c.Query("id"))
+	err := h.blob.Delete(c.Request.Context(), c.Query("id"))
+	if errors.Is(err, blob.ErrInvalidKey) {
+		c.JSON(400, Response{Data: "please enter a valid id"})
+		return
+	}
+	if errors.Is(err, blob.ErrNotFound) {
+		c.JSON(404, Response{Data: "File not found"})
+		return
+	}
 	if err != nil {
 		h.log.Error(err.Error() + "  :  " + "Upload error")
 		c.JSON(500, Response{Data: "Server error"})
@@ -57,3 +123,49 @@ func (h *Handler) DeleteFile(c *gin.Context) {
 	}
 	c.JSON(204, "success")
 }
+
+// GetSignedURL godoc
+// @ID get_signed_url
+// @Router /food/api/v1/signedurl [get]
+// @Summary Get Signed File URL
+// @Description Returns a fresh URL that grants read access to an uploaded file for a limited time
+// @Tags Upload File
+// @Accept json
+// @Produce json
+// @Param id query string true "ID of the file"
+// @Success 200 {object} models.Url "Success Request"
+// @Failure 400 {object} Response{data=string} "Bad Request"
+// @Failure 500 {object} Response{data=string} "Server error"
+func (h *Handler) GetSignedURL(c *gin.Context) {
+	id := c.Query("id")
+	url, err := h.blob.SignedURL(c.Request.Context(), id, h.cfg.BlobSignedURLTTL)
+	if errors.Is(err, blob.ErrInvalidKey) {
+		c.JSON(400, Response{Data: "please enter a valid id"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + "  :  " + "Signed url error")
+		c.JSON(500, Response{Data: "Server error"})
+		return
+	}
+
+	c.JSON(200, models.Url{Id: id, Url: url})
+}
+
+// ServeLocalFile serves the files of the local blob store. It is only
+// routed when that store is configured.
+func (h *Handler) ServeLocalFile(c *gin.Context) {
+	local, ok := h.blob.(*blob.LocalStore)
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	path, err := local.ServePath(strings.TrimPrefix(c.Param("key"), "/"), c.Query("expires"), c.Query("signature"), h.cfg.BlobSignedURLs)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	c.File(path)
+}
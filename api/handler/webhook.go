@@ -0,0 +1,324 @@
+package handler
+
+import (
+	"errors"
+	"food/api/models"
+	"food/pkg/webhook"
+	"food/storage"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// checkWebhookSubscription returns why a subscription cannot be saved, or ""
+// when it can. Repeated events are dropped.
+func checkWebhookSubscription(rawURL string, events *[]string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "url must be an absolute http or https URL"
+	}
+	if len(*events) == 0 {
+		return "events is required"
+	}
+
+	seen := make(map[string]bool)
+	unique := (*events)[:0]
+	for _, event := range *events {
+		if !webhook.Valid(event) {
+			return "events must be among " + strings.Join(webhook.Events, ", ")
+		}
+		if !seen[event] {
+			seen[event] = true
+			unique = append(unique, event)
+		}
+	}
+	*events = unique
+	return ""
+}
+
+// @ID 			create_webhook_subscription
+// @Router 		/food/api/v1/admin/webhooks [POST]
+// @Summary 	Create Webhook Subscription
+// @Description Subscribes a partner URL to order.created, order.status_changed and payment.paid events. Every request carries the X-Webhook-Signature header "t=<unix time>,v1=<hex>", the HMAC-SHA256 of "<unix time>.<body>" keyed with the secret. A secret is generated when none is given; it is only returned here and when it is replaced.
+// @Tags 		webhook
+// @Accept 		json
+// @Produce 	json
+// @Param 		subscription body models.CreateWebhookSubscription true "Subscription"
+// @Success 	201 {object} Response{data=models.WebhookSubscription} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) CreateWebhookSubscription(c *gin.Context) {
+	var req models.CreateWebhookSubscription
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Webhook Subscription Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	if msg := checkWebhookSubscription(req.Url, &req.Events); msg != "" {
+		c.JSON(http.StatusBadRequest, Response{Data: msg})
+		return
+	}
+	if req.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			h.log.Error(err.Error() + ":" + "error while generating webhook secret")
+			c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+			return
+		}
+		req.Secret = secret
+	}
+
+	sub, err := h.storage.Webhook().CreateSubscription(c.Request.Context(), &req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while creating webhook subscription")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Webhook subscription created successfully")
+	c.JSON(http.StatusCreated, Response{Data: sub})
+}
+
+// @ID 			get_webhook_subscriptions
+// @Router 		/food/api/v1/admin/webhooks [GET]
+// @Summary 	Get All Webhook Subscriptions
+// @Description Lists the webhook subscriptions, latest first
+// @Tags 		webhook
+// @Accept 		json
+// @Produce 	json
+// @Param 		page  query uint64 false "Page number"
+// @Param 		limit query uint64 false "Limit number of results per page"
+// @Success 	200 {object} Response{data=models.GetAllWebhookSubscriptionsResponse} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetWebhookSubscriptions(c *gin.Context) {
+	page, err := ParsePageQueryParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "BadRequest at paging"})
+		return
+	}
+	limit, err := ParseLimitQueryParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "BadRequest at limit"})
+		return
+	}
+
+	resp, err := h.storage.Webhook().GetSubscriptions(c.Request.Context(), page, limit)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting webhook subscriptions")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: resp})
+}
+
+// @ID 			get_webhook_subscription
+// @Router 		/food/api/v1/admin/webhooks/{id} [GET]
+// @Summary 	Get Webhook Subscription
+// @Description Returns a webhook subscription without its secret
+// @Tags 		webhook
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Subscription ID"
+// @Success 	200 {object} Response{data=models.WebhookSubscription} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Subscription not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetWebhookSubscription(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	sub, err := h.storage.Webhook().GetSubscription(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Subscription not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting webhook subscription")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: sub})
+}
+
+// @ID 			update_webhook_subscription
+// @Router 		/food/api/v1/admin/webhooks/{id} [PUT]
+// @Summary 	Update Webhook Subscription
+// @Description Replaces the URL, events, description and state of a subscription. The secret is replaced only when one is given. Events published while a subscription is inactive are not sent to it.
+// @Tags 		webhook
+// @Accept 		json
+// @Produce 	json
+// @Param 		id           path string                           true "Subscription ID"
+// @Param 		subscription body models.UpdateWebhookSubscription true "Subscription"
+// @Success 	200 {object} Response{data=models.WebhookSubscription} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Subscription not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) UpdateWebhookSubscription(c *gin.Context) {
+	var req models.UpdateWebhookSubscription
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.log.Error(err.Error() + " : " + "error Webhook Subscription Should Bind Json!")
+		c.JSON(http.StatusBadRequest, Response{Data: "Invalid request body"})
+		return
+	}
+
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+	if msg := checkWebhookSubscription(req.Url, &req.Events); msg != "" {
+		c.JSON(http.StatusBadRequest, Response{Data: msg})
+		return
+	}
+
+	sub, err := h.storage.Webhook().UpdateSubscription(c.Request.Context(), id, &req)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Subscription not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while updating webhook subscription")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Webhook subscription updated successfully")
+	c.JSON(http.StatusOK, Response{Data: sub})
+}
+
+// @ID 			delete_webhook_subscription
+// @Router 		/food/api/v1/admin/webhooks/{id} [DELETE]
+// @Summary 	Delete Webhook Subscription
+// @Description Stops a subscription. Its delivery log is kept and the deliveries still pending are dead-lettered.
+// @Tags 		webhook
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Subscription ID"
+// @Success 	200 {object} Response{data=string} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Subscription not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) DeleteWebhookSubscription(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	err := h.storage.Webhook().DeleteSubscription(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Subscription not found"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while deleting webhook subscription")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Webhook subscription deleted successfully")
+	c.JSON(http.StatusOK, Response{Data: id})
+}
+
+// @ID 			get_webhook_deliveries
+// @Router 		/food/api/v1/admin/webhooks/{id}/deliveries [GET]
+// @Summary 	Get Webhook Deliveries
+// @Description The delivery log of a subscription, latest first: the event sent, the number of attempts, the last response status and error, and when a pending delivery is retried. Deliveries that failed every attempt are dead.
+// @Tags 		webhook
+// @Accept 		json
+// @Produce 	json
+// @Param 		id     path  string true  "Subscription ID"
+// @Param 		status query string false "pending, delivered or dead"
+// @Param 		page   query uint64 false "Page number"
+// @Param 		limit  query uint64 false "Limit number of results per page"
+// @Success 	200 {object} Response{data=models.GetWebhookDeliveriesResponse} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) GetWebhookDeliveries(c *gin.Context) {
+	req := &models.GetWebhookDeliveriesRequest{
+		SubscriptionId: c.Param("id"),
+		Status:         c.Query("status"),
+	}
+	if err := uuid.Validate(req.SubscriptionId); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+	switch req.Status {
+	case "", "pending", "delivered", "dead":
+	default:
+		c.JSON(http.StatusBadRequest, Response{Data: "status must be pending, delivered or dead"})
+		return
+	}
+
+	var err error
+	req.Page, err = ParsePageQueryParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "BadRequest at paging"})
+		return
+	}
+	req.Limit, err = ParseLimitQueryParam(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "BadRequest at limit"})
+		return
+	}
+
+	resp, err := h.storage.Webhook().Deliveries(c.Request.Context(), req)
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while getting webhook deliveries")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	c.JSON(http.StatusOK, Response{Data: resp})
+}
+
+// @ID 			redeliver_webhook
+// @Router 		/food/api/v1/admin/webhook-deliveries/{id}/redeliver [POST]
+// @Summary 	Redeliver Webhook
+// @Description Sends a dead or delivered event to its subscription again with a fresh set of attempts
+// @Tags 		webhook
+// @Accept 		json
+// @Produce 	json
+// @Param 		id path string true "Delivery ID"
+// @Success 	200 {object} Response{data=models.WebhookDelivery} "Success Request"
+// @Response 	400 {object} Response{data=string} "Bad Request"
+// @Response 	404 {object} Response{data=string} "Delivery not found"
+// @Failure 	500 {object} Response{data=string} "Server error"
+func (h *Handler) RedeliverWebhook(c *gin.Context) {
+	id := c.Param("id")
+	if err := uuid.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, Response{Data: "please enter a valid id"})
+		return
+	}
+
+	delivery, err := h.storage.Webhook().Redeliver(c.Request.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, Response{Data: "Delivery not found"})
+		return
+	}
+	if errors.Is(err, storage.ErrInvalidStatus) {
+		c.JSON(http.StatusBadRequest, Response{Data: "Delivery is pending or its subscription is deleted"})
+		return
+	}
+	if err != nil {
+		h.log.Error(err.Error() + ":" + "error while redelivering webhook")
+		c.JSON(http.StatusInternalServerError, Response{Data: "Server Error!"})
+		return
+	}
+
+	h.log.Info("Webhook delivery queued again")
+	c.JSON(http.StatusOK, Response{Data: delivery})
+}
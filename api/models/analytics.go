@@ -0,0 +1,107 @@
+package models
+
+// AnalyticsRequest filters a report to orders created from From through To,
+// both local dates formatted 2006-01-02, and optionally to one branch.
+type AnalyticsRequest struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	BranchId string `json:"branch_id,omitempty"`
+	// GroupBy is day, week or month.
+	GroupBy  string `json:"group_by,omitempty"`
+	ByBranch bool   `json:"by_branch,omitempty"`
+	// SortBy is quantity or revenue.
+	SortBy string `json:"sort_by,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// SalesSummary counts every order but takes revenue and the average basket
+// from orders that were not cancelled. Tips go to the couriers and are not
+// part of the revenue.
+type SalesSummary struct {
+	Orders           int64   `json:"orders"`
+	CancelledOrders  int64   `json:"cancelled_orders"`
+	Revenue          float64 `json:"revenue"`
+	Tips             float64 `json:"tips"`
+	AverageBasket    float64 `json:"average_basket"`
+	CancellationRate float64 `json:"cancellation_rate"`
+}
+
+type RevenuePeriod struct {
+	Period   string `json:"period"`
+	BranchId string `json:"branch_id,omitempty"`
+	SalesSummary
+}
+
+type RevenueReport struct {
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	GroupBy string          `json:"group_by"`
+	Total   SalesSummary    `json:"total"`
+	Periods []RevenuePeriod `json:"periods"`
+}
+
+type TopProduct struct {
+	ProductId string  `json:"product_id"`
+	Name      string  `json:"name"`
+	Quantity  int64   `json:"quantity"`
+	Revenue   float64 `json:"revenue"`
+	Orders    int64   `json:"orders"`
+}
+
+type TopCombo struct {
+	ComboId  string  `json:"combo_id"`
+	Name     string  `json:"name"`
+	Quantity int64   `json:"quantity"`
+	Revenue  float64 `json:"revenue"`
+	Orders   int64   `json:"orders"`
+}
+
+type TopProductsReport struct {
+	From     string       `json:"from"`
+	To       string       `json:"to"`
+	SortBy   string       `json:"sort_by"`
+	Products []TopProduct `json:"products"`
+	Combos   []TopCombo   `json:"combos"`
+}
+
+type BranchPerformance struct {
+	BranchId string `json:"branch_id"`
+	Name     string `json:"name"`
+	SalesSummary
+	// AverageDeliveryMinutes runs from order creation to delivery.
+	AverageDeliveryMinutes float64 `json:"average_delivery_minutes"`
+}
+
+type BranchPerformanceReport struct {
+	From     string              `json:"from"`
+	To       string              `json:"to"`
+	Branches []BranchPerformance `json:"branches"`
+}
+
+type HourlyOrders struct {
+	Hour    int     `json:"hour"`
+	Orders  int64   `json:"orders"`
+	Revenue float64 `json:"revenue"`
+}
+
+// HourlyReport always has 24 hours, in local time.
+type HourlyReport struct {
+	From  string         `json:"from"`
+	To    string         `json:"to"`
+	Hours []HourlyOrders `json:"hours"`
+}
+
+// CustomerPeriod splits the customers who ordered in a period into those
+// whose first order ever was in it and those who ordered before.
+type CustomerPeriod struct {
+	Period    string `json:"period"`
+	New       int64  `json:"new"`
+	Returning int64  `json:"returning"`
+}
+
+type CustomerReport struct {
+	From    string           `json:"from"`
+	To      string           `json:"to"`
+	GroupBy string           `json:"group_by"`
+	Periods []CustomerPeriod `json:"periods"`
+}
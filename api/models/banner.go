@@ -1,12 +1,45 @@
 package models
 
 type Banner struct {
-	ImageUrl   string `json:"image_url"`
-	Created_at string `json:"created_at,omitempty"`
+	Id          string   `json:"id"`
+	Title       string   `json:"title"`
+	ImageUrl    string   `json:"image_url"`
+	TargetType  string   `json:"target_type,omitempty"`
+	TargetId    string   `json:"target_id,omitempty"`
+	SortOrder   int      `json:"sort_order"`
+	IsActive    bool     `json:"is_active"`
+	StartsAt    string   `json:"starts_at,omitempty"`
+	EndsAt      string   `json:"ends_at,omitempty"`
+	BranchIds   []string `json:"branch_ids"`
+	Impressions int64    `json:"impressions"`
+	Clicks      int64    `json:"clicks"`
+	CTR         float64  `json:"ctr"`
+	Created_at  string   `json:"created_at,omitempty"`
+	UpdatedAt   string   `json:"updated_at,omitempty"`
 }
 
 type CreateBanner struct {
-	ImageUrl string `json:"image_url"`
+	Title      string   `json:"title"`
+	ImageUrl   string   `json:"image_url"`
+	TargetType string   `json:"target_type"`
+	TargetId   string   `json:"target_id"`
+	SortOrder  int      `json:"sort_order"`
+	IsActive   *bool    `json:"is_active"`
+	StartsAt   string   `json:"starts_at"`
+	EndsAt     string   `json:"ends_at"`
+	BranchIds  []string `json:"branch_ids"`
+}
+
+type UpdateBanner struct {
+	Title      string   `json:"title"`
+	ImageUrl   string   `json:"image_url"`
+	TargetType string   `json:"target_type"`
+	TargetId   string   `json:"target_id"`
+	SortOrder  int      `json:"sort_order"`
+	IsActive   bool     `json:"is_active"`
+	StartsAt   string   `json:"starts_at"`
+	EndsAt     string   `json:"ends_at"`
+	BranchIds  []string `json:"branch_ids"`
 }
 
 type DeleteBanner struct {
@@ -14,12 +47,20 @@ type DeleteBanner struct {
 }
 
 type GetAllBannerRequest struct {
-	Search string `json:"search"`
-	Page   uint64 `json:"page"`
-	Limit  uint64 `json:"limit"`
+	Search          string `json:"search"`
+	BranchId        string `json:"branch_id"`
+	IncludeInactive bool   `json:"include_inactive"`
+	Page            uint64 `json:"page"`
+	Limit           uint64 `json:"limit"`
 }
 
 type GetAllBannerResponse struct {
-	Banners []Banner `json:"branches"`
+	Banners []Banner `json:"banners"`
 	Count   int64    `json:"count"`
 }
+
+// TrackBannerRequest records one impression or click on each banner.
+type TrackBannerRequest struct {
+	Event     string   `json:"event"`
+	BannerIds []string `json:"banner_ids"`
+}
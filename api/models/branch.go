@@ -1,27 +1,42 @@
 package models
 
 type Branch struct {
-	Id        string  `json:"id"`
-	Name      string  `json:"name"`
-	Address   string  `json:"address"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
-	CreatedAt string  `json:"created_at"`
-	UpdatedAt string  `json:"updated_at"`
+	Id           string  `json:"id"`
+	Name         string  `json:"name"`
+	Address      string  `json:"address"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	OpeningTime  string  `json:"opening_time"`
+	ClosingTime  string  `json:"closing_time"`
+	SlotMinutes  int     `json:"slot_minutes"`
+	SlotCapacity int     `json:"slot_capacity"`
+	LeadMinutes  int     `json:"lead_minutes"`
+	CreatedAt    string  `json:"created_at"`
+	UpdatedAt    string  `json:"updated_at"`
 }
 
 type CreateBranch struct {
-	Name      string  `json:"name"`
-	Address   string  `json:"address"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
+	Name         string  `json:"name"`
+	Address      string  `json:"address"`
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	OpeningTime  string  `json:"opening_time"`
+	ClosingTime  string  `json:"closing_time"`
+	SlotMinutes  int     `json:"slot_minutes"`
+	SlotCapacity int     `json:"slot_capacity"`
+	LeadMinutes  int     `json:"lead_minutes"`
 }
 
 type UpdateBranch struct {
-	Name      string  `json:"name,omitempty"`
-	Address   string  `json:"address,omitempty"`
-	Latitude  float64 `json:"latitude,omitempty"`
-	Longitude float64 `json:"longitude,omitempty"`
+	Name         string  `json:"name,omitempty"`
+	Address      string  `json:"address,omitempty"`
+	Latitude     float64 `json:"latitude,omitempty"`
+	Longitude    float64 `json:"longitude,omitempty"`
+	OpeningTime  string  `json:"opening_time,omitempty"`
+	ClosingTime  string  `json:"closing_time,omitempty"`
+	SlotMinutes  int     `json:"slot_minutes,omitempty"`
+	SlotCapacity int     `json:"slot_capacity,omitempty"`
+	LeadMinutes  int     `json:"lead_minutes,omitempty"`
 }
 
 type GetBranch struct {
@@ -44,3 +59,21 @@ type GetAllBranchesResponse struct {
 	Branches []Branch `json:"branches"`
 	Count    int64    `json:"count"`
 }
+
+type DeliverySlot struct {
+	StartsAt  string `json:"starts_at"`
+	EndsAt    string `json:"ends_at"`
+	Capacity  int    `json:"capacity"`
+	Booked    int    `json:"booked"`
+	Available bool   `json:"available"`
+}
+
+type GetBranchSlotsRequest struct {
+	BranchId string `json:"branch_id"`
+	Days     int    `json:"days"`
+}
+
+type GetBranchSlotsResponse struct {
+	BranchId string         `json:"branch_id"`
+	Slots    []DeliverySlot `json:"slots"`
+}
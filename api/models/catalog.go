@@ -0,0 +1,38 @@
+package models
+
+// CatalogRow is one product of a catalog import or export together with its
+// category. Products and categories are matched by SKU, or by id when they
+// have no SKU yet. An empty parent_category_sku keeps the parent of an
+// existing category, and missing translations keep the stored ones.
+type CatalogRow struct {
+	SKU               string                 `json:"sku"`
+	Name              string                 `json:"name"`
+	Description       string                 `json:"description,omitempty"`
+	Price             float64                `json:"price"`
+	ImageURL          string                 `json:"image_url,omitempty"`
+	CategorySKU       string                 `json:"category_sku"`
+	CategoryName      string                 `json:"category_name"`
+	ParentCategorySKU string                 `json:"parent_category_sku,omitempty"`
+	Translations      map[string]Translation `json:"translations,omitempty"`
+}
+
+// CatalogRowError reports why a row cannot be imported. Row counts products
+// from 1 and leaves out the CSV header.
+type CatalogRowError struct {
+	Row     int    `json:"row"`
+	SKU     string `json:"sku,omitempty"`
+	Message string `json:"message"`
+}
+
+// CatalogImportResult tells what an import did, or would do in a dry run.
+// Nothing is saved unless Applied is true.
+type CatalogImportResult struct {
+	DryRun            bool              `json:"dry_run"`
+	Applied           bool              `json:"applied"`
+	Rows              int               `json:"rows"`
+	CategoriesCreated int               `json:"categories_created"`
+	CategoriesUpdated int               `json:"categories_updated"`
+	ProductsCreated   int               `json:"products_created"`
+	ProductsUpdated   int               `json:"products_updated"`
+	Errors            []CatalogRowError `json:"errors"`
+}
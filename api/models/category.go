@@ -1,18 +1,46 @@
 package models
 
 type Category struct {
-	Id        string `json:"id"`
-	Name      string `json:"name"`
-	CreatedAt string `json:"created_at,omitempty"`
-	UpdatedAt string `json:"updated_at,omitempty"`
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	ParentId     string `json:"parent_id,omitempty"`
+	SortOrder    int    `json:"sort_order"`
+	IconURL      string `json:"icon_url,omitempty"`
+	IsActive     bool   `json:"is_active"`
+	VisibleFrom  string `json:"visible_from,omitempty"`
+	VisibleTo    string `json:"visible_to,omitempty"`
+	IsVisible    bool   `json:"is_visible"`
+	ProductCount int64  `json:"product_count"`
+	CreatedAt    string `json:"created_at,omitempty"`
+	UpdatedAt    string `json:"updated_at,omitempty"`
+
+	Translations map[string]Translation `json:"translations,omitempty"`
+	Children     []Category             `json:"children,omitempty"`
 }
 
 type CreateCategory struct {
-	Name string `json:"name"`
+	Name         string                 `json:"name"`
+	ParentId     string                 `json:"parent_id,omitempty"`
+	SortOrder    int                    `json:"sort_order"`
+	IconURL      string                 `json:"icon_url,omitempty"`
+	IsActive     *bool                  `json:"is_active,omitempty"`
+	VisibleFrom  string                 `json:"visible_from,omitempty"`
+	VisibleTo    string                 `json:"visible_to,omitempty"`
+	Translations map[string]Translation `json:"translations,omitempty"`
 }
 
+// UpdateCategory only changes the fields that are present. An empty
+// parent_id moves the category to the top level and empty visible_from and
+// visible_to make it visible all day.
 type UpdateCategory struct {
-	Name string `json:"name"`
+	Name         string                 `json:"name"`
+	ParentId     *string                `json:"parent_id,omitempty"`
+	SortOrder    *int                   `json:"sort_order,omitempty"`
+	IconURL      *string                `json:"icon_url,omitempty"`
+	IsActive     *bool                  `json:"is_active,omitempty"`
+	VisibleFrom  *string                `json:"visible_from,omitempty"`
+	VisibleTo    *string                `json:"visible_to,omitempty"`
+	Translations map[string]Translation `json:"translations,omitempty"`
 }
 
 type GetCategory struct {
@@ -23,9 +51,10 @@ type GetCategory struct {
 }
 
 type GetAllCategoriesRequest struct {
-	Search string `json:"search"`
-	Page   uint64 `json:"page"`
-	Limit  uint64 `json:"limit"`
+	Search        string `json:"search"`
+	IncludeHidden bool   `json:"include_hidden"`
+	Page          uint64 `json:"page"`
+	Limit         uint64 `json:"limit"`
 }
 
 type GetAllCategoriesResponse struct {
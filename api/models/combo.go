@@ -1,9 +1,10 @@
 package models
 
 type GetAllCombosRequest struct {
-	Search string `json:"search"`
-	Page   uint64 `json:"page"`
-	Limit  uint64 `json:"limit"`
+	Search          string `json:"search"`
+	IncludeInactive bool   `json:"include_inactive"`
+	Page            uint64 `json:"page"`
+	Limit           uint64 `json:"limit"`
 }
 
 type GetAllCombosResponse struct {
@@ -21,6 +22,20 @@ type Combo struct {
 	CreatedAt   string      `json:"created_at,omitempty"`
 	UpdatedAt   string      `json:"updated_at,omitempty"`
 	ComboItems  []ComboItem `json:"combo_items,omitempty"`
+
+	IsActive      bool   `json:"is_active"`
+	AvailableFrom string `json:"available_from,omitempty"`
+	AvailableTo   string `json:"available_to,omitempty"`
+	IsAvailable   bool   `json:"is_available"`
+	ArchivedAt    string `json:"archived_at,omitempty"`
+
+	// ItemsTotal is what the items cost bought separately at current prices,
+	// and Savings how much less the combo costs.
+	ItemsTotal float64 `json:"items_total"`
+	Savings    float64 `json:"savings"`
+
+	Images       []ProductImage         `json:"images,omitempty"`
+	Translations map[string]Translation `json:"translations,omitempty"`
 }
 
 type ComboCreate struct {
@@ -30,9 +45,13 @@ type ComboCreate struct {
 }
 
 type SwaggerComboCreate struct {
-	Name        string  `json:"name,omitempty"`
-	Description string  `json:"description,omitempty"`
-	Price       float64 `json:"price"`
+	Name          string                 `json:"name,omitempty"`
+	Description   string                 `json:"description,omitempty"`
+	Price         float64                `json:"price"`
+	IsActive      *bool                  `json:"is_active,omitempty"`
+	AvailableFrom string                 `json:"available_from,omitempty"`
+	AvailableTo   string                 `json:"available_to,omitempty"`
+	Translations  map[string]Translation `json:"translations,omitempty"`
 }
 
 type ComboUpdate struct {
@@ -42,11 +61,22 @@ type ComboUpdate struct {
 	ComboItems  []ComboItem `json:"combo_items,omitempty"`
 }
 
+// ComboUpdateS documents UpdateCombo. Omitted fields keep their values and
+// combo_items, when sent, replaces every item.
 type ComboUpdateS struct {
-	Name        string            `json:"name,omitempty"`
-	Description string            `json:"description,omitempty"`
-	Price       float64           `json:"price"`
-	ComboItems  []UpdateComboItem `json:"combo_items,omitempty"`
+	Name          string                 `json:"name,omitempty"`
+	Description   *string                `json:"description,omitempty"`
+	Price         float64                `json:"price,omitempty"`
+	IsActive      *bool                  `json:"is_active,omitempty"`
+	AvailableFrom *string                `json:"available_from,omitempty"`
+	AvailableTo   *string                `json:"available_to,omitempty"`
+	ComboItems    []SwaggerComboItems    `json:"combo_items,omitempty" binding:"omitempty,dive"`
+	Translations  map[string]Translation `json:"translations,omitempty"`
+}
+
+// ReplaceComboItemsRequest lists the new items of a combo.
+type ReplaceComboItemsRequest struct {
+	Items []SwaggerComboItems `json:"items" binding:"dive"`
 }
 
 type ComboPrimaryKey struct {
@@ -76,5 +106,5 @@ type SwaggerComboCreateRequest struct {
 
 type SwaggerComboItems struct {
 	ProductId string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
+	Quantity  int    `json:"quantity" binding:"min=1"`
 }
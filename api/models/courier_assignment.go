@@ -1,12 +1,87 @@
 package models
 
 type CourierAssignment struct {
-	Id         string `json:"id"`
-	OrderId    string `json:"order_id"`
-	CourierId  string `json:"courier_id"`
-	Status     string `json:"status"`
-	AssignedAt string `json:"assigned_at"`
-	UpdatedAt  string `json:"updated_at"`
+	Id          string         `json:"id"`
+	OrderId     string         `json:"order_id"`
+	CourierId   string         `json:"courier_id"`
+	Status      string         `json:"status"`
+	AssignedAt  string         `json:"assigned_at"`
+	AcceptedAt  string         `json:"accepted_at,omitempty"`
+	DeliveredAt string         `json:"delivered_at,omitempty"`
+	Proof       *DeliveryProof `json:"proof,omitempty"`
+	UpdatedAt   string         `json:"updated_at"`
+}
+
+// DeliveryProof shows that an order reached its customer: a photo of the
+// handover, the customer's handover code, the courier's position near the
+// drop-off, or an admin override with its note. The code itself is never
+// returned.
+type DeliveryProof struct {
+	Type      string  `json:"type"`
+	PhotoURL  string  `json:"photo_url,omitempty"`
+	Code      string  `json:"code,omitempty"`
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+	DistanceM float64 `json:"distance_m,omitempty"`
+	Note      string  `json:"note,omitempty"`
+}
+
+type HandoverOverrideRequest struct {
+	Note string `json:"note"`
+}
+
+// HandoverAttempt is a check of an order's handover code by its courier, or
+// an admin override of it.
+type HandoverAttempt struct {
+	Id           string `json:"id"`
+	OrderId      string `json:"order_id"`
+	AssignmentId string `json:"assignment_id,omitempty"`
+	CourierId    string `json:"courier_id,omitempty"`
+	Kind         string `json:"kind"`
+	Success      bool   `json:"success"`
+	Note         string `json:"note,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// GetHandoverAttemptsResponse lists the attempts of an order, latest first.
+// Locked is set once the failed attempts reach the limit.
+type GetHandoverAttemptsResponse struct {
+	Attempts []HandoverAttempt `json:"attempts"`
+	Failed   int               `json:"failed"`
+	Locked   bool              `json:"locked"`
+}
+
+// CourierStatusChange moves an assignment to Status. A non-empty CourierId
+// limits the change to that courier's assignments and enforces the courier
+// workflow; admins leave it empty.
+type CourierStatusChange struct {
+	Id        string         `json:"id"`
+	CourierId string         `json:"courier_id"`
+	Status    string         `json:"status"`
+	Proof     *DeliveryProof `json:"proof,omitempty"`
+}
+
+// CourierJob is an assignment with what the courier needs to deliver it.
+type CourierJob struct {
+	CourierAssignment
+	OrderStatus   string  `json:"order_status"`
+	TotalPrice    float64 `json:"total_price"`
+	Tip           float64 `json:"tip"`
+	PaymentMethod string  `json:"payment_method,omitempty"`
+	IsPaid        bool    `json:"is_paid"`
+	AddressName   string  `json:"address_name"`
+	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude"`
+	CustomerName  string  `json:"customer_name"`
+	CustomerPhone string  `json:"customer_phone"`
+	BranchId      string  `json:"branch_id,omitempty"`
+	BranchName    string  `json:"branch_name,omitempty"`
+	BranchAddress string  `json:"branch_address,omitempty"`
+}
+
+type GetCourierJobsResponse struct {
+	Jobs  []CourierJob `json:"jobs"`
+	Count int64        `json:"count"`
 }
 
 type CreateCourierAssignment struct {
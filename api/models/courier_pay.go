@@ -0,0 +1,162 @@
+package models
+
+// CourierRate holds how couriers are paid per delivery. The rate without a
+// branch is the default for branches that have none.
+type CourierRate struct {
+	Id            string  `json:"id"`
+	BranchId      string  `json:"branch_id,omitempty"`
+	BaseRate      float64 `json:"base_rate"`
+	PerKmRate     float64 `json:"per_km_rate"`
+	PeakSurcharge float64 `json:"peak_surcharge"`
+	PeakFrom      string  `json:"peak_from,omitempty"`
+	PeakTo        string  `json:"peak_to,omitempty"`
+	TipShare      int     `json:"tip_share"`
+	CreatedAt     string  `json:"created_at"`
+	UpdatedAt     string  `json:"updated_at"`
+}
+
+// SetCourierRate replaces the rate of a branch, or the default rate when
+// branch_id is empty. Empty peak_from and peak_to mean no peak hours.
+type SetCourierRate struct {
+	BranchId      string  `json:"branch_id,omitempty"`
+	BaseRate      float64 `json:"base_rate"`
+	PerKmRate     float64 `json:"per_km_rate"`
+	PeakSurcharge float64 `json:"peak_surcharge"`
+	PeakFrom      string  `json:"peak_from,omitempty"`
+	PeakTo        string  `json:"peak_to,omitempty"`
+	TipShare      int     `json:"tip_share"`
+}
+
+type AssignCourierRequest struct {
+	OrderId   string `json:"order_id"`
+	CourierId string `json:"courier_id"`
+}
+
+type CourierAssignmentStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// CourierDelivery is a delivery with how its earnings add up. Tips added
+// after delivery have their own entry of kind tip.
+type CourierDelivery struct {
+	Id             string  `json:"id"`
+	Kind           string  `json:"kind"`
+	OrderId        string  `json:"order_id"`
+	AssignmentId   string  `json:"assignment_id,omitempty"`
+	DistanceKm     float64 `json:"distance_km"`
+	BaseAmount     float64 `json:"base_amount"`
+	DistanceAmount float64 `json:"distance_amount"`
+	PeakAmount     float64 `json:"peak_amount"`
+	TipAmount      float64 `json:"tip_amount"`
+	Earnings       float64 `json:"earnings"`
+	PayoutId       string  `json:"payout_id,omitempty"`
+	DeliveredAt    string  `json:"delivered_at"`
+}
+
+type CourierEarningsRequest struct {
+	CourierId string `json:"courier_id"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Page      uint64 `json:"page"`
+	Limit     uint64 `json:"limit"`
+}
+
+// CourierEarningsResponse sums up the deliveries of a range; Deliveries
+// does not count tip entries. Unpaid and CashOnHand are not limited to the
+// range.
+type CourierEarningsResponse struct {
+	CourierId      string            `json:"courier_id"`
+	From           string            `json:"from"`
+	To             string            `json:"to"`
+	Deliveries     int64             `json:"deliveries"`
+	DistanceKm     float64           `json:"distance_km"`
+	BaseAmount     float64           `json:"base_amount"`
+	DistanceAmount float64           `json:"distance_amount"`
+	PeakAmount     float64           `json:"peak_amount"`
+	TipAmount      float64           `json:"tip_amount"`
+	Earnings       float64           `json:"earnings"`
+	Unpaid         float64           `json:"unpaid"`
+	CashOnHand     float64           `json:"cash_on_hand"`
+	Items          []CourierDelivery `json:"items"`
+}
+
+// CourierCash is cash collected on a 'naxt pul' order.
+type CourierCash struct {
+	Id               string  `json:"id"`
+	OrderId          string  `json:"order_id"`
+	Amount           float64 `json:"amount"`
+	CollectedAt      string  `json:"collected_at"`
+	ReconciliationId string  `json:"reconciliation_id,omitempty"`
+}
+
+// CourierCashResponse is the cash a courier holds and has not handed in yet.
+type CourierCashResponse struct {
+	CourierId string        `json:"courier_id"`
+	Total     float64       `json:"total"`
+	Items     []CourierCash `json:"items"`
+}
+
+type ReconcileCashRequest struct {
+	Received float64 `json:"received"`
+	Note     string  `json:"note,omitempty"`
+}
+
+// CourierReconciliation compares the cash a courier handed in with what was
+// collected. A negative difference is a shortage.
+type CourierReconciliation struct {
+	Id         string  `json:"id"`
+	CourierId  string  `json:"courier_id"`
+	Expected   float64 `json:"expected"`
+	Received   float64 `json:"received"`
+	Difference float64 `json:"difference"`
+	Orders     int     `json:"orders"`
+	Note       string  `json:"note,omitempty"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+type GetCourierReconciliationsRequest struct {
+	CourierId string `json:"courier_id"`
+	Page      uint64 `json:"page"`
+	Limit     uint64 `json:"limit"`
+}
+
+type GetCourierReconciliationsResponse struct {
+	Reconciliations []CourierReconciliation `json:"reconciliations"`
+	Count           int64                   `json:"count"`
+}
+
+// CreatePayoutsRequest batches the unpaid earnings of deliveries made
+// through Until, a local date formatted 2006-01-02, into one payout per
+// courier.
+type CreatePayoutsRequest struct {
+	Until     string `json:"until"`
+	CourierId string `json:"courier_id,omitempty"`
+}
+
+type CourierPayout struct {
+	Id         string  `json:"id"`
+	CourierId  string  `json:"courier_id"`
+	Amount     float64 `json:"amount"`
+	Deliveries int     `json:"deliveries"`
+	PeriodTo   string  `json:"period_to"`
+	Status     string  `json:"status"`
+	Reference  string  `json:"reference,omitempty"`
+	CreatedAt  string  `json:"created_at"`
+	PaidAt     string  `json:"paid_at,omitempty"`
+}
+
+type PayoutPaidRequest struct {
+	Reference string `json:"reference"`
+}
+
+type GetCourierPayoutsRequest struct {
+	CourierId string `json:"courier_id"`
+	Status    string `json:"status"`
+	Page      uint64 `json:"page"`
+	Limit     uint64 `json:"limit"`
+}
+
+type GetCourierPayoutsResponse struct {
+	Payouts []CourierPayout `json:"payouts"`
+	Count   int64           `json:"count"`
+}
@@ -0,0 +1,25 @@
+package models
+
+type DeviceToken struct {
+	Id         string `json:"id"`
+	UserId     string `json:"user_id"`
+	Token      string `json:"token"`
+	Platform   string `json:"platform"`
+	AppVersion string `json:"app_version"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+type RegisterDeviceRequest struct {
+	Token      string `json:"token"`
+	Platform   string `json:"platform"`
+	AppVersion string `json:"app_version"`
+}
+
+type UnregisterDeviceRequest struct {
+	Token string `json:"token"`
+}
+
+type GetDeviceTokensResponse struct {
+	Devices []DeviceToken `json:"devices"`
+}
@@ -0,0 +1,63 @@
+package models
+
+// ExportRequest filters an export to records from From through To, both
+// local dates formatted 2006-01-02. The other filters are optional.
+type ExportRequest struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	BranchId  string `json:"branch_id,omitempty"`
+	Status    string `json:"status,omitempty"`
+	CourierId string `json:"courier_id,omitempty"`
+}
+
+// OrderExportRow is one item of an order with the order it belongs to.
+// Orders without items have one row with empty item fields.
+type OrderExportRow struct {
+	OrderId        string
+	CreatedAt      string
+	UserId         string
+	CustomerName   string
+	CustomerPhone  string
+	BranchName     string
+	Status         string
+	DeliveryStatus string
+	Address        string
+	CourierId      string
+	OrderTotal     float64
+	ItemId         string
+	ProductId      string
+	ComboId        string
+	ProductName    string
+	Quantity       int
+	Price          float64
+	ItemTotal      float64
+	Modifiers      []string
+}
+
+type PaymentExportRow struct {
+	PaymentId     string
+	CreatedAt     string
+	OrderId       string
+	UserId        string
+	CustomerName  string
+	BranchName    string
+	OrderStatus   string
+	Kind          string
+	PaymentMethod string
+	IsPaid        bool
+	Amount        float64
+	Tip           float64
+}
+
+type CourierEarningExportRow struct {
+	Id          string
+	DeliveredAt string
+	Kind        string
+	CourierId   string
+	CourierName string
+	OrderId     string
+	BranchName  string
+	OrderTotal  float64
+	TipAmount   float64
+	Earnings    float64
+}
@@ -0,0 +1,28 @@
+package models
+
+type ProductImage struct {
+	Id           string `json:"id"`
+	ProductId    string `json:"product_id,omitempty"`
+	ComboId      string `json:"combo_id,omitempty"`
+	Position     int    `json:"position"`
+	ThumbURL     string `json:"thumb_url"`
+	CardURL      string `json:"card_url"`
+	FullURL      string `json:"full_url"`
+	ThumbWebPURL string `json:"thumb_webp_url,omitempty"`
+	CardWebPURL  string `json:"card_webp_url,omitempty"`
+	FullWebPURL  string `json:"full_webp_url,omitempty"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Srcset       string `json:"srcset"`
+	WebPSrcset   string `json:"webp_srcset,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type UploadImagesResponse struct {
+	Images []ProductImage `json:"images"`
+}
+
+// ReorderImagesRequest lists every image of a gallery in its new order.
+type ReorderImagesRequest struct {
+	ImageIds []string `json:"image_ids"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Job is a background job of the queue.
+type Job struct {
+	Id          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload" swaggertype:"object"`
+	UniqueKey   string          `json:"unique_key,omitempty"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       string          `json:"run_at"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   string          `json:"created_at"`
+	FinishedAt  string          `json:"finished_at,omitempty"`
+	// LeasedUntil is when the claim of the worker running the job runs
+	// out.
+	LeasedUntil time.Time `json:"-"`
+}
+
+// EnqueueJob adds a job of Type with Payload encoded as JSON. The job runs
+// from RunAt, or at once when it is zero, and up to MaxAttempts times, or
+// config.MaxJobAttempts when it is zero. While a job with the same
+// UniqueKey is pending or running no other is added.
+type EnqueueJob struct {
+	Type        string
+	Payload     interface{}
+	UniqueKey   string
+	RunAt       time.Time
+	MaxAttempts int
+}
+
+type GetAllJobsRequest struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Page   uint64 `json:"page"`
+	Limit  uint64 `json:"limit"`
+}
+
+type GetAllJobsResponse struct {
+	Jobs  []Job `json:"jobs"`
+	Count int64 `json:"count"`
+}
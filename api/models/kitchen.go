@@ -0,0 +1,51 @@
+package models
+
+type KitchenQueueItem struct {
+	Id          string   `json:"id"`
+	ProductId   string   `json:"product_id"`
+	ComboId     string   `json:"combo_id,omitempty"`
+	ProductName string   `json:"product_name"`
+	Quantity    int      `json:"quantity"`
+	Modifiers   []string `json:"modifiers"`
+	Status      string   `json:"status"`
+	StartedAt   string   `json:"started_at,omitempty"`
+	ReadyAt     string   `json:"ready_at,omitempty"`
+}
+
+type KitchenQueueOrder struct {
+	Id             string             `json:"id"`
+	Status         string             `json:"status"`
+	DeliveryStatus string             `json:"delivery_status"`
+	DueAt          string             `json:"due_at"`
+	CreatedAt      string             `json:"created_at"`
+	Items          []KitchenQueueItem `json:"items"`
+}
+
+type KitchenQueueResponse struct {
+	BranchId string              `json:"branch_id"`
+	Orders   []KitchenQueueOrder `json:"orders"`
+}
+
+type KitchenStatusRequest struct {
+	Status string `json:"status"`
+}
+
+type KitchenStatsRequest struct {
+	BranchId string `json:"branch_id"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+type KitchenProductStat struct {
+	ProductId      string  `json:"product_id"`
+	ComboId        string  `json:"combo_id,omitempty"`
+	ProductName    string  `json:"product_name"`
+	Prepared       int64   `json:"prepared"`
+	AvgPrepSeconds float64 `json:"avg_prep_seconds"`
+	MaxPrepSeconds float64 `json:"max_prep_seconds"`
+}
+
+type KitchenStatsResponse struct {
+	BranchId string               `json:"branch_id"`
+	Products []KitchenProductStat `json:"products"`
+}
@@ -1,8 +1,13 @@
 package models
 
+import "time"
+
 type Notification struct {
 	Id        string `json:"id"`
 	UserId    string `json:"user_id"`
+	Event     string `json:"event,omitempty"`
+	OrderId   string `json:"order_id,omitempty"`
+	Title     string `json:"title,omitempty"`
 	Message   string `json:"message"`
 	IsRead    bool   `json:"is_read"`
 	CreatedAt string `json:"created_at"`
@@ -38,3 +43,92 @@ type GetAllNotificationsResponse struct {
 	Notifications []Notification `json:"notifications"`
 	Count         int64          `json:"count"`
 }
+
+// NotificationEvent is something that happened to a user's order. UserId is
+// looked up from the order when empty.
+type NotificationEvent struct {
+	Event   string
+	UserId  string
+	OrderId string
+	Args    []interface{}
+}
+
+type NotificationPreferences struct {
+	UserId    string `json:"user_id"`
+	Locale    string `json:"locale"`
+	Sms       bool   `json:"sms"`
+	Email     bool   `json:"email"`
+	Push      bool   `json:"push"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+type SetNotificationPreferences struct {
+	Locale string `json:"locale"`
+	Sms    bool   `json:"sms"`
+	Email  bool   `json:"email"`
+	Push   bool   `json:"push"`
+}
+
+// NotificationRecipient is a user with the addresses and preferences the
+// dispatcher needs.
+type NotificationRecipient struct {
+	UserId      string
+	Phone       string
+	Email       string
+	Preferences NotificationPreferences
+}
+
+// NotificationDelivery tracks one notification on one channel.
+type NotificationDelivery struct {
+	Id             string `json:"id"`
+	NotificationId string `json:"notification_id"`
+	UserId         string `json:"user_id"`
+	Channel        string `json:"channel"`
+	Recipient      string `json:"recipient,omitempty"`
+	Title          string `json:"title,omitempty"`
+	Message        string `json:"message,omitempty"`
+	OrderId        string `json:"order_id,omitempty"`
+	Status         string `json:"status"`
+	Attempts       int    `json:"attempts"`
+	LastError      string `json:"last_error,omitempty"`
+	NextAttemptAt  string `json:"next_attempt_at,omitempty"`
+	SentAt         string `json:"sent_at,omitempty"`
+	CreatedAt      string `json:"created_at"`
+	// LeasedUntil is when the claim of a worker sending the delivery runs
+	// out.
+	LeasedUntil time.Time `json:"-"`
+}
+
+type GetNotificationDeliveriesResponse struct {
+	Deliveries []NotificationDelivery `json:"deliveries"`
+}
+
+type GetInboxRequest struct {
+	UserId string `json:"user_id"`
+	Unread bool   `json:"unread"`
+	Cursor string `json:"cursor"`
+	Limit  uint64 `json:"limit"`
+}
+
+// GetInboxResponse is a page of the inbox. NextCursor is empty on the last
+// page.
+type GetInboxResponse struct {
+	Notifications []Notification `json:"notifications"`
+	NextCursor    string         `json:"next_cursor,omitempty"`
+}
+
+type UnreadCountResponse struct {
+	Unread int64 `json:"unread"`
+}
+
+// MarkNotificationsReadRequest marks the listed notifications read, or all
+// of the user's notifications when All is set.
+type MarkNotificationsReadRequest struct {
+	Ids []string `json:"ids"`
+	All bool     `json:"all"`
+}
+
+type MarkNotificationsReadResponse struct {
+	Updated int64 `json:"updated"`
+	Unread  int64 `json:"unread"`
+}
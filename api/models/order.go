@@ -24,11 +24,18 @@ type Order struct {
 	Id             string      `json:"id,omitempty"`
 	UserId         string      `json:"user_id,omitempty"`
 	TotalPrice     float64     `json:"total_price,omitempty"`
+	// Tip for the courier on top of the total. At checkout it is given as
+	// an amount or as TipPercent of the total.
+	Tip            float64     `json:"tip,omitempty"`
+	TipPercent     float64     `json:"tip_percent,omitempty"`
 	Longitude      float64     `json:"longitude"`
 	Latitude       float64     `json:"latitude"`
 	AddressName    string      `json:"address_name"`
 	Status         string      `json:"status,omitempty"`
 	DeliveryStatus string      `json:"delivery_status"`
+	BranchId       string      `json:"branch_id,omitempty"`
+	ScheduledAt    string      `json:"scheduled_at,omitempty"`
+	HandoverCode   string      `json:"handover_code,omitempty"`
 	CreatedAt      string      `json:"created_at"`
 	UpdatedAt      string      `json:"updated_at"`
 	OrderItems     []OrderItem `json:"order_items,omitempty"`
@@ -48,6 +55,10 @@ type SwaggerOrderCreate struct {
 	Longitude      float64 `json:"longitude"`
 	Latitude       float64 `json:"latitude"`
 	AddressName    string  `json:"address_name"`
+	BranchId       string  `json:"branch_id,omitempty"`
+	ScheduledAt    string  `json:"scheduled_at,omitempty"`
+	Tip            float64 `json:"tip,omitempty"`
+	TipPercent     float64 `json:"tip_percent,omitempty"`
 }
 
 type OrderUpdate struct {
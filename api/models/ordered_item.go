@@ -1,14 +1,16 @@
 package models
 
 type OrderItem struct {
-	Id         string  `json:"id"`
-	ProductId  string  `json:"product_id"`
-	OrderId    string  `json:"order_id"`
-	Quantity   int     `json:"quantity"`
-	Price      float64 `json:"price"`
-	TotalPrice float64 `json:"total_price"`
-	CreatedAt  string  `json:"created_at"`
-	UpdatedAt  string  `json:"updated_at"`
+	Id         string   `json:"id"`
+	ProductId  string   `json:"product_id"`
+	ComboId    string   `json:"combo_id,omitempty"`
+	OrderId    string   `json:"order_id"`
+	Quantity   int      `json:"quantity"`
+	Price      float64  `json:"price"`
+	TotalPrice float64  `json:"total_price"`
+	Modifiers  []string `json:"modifiers,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+	UpdatedAt  string   `json:"updated_at"`
 }
 
 type CreateOrderItem struct {
@@ -50,6 +52,8 @@ type OrderItemsGetListResponse struct {
 }
 
 type SwaggerOrderItems struct {
-	ProductId string  `json:"product_id,omitempty"`
-	Quantity  int     `json:"quantity,omitempty"`
+	ProductId string   `json:"product_id,omitempty"`
+	ComboId   string   `json:"combo_id,omitempty"`
+	Quantity  int      `json:"quantity,omitempty"`
+	Modifiers []string `json:"modifiers,omitempty"`
 }
@@ -7,8 +7,13 @@ type Product struct {
 	Description string  `json:"description"`
 	Price       float64 `json:"price"`
 	ImageURL    string  `json:"image_url"`
+	RatingAvg   float64 `json:"rating_avg"`
+	RatingCount int64   `json:"rating_count"`
 	CreatedAt   string  `json:"created_at"`
 	UpdatedAt   string  `json:"updated_at"`
+
+	Images       []ProductImage         `json:"images,omitempty"`
+	Translations map[string]Translation `json:"translations,omitempty"`
 }
 
 type CreateProduct struct {
@@ -17,6 +22,8 @@ type CreateProduct struct {
 	Description string  `json:"description"`
 	Price       float64 `json:"price"`
 	ImageURL    string  `json:"image_url"`
+
+	Translations map[string]Translation `json:"translations,omitempty"`
 }
 
 type UpdateProduct struct {
@@ -25,6 +32,8 @@ type UpdateProduct struct {
 	Description string  `json:"description"`
 	Price       float64 `json:"price"`
 	ImageURL    string  `json:"image_url"`
+
+	Translations map[string]Translation `json:"translations,omitempty"`
 }
 
 type GetProduct struct {
@@ -39,13 +48,40 @@ type GetProduct struct {
 }
 
 type GetAllProductsRequest struct {
-	CategoryId string `json:"category_id"`
-	Search     string `json:"search"`
-	Page       uint64 `json:"page"`
-	Limit      uint64 `json:"limit"`
+	CategoryId    string `json:"category_id"`
+	Search        string `json:"search"`
+	IncludeHidden bool   `json:"include_hidden"`
+	Page          uint64 `json:"page"`
+	Limit         uint64 `json:"limit"`
 }
 
 type GetAllProductsResponse struct {
 	Products []Product `json:"products"`
 	Count    int64     `json:"count"`
 }
+
+// ProductPrice is one entry of a product's price timeline. The entry is in
+// effect from EffectiveFrom until EffectiveTo, which is empty for the last
+// entry. Status is scheduled, current or past.
+type ProductPrice struct {
+	Id            string  `json:"id"`
+	ProductId     string  `json:"product_id"`
+	Price         float64 `json:"price"`
+	EffectiveFrom string  `json:"effective_from"`
+	EffectiveTo   string  `json:"effective_to,omitempty"`
+	AppliedAt     string  `json:"applied_at,omitempty"`
+	Status        string  `json:"status"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+// SchedulePriceRequest plans a price change; EffectiveFrom is RFC 3339 and
+// must be in the future.
+type SchedulePriceRequest struct {
+	Price         float64 `json:"price"`
+	EffectiveFrom string  `json:"effective_from"`
+}
+
+type PriceTimelineResponse struct {
+	ProductId string         `json:"product_id"`
+	Prices    []ProductPrice `json:"prices"`
+}
@@ -0,0 +1,24 @@
+package models
+
+type Receipt struct {
+	OrderId        string        `json:"order_id"`
+	BranchName     string        `json:"branch_name"`
+	BranchAddress  string        `json:"branch_address"`
+	Status         string        `json:"status"`
+	DeliveryStatus string        `json:"delivery_status"`
+	AddressName    string        `json:"address_name"`
+	ScheduledAt    string        `json:"scheduled_at,omitempty"`
+	CreatedAt      string        `json:"created_at"`
+	Items          []ReceiptItem `json:"items"`
+	Total          float64       `json:"total"`
+	PaymentMethod  string        `json:"payment_method"`
+	IsPaid         bool          `json:"is_paid"`
+}
+
+type ReceiptItem struct {
+	Name      string   `json:"name"`
+	Quantity  int      `json:"quantity"`
+	Price     float64  `json:"price"`
+	Total     float64  `json:"total"`
+	Modifiers []string `json:"modifiers,omitempty"`
+}
@@ -0,0 +1,46 @@
+package models
+
+type Review struct {
+	Id            string   `json:"id"`
+	OrderId       string   `json:"order_id"`
+	UserId        string   `json:"user_id"`
+	CourierId     string   `json:"courier_id,omitempty"`
+	FoodRating    int      `json:"food_rating"`
+	CourierRating int      `json:"courier_rating,omitempty"`
+	Comment       string   `json:"comment"`
+	PhotoUrls     []string `json:"photo_urls"`
+	Status        string   `json:"status"`
+	AdminReply    string   `json:"admin_reply,omitempty"`
+	RepliedAt     string   `json:"replied_at,omitempty"`
+	CreatedAt     string   `json:"created_at"`
+	UpdatedAt     string   `json:"updated_at"`
+}
+
+type CreateReview struct {
+	UserId        string   `json:"user_id"`
+	FoodRating    int      `json:"food_rating"`
+	CourierRating int      `json:"courier_rating,omitempty"`
+	Comment       string   `json:"comment"`
+	PhotoUrls     []string `json:"photo_urls"`
+}
+
+type ModerateReviewRequest struct {
+	Status string `json:"status"`
+}
+
+type ReplyReviewRequest struct {
+	Reply string `json:"reply"`
+}
+
+type GetAllReviewsRequest struct {
+	ProductId string `json:"product_id"`
+	CourierId string `json:"courier_id"`
+	Status    string `json:"status"`
+	Page      uint64 `json:"page"`
+	Limit     uint64 `json:"limit"`
+}
+
+type GetAllReviewsResponse struct {
+	Reviews []Review `json:"reviews"`
+	Count   int64    `json:"count"`
+}
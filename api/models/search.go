@@ -0,0 +1,23 @@
+package models
+
+type SearchRequest struct {
+	Query string `json:"q"`
+	Type  string `json:"type"`
+	Limit int    `json:"limit"`
+	Lang  string `json:"lang"`
+}
+
+type SearchResult struct {
+	Type     string  `json:"type"`
+	Id       string  `json:"id"`
+	Name     string  `json:"name"`
+	Snippet  string  `json:"snippet"`
+	Price    float64 `json:"price,omitempty"`
+	ImageURL string  `json:"image_url,omitempty"`
+	Score    float64 `json:"score"`
+}
+
+type SearchResponse struct {
+	Query   string         `json:"q"`
+	Results []SearchResult `json:"results"`
+}
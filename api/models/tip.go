@@ -0,0 +1,22 @@
+package models
+
+// OrderTip is a tip added to a delivered order, paid on its own and credited
+// to the courier who delivered it.
+type OrderTip struct {
+	PaymentId     string  `json:"payment_id"`
+	OrderId       string  `json:"order_id"`
+	CourierId     string  `json:"courier_id"`
+	Amount        float64 `json:"amount"`
+	CourierAmount float64 `json:"courier_amount"`
+	PaymentMethod string  `json:"payment_method"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+// CreateTipRequest tips the courier of a delivered order, either as an amount
+// or as a percentage of the order total.
+type CreateTipRequest struct {
+	UserId        string  `json:"user_id"`
+	Amount        float64 `json:"amount,omitempty"`
+	Percent       float64 `json:"percent,omitempty"`
+	PaymentMethod string  `json:"payment_method"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// Translation holds the translated catalog fields of one locale. Empty fields
+// fall back to the base uz-Latn value.
+type Translation struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookSubscription sends the listed events to Url. The secret is only
+// returned when the subscription is created or its secret is replaced.
+type WebhookSubscription struct {
+	Id          string   `json:"id"`
+	Url         string   `json:"url"`
+	Secret      string   `json:"secret,omitempty"`
+	Events      []string `json:"events"`
+	Description string   `json:"description,omitempty"`
+	IsActive    bool     `json:"is_active"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+// CreateWebhookSubscription makes a subscription. A secret is generated when
+// none is given, and the subscription is active unless IsActive is false.
+type CreateWebhookSubscription struct {
+	Url         string   `json:"url"`
+	Secret      string   `json:"secret"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	IsActive    *bool    `json:"is_active"`
+}
+
+// UpdateWebhookSubscription replaces the URL, events, description and state
+// of a subscription. The secret is kept when none is given.
+type UpdateWebhookSubscription struct {
+	Url         string   `json:"url"`
+	Secret      string   `json:"secret"`
+	Events      []string `json:"events"`
+	Description string   `json:"description"`
+	IsActive    bool     `json:"is_active"`
+}
+
+type GetAllWebhookSubscriptionsResponse struct {
+	Subscriptions []WebhookSubscription `json:"subscriptions"`
+	Count         int64                 `json:"count"`
+}
+
+// WebhookDelivery tracks one event sent to one subscription. Url and Secret
+// are only filled for the worker that sends it.
+type WebhookDelivery struct {
+	Id             string          `json:"id"`
+	SubscriptionId string          `json:"subscription_id"`
+	EventId        string          `json:"event_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Url            string          `json:"-"`
+	Secret         string          `json:"-"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  string          `json:"next_attempt_at,omitempty"`
+	DeliveredAt    string          `json:"delivered_at,omitempty"`
+	EventCreatedAt string          `json:"event_created_at"`
+	CreatedAt      string          `json:"created_at"`
+	// LeasedUntil is when the claim of a worker sending the delivery runs
+	// out.
+	LeasedUntil time.Time `json:"-"`
+}
+
+type GetWebhookDeliveriesRequest struct {
+	SubscriptionId string `json:"subscription_id"`
+	Status         string `json:"status"`
+	Page           uint64 `json:"page"`
+	Limit          uint64 `json:"limit"`
+}
+
+type GetWebhookDeliveriesResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Count      int64             `json:"count"`
+}
+
+// WebhookOrderCreated is the data of an order.created event.
+type WebhookOrderCreated struct {
+	OrderId     string  `json:"order_id"`
+	UserId      string  `json:"user_id"`
+	BranchId    string  `json:"branch_id,omitempty"`
+	Status      string  `json:"status"`
+	TotalPrice  float64 `json:"total_price"`
+	Tip         float64 `json:"tip"`
+	ScheduledAt string  `json:"scheduled_at,omitempty"`
+}
+
+// WebhookOrderStatusChanged is the data of an order.status_changed event.
+type WebhookOrderStatusChanged struct {
+	OrderId        string `json:"order_id"`
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previous_status"`
+}
+
+// WebhookPaymentPaid is the data of a payment.paid event. Kind is order for
+// the payment of an order and tip for a tip.
+type WebhookPaymentPaid struct {
+	PaymentId     string  `json:"payment_id"`
+	OrderId       string  `json:"order_id"`
+	UserId        string  `json:"user_id"`
+	Kind          string  `json:"kind"`
+	Amount        float64 `json:"amount"`
+	PaymentMethod string  `json:"payment_method"`
+}
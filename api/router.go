@@ -4,6 +4,7 @@ import (
 	_ "food/api/docs"
 	"food/api/handler"
 	"food/config"
+	"food/pkg/blob"
 	"food/pkg/logger"
 	"food/service"
 	"food/storage"
@@ -20,14 +21,20 @@ import (
 // @securityDefinitions.apikey ApiKeyAuth
 // @in header
 // @name Authorization
-func NewApi(r *gin.Engine, cfg *config.Config, storage storage.IStorage, logger logger.LoggerI, service service.IServiceManager) {
-	h := handler.NewStrg(logger, storage, cfg, service)
+func NewApi(r *gin.Engine, cfg *config.Config, storage storage.IStorage, logger logger.LoggerI, service service.IServiceManager, blobs blob.BlobStore) {
+	h := handler.NewStrg(logger, storage, cfg, service, blobs)
 	r.Use(customCORSMiddleware())
 	// r.Use(authMiddleware)
 	v1 := r.Group("/food/api/v1")
 
 	v1.POST("/uploadfiles", h.UploadFiles)
 	v1.DELETE("/deletefiles", h.DeleteFile)
+	v1.GET("/signedurl", h.GetSignedURL)
+
+	// Files of the local blob store are served by the API itself.
+	if _, ok := blobs.(*blob.LocalStore); ok {
+		r.GET("/files/*key", h.ServeLocalFile)
+	}
 
 	v1.POST("/sendcode", h.UserRegister)
 	v1.POST("/user/register", h.Register)
@@ -38,10 +45,18 @@ func NewApi(r *gin.Engine, cfg *config.Config, storage storage.IStorage, logger
 	// v1.POST("/admin/verifycode", h.AdminRegisterConfirm)
 	v1.POST("/admin/login", h.AdminLogin)
 
+	v1.GET("/search", h.Search)
+
 	v1.POST("/combo", h.CreateCombo)
 	v1.GET("/getallcombos", h.GetAllCombos)
 	v1.GET("/getcombo/:id", h.GetCombo)
 	v1.PUT("/updatecombo/:id", h.UpdateCombo)
+	v1.DELETE("/deletecombo/:id", h.DeleteCombo)
+	v1.POST("/combos/:id/items", h.AddComboItem)
+	v1.PUT("/combos/:id/items", h.ReplaceComboItems)
+	v1.DELETE("/combos/:id/items/:item_id", h.RemoveComboItem)
+	v1.POST("/combos/:id/images", h.UploadComboImages)
+	v1.PUT("/combos/:id/images", h.ReorderComboImages)
 
 	v1.POST("/category", h.CreateCategory)
 	v1.GET("/getbycategory/:id", h.GetCategoryByID)
@@ -55,6 +70,15 @@ func NewApi(r *gin.Engine, cfg *config.Config, storage storage.IStorage, logger
 	v1.PUT("/updateorder", h.UpdateOrder)
 	v1.DELETE("/deleteorder/:id", h.DeleteOrder)
 	v1.PATCH("/orderStatus/:id", h.ChangeOrderStatus)
+	v1.GET("/orders/:id/receipt", h.GetOrderReceipt)
+	v1.POST("/orders/:id/receipt/email", h.EmailOrderReceipt)
+	v1.GET("/orders/:id/ticket", h.GetOrderKitchenTicket)
+	v1.POST("/orders/:id/review", h.CreateReview)
+	v1.POST("/orders/:id/tip", h.CreateTip)
+
+	v1.GET("/reviews", h.GetAllReviews)
+	v1.PATCH("/reviews/:id/status", h.ModerateReview)
+	v1.POST("/reviews/:id/reply", h.ReplyReview)
 
 	v1.POST("/createadmin", h.CreateAdmin)
 	v1.GET("/getbyidadmin/:id", h.GetAdminByID)
@@ -62,27 +86,96 @@ func NewApi(r *gin.Engine, cfg *config.Config, storage storage.IStorage, logger
 	v1.PUT("/updateadmin/:id", h.UpdateAdmin)
 	v1.DELETE("/deleteadmin/:id", h.DeleteAdmin)
 
+	v1.GET("/admin/analytics/revenue", h.GetRevenueReport)
+	v1.GET("/admin/analytics/top-products", h.GetTopProductsReport)
+	v1.GET("/admin/analytics/branches", h.GetBranchPerformanceReport)
+	v1.GET("/admin/analytics/hourly", h.GetHourlyReport)
+	v1.GET("/admin/analytics/customers", h.GetCustomerReport)
+
+	v1.GET("/admin/export/orders", h.ExportOrders)
+	v1.GET("/admin/export/payments", h.ExportPayments)
+	v1.GET("/admin/export/courier-earnings", h.ExportCourierEarnings)
+
+	v1.POST("/admin/catalog/import", h.ImportCatalog)
+	v1.GET("/admin/catalog/export", h.ExportCatalog)
+
+	v1.GET("/admin/courier-rates", h.GetCourierRates)
+	v1.PUT("/admin/courier-rates", h.SetCourierRate)
+	v1.POST("/admin/courier-assignments", h.AssignCourier)
+	v1.PATCH("/admin/courier-assignments/:id/status", h.ChangeCourierAssignmentStatus)
+	v1.POST("/admin/courier-assignments/:id/handover-override", h.OverrideCourierHandover)
+	v1.GET("/admin/orders/:id/handover-attempts", h.GetHandoverAttempts)
+	v1.POST("/admin/couriers/:id/reconciliations", h.ReconcileCourierCash)
+	v1.GET("/admin/courier-reconciliations", h.GetCourierReconciliations)
+	v1.POST("/admin/courier-payouts", h.CreateCourierPayouts)
+	v1.GET("/admin/courier-payouts", h.GetCourierPayouts)
+	v1.PATCH("/admin/courier-payouts/:id/paid", h.MarkCourierPayoutPaid)
+	v1.GET("/couriers/:id/earnings", h.GetCourierEarnings)
+	v1.GET("/couriers/:id/cash", h.GetCourierCash)
+	v1.GET("/couriers/:id/payouts", h.GetCourierOwnPayouts)
+	v1.GET("/couriers/:id/jobs", h.GetCourierJobs)
+	v1.POST("/couriers/:id/jobs/:assignment_id/accept", h.AcceptCourierJob)
+	v1.PATCH("/couriers/:id/jobs/:assignment_id/status", h.ChangeCourierJobStatus)
+	v1.POST("/couriers/:id/jobs/:assignment_id/deliver", h.DeliverCourierJob)
+
 	v1.POST("/createuser", h.CreateUser)
 	v1.GET("/getbyiduser/:id", h.GetUserByID)
 	v1.GET("/getallusers", h.GetAllUsers)
 	v1.PUT("/updateuser/:id", h.UpdateUser)
 	v1.DELETE("/deleteuser/:id", h.DeleteUser)
+	v1.GET("/users/:id/notification-preferences", h.GetNotificationPreferences)
+	v1.PUT("/users/:id/notification-preferences", h.SetNotificationPreferences)
+	v1.GET("/users/:id/devices", h.GetDevices)
+	v1.POST("/users/:id/devices", h.RegisterDevice)
+	v1.DELETE("/users/:id/devices", h.UnregisterDevice)
+	v1.GET("/admin/notifications/:id/deliveries", h.GetNotificationDeliveries)
+	v1.GET("/me/notifications", h.GetMyNotifications)
+	v1.GET("/me/notifications/unread-count", h.GetMyUnreadCount)
+	v1.POST("/me/notifications/read", h.MarkMyNotificationsRead)
+
+	v1.POST("/admin/webhooks", h.CreateWebhookSubscription)
+	v1.GET("/admin/webhooks", h.GetWebhookSubscriptions)
+	v1.GET("/admin/webhooks/:id", h.GetWebhookSubscription)
+	v1.PUT("/admin/webhooks/:id", h.UpdateWebhookSubscription)
+	v1.DELETE("/admin/webhooks/:id", h.DeleteWebhookSubscription)
+	v1.GET("/admin/webhooks/:id/deliveries", h.GetWebhookDeliveries)
+	v1.POST("/admin/webhook-deliveries/:id/redeliver", h.RedeliverWebhook)
+
+	v1.GET("/admin/jobs", h.GetJobs)
+	v1.POST("/admin/jobs/:id/retry", h.RetryJob)
 
 	v1.POST("/createproduct", h.CreateProduct)
 	v1.GET("/getproduct/:id", h.GetProductByID)
 	v1.GET("/getallproducts", h.GetAllProducts)
 	v1.PUT("/updateproduct/:id", h.UpdateProduct)
 	v1.DELETE("/deleteproduct/:id", h.DeleteProduct)
+	v1.GET("/products/:id/reviews", h.GetProductReviews)
+	v1.POST("/products/:id/images", h.UploadProductImages)
+	v1.PUT("/products/:id/images", h.ReorderProductImages)
+	v1.POST("/products/:id/prices", h.ScheduleProductPrice)
+	v1.GET("/products/:id/prices", h.GetProductPrices)
+	v1.DELETE("/products/:id/prices/:price_id", h.CancelProductPrice)
+	v1.DELETE("/images/:id", h.DeleteImage)
 
 	v1.POST("/createbranch", h.CreateBranch)
 	v1.GET("/getbranch/:id", h.GetBranchByID)
 	v1.GET("/getallbranches", h.GetAllBranches)
 	v1.PUT("/updatebranch/:id", h.UpdateBranch)
 	v1.DELETE("/deletebranch/:id", h.DeleteBranch)
+	v1.GET("/branches/:id/slots", h.GetBranchSlots)
+
+	v1.GET("/kitchen/:branch_id/queue", h.GetKitchenQueue)
+	v1.GET("/kitchen/:branch_id/feed", h.KitchenFeed)
+	v1.GET("/kitchen/:branch_id/stats", h.GetKitchenStats)
+	v1.PATCH("/kitchen/:branch_id/orders/:id/status", h.BumpKitchenOrder)
+	v1.PATCH("/kitchen/:branch_id/items/:id/status", h.BumpKitchenItem)
 
 	v1.POST("/createbanner", h.CreateBanner)
 	v1.GET("/getallbanners", h.GetAllBanners)
-	v1.DELETE("/deletebanner", h.DeleteBanner)
+	v1.DELETE("/deletebanner/:id", h.DeleteBanner)
+	v1.POST("/banners/track", h.TrackBanners)
+	v1.GET("/banners/:id", h.GetBannerByID)
+	v1.PUT("/banners/:id", h.UpdateBanner)
 
 	url := ginSwagger.URL("swagger/doc.json")
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"food/api"
 	"food/config"
+	"food/pkg/blob"
 	"food/pkg/logger"
+	"food/pkg/push"
+	"food/pkg/smtp"
 	"food/service"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	postgres "food/storage/postgres"
@@ -30,6 +38,9 @@ func KeepAlive(cfg *config.Config) {
 func main() {
 	cfg := config.Load()
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	var loggerLevel = new(string)
 
 	*loggerLevel = logger.LevelDebug
@@ -60,24 +71,66 @@ func main() {
 	}
 	defer pgconn.CloseDB()
 
+	blobs, err := blob.New(context.Background(), &cfg)
+	if err != nil {
+		panic("blob store: " + err.Error())
+	}
+
+	pusher, err := push.New(context.Background(), &cfg)
+	if err != nil {
+		panic("push sender: " + err.Error())
+	}
+
+	mailer, err := smtp.New(&cfg)
+	if err != nil {
+		panic("mailer: " + err.Error())
+	}
+
 	newRedis := redis.New(cfg)
-	services := service.New(pgconn, log, newRedis)
+	services := service.New(pgconn, log, newRedis, blobs, pusher, mailer, cfg.AdminAlertEmails)
 
 	r := gin.New()
 	r.Use(gin.Recovery(), gin.Logger())
 
-	api.NewApi(r, &cfg, pgconn, log, services)
+	api.NewApi(r, &cfg, pgconn, log, services, blobs)
 
 	go KeepAlive(&cfg)
+	go services.Scheduler().Run(ctx, time.Minute)
+	go services.Notification().Run(ctx, time.Minute)
+	go services.Webhook().Run(ctx, 10*time.Second)
+
+	jobsDone := make(chan struct{})
+	go func() {
+		services.Job().Run(ctx, cfg.JobWorkers, time.Second)
+		close(jobsDone)
+	}()
 
 	r.GET("/ping", func(c *gin.Context) {
 		c.String(200, "pong")
 	})
 
-	fmt.Println("Listening server", cfg.PostgresHost+cfg.HTTPPort)
-	err = r.Run(cfg.HTTPPort)
-	if err != nil {
-		panic(err)
+	srv := &http.Server{Addr: cfg.HTTPPort, Handler: r}
+	go func() {
+		fmt.Println("Listening server", cfg.PostgresHost+cfg.HTTPPort)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			panic(err)
+		}
+	}()
+
+	// On SIGINT or SIGTERM stop taking requests and jobs, and wait for the
+	// ones in flight up to the shutdown timeout.
+	<-ctx.Done()
+	fmt.Println("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		fmt.Println("Error while shutting down server:", err)
+	}
+	select {
+	case <-jobsDone:
+	case <-shutdownCtx.Done():
+		fmt.Println("Jobs were still running at the shutdown timeout")
 	}
 //
 }
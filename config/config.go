@@ -3,6 +3,8 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/cast"
@@ -47,6 +49,44 @@ type Config struct {
 
 	AuthServiceHost string
 	AuthGRPCPort    string
+
+	// BlobBackend is firebase, gcs, s3 or local. It defaults to local, so
+	// the bucket backends are only used when configured explicitly.
+	BlobBackend         string
+	BlobBucket          string
+	BlobCredentialsFile string
+	BlobLocalDir        string
+	BlobPublicURL       string
+	BlobSignedURLs      bool
+	BlobSignedURLTTL    time.Duration
+
+	S3Endpoint  string
+	S3Region    string
+	S3AccessKey string
+	S3SecretKey string
+
+	// PushBackend is fcm or fake. It defaults to fake, so notifications only
+	// reach devices through FCM when it is configured explicitly.
+	PushBackend         string
+	PushCredentialsFile string
+
+	// MailBackend is smtp or file.
+	MailBackend   string
+	SmtpHost      string
+	SmtpPort      string
+	SmtpUsername  string
+	SmtpPassword  string
+	MailFrom      string
+	MailFromName  string
+	MailOutboxDir string
+	// AdminAlertEmails receive alerts such as cash shortages.
+	AdminAlertEmails []string
+
+	// JobWorkers is how many background jobs run at the same time.
+	JobWorkers int
+	// ShutdownTimeout is how long the server waits for requests and jobs in
+	// flight before it exits.
+	ShutdownTimeout time.Duration
 }
 
 // Load ...
@@ -85,6 +125,37 @@ func Load() Config {
 
 	config.SecretKey = cast.ToString(getOrReturnDefaultValue("SECRET_KEY", "NVWmbbPGxh7gy1igr4irX3qaAYun9nxi"))
 
+	config.BlobBackend = cast.ToString(getOrReturnDefaultValue("BLOB_BACKEND", "local"))
+	config.BlobBucket = cast.ToString(getOrReturnDefaultValue("BLOB_BUCKET", "food-8ceb4.appspot.com"))
+	config.BlobCredentialsFile = cast.ToString(getOrReturnDefaultValue("BLOB_CREDENTIALS_FILE", "serviceAccountKey.json"))
+	config.BlobLocalDir = cast.ToString(getOrReturnDefaultValue("BLOB_LOCAL_DIR", "./uploads"))
+	config.BlobPublicURL = cast.ToString(getOrReturnDefaultValue("BLOB_PUBLIC_URL", "http://localhost"+config.HTTPPort+"/files"))
+	config.BlobSignedURLs = cast.ToBool(getOrReturnDefaultValue("BLOB_SIGNED_URLS", false))
+	config.BlobSignedURLTTL = cast.ToDuration(getOrReturnDefaultValue("BLOB_SIGNED_URL_TTL", "15m"))
+
+	config.S3Endpoint = cast.ToString(getOrReturnDefaultValue("S3_ENDPOINT", "https://s3.amazonaws.com"))
+	config.S3Region = cast.ToString(getOrReturnDefaultValue("S3_REGION", "us-east-1"))
+	config.S3AccessKey = cast.ToString(getOrReturnDefaultValue("S3_ACCESS_KEY", ""))
+	config.S3SecretKey = cast.ToString(getOrReturnDefaultValue("S3_SECRET_KEY", ""))
+
+	config.PushBackend = cast.ToString(getOrReturnDefaultValue("PUSH_BACKEND", "fake"))
+	config.PushCredentialsFile = cast.ToString(getOrReturnDefaultValue("PUSH_CREDENTIALS_FILE", config.BlobCredentialsFile))
+
+	config.MailBackend = cast.ToString(getOrReturnDefaultValue("MAIL_BACKEND", "smtp"))
+	config.SmtpHost = cast.ToString(getOrReturnDefaultValue("SMTP_HOST", "smtp.gmail.com"))
+	config.SmtpPort = cast.ToString(getOrReturnDefaultValue("SMTP_PORT", "587"))
+	config.SmtpUsername = cast.ToString(getOrReturnDefaultValue("SMTP_USERNAME", ""))
+	config.SmtpPassword = cast.ToString(getOrReturnDefaultValue("SMTP_PASSWORD", ""))
+	config.MailFrom = cast.ToString(getOrReturnDefaultValue("MAIL_FROM", config.SmtpUsername))
+	config.MailFromName = cast.ToString(getOrReturnDefaultValue("MAIL_FROM_NAME", "Khorezm Shashlik"))
+	config.MailOutboxDir = cast.ToString(getOrReturnDefaultValue("MAIL_OUTBOX_DIR", "./outbox"))
+	config.AdminAlertEmails = strings.FieldsFunc(cast.ToString(getOrReturnDefaultValue("ADMIN_ALERT_EMAILS", "")), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+
+	config.JobWorkers = cast.ToInt(getOrReturnDefaultValue("JOB_WORKERS", 4))
+	config.ShutdownTimeout = cast.ToDuration(getOrReturnDefaultValue("SHUTDOWN_TIMEOUT", "30s"))
+
 	return config
 }
 
@@ -12,10 +12,32 @@ const (
 	STATUS_IN_PROCESS   = "in-process"
 	STATUS_FINISHED     = "finished"
 	STATUS_CANCELED     = "canceled"
-	SmtpServer          = "smtp.gmail.com"
-	SmtpPort            = "587"
-	SmtpUsername        = "sharofiddinbobomurodov7011@gmail.com"
-	SmtpPassword        = "duriexakadbzalxw"
+	MaxScheduleDays     = 7
+	ReviewWindowDays    = 3
+	TipWindowHours      = 24
+	MaxGalleryImages    = 10
+	MaxImageUploadBytes = 10 << 20
+	MaxUploadBytes      = 10 << 20
+	MaxCatalogBytes     = 10 << 20
+	MaxCatalogRows      = 5000
+	// DeliveryGeofenceMeters is how close to the drop-off a courier must be
+	// for the location alone to prove a delivery.
+	DeliveryGeofenceMeters = 150
+	// MaxHandoverAttempts is how many wrong handover codes lock an order
+	// until an admin overrides the handover.
+	MaxHandoverAttempts = 5
+	// MaxNotificationAttempts is how many times a notification is sent on a
+	// channel before its delivery is marked failed.
+	MaxNotificationAttempts = 5
+	// MaxWebhookAttempts is how many times an event is sent to a
+	// subscription before its delivery is dead-lettered.
+	MaxWebhookAttempts = 10
+	// MaxJobAttempts is how many times a background job runs before it is
+	// marked failed, unless it was enqueued with its own limit.
+	MaxJobAttempts = 5
+	// CourierAcceptMinutes is how long a courier has to accept an assigned
+	// job before the admins are alerted.
+	CourierAcceptMinutes = 5
 )
 
 var SignedKey = []byte("MGJd@Ro]yKoCc)mVY1^c:upz~4rn9Pt!hYd]>c8dt#+%")
@@ -0,0 +1,20 @@
+DROP INDEX IF EXISTS order_branch_scheduled_at_idx;
+
+UPDATE "order" SET status = 'pending' WHERE status = 'scheduled';
+
+ALTER TABLE "order" DROP CONSTRAINT IF EXISTS order_status_check;
+ALTER TABLE "order" ADD CONSTRAINT order_status_check
+  CHECK (status IN ('pending', 'confirmed', 'picked_up', 'delivered'));
+
+ALTER TABLE "order"
+  DROP COLUMN IF EXISTS deleted_at,
+  DROP COLUMN IF EXISTS released_at,
+  DROP COLUMN IF EXISTS scheduled_at,
+  DROP COLUMN IF EXISTS branch_id;
+
+ALTER TABLE "branch"
+  DROP COLUMN IF EXISTS lead_minutes,
+  DROP COLUMN IF EXISTS slot_capacity,
+  DROP COLUMN IF EXISTS slot_minutes,
+  DROP COLUMN IF EXISTS closing_time,
+  DROP COLUMN IF EXISTS opening_time;
@@ -0,0 +1,19 @@
+ALTER TABLE "branch"
+  ADD COLUMN IF NOT EXISTS opening_time TIME NOT NULL DEFAULT '09:00',
+  ADD COLUMN IF NOT EXISTS closing_time TIME NOT NULL DEFAULT '23:00',
+  ADD COLUMN IF NOT EXISTS slot_minutes INT NOT NULL DEFAULT 30 CHECK (slot_minutes > 0),
+  ADD COLUMN IF NOT EXISTS slot_capacity INT NOT NULL DEFAULT 10 CHECK (slot_capacity >= 0),
+  ADD COLUMN IF NOT EXISTS lead_minutes INT NOT NULL DEFAULT 45 CHECK (lead_minutes >= 0);
+
+ALTER TABLE "order"
+  ADD COLUMN IF NOT EXISTS branch_id UUID REFERENCES "branch"(id),
+  ADD COLUMN IF NOT EXISTS scheduled_at TIMESTAMP,
+  ADD COLUMN IF NOT EXISTS released_at TIMESTAMP,
+  ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+ALTER TABLE "order" DROP CONSTRAINT IF EXISTS order_status_check;
+ALTER TABLE "order" ADD CONSTRAINT order_status_check
+  CHECK (status IN ('scheduled', 'pending', 'confirmed', 'picked_up', 'delivered'));
+
+CREATE INDEX IF NOT EXISTS order_branch_scheduled_at_idx ON "order" (branch_id, scheduled_at)
+  WHERE scheduled_at IS NOT NULL;
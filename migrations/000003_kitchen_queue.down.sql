@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS orderiteam_order_id_idx;
+DROP INDEX IF EXISTS order_branch_status_idx;
+
+UPDATE "order" SET status = 'confirmed' WHERE status IN ('preparing', 'ready');
+
+ALTER TABLE "order" DROP CONSTRAINT IF EXISTS order_status_check;
+ALTER TABLE "order" ADD CONSTRAINT order_status_check
+  CHECK (status IN ('scheduled', 'pending', 'confirmed', 'picked_up', 'delivered'));
+
+ALTER TABLE "orderiteam"
+  DROP COLUMN IF EXISTS ready_at,
+  DROP COLUMN IF EXISTS started_at,
+  DROP COLUMN IF EXISTS kitchen_status,
+  DROP COLUMN IF EXISTS modifiers;
@@ -0,0 +1,12 @@
+ALTER TABLE "orderiteam"
+  ADD COLUMN IF NOT EXISTS modifiers TEXT[] NOT NULL DEFAULT '{}',
+  ADD COLUMN IF NOT EXISTS kitchen_status VARCHAR NOT NULL DEFAULT 'queued' CHECK (kitchen_status IN ('queued', 'preparing', 'ready')),
+  ADD COLUMN IF NOT EXISTS started_at TIMESTAMP,
+  ADD COLUMN IF NOT EXISTS ready_at TIMESTAMP;
+
+ALTER TABLE "order" DROP CONSTRAINT IF EXISTS order_status_check;
+ALTER TABLE "order" ADD CONSTRAINT order_status_check
+  CHECK (status IN ('scheduled', 'pending', 'confirmed', 'preparing', 'ready', 'picked_up', 'delivered'));
+
+CREATE INDEX IF NOT EXISTS order_branch_status_idx ON "order" (branch_id, status);
+CREATE INDEX IF NOT EXISTS orderiteam_order_id_idx ON "orderiteam" (order_id);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS orderiteam_product_id_idx;
+DROP TABLE IF EXISTS "review";
+
+ALTER TABLE "product"
+  DROP COLUMN IF EXISTS rating_count,
+  DROP COLUMN IF EXISTS rating_avg;
+
+ALTER TABLE "order" DROP COLUMN IF EXISTS delivered_at;
@@ -0,0 +1,25 @@
+ALTER TABLE "order" ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMP;
+
+ALTER TABLE "product"
+  ADD COLUMN IF NOT EXISTS rating_avg DECIMAL(3, 2) NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS rating_count INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "review" (
+  id UUID PRIMARY KEY,
+  order_id UUID NOT NULL UNIQUE REFERENCES "order"(id),
+  user_id UUID NOT NULL REFERENCES "user"(id),
+  courier_id UUID REFERENCES "user"(id),
+  food_rating INT NOT NULL CHECK (food_rating BETWEEN 1 AND 5),
+  courier_rating INT CHECK (courier_rating BETWEEN 1 AND 5),
+  comment TEXT NOT NULL DEFAULT '',
+  photo_urls TEXT[] NOT NULL DEFAULT '{}',
+  status VARCHAR NOT NULL DEFAULT 'published' CHECK (status IN ('published', 'hidden')),
+  admin_reply TEXT,
+  replied_at TIMESTAMP,
+  created_at TIMESTAMP DEFAULT now(),
+  updated_at TIMESTAMP DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS review_courier_id_idx ON "review" (courier_id) WHERE courier_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS review_status_created_at_idx ON "review" (status, created_at DESC);
+CREATE INDEX IF NOT EXISTS orderiteam_product_id_idx ON "orderiteam" (product_id);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS category_name_trgm_idx;
+DROP INDEX IF EXISTS combo_name_trgm_idx;
+DROP INDEX IF EXISTS product_name_trgm_idx;
+
+DROP INDEX IF EXISTS category_search_vector_idx;
+DROP INDEX IF EXISTS combo_search_vector_idx;
+DROP INDEX IF EXISTS product_search_vector_idx;
+
+ALTER TABLE "category" DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE "combo" DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE "product" DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,25 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Apostrophes are dropped before indexing so o'sh and osh, or g'isht and
+-- gisht, produce the same lexeme.
+ALTER TABLE "product" ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+  setweight(to_tsvector('simple', translate(lower(coalesce(name, '')), '''‘’ʻʼ`´', '')), 'A') ||
+  setweight(to_tsvector('simple', translate(lower(coalesce(description, '')), '''‘’ʻʼ`´', '')), 'B')
+) STORED;
+
+ALTER TABLE "combo" ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+  setweight(to_tsvector('simple', translate(lower(coalesce(name, '')), '''‘’ʻʼ`´', '')), 'A') ||
+  setweight(to_tsvector('simple', translate(lower(coalesce(description, '')), '''‘’ʻʼ`´', '')), 'B')
+) STORED;
+
+ALTER TABLE "category" ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+  to_tsvector('simple', translate(lower(coalesce(name, '')), '''‘’ʻʼ`´', ''))
+) STORED;
+
+CREATE INDEX IF NOT EXISTS product_search_vector_idx ON "product" USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS combo_search_vector_idx ON "combo" USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS category_search_vector_idx ON "category" USING GIN (search_vector);
+
+CREATE INDEX IF NOT EXISTS product_name_trgm_idx ON "product" USING GIN (lower(name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS combo_name_trgm_idx ON "combo" USING GIN (lower(name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS category_name_trgm_idx ON "category" USING GIN (lower(name) gin_trgm_ops);
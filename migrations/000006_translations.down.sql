@@ -0,0 +1,24 @@
+ALTER TABLE "product" DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE "product" ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+  setweight(to_tsvector('simple', translate(lower(coalesce(name, '')), '''‘’ʻʼ`´', '')), 'A') ||
+  setweight(to_tsvector('simple', translate(lower(coalesce(description, '')), '''‘’ʻʼ`´', '')), 'B')
+) STORED;
+
+ALTER TABLE "combo" DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE "combo" ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+  setweight(to_tsvector('simple', translate(lower(coalesce(name, '')), '''‘’ʻʼ`´', '')), 'A') ||
+  setweight(to_tsvector('simple', translate(lower(coalesce(description, '')), '''‘’ʻʼ`´', '')), 'B')
+) STORED;
+
+ALTER TABLE "category" DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE "category" ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+  to_tsvector('simple', translate(lower(coalesce(name, '')), '''‘’ʻʼ`´', ''))
+) STORED;
+
+CREATE INDEX IF NOT EXISTS product_search_vector_idx ON "product" USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS combo_search_vector_idx ON "combo" USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS category_search_vector_idx ON "category" USING GIN (search_vector);
+
+ALTER TABLE "combo" DROP COLUMN IF EXISTS translations;
+ALTER TABLE "category" DROP COLUMN IF EXISTS translations;
+ALTER TABLE "product" DROP COLUMN IF EXISTS translations;
@@ -0,0 +1,33 @@
+-- Translations are keyed by locale (uz-Cyrl, ru, en); the base name and
+-- description columns hold the uz-Latn text.
+ALTER TABLE "product" ADD COLUMN IF NOT EXISTS translations JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE "category" ADD COLUMN IF NOT EXISTS translations JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE "combo" ADD COLUMN IF NOT EXISTS translations JSONB NOT NULL DEFAULT '{}';
+
+-- Rebuild the search vectors so translated names and descriptions are
+-- searchable too.
+ALTER TABLE "product" DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE "product" ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+  setweight(to_tsvector('simple', translate(lower(coalesce(name, '')), '''‘’ʻʼ`´', '')), 'A') ||
+  setweight(to_tsvector('simple', translate(lower(jsonb_path_query_array(translations, '$.*.name')::text), '''‘’ʻʼ`´', '')), 'A') ||
+  setweight(to_tsvector('simple', translate(lower(coalesce(description, '')), '''‘’ʻʼ`´', '')), 'B') ||
+  setweight(to_tsvector('simple', translate(lower(jsonb_path_query_array(translations, '$.*.description')::text), '''‘’ʻʼ`´', '')), 'B')
+) STORED;
+
+ALTER TABLE "combo" DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE "combo" ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+  setweight(to_tsvector('simple', translate(lower(coalesce(name, '')), '''‘’ʻʼ`´', '')), 'A') ||
+  setweight(to_tsvector('simple', translate(lower(jsonb_path_query_array(translations, '$.*.name')::text), '''‘’ʻʼ`´', '')), 'A') ||
+  setweight(to_tsvector('simple', translate(lower(coalesce(description, '')), '''‘’ʻʼ`´', '')), 'B') ||
+  setweight(to_tsvector('simple', translate(lower(jsonb_path_query_array(translations, '$.*.description')::text), '''‘’ʻʼ`´', '')), 'B')
+) STORED;
+
+ALTER TABLE "category" DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE "category" ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+  to_tsvector('simple', translate(lower(coalesce(name, '')), '''‘’ʻʼ`´', '')) ||
+  to_tsvector('simple', translate(lower(jsonb_path_query_array(translations, '$.*.name')::text), '''‘’ʻʼ`´', ''))
+) STORED;
+
+CREATE INDEX IF NOT EXISTS product_search_vector_idx ON "product" USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS combo_search_vector_idx ON "combo" USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS category_search_vector_idx ON "category" USING GIN (search_vector);
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS product_category_id_idx;
+DROP INDEX IF EXISTS category_parent_id_idx;
+
+ALTER TABLE "category" DROP CONSTRAINT IF EXISTS category_visible_window;
+ALTER TABLE "category" DROP CONSTRAINT IF EXISTS category_parent_not_self;
+
+ALTER TABLE "category"
+  DROP COLUMN IF EXISTS visible_to,
+  DROP COLUMN IF EXISTS visible_from,
+  DROP COLUMN IF EXISTS is_active,
+  DROP COLUMN IF EXISTS icon_url,
+  DROP COLUMN IF EXISTS sort_order,
+  DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,15 @@
+ALTER TABLE "category"
+  ADD COLUMN IF NOT EXISTS parent_id UUID REFERENCES "category"(id),
+  ADD COLUMN IF NOT EXISTS sort_order INT NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS icon_url VARCHAR,
+  ADD COLUMN IF NOT EXISTS is_active BOOLEAN NOT NULL DEFAULT true,
+  -- Daily visibility window in local time; NULL means always visible. A
+  -- window whose end is before its start runs past midnight.
+  ADD COLUMN IF NOT EXISTS visible_from TIME,
+  ADD COLUMN IF NOT EXISTS visible_to TIME;
+
+ALTER TABLE "category" ADD CONSTRAINT category_parent_not_self CHECK (parent_id <> id);
+ALTER TABLE "category" ADD CONSTRAINT category_visible_window CHECK ((visible_from IS NULL) = (visible_to IS NULL));
+
+CREATE INDEX IF NOT EXISTS category_parent_id_idx ON "category" (parent_id);
+CREATE INDEX IF NOT EXISTS product_category_id_idx ON "product" (category_id);
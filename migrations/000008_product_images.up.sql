@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS "product_image" (
+  id UUID PRIMARY KEY,
+  product_id UUID REFERENCES "product"(id) ON DELETE CASCADE,
+  combo_id UUID REFERENCES "combo"(id) ON DELETE CASCADE,
+  position INT NOT NULL DEFAULT 0,
+  thumb_url VARCHAR NOT NULL,
+  card_url VARCHAR NOT NULL,
+  full_url VARCHAR NOT NULL,
+  -- Size of the full rendition; smaller renditions keep the aspect ratio.
+  width INT NOT NULL,
+  height INT NOT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT product_image_one_owner CHECK (num_nonnulls(product_id, combo_id) = 1)
+);
+
+CREATE INDEX IF NOT EXISTS product_image_product_id_idx ON "product_image" (product_id, position);
+CREATE INDEX IF NOT EXISTS product_image_combo_id_idx ON "product_image" (combo_id, position);
@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS "banner_branch";
+
+ALTER TABLE "banner" DROP CONSTRAINT IF EXISTS banner_window;
+ALTER TABLE "banner" DROP CONSTRAINT IF EXISTS banner_target;
+ALTER TABLE "banner" DROP CONSTRAINT IF EXISTS banner_pkey;
+
+ALTER TABLE "banner"
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS clicks,
+  DROP COLUMN IF EXISTS impressions,
+  DROP COLUMN IF EXISTS ends_at,
+  DROP COLUMN IF EXISTS starts_at,
+  DROP COLUMN IF EXISTS is_active,
+  DROP COLUMN IF EXISTS sort_order,
+  DROP COLUMN IF EXISTS target_id,
+  DROP COLUMN IF EXISTS target_type,
+  DROP COLUMN IF EXISTS title,
+  DROP COLUMN IF EXISTS id;
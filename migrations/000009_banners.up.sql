@@ -0,0 +1,34 @@
+ALTER TABLE "banner"
+  ADD COLUMN IF NOT EXISTS id UUID,
+  ADD COLUMN IF NOT EXISTS title VARCHAR NOT NULL DEFAULT '',
+  -- Deep link opened on tap: a product, combo or category id, or a
+  -- promotion code or URL.
+  ADD COLUMN IF NOT EXISTS target_type VARCHAR,
+  ADD COLUMN IF NOT EXISTS target_id VARCHAR,
+  ADD COLUMN IF NOT EXISTS sort_order INT NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS is_active BOOLEAN NOT NULL DEFAULT true,
+  -- Campaign window in local time; NULL leaves that side open.
+  ADD COLUMN IF NOT EXISTS starts_at TIMESTAMP,
+  ADD COLUMN IF NOT EXISTS ends_at TIMESTAMP,
+  ADD COLUMN IF NOT EXISTS impressions BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS clicks BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT now();
+
+UPDATE "banner" SET id = gen_random_uuid() WHERE id IS NULL;
+ALTER TABLE "banner" ALTER COLUMN id SET NOT NULL;
+ALTER TABLE "banner" ADD PRIMARY KEY (id);
+
+ALTER TABLE "banner" ADD CONSTRAINT banner_target CHECK (
+  (target_type IS NULL AND target_id IS NULL) OR
+  (target_type IN ('product', 'combo', 'category', 'promotion') AND target_id IS NOT NULL)
+);
+ALTER TABLE "banner" ADD CONSTRAINT banner_window CHECK (starts_at IS NULL OR ends_at IS NULL OR starts_at < ends_at);
+
+-- A banner without rows here is shown at every branch.
+CREATE TABLE IF NOT EXISTS "banner_branch" (
+  banner_id UUID NOT NULL REFERENCES "banner"(id) ON DELETE CASCADE,
+  branch_id UUID NOT NULL REFERENCES "branch"(id) ON DELETE CASCADE,
+  PRIMARY KEY (banner_id, branch_id)
+);
+
+CREATE INDEX IF NOT EXISTS banner_branch_branch_id_idx ON "banner_branch" (branch_id);
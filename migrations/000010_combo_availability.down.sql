@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS combo_items_combo_id_idx;
+
+ALTER TABLE "combo_items" DROP CONSTRAINT IF EXISTS combo_items_combo_id_fkey;
+ALTER TABLE "combo_items" ADD CONSTRAINT combo_items_combo_id_fkey FOREIGN KEY (combo_id) REFERENCES "combo"(id);
+
+ALTER TABLE "combo" DROP CONSTRAINT IF EXISTS combo_available_window;
+
+ALTER TABLE "combo"
+  DROP COLUMN IF EXISTS archived_at,
+  DROP COLUMN IF EXISTS available_to,
+  DROP COLUMN IF EXISTS available_from,
+  DROP COLUMN IF EXISTS is_active;
@@ -0,0 +1,14 @@
+ALTER TABLE "combo"
+  ADD COLUMN IF NOT EXISTS is_active BOOLEAN NOT NULL DEFAULT true,
+  -- Daily availability window in local time, e.g. lunch combos; NULL means
+  -- all day. A window whose end is before its start runs past midnight.
+  ADD COLUMN IF NOT EXISTS available_from TIME,
+  ADD COLUMN IF NOT EXISTS available_to TIME,
+  ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+
+ALTER TABLE "combo" ADD CONSTRAINT combo_available_window CHECK ((available_from IS NULL) = (available_to IS NULL));
+
+ALTER TABLE "combo_items" DROP CONSTRAINT IF EXISTS combo_items_combo_id_fkey;
+ALTER TABLE "combo_items" ADD CONSTRAINT combo_items_combo_id_fkey FOREIGN KEY (combo_id) REFERENCES "combo"(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS combo_items_combo_id_idx ON "combo_items" (combo_id);
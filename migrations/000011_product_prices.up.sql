@@ -0,0 +1,18 @@
+-- Every price a product had or will have. product.price stays the current
+-- price; rows with applied_at NULL are scheduled and applied by a background
+-- job once effective_from has passed.
+CREATE TABLE IF NOT EXISTS "product_price" (
+  id UUID PRIMARY KEY,
+  product_id UUID NOT NULL REFERENCES "product"(id) ON DELETE CASCADE,
+  price DECIMAL NOT NULL CHECK (price >= 0),
+  effective_from TIMESTAMP NOT NULL,
+  applied_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT product_price_effective_key UNIQUE (product_id, effective_from)
+);
+
+CREATE INDEX IF NOT EXISTS product_price_due_idx ON "product_price" (effective_from) WHERE applied_at IS NULL;
+
+INSERT INTO "product_price" (id, product_id, price, effective_from, applied_at)
+SELECT gen_random_uuid(), id, price, coalesce(created_at, CURRENT_TIMESTAMP), coalesce(created_at, CURRENT_TIMESTAMP)
+FROM "product";
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS order_user_id_created_at_idx;
+DROP INDEX IF EXISTS order_created_at_idx;
+
+UPDATE "order" SET deleted_at = COALESCE(deleted_at, cancelled_at, CURRENT_TIMESTAMP), status = 'pending' WHERE status = 'cancelled';
+
+ALTER TABLE "order" DROP CONSTRAINT IF EXISTS order_status_check;
+ALTER TABLE "order" ADD CONSTRAINT order_status_check
+  CHECK (status IN ('scheduled', 'pending', 'confirmed', 'preparing', 'ready', 'picked_up', 'delivered'));
+
+ALTER TABLE "order" DROP COLUMN IF EXISTS cancelled_at;
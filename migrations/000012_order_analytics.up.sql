@@ -0,0 +1,9 @@
+ALTER TABLE "order" ADD COLUMN IF NOT EXISTS cancelled_at TIMESTAMP;
+
+ALTER TABLE "order" DROP CONSTRAINT IF EXISTS order_status_check;
+ALTER TABLE "order" ADD CONSTRAINT order_status_check
+  CHECK (status IN ('scheduled', 'pending', 'confirmed', 'preparing', 'ready', 'picked_up', 'delivered', 'cancelled'));
+
+-- Reports scan orders by creation time.
+CREATE INDEX IF NOT EXISTS order_created_at_idx ON "order" (created_at) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS order_user_id_created_at_idx ON "order" (user_id, created_at) WHERE deleted_at IS NULL;
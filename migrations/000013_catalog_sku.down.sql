@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS product_sku_key;
+DROP INDEX IF EXISTS category_sku_key;
+
+ALTER TABLE "product" DROP COLUMN IF EXISTS sku;
+ALTER TABLE "category" DROP COLUMN IF EXISTS sku;
@@ -0,0 +1,7 @@
+-- External SKUs identify products and categories across catalog imports.
+-- Records without one are matched by their id until an import assigns it.
+ALTER TABLE "category" ADD COLUMN IF NOT EXISTS sku VARCHAR;
+ALTER TABLE "product" ADD COLUMN IF NOT EXISTS sku VARCHAR;
+
+CREATE UNIQUE INDEX IF NOT EXISTS category_sku_key ON "category" (sku);
+CREATE UNIQUE INDEX IF NOT EXISTS product_sku_key ON "product" (sku);
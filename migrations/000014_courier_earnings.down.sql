@@ -0,0 +1,20 @@
+DROP TABLE IF EXISTS "courier_cash";
+DROP TABLE IF EXISTS "courier_reconciliation";
+
+DROP INDEX IF EXISTS deliveryhistory_unpaid_idx;
+DROP INDEX IF EXISTS deliveryhistory_courier_id_idx;
+DROP INDEX IF EXISTS deliveryhistory_assignment_id_key;
+
+ALTER TABLE "deliveryhistory"
+  DROP COLUMN IF EXISTS payout_id,
+  DROP COLUMN IF EXISTS tip_amount,
+  DROP COLUMN IF EXISTS peak_amount,
+  DROP COLUMN IF EXISTS distance_amount,
+  DROP COLUMN IF EXISTS base_amount,
+  DROP COLUMN IF EXISTS distance_km,
+  DROP COLUMN IF EXISTS assignment_id;
+
+DROP TABLE IF EXISTS "courier_payout";
+DROP TABLE IF EXISTS "courier_rate";
+
+ALTER TABLE "order" DROP COLUMN IF EXISTS tip;
@@ -0,0 +1,84 @@
+-- Tip for the courier, on top of the order total.
+ALTER TABLE "order" ADD COLUMN IF NOT EXISTS tip DECIMAL NOT NULL DEFAULT 0 CHECK (tip >= 0);
+
+-- How couriers are paid per delivery. The row without a branch is the
+-- default; a branch row replaces it for that branch's orders.
+CREATE TABLE IF NOT EXISTS "courier_rate" (
+  id UUID PRIMARY KEY,
+  branch_id UUID REFERENCES "branch"(id) ON DELETE CASCADE,
+  base_rate DECIMAL NOT NULL DEFAULT 0 CHECK (base_rate >= 0),
+  per_km_rate DECIMAL NOT NULL DEFAULT 0 CHECK (per_km_rate >= 0),
+  peak_surcharge DECIMAL NOT NULL DEFAULT 0 CHECK (peak_surcharge >= 0),
+  -- Daily peak window in local time; NULL means no peak hours. A window
+  -- whose end is before its start runs past midnight.
+  peak_from TIME,
+  peak_to TIME,
+  -- Percentage of the order's tip paid to the courier.
+  tip_share INT NOT NULL DEFAULT 100 CHECK (tip_share BETWEEN 0 AND 100),
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  CONSTRAINT courier_rate_peak_window CHECK ((peak_from IS NULL) = (peak_to IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS courier_rate_branch_key ON "courier_rate" (branch_id) WHERE branch_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS courier_rate_default_key ON "courier_rate" ((branch_id IS NULL)) WHERE branch_id IS NULL;
+
+INSERT INTO "courier_rate" (id) VALUES (gen_random_uuid());
+
+-- A batch of delivery earnings paid to a courier at once.
+CREATE TABLE IF NOT EXISTS "courier_payout" (
+  id UUID PRIMARY KEY,
+  courier_id UUID NOT NULL REFERENCES "user"(id),
+  amount DECIMAL NOT NULL DEFAULT 0,
+  deliveries INT NOT NULL DEFAULT 0,
+  -- Deliveries before this time are included.
+  period_to TIMESTAMP NOT NULL,
+  status VARCHAR NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'paid')),
+  reference VARCHAR,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  paid_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS courier_payout_courier_id_idx ON "courier_payout" (courier_id, created_at);
+
+-- Earnings are computed from courier_rate when an assignment is delivered.
+-- Older rows only have the total.
+ALTER TABLE "deliveryhistory"
+  ADD COLUMN IF NOT EXISTS assignment_id UUID REFERENCES "courierassignment"(id),
+  ADD COLUMN IF NOT EXISTS distance_km DECIMAL NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS base_amount DECIMAL NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS distance_amount DECIMAL NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS peak_amount DECIMAL NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS tip_amount DECIMAL NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS payout_id UUID REFERENCES "courier_payout"(id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS deliveryhistory_assignment_id_key ON "deliveryhistory" (assignment_id);
+CREATE INDEX IF NOT EXISTS deliveryhistory_courier_id_idx ON "deliveryhistory" (courier_id, delivered_at);
+CREATE INDEX IF NOT EXISTS deliveryhistory_unpaid_idx ON "deliveryhistory" (delivered_at) WHERE payout_id IS NULL;
+
+-- Cash a courier hands in at the end of a shift, against what was collected.
+CREATE TABLE IF NOT EXISTS "courier_reconciliation" (
+  id UUID PRIMARY KEY,
+  courier_id UUID NOT NULL REFERENCES "user"(id),
+  expected DECIMAL NOT NULL,
+  received DECIMAL NOT NULL CHECK (received >= 0),
+  orders INT NOT NULL,
+  note TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS courier_reconciliation_courier_id_idx ON "courier_reconciliation" (courier_id, created_at);
+
+-- Cash collected on 'naxt pul' orders, held by the courier until it is
+-- reconciled.
+CREATE TABLE IF NOT EXISTS "courier_cash" (
+  id UUID PRIMARY KEY,
+  courier_id UUID NOT NULL REFERENCES "user"(id),
+  order_id UUID NOT NULL REFERENCES "order"(id),
+  assignment_id UUID NOT NULL UNIQUE REFERENCES "courierassignment"(id),
+  amount DECIMAL NOT NULL CHECK (amount >= 0),
+  collected_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  reconciliation_id UUID REFERENCES "courier_reconciliation"(id)
+);
+
+CREATE INDEX IF NOT EXISTS courier_cash_held_idx ON "courier_cash" (courier_id) WHERE reconciliation_id IS NULL;
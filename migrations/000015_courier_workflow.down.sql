@@ -0,0 +1,18 @@
+DROP INDEX IF EXISTS courierassignment_courier_id_idx;
+
+ALTER TABLE "courierassignment"
+  DROP COLUMN IF EXISTS proof_distance_m,
+  DROP COLUMN IF EXISTS proof_longitude,
+  DROP COLUMN IF EXISTS proof_latitude,
+  DROP COLUMN IF EXISTS proof_photo_url,
+  DROP COLUMN IF EXISTS proof_type,
+  DROP COLUMN IF EXISTS delivered_at,
+  DROP COLUMN IF EXISTS accepted_at;
+
+ALTER TABLE "order" DROP COLUMN IF EXISTS handover_code;
+
+UPDATE "order" SET status = 'picked_up' WHERE status = 'en_route';
+
+ALTER TABLE "order" DROP CONSTRAINT IF EXISTS order_status_check;
+ALTER TABLE "order" ADD CONSTRAINT order_status_check
+  CHECK (status IN ('scheduled', 'pending', 'confirmed', 'preparing', 'ready', 'picked_up', 'delivered', 'cancelled'));
@@ -0,0 +1,19 @@
+-- The order is on its way once the courier leaves the branch.
+ALTER TABLE "order" DROP CONSTRAINT IF EXISTS order_status_check;
+ALTER TABLE "order" ADD CONSTRAINT order_status_check
+  CHECK (status IN ('scheduled', 'pending', 'confirmed', 'preparing', 'ready', 'picked_up', 'en_route', 'delivered', 'cancelled'));
+
+-- Code the customer gives the courier at the door.
+ALTER TABLE "order" ADD COLUMN IF NOT EXISTS handover_code VARCHAR(4);
+
+-- When the courier took the job and how the delivery was proven.
+ALTER TABLE "courierassignment"
+  ADD COLUMN IF NOT EXISTS accepted_at TIMESTAMP,
+  ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMP,
+  ADD COLUMN IF NOT EXISTS proof_type VARCHAR CHECK (proof_type IN ('photo', 'pin', 'geofence')),
+  ADD COLUMN IF NOT EXISTS proof_photo_url TEXT,
+  ADD COLUMN IF NOT EXISTS proof_latitude DECIMAL(9,6),
+  ADD COLUMN IF NOT EXISTS proof_longitude DECIMAL(9,6),
+  ADD COLUMN IF NOT EXISTS proof_distance_m DECIMAL;
+
+CREATE INDEX IF NOT EXISTS courierassignment_courier_id_idx ON "courierassignment" (courier_id, status);
@@ -0,0 +1,7 @@
+ALTER TABLE "courierassignment" DROP COLUMN IF EXISTS proof_note;
+UPDATE "courierassignment" SET proof_type = NULL WHERE proof_type = 'override';
+ALTER TABLE "courierassignment" DROP CONSTRAINT IF EXISTS courierassignment_proof_type_check;
+ALTER TABLE "courierassignment" ADD CONSTRAINT courierassignment_proof_type_check
+  CHECK (proof_type IN ('photo', 'pin', 'geofence'));
+
+DROP TABLE IF EXISTS "handover_attempt";
@@ -0,0 +1,18 @@
+-- Every check of an order's handover code, and every admin override of it.
+CREATE TABLE IF NOT EXISTS "handover_attempt" (
+  id UUID PRIMARY KEY,
+  order_id UUID NOT NULL REFERENCES "order"(id) ON DELETE CASCADE,
+  assignment_id UUID REFERENCES "courierassignment"(id) ON DELETE SET NULL,
+  courier_id UUID REFERENCES "user"(id),
+  kind VARCHAR NOT NULL CHECK (kind IN ('code', 'override')),
+  success BOOLEAN NOT NULL,
+  note TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS handover_attempt_order_id_idx ON "handover_attempt" (order_id, created_at);
+
+ALTER TABLE "courierassignment" DROP CONSTRAINT IF EXISTS courierassignment_proof_type_check;
+ALTER TABLE "courierassignment" ADD CONSTRAINT courierassignment_proof_type_check
+  CHECK (proof_type IN ('photo', 'pin', 'geofence', 'override'));
+ALTER TABLE "courierassignment" ADD COLUMN IF NOT EXISTS proof_note TEXT;
@@ -0,0 +1,8 @@
+DELETE FROM "deliveryhistory" WHERE kind = 'tip';
+ALTER TABLE "deliveryhistory" DROP COLUMN IF EXISTS kind;
+
+DROP INDEX IF EXISTS payment_order_id_tip_key;
+DELETE FROM "payment" WHERE kind = 'tip';
+ALTER TABLE "payment"
+  DROP COLUMN IF EXISTS amount,
+  DROP COLUMN IF EXISTS kind;
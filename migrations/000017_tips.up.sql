@@ -0,0 +1,12 @@
+-- Payments are for the order itself or for a tip added after delivery.
+-- A tip payment carries its own amount; an order payment covers the order
+-- total and the tip given at checkout.
+ALTER TABLE "payment"
+  ADD COLUMN IF NOT EXISTS kind VARCHAR NOT NULL DEFAULT 'order' CHECK (kind IN ('order', 'tip')),
+  ADD COLUMN IF NOT EXISTS amount DECIMAL CHECK (amount > 0);
+
+CREATE UNIQUE INDEX IF NOT EXISTS payment_order_id_tip_key ON "payment" (order_id) WHERE kind = 'tip';
+
+-- A tip added after delivery is credited to the courier as its own row.
+ALTER TABLE "deliveryhistory"
+  ADD COLUMN IF NOT EXISTS kind VARCHAR NOT NULL DEFAULT 'delivery' CHECK (kind IN ('delivery', 'tip'));
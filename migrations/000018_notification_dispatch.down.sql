@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS "notification_delivery";
+DROP TABLE IF EXISTS "notification_preference";
+
+ALTER TABLE "notification"
+  DROP COLUMN IF EXISTS updated_at,
+  DROP COLUMN IF EXISTS order_id,
+  DROP COLUMN IF EXISTS title,
+  DROP COLUMN IF EXISTS event;
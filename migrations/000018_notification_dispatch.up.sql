@@ -0,0 +1,37 @@
+-- In-app notifications are rendered from an event template; order events
+-- keep a link to their order.
+ALTER TABLE "notification"
+  ADD COLUMN IF NOT EXISTS event VARCHAR,
+  ADD COLUMN IF NOT EXISTS title TEXT,
+  ADD COLUMN IF NOT EXISTS order_id UUID REFERENCES "order"(id) ON DELETE SET NULL,
+  ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT now();
+
+-- Which channels besides the in-app inbox a user wants to be notified on,
+-- and in which language. Users without a row get the defaults.
+CREATE TABLE IF NOT EXISTS "notification_preference" (
+  user_id UUID PRIMARY KEY REFERENCES "user"(id) ON DELETE CASCADE,
+  locale VARCHAR NOT NULL DEFAULT 'uz-Latn',
+  sms BOOLEAN NOT NULL DEFAULT true,
+  email BOOLEAN NOT NULL DEFAULT false,
+  push BOOLEAN NOT NULL DEFAULT true,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per channel a notification is sent to. Pending rows are retried
+-- from next_attempt_at until they are sent or run out of attempts.
+CREATE TABLE IF NOT EXISTS "notification_delivery" (
+  id UUID PRIMARY KEY,
+  notification_id UUID NOT NULL REFERENCES "notification"(id) ON DELETE CASCADE,
+  channel VARCHAR NOT NULL CHECK (channel IN ('in_app', 'sms', 'email', 'push')),
+  recipient VARCHAR NOT NULL DEFAULT '',
+  status VARCHAR NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed', 'skipped')),
+  attempts INT NOT NULL DEFAULT 0,
+  last_error TEXT,
+  next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  sent_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (notification_id, channel)
+);
+
+CREATE INDEX IF NOT EXISTS notification_delivery_due_idx ON "notification_delivery" (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,13 @@
+-- Push tokens of the apps users are signed in to. A token belongs to one
+-- user at a time; signing in as someone else on the device moves it.
+CREATE TABLE IF NOT EXISTS "device_token" (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL REFERENCES "user"(id) ON DELETE CASCADE,
+  token VARCHAR NOT NULL UNIQUE,
+  platform VARCHAR NOT NULL CHECK (platform IN ('android', 'ios', 'web')),
+  app_version VARCHAR NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS device_token_user_id_idx ON "device_token" (user_id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS notification_inbox_idx;
+
+ALTER TABLE "notification"
+  ALTER COLUMN created_at DROP NOT NULL,
+  ALTER COLUMN is_read DROP NOT NULL;
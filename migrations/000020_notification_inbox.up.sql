@@ -0,0 +1,9 @@
+-- The inbox reads a user's notifications newest first, optionally only the
+-- unread ones, and counts the unread ones.
+UPDATE "notification" SET is_read = false WHERE is_read IS NULL;
+UPDATE "notification" SET created_at = now() WHERE created_at IS NULL;
+ALTER TABLE "notification"
+  ALTER COLUMN is_read SET NOT NULL,
+  ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS notification_inbox_idx ON "notification" (user_id, is_read, created_at);
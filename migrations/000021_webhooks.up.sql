@@ -0,0 +1,43 @@
+-- Partners subscribe a URL to the event types they want. The secret signs
+-- every request sent to the URL.
+CREATE TABLE IF NOT EXISTS "webhook_subscription" (
+  id UUID PRIMARY KEY,
+  url TEXT NOT NULL,
+  secret VARCHAR NOT NULL,
+  events TEXT[] NOT NULL,
+  description TEXT,
+  is_active BOOLEAN NOT NULL DEFAULT true,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP
+);
+
+-- The outbox: events are written in the transaction of the change they
+-- describe, so an event exists if and only if the change was committed.
+CREATE TABLE IF NOT EXISTS "webhook_event" (
+  id UUID PRIMARY KEY,
+  type VARCHAR NOT NULL,
+  payload JSONB NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One row per event and subscription. Pending rows are retried from
+-- next_attempt_at until they are delivered or run out of attempts and are
+-- dead-lettered.
+CREATE TABLE IF NOT EXISTS "webhook_delivery" (
+  id UUID PRIMARY KEY,
+  event_id UUID NOT NULL REFERENCES "webhook_event"(id) ON DELETE CASCADE,
+  subscription_id UUID NOT NULL REFERENCES "webhook_subscription"(id) ON DELETE CASCADE,
+  status VARCHAR NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'dead')),
+  attempts INT NOT NULL DEFAULT 0,
+  response_status INT,
+  last_error TEXT,
+  next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  delivered_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE (event_id, subscription_id)
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_due_idx ON "webhook_delivery" (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_delivery_subscription_idx ON "webhook_delivery" (subscription_id, created_at);
@@ -0,0 +1,25 @@
+-- Background jobs. A job is enqueued in the transaction of the change that
+-- needs it, runs from run_at, and is retried with backoff until it is done
+-- or has used max_attempts. A running job whose worker died is claimed
+-- again once locked_until has passed.
+CREATE TABLE IF NOT EXISTS "job" (
+  id UUID PRIMARY KEY,
+  type VARCHAR NOT NULL,
+  payload JSONB NOT NULL DEFAULT '{}',
+  unique_key VARCHAR,
+  status VARCHAR NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done', 'failed')),
+  attempts INT NOT NULL DEFAULT 0,
+  max_attempts INT NOT NULL DEFAULT 5 CHECK (max_attempts > 0),
+  run_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  locked_until TIMESTAMP,
+  last_error TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  finished_at TIMESTAMP
+);
+
+-- Only one job with a unique key waits or runs at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS job_unique_key_idx ON "job" (unique_key) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS job_due_idx ON "job" (run_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS job_running_idx ON "job" (locked_until) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS job_finished_idx ON "job" (finished_at) WHERE status = 'done';
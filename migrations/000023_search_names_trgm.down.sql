@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS product_names_trgm_idx;
+DROP INDEX IF EXISTS combo_names_trgm_idx;
+DROP INDEX IF EXISTS category_names_trgm_idx;
+
+CREATE INDEX IF NOT EXISTS product_name_trgm_idx ON "product" USING GIN (lower(name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS combo_name_trgm_idx ON "combo" USING GIN (lower(name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS category_name_trgm_idx ON "category" USING GIN (lower(name) gin_trgm_ops);
@@ -0,0 +1,13 @@
+-- The menu search compares queries with every name of a row, translations
+-- included, so the trigram indexes are built on that expression instead of
+-- the base name only.
+DROP INDEX IF EXISTS product_name_trgm_idx;
+DROP INDEX IF EXISTS combo_name_trgm_idx;
+DROP INDEX IF EXISTS category_name_trgm_idx;
+
+CREATE INDEX IF NOT EXISTS product_names_trgm_idx ON "product"
+  USING GIN (lower(name || ' ' || jsonb_path_query_array(translations, '$.*.name')::text) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS combo_names_trgm_idx ON "combo"
+  USING GIN (lower(name || ' ' || jsonb_path_query_array(translations, '$.*.name')::text) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS category_names_trgm_idx ON "category"
+  USING GIN (lower(name || ' ' || jsonb_path_query_array(translations, '$.*.name')::text) gin_trgm_ops);
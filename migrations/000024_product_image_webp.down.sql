@@ -0,0 +1,4 @@
+ALTER TABLE "product_image"
+  DROP COLUMN IF EXISTS thumb_webp_url,
+  DROP COLUMN IF EXISTS card_webp_url,
+  DROP COLUMN IF EXISTS full_webp_url;
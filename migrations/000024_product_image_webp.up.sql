@@ -0,0 +1,6 @@
+-- WebP copies of the renditions, stored next to the JPEG ones. Images
+-- uploaded before they existed have none.
+ALTER TABLE "product_image"
+  ADD COLUMN IF NOT EXISTS thumb_webp_url VARCHAR NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS card_webp_url VARCHAR NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS full_webp_url VARCHAR NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS orderiteam_combo_id_idx;
+
+ALTER TABLE "orderiteam" DROP CONSTRAINT IF EXISTS orderiteam_one_item;
+
+DELETE FROM "orderiteam" WHERE combo_id IS NOT NULL;
+
+ALTER TABLE "orderiteam"
+  DROP COLUMN IF EXISTS combo_id,
+  ALTER COLUMN product_id SET NOT NULL;
@@ -0,0 +1,10 @@
+-- An order item is either a product or a combo, which is priced and ranked
+-- as a whole.
+ALTER TABLE "orderiteam"
+  ADD COLUMN IF NOT EXISTS combo_id UUID REFERENCES "combo"(id),
+  ALTER COLUMN product_id DROP NOT NULL;
+
+ALTER TABLE "orderiteam" DROP CONSTRAINT IF EXISTS orderiteam_one_item;
+ALTER TABLE "orderiteam" ADD CONSTRAINT orderiteam_one_item CHECK (num_nonnulls(product_id, combo_id) = 1);
+
+CREATE INDEX IF NOT EXISTS orderiteam_combo_id_idx ON "orderiteam" (combo_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS notification_inbox_unread_idx;
+DROP INDEX IF EXISTS notification_inbox_idx;
+
+CREATE INDEX IF NOT EXISTS notification_inbox_idx ON "notification" (user_id, is_read, created_at);
@@ -0,0 +1,8 @@
+-- The inbox pages newest first by (created_at, id), with or without the
+-- unread filter, so both listings get an index in that order. The unread
+-- one is partial and also serves the unread count.
+DROP INDEX IF EXISTS notification_inbox_idx;
+
+CREATE INDEX IF NOT EXISTS notification_inbox_idx ON "notification" (user_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS notification_inbox_unread_idx ON "notification" (user_id, created_at DESC, id DESC)
+  WHERE is_read = false;
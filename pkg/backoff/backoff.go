@@ -0,0 +1,25 @@
+// Package backoff computes how long to wait before retrying work that
+// failed, for the job queue and the notification and webhook deliveries.
+package backoff
+
+import "time"
+
+// Policy waits First after the first failure and twice as long after each
+// further one, up to Max.
+type Policy struct {
+	First time.Duration
+	Max   time.Duration
+}
+
+// Wait returns how long to wait before retrying work that failed attempts
+// times.
+func (p Policy) Wait(attempts int) time.Duration {
+	wait := p.First
+	for i := 1; i < attempts && wait < p.Max; i++ {
+		wait *= 2
+	}
+	if wait > p.Max {
+		wait = p.Max
+	}
+	return wait
+}
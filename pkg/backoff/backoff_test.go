@@ -0,0 +1,24 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWait(t *testing.T) {
+	policy := Policy{First: 10 * time.Second, Max: time.Minute}
+
+	for attempts, want := range map[int]time.Duration{
+		0:       10 * time.Second,
+		1:       10 * time.Second,
+		2:       20 * time.Second,
+		3:       40 * time.Second,
+		4:       time.Minute,
+		5:       time.Minute,
+		1 << 20: time.Minute,
+	} {
+		if got := policy.Wait(attempts); got != want {
+			t.Errorf("Wait(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package blob stores uploaded files in Firebase Storage, Google Cloud
+// Storage, an S3-compatible service or a local directory, selected by
+// configuration.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"food/config"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/google/uuid"
+)
+
+const (
+	Firebase = "firebase"
+	GCS      = "gcs"
+	S3       = "s3"
+	Local    = "local"
+)
+
+// ErrNotFound is returned when an object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// ErrTooLarge is returned for uploads above the size limit.
+var ErrTooLarge = errors.New("file is too large")
+
+// ErrTypeNotAllowed is returned for uploads whose content is not one of the
+// allowed types.
+var ErrTypeNotAllowed = errors.New("file type is not allowed")
+
+// ErrInvalidKey is returned for keys that are empty, absolute or contain
+// ".." segments.
+var ErrInvalidKey = errors.New("invalid object key")
+
+// BlobStore keeps uploaded files under slash-separated keys.
+type BlobStore interface {
+	// Put stores data under key and returns its permanent public URL.
+	Put(ctx context.Context, key, contentType string, data []byte) (string, error)
+	// Delete removes the object. It returns ErrNotFound if there is none.
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL that grants read access to the object until
+	// ttl has passed.
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+// New returns the store selected by cfg.BlobBackend.
+func New(ctx context.Context, cfg *config.Config) (BlobStore, error) {
+	switch cfg.BlobBackend {
+	case Firebase, GCS:
+		return NewGCS(ctx, cfg.BlobBucket, cfg.BlobCredentialsFile, cfg.BlobBackend == Firebase)
+	case S3:
+		return NewS3(cfg.S3Endpoint, cfg.S3Region, cfg.BlobBucket, cfg.S3AccessKey, cfg.S3SecretKey), nil
+	case Local:
+		return NewLocal(cfg.BlobLocalDir, cfg.BlobPublicURL, cfg.SecretKey)
+	default:
+		return nil, fmt.Errorf("unknown blob backend %q", cfg.BlobBackend)
+	}
+}
+
+// NewKey returns a key under prefix that cannot collide with another upload,
+// e.g. uploads/2024/05/3f6c….jpg. The extension follows the content type.
+func NewKey(prefix, contentType string) string {
+	ext := ""
+	if mime := mimetype.Lookup(contentType); mime != nil {
+		ext = mime.Extension()
+	}
+	return path.Join(prefix, time.Now().UTC().Format("2006/01"), uuid.New().String()+ext)
+}
+
+// Check enforces the upload limits and returns the sniffed content type of
+// data, which must be one of allowed.
+func Check(data []byte, maxBytes int, allowed ...string) (string, error) {
+	if len(data) > maxBytes {
+		return "", ErrTooLarge
+	}
+	mime := mimetype.Detect(data)
+	for _, contentType := range allowed {
+		if mime.Is(contentType) {
+			return contentType, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrTypeNotAllowed, mime.String())
+}
+
+// validKey rejects keys that could escape the bucket or the local directory.
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
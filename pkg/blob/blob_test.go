@@ -0,0 +1,31 @@
+package blob
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidKey(t *testing.T) {
+	for _, key := range []string{"photo.jpg", "uploads/2024/05/photo.jpg", "products/a..b.jpg", ".well-known/file"} {
+		if err := validKey(key); err != nil {
+			t.Errorf("validKey(%q) = %v, want nil", key, err)
+		}
+	}
+
+	for _, key := range []string{
+		"",
+		"/etc/passwd",
+		"../secret",
+		"uploads/../../secret",
+		"uploads/..",
+		"uploads/./photo.jpg",
+		"uploads//photo.jpg",
+		"uploads/",
+		`uploads\..\secret`,
+		`C:\secret`,
+	} {
+		if err := validKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("validKey(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocal(t *testing.T) *LocalStore {
+	t.Helper()
+
+	store, err := NewLocal(t.TempDir(), "http://localhost/files", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// signedQuery returns the key, expires and signature of a local signed URL.
+func signedQuery(t *testing.T, signed string) (string, string, string) {
+	t.Helper()
+
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimPrefix(u.Path, "/files/"), u.Query().Get("expires"), u.Query().Get("signature")
+}
+
+func TestLocalPutDelete(t *testing.T) {
+	store := newTestLocal(t)
+	ctx := context.Background()
+
+	got, err := store.Put(ctx, "uploads/a b.jpg", "image/jpeg", []byte("data"))
+	if err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+	if want := "http://localhost/files/uploads/a%20b.jpg"; got != want {
+		t.Errorf("Put() = %q, want %q", got, want)
+	}
+	data, err := os.ReadFile(filepath.Join(store.dir, "uploads", "a b.jpg"))
+	if err != nil || string(data) != "data" {
+		t.Errorf("stored file = %q, %v", data, err)
+	}
+
+	if _, err := store.Put(ctx, "../escape.jpg", "image/jpeg", []byte("data")); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put(../escape.jpg) = %v, want ErrInvalidKey", err)
+	}
+
+	if err := store.Delete(ctx, "uploads/a b.jpg"); err != nil {
+		t.Errorf("Delete() = %v", err)
+	}
+	if err := store.Delete(ctx, "uploads/a b.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete() = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalSignedURL(t *testing.T) {
+	store := newTestLocal(t)
+
+	signed, err := store.SignedURL(context.Background(), "receipts/order.pdf", time.Hour)
+	if err != nil {
+		t.Fatalf("SignedURL() = %v", err)
+	}
+	key, expires, signature := signedQuery(t, signed)
+
+	path, err := store.ServePath(key, expires, signature, true)
+	if err != nil {
+		t.Fatalf("ServePath() = %v", err)
+	}
+	if want := filepath.Join(store.dir, "receipts", "order.pdf"); path != want {
+		t.Errorf("ServePath() = %q, want %q", path, want)
+	}
+
+	later := strconv.FormatInt(time.Now().Add(2*time.Hour).Unix(), 10)
+	for name, tc := range map[string][3]string{
+		"other key":          {"receipts/other.pdf", expires, signature},
+		"extended expiry":    {key, later, signature},
+		"tampered signature": {key, expires, strings.Repeat("0", len(signature))},
+		"no signature":       {key, "", ""},
+	} {
+		if _, err := store.ServePath(tc[0], tc[1], tc[2], true); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: ServePath() = %v, want ErrNotFound", name, err)
+		}
+	}
+
+	if _, err := store.ServePath(key, "", "", false); err != nil {
+		t.Errorf("ServePath() of a public file = %v", err)
+	}
+}
+
+func TestLocalSignedURLExpired(t *testing.T) {
+	store := newTestLocal(t)
+
+	signed, err := store.SignedURL(context.Background(), "receipts/order.pdf", -time.Second)
+	if err != nil {
+		t.Fatalf("SignedURL() = %v", err)
+	}
+	key, expires, signature := signedQuery(t, signed)
+	if _, err := store.ServePath(key, expires, signature, true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ServePath() of an expired URL = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,19 @@
+// Package geo measures distances between coordinates.
+package geo
+
+import "math"
+
+// earthRadius is the mean radius of the Earth in meters.
+const earthRadius = 6371008.8
+
+// Distance returns the great-circle distance in meters between two points
+// given in degrees.
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	rad1 := lat1 * math.Pi / 180
+	rad2 := lat2 * math.Pi / 180
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLng := (lng2 - lng1) * math.Pi / 180
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad1)*math.Cos(rad2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
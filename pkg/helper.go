@@ -2,6 +2,7 @@ package pkg
 
 import (
 	"database/sql"
+	"fmt"
 	"math/rand"
 	"strconv"
 )
@@ -25,3 +26,9 @@ func GenerateOTP() int {
 
 	return rand.Intn(900000) + 100000
 }
+
+// GenerateHandoverCode returns the 4-digit code a customer gives the courier
+// to receive an order.
+func GenerateHandoverCode() string {
+	return fmt.Sprintf("%04d", rand.Intn(10000))
+}
@@ -0,0 +1,96 @@
+// Package i18n resolves the language of a request and holds the translated
+// user-facing messages.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	UzLatn = "uz-Latn"
+	UzCyrl = "uz-Cyrl"
+	Ru     = "ru"
+	En     = "en"
+
+	// Default is the language of the base name and description columns and
+	// the fallback for every missing translation.
+	Default = UzLatn
+
+	// All is accepted as ?lang=all on catalog reads and returns the base
+	// fields together with every translation, for admin editing.
+	All = "all"
+)
+
+// Supported lists the catalog languages in display order.
+var Supported = []string{UzLatn, UzCyrl, Ru, En}
+
+// Match maps a language tag such as "uz", "uz_Cyrl", "ru-RU" or "en-GB" to a
+// supported locale.
+func Match(tag string) (string, bool) {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if tag == "" {
+		return "", false
+	}
+
+	parts := strings.Split(tag, "-")
+	switch parts[0] {
+	case "uz", "oz":
+		for _, part := range parts[1:] {
+			if part == "cyrl" {
+				return UzCyrl, true
+			}
+		}
+		return UzLatn, true
+	case "ru":
+		return Ru, true
+	case "en":
+		return En, true
+	}
+	return "", false
+}
+
+// Parse picks the locale of a request: an explicit lang parameter wins, then
+// the highest weighted supported language in Accept-Language, then Default.
+func Parse(lang, acceptLanguage string) string {
+	if strings.EqualFold(strings.TrimSpace(lang), All) {
+		return All
+	}
+	if locale, ok := Match(lang); ok {
+		return locale
+	}
+
+	type candidate struct {
+		locale string
+		weight float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		locale, ok := Match(fields[0])
+		if !ok {
+			continue
+		}
+		weight := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					weight = q
+				}
+			}
+		}
+		if weight > 0 {
+			candidates = append(candidates, candidate{locale, weight})
+		}
+	}
+	if len(candidates) == 0 {
+		return Default
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].weight > candidates[j].weight
+	})
+	return candidates[0].locale
+}
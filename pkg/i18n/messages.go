@@ -0,0 +1,92 @@
+package i18n
+
+import "fmt"
+
+// Message keys of the user-facing texts sent by the services.
+const (
+	MsgOtpSms       = "otp_sms"
+	MsgOtpExpired   = "otp_expired"
+	MsgOtpIncorrect = "otp_incorrect"
+	MsgSystemError  = "system_error"
+	MsgHandoverSms  = "handover_sms"
+
+	// Notification templates are keyed by event, with a _title and a _body
+	// text each.
+	MsgOrderConfirmedTitle  = "order_confirmed_title"
+	MsgOrderConfirmedBody   = "order_confirmed_body"
+	MsgCourierAssignedTitle = "courier_assigned_title"
+	MsgCourierAssignedBody  = "courier_assigned_body"
+	MsgOrderDeliveredTitle  = "order_delivered_title"
+	MsgOrderDeliveredBody   = "order_delivered_body"
+)
+
+var messages = map[string]map[string]string{
+	UzLatn: {
+		MsgOtpSms:               "iBron ilovasi ro‘yxatdan o‘tish uchun tasdiqlash kodi: %v",
+		MsgOtpExpired:           "OTP kod topilmadi yoki muddati tugagan",
+		MsgOtpIncorrect:         "noto'g'ri OTP kod",
+		MsgSystemError:          "tizim xatosi yuz berdi",
+		MsgHandoverSms:          "Buyurtmangiz yo‘lda. Kuryerga ushbu kodni ayting: %v",
+		MsgOrderConfirmedTitle:  "Buyurtma tasdiqlandi",
+		MsgOrderConfirmedBody:   "#%v buyurtmangiz tasdiqlandi va tayyorlanmoqda.",
+		MsgCourierAssignedTitle: "Kuryer tayinlandi",
+		MsgCourierAssignedBody:  "#%v buyurtmangizga kuryer tayinlandi.",
+		MsgOrderDeliveredTitle:  "Buyurtma yetkazildi",
+		MsgOrderDeliveredBody:   "#%v buyurtmangiz yetkazildi. Yoqimli ishtaha!",
+	},
+	UzCyrl: {
+		MsgOtpSms:               "iBron иловаси рўйхатдан ўтиш учун тасдиқлаш коди: %v",
+		MsgOtpExpired:           "OTP код топилмади ёки муддати тугаган",
+		MsgOtpIncorrect:         "нотўғри OTP код",
+		MsgSystemError:          "тизим хатоси юз берди",
+		MsgHandoverSms:          "Буюртмангиз йўлда. Курьерга ушбу кодни айтинг: %v",
+		MsgOrderConfirmedTitle:  "Буюртма тасдиқланди",
+		MsgOrderConfirmedBody:   "#%v буюртмангиз тасдиқланди ва тайёрланмоқда.",
+		MsgCourierAssignedTitle: "Курьер тайинланди",
+		MsgCourierAssignedBody:  "#%v буюртмангизга курьер тайинланди.",
+		MsgOrderDeliveredTitle:  "Буюртма етказилди",
+		MsgOrderDeliveredBody:   "#%v буюртмангиз етказилди. Ёқимли иштаҳа!",
+	},
+	Ru: {
+		MsgOtpSms:               "Код подтверждения для регистрации в приложении iBron: %v",
+		MsgOtpExpired:           "OTP-код не найден или срок его действия истёк",
+		MsgOtpIncorrect:         "неверный OTP-код",
+		MsgSystemError:          "произошла системная ошибка",
+		MsgHandoverSms:          "Ваш заказ в пути. Назовите курьеру этот код: %v",
+		MsgOrderConfirmedTitle:  "Заказ подтверждён",
+		MsgOrderConfirmedBody:   "Ваш заказ #%v подтверждён и готовится.",
+		MsgCourierAssignedTitle: "Курьер назначен",
+		MsgCourierAssignedBody:  "К вашему заказу #%v назначен курьер.",
+		MsgOrderDeliveredTitle:  "Заказ доставлен",
+		MsgOrderDeliveredBody:   "Ваш заказ #%v доставлен. Приятного аппетита!",
+	},
+	En: {
+		MsgOtpSms:               "Your iBron sign-up verification code: %v",
+		MsgOtpExpired:           "OTP code not found or expired",
+		MsgOtpIncorrect:         "incorrect OTP code",
+		MsgSystemError:          "a system error occurred",
+		MsgHandoverSms:          "Your order is on its way. Give the courier this code: %v",
+		MsgOrderConfirmedTitle:  "Order confirmed",
+		MsgOrderConfirmedBody:   "Your order #%v is confirmed and being prepared.",
+		MsgCourierAssignedTitle: "Courier assigned",
+		MsgCourierAssignedBody:  "A courier is assigned to your order #%v.",
+		MsgOrderDeliveredTitle:  "Order delivered",
+		MsgOrderDeliveredBody:   "Your order #%v is delivered. Enjoy your meal!",
+	},
+}
+
+// Message returns the text of key in locale, falling back to Default, and
+// formats it with args.
+func Message(locale, key string, args ...interface{}) string {
+	text, ok := messages[locale][key]
+	if !ok {
+		text, ok = messages[Default][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
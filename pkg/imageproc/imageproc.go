@@ -0,0 +1,116 @@
+// Package imageproc validates uploaded photos and re-encodes them into the
+// fixed sizes served by the menu, as JPEG and WebP. Re-encoding drops all
+// metadata, so EXIF data such as GPS coordinates never reaches the bucket.
+package imageproc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"strings"
+
+	// Decoders for the accepted upload formats.
+	_ "image/gif"
+	_ "image/png"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// MaxPixels bounds the decoded size of an upload so a small, highly
+// compressed file cannot exhaust memory.
+const MaxPixels = 40_000_000
+
+// Quality is the JPEG quality of every rendition.
+const Quality = 82
+
+// ErrUnsupported is returned for files that are not JPEG, PNG or GIF images
+// or are too large to decode.
+var ErrUnsupported = errors.New("unsupported image")
+
+// Size is a named rendition bounded by a maximum width.
+type Size struct {
+	Name     string
+	MaxWidth int
+}
+
+// Sizes lists the renditions generated for every upload, smallest first.
+var Sizes = []Size{
+	{Name: "thumb", MaxWidth: 160},
+	{Name: "card", MaxWidth: 480},
+	{Name: "full", MaxWidth: 1280},
+}
+
+// Rendition is one size of an image, encoded as JPEG in Data and as WebP in
+// WebP.
+type Rendition struct {
+	Name   string
+	Width  int
+	Height int
+	Data   []byte
+	WebP   []byte
+}
+
+// ContentType is the MIME type of Data.
+const ContentType = "image/jpeg"
+
+// Process sniffs the upload, applies its EXIF orientation and returns one
+// rendition per entry of Sizes. Images are never upscaled.
+func Process(data []byte) ([]Rendition, error) {
+	mime := mimetype.Detect(data)
+	if !mime.Is("image/jpeg") && !mime.Is("image/png") && !mime.Is("image/gif") {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, mime.String())
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d is too large", ErrUnsupported, config.Width, config.Height)
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+
+	img := flatten(decoded)
+	if strings.HasPrefix(mime.String(), "image/jpeg") {
+		img = orient(img, exifOrientation(data))
+	}
+
+	renditions := make([]Rendition, 0, len(Sizes))
+	for _, size := range Sizes {
+		resized := resize(img, size.MaxWidth)
+
+		var out bytes.Buffer
+		if err := jpeg.Encode(&out, resized, &jpeg.Options{Quality: Quality}); err != nil {
+			return nil, fmt.Errorf("failed to encode %s rendition: %w", size.Name, err)
+		}
+
+		bounds := resized.Bounds()
+		renditions = append(renditions, Rendition{
+			Name:   size.Name,
+			Width:  bounds.Dx(),
+			Height: bounds.Dy(),
+			Data:   out.Bytes(),
+			WebP:   encodeWebP(resized),
+		})
+	}
+
+	return renditions, nil
+}
+
+// flatten copies img into an RGBA image on a white background, since JPEG
+// and the lossy WebP encoding have no transparency.
+func flatten(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(out, out.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(out, out.Bounds(), img, bounds.Min, draw.Over)
+	return out
+}
@@ -0,0 +1,223 @@
+package imageproc
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// minPSNR is the least luma quality, in dB, a WebP rendition may have
+// against the image it was encoded from. Chroma is not compared since 4:2:0
+// subsampling loses fine color detail by design.
+const minPSNR = 38
+
+// photo draws a w x h test picture with smooth gradients, hard edges and
+// fine detail, which is what the encoder has to get right.
+func photo(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r := uint8(255 * x / max(w, 1))
+			g := uint8(255 * y / max(h, 1))
+			b := uint8(128 + 100*math.Sin(float64(x+y)/23))
+			if (x/40+y/40)%2 == 0 && x > w/3 && y > h/3 {
+				r, g, b = 240, 200, 40
+			}
+			if (x*7+y*13)%97 == 0 {
+				r, g, b = 20, 20, 20
+			}
+			img.SetRGBA(x, y, color.RGBA{R: r, G: g, B: b, A: 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// lumaPSNR compares the luma of a decoded WebP file with that of src, which
+// the encoder computes with the same BT.601 coefficients as libwebp.
+func lumaPSNR(src *image.RGBA, ycc *image.YCbCr) float64 {
+	var sse float64
+	bounds := src.Bounds()
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			p := src.Pix[src.PixOffset(bounds.Min.X+x, bounds.Min.Y+y):]
+			want := (16839*int(p[0]) + 33059*int(p[1]) + 6420*int(p[2]) + 1<<15 + 16<<16) >> 16
+			d := float64(want) - float64(ycc.Y[ycc.YOffset(x, y)])
+			sse += d * d
+		}
+	}
+	if sse == 0 {
+		return math.Inf(1)
+	}
+	return 10 * math.Log10(255*255*float64(bounds.Dx()*bounds.Dy())/sse)
+}
+
+func decodeWebP(t *testing.T, data []byte, w, h int) *image.YCbCr {
+	t.Helper()
+
+	decoded, err := webp.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode WebP: %v", err)
+	}
+	if got := decoded.Bounds().Size(); got != image.Pt(w, h) {
+		t.Fatalf("WebP is %v, want %dx%d", got, w, h)
+	}
+	ycc, ok := decoded.(*image.YCbCr)
+	if !ok {
+		t.Fatalf("decoded WebP is %T, want *image.YCbCr", decoded)
+	}
+	return ycc
+}
+
+// webpRGB is the color of a pixel of a decoded WebP file, converted with
+// the limited range BT.601 formulas of libwebp, which browsers use;
+// image.YCbCr converts as JPEG does, with the full range.
+func webpRGB(ycc *image.YCbCr, x, y int) [3]int {
+	yy := 1.164 * (float64(ycc.Y[ycc.YOffset(x, y)]) - 16)
+	cb := float64(ycc.Cb[ycc.COffset(x, y)]) - 128
+	cr := float64(ycc.Cr[ycc.COffset(x, y)]) - 128
+	clip := func(v float64) int { return int(math.Max(0, math.Min(255, math.Round(v)))) }
+	return [3]int{clip(yy + 1.596*cr), clip(yy - 0.813*cr - 0.391*cb), clip(yy + 2.018*cb)}
+}
+
+func TestEncodeWebP(t *testing.T) {
+	for _, tc := range []struct {
+		w, h    int
+		minPSNR float64
+	}{
+		{1, 1, minPSNR},
+		// Six pixels of steep gradient are all edge.
+		{2, 3, 28},
+		{15, 17, minPSNR},
+		{16, 16, minPSNR},
+		{17, 9, minPSNR},
+		{33, 65, minPSNR},
+		{160, 107, minPSNR},
+		{481, 321, minPSNR},
+	} {
+		t.Run(fmt.Sprintf("%dx%d", tc.w, tc.h), func(t *testing.T) {
+			src := photo(tc.w, tc.h)
+			got := decodeWebP(t, encodeWebP(src), tc.w, tc.h)
+			if p := lumaPSNR(src, got); p < tc.minPSNR {
+				t.Errorf("PSNR = %.1f dB, want at least %.0f", p, tc.minPSNR)
+			}
+		})
+	}
+}
+
+func TestEncodeWebPColor(t *testing.T) {
+	want := [3]int{200, 30, 90}
+	src := image.NewRGBA(image.Rect(0, 0, 50, 30))
+	draw.Draw(src, src.Bounds(), &image.Uniform{C: color.RGBA{R: 200, G: 30, B: 90, A: 255}}, image.Point{}, draw.Src)
+
+	got := decodeWebP(t, encodeWebP(src), 50, 30)
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 50; x++ {
+			if c := webpRGB(got, x, y); !near(c, want, 3) {
+				t.Fatalf("pixel (%d, %d) = %v, want about %v", x, y, c, want)
+			}
+		}
+	}
+}
+
+func near(a, b [3]int, tolerance int) bool {
+	for i := range a {
+		if abs(a[i]-b[i]) > tolerance {
+			return false
+		}
+	}
+	return true
+}
+
+func TestProcess(t *testing.T) {
+	src := photo(1600, 1000)
+	renditions, err := Process(encodePNG(t, src))
+	if err != nil {
+		t.Fatalf("Process() = %v", err)
+	}
+	if len(renditions) != len(Sizes) {
+		t.Fatalf("got %d renditions, want %d", len(renditions), len(Sizes))
+	}
+
+	for i, r := range renditions {
+		size := Sizes[i]
+		resized := resize(flatten(src), size.MaxWidth)
+		w, h := resized.Bounds().Dx(), resized.Bounds().Dy()
+		if r.Name != size.Name || r.Width != w || r.Height != h || w != size.MaxWidth {
+			t.Errorf("rendition %d is %s %dx%d, want %s %dx%d", i, r.Name, r.Width, r.Height, size.Name, size.MaxWidth, h)
+			continue
+		}
+
+		jpg, err := jpeg.Decode(bytes.NewReader(r.Data))
+		if err != nil {
+			t.Fatalf("%s: failed to decode JPEG: %v", r.Name, err)
+		}
+		if got := jpg.Bounds().Size(); got != image.Pt(w, h) {
+			t.Errorf("%s: JPEG is %v, want %dx%d", r.Name, got, w, h)
+		}
+
+		got := decodeWebP(t, r.WebP, w, h)
+		if p := lumaPSNR(resized, got); p < minPSNR {
+			t.Errorf("%s: WebP PSNR = %.1f dB, want at least %d", r.Name, p, minPSNR)
+		}
+	}
+}
+
+func TestProcessSmall(t *testing.T) {
+	renditions, err := Process(encodePNG(t, photo(1, 1)))
+	if err != nil {
+		t.Fatalf("Process() = %v", err)
+	}
+	for _, r := range renditions {
+		if r.Width != 1 || r.Height != 1 {
+			t.Errorf("%s is %dx%d, want 1x1 since images are never upscaled", r.Name, r.Width, r.Height)
+		}
+		decodeWebP(t, r.WebP, 1, 1)
+	}
+}
+
+func TestProcessAlpha(t *testing.T) {
+	// The left half is transparent, the right half half-transparent black.
+	src := image.NewNRGBA(image.Rect(0, 0, 64, 48))
+	for y := 0; y < 48; y++ {
+		for x := 32; x < 64; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{A: 128})
+		}
+	}
+
+	renditions, err := Process(encodePNG(t, src))
+	if err != nil {
+		t.Fatalf("Process() = %v", err)
+	}
+	got := decodeWebP(t, renditions[0].WebP, 64, 48)
+
+	// Transparency is flattened onto white.
+	for _, tc := range []struct {
+		x, y int
+		want [3]int
+	}{
+		{8, 24, [3]int{255, 255, 255}},
+		{56, 24, [3]int{127, 127, 127}},
+	} {
+		if c := webpRGB(got, tc.x, tc.y); !near(c, tc.want, 4) {
+			t.Errorf("pixel (%d, %d) = %v, want about %v", tc.x, tc.y, c, tc.want)
+		}
+	}
+}
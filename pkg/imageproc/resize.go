@@ -0,0 +1,91 @@
+package imageproc
+
+import (
+	"image"
+	"math"
+)
+
+// resize scales img down to maxWidth, keeping the aspect ratio. Every output
+// pixel averages the source area it covers, which keeps small thumbnails
+// sharp without aliasing. Images that already fit are returned as they are.
+func resize(img *image.RGBA, maxWidth int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	if w <= maxWidth {
+		return img
+	}
+
+	dw := maxWidth
+	dh := int(math.Round(float64(h) * float64(dw) / float64(w)))
+	if dh < 1 {
+		dh = 1
+	}
+
+	// Resample rows first, then columns.
+	horizontal := image.NewRGBA(image.Rect(0, 0, dw, h))
+	xWeights := areaWeights(w, dw)
+	for y := 0; y < h; y++ {
+		src := img.Pix[y*img.Stride:]
+		dst := horizontal.Pix[y*horizontal.Stride:]
+		for x, weights := range xWeights {
+			var acc [4]float64
+			for _, wt := range weights {
+				p := src[wt.index*4:]
+				for c := 0; c < 4; c++ {
+					acc[c] += float64(p[c]) * wt.weight
+				}
+			}
+			store(dst[x*4:], acc)
+		}
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	yWeights := areaWeights(h, dh)
+	for y, weights := range yWeights {
+		dst := out.Pix[y*out.Stride:]
+		for x := 0; x < dw; x++ {
+			var acc [4]float64
+			for _, wt := range weights {
+				p := horizontal.Pix[wt.index*horizontal.Stride+x*4:]
+				for c := 0; c < 4; c++ {
+					acc[c] += float64(p[c]) * wt.weight
+				}
+			}
+			store(dst[x*4:], acc)
+		}
+	}
+
+	return out
+}
+
+type sampleWeight struct {
+	index  int
+	weight float64
+}
+
+// areaWeights returns, for every output position, the source positions it
+// covers and the share of each, summing to 1.
+func areaWeights(src, dst int) [][]sampleWeight {
+	scale := float64(src) / float64(dst)
+	weights := make([][]sampleWeight, dst)
+	for i := range weights {
+		start := float64(i) * scale
+		end := start + scale
+		for j := int(start); j < src && float64(j) < end; j++ {
+			overlap := math.Min(end, float64(j+1)) - math.Max(start, float64(j))
+			if overlap > 0 {
+				weights[i] = append(weights[i], sampleWeight{index: j, weight: overlap / scale})
+			}
+		}
+	}
+	return weights
+}
+
+func store(dst []byte, acc [4]float64) {
+	for c := 0; c < 4; c++ {
+		v := math.Round(acc[c])
+		if v > 255 {
+			v = 255
+		}
+		dst[c] = byte(v)
+	}
+}
@@ -0,0 +1,798 @@
+package imageproc
+
+import (
+	"encoding/binary"
+	"image"
+	"math"
+)
+
+// WebPContentType is the MIME type of the WebP encoding of a rendition.
+const WebPContentType = "image/webp"
+
+const (
+	// webpQuantizer is the VP8 quantizer index of the WebP renditions, on a
+	// scale of 0 (best) to 127. It looks about as good as the JPEG Quality.
+	webpQuantizer = 30
+
+	// webpFilterLevel is the strength of the VP8 loop filter, which smooths
+	// the edges between blocks when the image is decoded.
+	webpFilterLevel = 12
+)
+
+// Quantization rounding, in 1/256 of a step, as libwebp does. Rounding
+// coefficients down a little more than half a step costs little quality and
+// saves a lot of tokens.
+const (
+	vp8DCBias = 96
+	vp8ACBias = 110
+)
+
+// VP8 intra prediction modes. The encoder predicts each macroblock as a
+// whole, so the 4x4 luma modes are never used.
+const (
+	vp8PredDC = iota
+	vp8PredTM
+	vp8PredVE
+	vp8PredHE
+	vp8PredModes
+)
+
+// encodeWebP encodes img as a lossy WebP file: a single VP8 key frame in a
+// RIFF container. The image must be opaque, as flatten makes it.
+func encodeWebP(img *image.RGBA) []byte {
+	frame := newVP8Encoder(img, webpQuantizer).encode()
+
+	size := len(frame) + len(frame)&1
+	out := make([]byte, 0, 20+size)
+	out = append(out, "RIFF"...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(12+size))
+	out = append(out, "WEBPVP8 "...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(frame)))
+	out = append(out, frame...)
+	if len(frame)&1 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+// vp8Quant holds the DC and AC quantizer steps of one kind of block.
+type vp8Quant [2]int
+
+// vp8Macroblock is a coded 16x16 block: its prediction modes and quantized
+// coefficients, which are 16 luma blocks, 4 Cb and 4 Cr blocks and the block
+// of luma DC coefficients.
+type vp8Macroblock struct {
+	yMode  uint8
+	uvMode uint8
+	skip   bool
+	coeffs [25][16]int16
+}
+
+const vp8Y2Block = 24
+
+type vp8Encoder struct {
+	width, height int
+	mbw, mbh      int
+	q             int
+	y1, y2, uv    vp8Quant
+
+	// The source and reconstructed planes, padded to whole macroblocks.
+	// Blocks are predicted from the reconstruction, as the decoder does.
+	yStride, uvStride int
+	srcY, srcU, srcV  []uint8
+	recY, recU, recV  []uint8
+
+	mbs []vp8Macroblock
+}
+
+func newVP8Encoder(img *image.RGBA, q int) *vp8Encoder {
+	bounds := img.Bounds()
+	e := &vp8Encoder{
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+		q:      q,
+	}
+	e.mbw, e.mbh = (e.width+15)/16, (e.height+15)/16
+	e.yStride, e.uvStride = 16*e.mbw, 8*e.mbw
+
+	// The quantizer steps are derived as in section 14.1 of RFC 6386.
+	e.y1 = vp8Quant{vp8DCTable[q], vp8ACTable[q]}
+	e.y2 = vp8Quant{2 * vp8DCTable[q], vp8ACTable[q] * 155 / 100}
+	if e.y2[1] < 8 {
+		e.y2[1] = 8
+	}
+	e.uv = vp8Quant{vp8DCTable[min(q, 117)], vp8ACTable[q]}
+
+	e.srcY = make([]uint8, e.yStride*16*e.mbh)
+	e.srcU = make([]uint8, e.uvStride*8*e.mbh)
+	e.srcV = make([]uint8, e.uvStride*8*e.mbh)
+	e.recY = make([]uint8, len(e.srcY))
+	e.recU = make([]uint8, len(e.srcU))
+	e.recV = make([]uint8, len(e.srcV))
+	e.importRGBA(img)
+
+	return e
+}
+
+// importRGBA converts img to BT.601 YCbCr 4:2:0 with the coefficients of
+// libwebp, repeating the last row and column into the padding.
+func (e *vp8Encoder) importRGBA(img *image.RGBA) {
+	bounds := img.Bounds()
+	pixel := func(x, y int) (r, g, b int) {
+		i := img.PixOffset(bounds.Min.X+min(x, e.width-1), bounds.Min.Y+min(y, e.height-1))
+		return int(img.Pix[i]), int(img.Pix[i+1]), int(img.Pix[i+2])
+	}
+
+	for y := 0; y < 16*e.mbh; y++ {
+		for x := 0; x < e.yStride; x++ {
+			r, g, b := pixel(x, y)
+			e.srcY[y*e.yStride+x] = uint8((16839*r + 33059*g + 6420*b + 1<<15 + 16<<16) >> 16)
+		}
+	}
+
+	clipUV := func(v int) uint8 {
+		v = (v + 1<<17 + 128<<18) >> 18
+		return uint8(max(0, min(v, 255)))
+	}
+	for y := 0; y < 8*e.mbh; y++ {
+		for x := 0; x < e.uvStride; x++ {
+			var r, g, b int
+			for _, d := range [4][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				pr, pg, pb := pixel(2*x+d[0], 2*y+d[1])
+				r, g, b = r+pr, g+pg, b+pb
+			}
+			e.srcU[y*e.uvStride+x] = clipUV(-9719*r - 19081*g + 28800*b)
+			e.srcV[y*e.uvStride+x] = clipUV(28800*r - 24116*g - 4684*b)
+		}
+	}
+}
+
+// encode codes every macroblock and returns the VP8 frame.
+func (e *vp8Encoder) encode() []byte {
+	e.mbs = make([]vp8Macroblock, e.mbw*e.mbh)
+	skipped := 0
+	for mby := 0; mby < e.mbh; mby++ {
+		for mbx := 0; mbx < e.mbw; mbx++ {
+			mb := &e.mbs[mby*e.mbw+mbx]
+			e.codeLuma(mb, mbx, mby)
+			e.codeChroma(mb, mbx, mby)
+			mb.skip = mb.coeffs == [25][16]int16{}
+			if mb.skip {
+				skipped++
+			}
+		}
+	}
+
+	// Token probabilities are fitted to this frame where that pays for the
+	// cost of sending them.
+	var counts vp8TokenCounts
+	e.writeTokens(&vp8TokenWriter{probs: &vp8DefaultTokenProbs, counts: &counts})
+	probs, updated := fitTokenProbs(&counts)
+
+	tokens := newBoolEncoder()
+	e.writeTokens(&vp8TokenWriter{probs: &probs, enc: tokens})
+
+	header := newBoolEncoder()
+	header.putLiteral(0, 1)               // color space
+	header.putLiteral(0, 1)               // clamping type
+	header.putLiteral(0, 1)               // no segmentation
+	header.putLiteral(0, 1)               // normal loop filter
+	header.putLiteral(webpFilterLevel, 6) // loop filter level
+	header.putLiteral(0, 3)               // sharpness
+	header.putLiteral(0, 1)               // no loop filter deltas
+	header.putLiteral(0, 2)               // one token partition
+	header.putLiteral(e.q, 7)             // quantizer index
+	header.putLiteral(0, 5)               // no quantizer deltas
+	header.putLiteral(0, 1)               // refresh entropy probs
+
+	for i := range probs {
+		for j := range probs[i] {
+			for k := range probs[i][j] {
+				for l := range probs[i][j][k] {
+					header.put(vp8TokenUpdateProbs[i][j][k][l], updated[i][j][k][l])
+					if updated[i][j][k][l] {
+						header.putLiteral(int(probs[i][j][k][l]), 8)
+					}
+				}
+			}
+		}
+	}
+
+	// The skip flag is coded with the probability of a macroblock having
+	// coefficients.
+	skipProb := 0
+	if skipped > 0 {
+		skipProb = max(1, min(254, 255*(len(e.mbs)-skipped)/len(e.mbs)))
+	}
+	header.putLiteral(min(skipProb, 1), 1)
+	if skipProb > 0 {
+		header.putLiteral(skipProb, 8)
+	}
+
+	for i := range e.mbs {
+		mb := &e.mbs[i]
+		if skipProb > 0 {
+			header.put(uint8(skipProb), mb.skip)
+		}
+		header.put(145, true) // 16x16 luma prediction
+		switch mb.yMode {
+		case vp8PredDC:
+			header.put(156, false)
+			header.put(163, false)
+		case vp8PredVE:
+			header.put(156, false)
+			header.put(163, true)
+		case vp8PredHE:
+			header.put(156, true)
+			header.put(128, false)
+		case vp8PredTM:
+			header.put(156, true)
+			header.put(128, true)
+		}
+		switch mb.uvMode {
+		case vp8PredDC:
+			header.put(142, false)
+		case vp8PredVE:
+			header.put(142, true)
+			header.put(114, false)
+		case vp8PredHE:
+			header.put(142, true)
+			header.put(114, true)
+			header.put(183, false)
+		case vp8PredTM:
+			header.put(142, true)
+			header.put(114, true)
+			header.put(183, true)
+		}
+	}
+
+	first, rest := header.flush(), tokens.flush()
+
+	frame := make([]byte, 10, 10+len(first)+len(rest))
+	tag := uint32(len(first))<<5 | 1<<4 // key frame, version 0, shown
+	frame[0], frame[1], frame[2] = byte(tag), byte(tag>>8), byte(tag>>16)
+	frame[3], frame[4], frame[5] = 0x9d, 0x01, 0x2a
+	binary.LittleEndian.PutUint16(frame[6:], uint16(e.width))
+	binary.LittleEndian.PutUint16(frame[8:], uint16(e.height))
+	frame = append(frame, first...)
+	return append(frame, rest...)
+}
+
+// codeLuma picks the 16x16 prediction of a macroblock, quantizes the residual
+// and reconstructs the block as the decoder will see it.
+func (e *vp8Encoder) codeLuma(mb *vp8Macroblock, mbx, mby int) {
+	x0, y0 := 16*mbx, 16*mby
+
+	var pred [256]uint8
+	mb.yMode = e.bestMode(pred[:], 16, [][]uint8{e.srcY}, [][]uint8{e.recY}, e.yStride, x0, y0)
+
+	var (
+		coeffs [16][16]int
+		dc     [16]int
+	)
+	for n := range coeffs {
+		bx, by := 4*(n%4), 4*(n/4)
+		coeffs[n] = forwardDCT(e.srcY[(y0+by)*e.yStride+x0+bx:], e.yStride, pred[by*16+bx:], 16)
+		dc[n] = coeffs[n][0]
+		for i := 1; i < 16; i++ {
+			mb.coeffs[n][i] = quantize(coeffs[n][i], e.y1[1], vp8ACBias)
+		}
+	}
+	wht := forwardWHT(dc)
+	for i := range wht {
+		mb.coeffs[vp8Y2Block][i] = quantize(wht[i], e.y2[min(i, 1)], [2]int{vp8DCBias, vp8ACBias}[min(i, 1)])
+	}
+
+	var dequantized [16]int32
+	for i, level := range mb.coeffs[vp8Y2Block] {
+		dequantized[i] = int32(level) * int32(e.y2[min(i, 1)])
+	}
+	dc32 := inverseWHT(dequantized)
+	for n := range coeffs {
+		var block [16]int32
+		block[0] = dc32[n]
+		for i := 1; i < 16; i++ {
+			block[i] = int32(mb.coeffs[n][i]) * int32(e.y1[1])
+		}
+		bx, by := 4*(n%4), 4*(n/4)
+		inverseDCT(pred[by*16+bx:], 16, &block)
+	}
+	for y := 0; y < 16; y++ {
+		copy(e.recY[(y0+y)*e.yStride+x0:], pred[y*16:y*16+16])
+	}
+}
+
+// codeChroma does for both chroma planes what codeLuma does for luma. They
+// share one prediction mode.
+func (e *vp8Encoder) codeChroma(mb *vp8Macroblock, mbx, mby int) {
+	x0, y0 := 8*mbx, 8*mby
+	src := [][]uint8{e.srcU, e.srcV}
+	rec := [][]uint8{e.recU, e.recV}
+
+	var pred [2 * 64]uint8
+	mb.uvMode = e.bestMode(pred[:], 8, src, rec, e.uvStride, x0, y0)
+
+	for p := range src {
+		plane := pred[64*p : 64*p+64]
+		for n := 0; n < 4; n++ {
+			bx, by := 4*(n%2), 4*(n/2)
+			coeffs := forwardDCT(src[p][(y0+by)*e.uvStride+x0+bx:], e.uvStride, plane[by*8+bx:], 8)
+
+			levels := &mb.coeffs[16+4*p+n]
+			var block [16]int32
+			for i := range coeffs {
+				bias := vp8ACBias
+				if i == 0 {
+					bias = vp8DCBias
+				}
+				levels[i] = quantize(coeffs[i], e.uv[min(i, 1)], bias)
+				block[i] = int32(levels[i]) * int32(e.uv[min(i, 1)])
+			}
+			inverseDCT(plane[by*8+bx:], 8, &block)
+		}
+		for y := 0; y < 8; y++ {
+			copy(rec[p][(y0+y)*e.uvStride+x0:], plane[y*8:y*8+8])
+		}
+	}
+}
+
+// bestMode fills pred with the n×n prediction of every plane at (x0, y0)
+// that is closest to the source, and returns its mode.
+func (e *vp8Encoder) bestMode(pred []uint8, n int, src, rec [][]uint8, stride, x0, y0 int) uint8 {
+	best, bestErr := uint8(0), math.MaxInt
+	candidate := make([]uint8, len(pred))
+	for mode := uint8(0); mode < vp8PredModes; mode++ {
+		sse := 0
+		for p := range src {
+			plane := candidate[n*n*p : n*n*(p+1)]
+			predict(plane, n, rec[p], stride, x0, y0, mode)
+			for y := 0; y < n; y++ {
+				for x := 0; x < n; x++ {
+					d := int(src[p][(y0+y)*stride+x0+x]) - int(plane[y*n+x])
+					sse += d * d
+				}
+			}
+		}
+		if sse < bestErr {
+			best, bestErr = mode, sse
+			copy(pred, candidate)
+		}
+	}
+	return best
+}
+
+// predict fills the n×n block pred as the decoder predicts the block at
+// (x0, y0) of a plane. Edges outside the image read as 127 above and 129 to
+// the left (section 12.2).
+func predict(pred []uint8, n int, plane []uint8, stride, x0, y0 int, mode uint8) {
+	top := make([]int, n)
+	left := make([]int, n)
+	topLeft := 127
+	for i := 0; i < n; i++ {
+		top[i], left[i] = 127, 129
+		if y0 > 0 {
+			top[i] = int(plane[(y0-1)*stride+x0+i])
+		}
+		if x0 > 0 {
+			left[i] = int(plane[(y0+i)*stride+x0-1])
+		}
+	}
+	switch {
+	case y0 > 0 && x0 > 0:
+		topLeft = int(plane[(y0-1)*stride+x0-1])
+	case y0 > 0:
+		topLeft = 129
+	}
+
+	shift := 3
+	if n == 16 {
+		shift = 4
+	}
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			var v int
+			switch mode {
+			case vp8PredDC:
+				v = 128
+				switch {
+				case x0 > 0 && y0 > 0:
+					v = (sum(top) + sum(left) + n) >> (shift + 1)
+				case x0 > 0:
+					v = (sum(left) + n/2) >> shift
+				case y0 > 0:
+					v = (sum(top) + n/2) >> shift
+				}
+			case vp8PredTM:
+				v = max(0, min(left[y]+top[x]-topLeft, 255))
+			case vp8PredVE:
+				v = top[x]
+			case vp8PredHE:
+				v = left[y]
+			}
+			pred[y*n+x] = uint8(v)
+		}
+	}
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// quantize divides a coefficient by step, rounding towards zero by bias/256
+// of a step, and clamps it to what a token can code.
+func quantize(coeff, step, bias int) int16 {
+	level := (abs(coeff)*256 + step*bias) / (step * 256)
+	level = min(level, 2048)
+	if coeff < 0 {
+		return int16(-level)
+	}
+	return int16(level)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// forwardDCT transforms the difference between a 4x4 source block and its
+// prediction, as libwebp does. Coefficients are in raster order.
+func forwardDCT(src []uint8, srcStride int, pred []uint8, predStride int) [16]int {
+	var tmp, out [16]int
+	for i := 0; i < 4; i++ {
+		d0 := int(src[i*srcStride+0]) - int(pred[i*predStride+0])
+		d1 := int(src[i*srcStride+1]) - int(pred[i*predStride+1])
+		d2 := int(src[i*srcStride+2]) - int(pred[i*predStride+2])
+		d3 := int(src[i*srcStride+3]) - int(pred[i*predStride+3])
+		a0, a1, a2, a3 := d0+d3, d1+d2, d1-d2, d0-d3
+		tmp[0+4*i] = (a0 + a1) * 8
+		tmp[1+4*i] = (a2*2217 + a3*5352 + 1812) >> 9
+		tmp[2+4*i] = (a0 - a1) * 8
+		tmp[3+4*i] = (a3*2217 - a2*5352 + 937) >> 9
+	}
+	for i := 0; i < 4; i++ {
+		a0, a1 := tmp[0+i]+tmp[12+i], tmp[4+i]+tmp[8+i]
+		a2, a3 := tmp[4+i]-tmp[8+i], tmp[0+i]-tmp[12+i]
+		out[0+i] = (a0 + a1 + 7) >> 4
+		out[4+i] = (a2*2217 + a3*5352 + 12000) >> 16
+		if a3 != 0 {
+			out[4+i]++
+		}
+		out[8+i] = (a0 - a1 + 7) >> 4
+		out[12+i] = (a3*2217 - a2*5352 + 51000) >> 16
+	}
+	return out
+}
+
+// forwardWHT transforms the DC coefficients of the 16 luma blocks, as
+// libwebp does.
+func forwardWHT(dc [16]int) [16]int {
+	var tmp, out [16]int
+	for i := 0; i < 4; i++ {
+		a0, a1 := dc[4*i+0]+dc[4*i+2], dc[4*i+1]+dc[4*i+3]
+		a2, a3 := dc[4*i+1]-dc[4*i+3], dc[4*i+0]-dc[4*i+2]
+		tmp[0+4*i] = a0 + a1
+		tmp[1+4*i] = a3 + a2
+		tmp[2+4*i] = a3 - a2
+		tmp[3+4*i] = a0 - a1
+	}
+	for i := 0; i < 4; i++ {
+		a0, a1 := tmp[0+i]+tmp[8+i], tmp[4+i]+tmp[12+i]
+		a2, a3 := tmp[4+i]-tmp[12+i], tmp[0+i]-tmp[8+i]
+		out[0+i] = (a0 + a1) >> 1
+		out[4+i] = (a3 + a2) >> 1
+		out[8+i] = (a3 - a2) >> 1
+		out[12+i] = (a0 - a1) >> 1
+	}
+	return out
+}
+
+// inverseWHT returns the DC coefficients of the 16 luma blocks exactly as the
+// decoder computes them (section 14.3).
+func inverseWHT(in [16]int32) [16]int32 {
+	var m, out [16]int32
+	for i := 0; i < 4; i++ {
+		a0, a1 := in[0+i]+in[12+i], in[4+i]+in[8+i]
+		a2, a3 := in[4+i]-in[8+i], in[0+i]-in[12+i]
+		m[0+i] = a0 + a1
+		m[8+i] = a0 - a1
+		m[4+i] = a3 + a2
+		m[12+i] = a3 - a2
+	}
+	for i := 0; i < 4; i++ {
+		dc := m[0+4*i] + 3
+		a0, a1 := dc+m[3+4*i], m[1+4*i]+m[2+4*i]
+		a2, a3 := m[1+4*i]-m[2+4*i], dc-m[3+4*i]
+		out[4*i+0] = (a0 + a1) >> 3
+		out[4*i+1] = (a3 + a2) >> 3
+		out[4*i+2] = (a0 - a1) >> 3
+		out[4*i+3] = (a3 - a2) >> 3
+	}
+	return out
+}
+
+// inverseDCT adds the inverse transform of coeffs to the 4x4 block exactly as
+// the decoder does (section 14.4).
+func inverseDCT(block []uint8, stride int, coeffs *[16]int32) {
+	const (
+		c1 = 85627 // 65536 * cos(pi/8) * sqrt(2)
+		c2 = 35468 // 65536 * sin(pi/8) * sqrt(2)
+	)
+	var m [4][4]int32
+	for i := 0; i < 4; i++ {
+		a := coeffs[i] + coeffs[8+i]
+		b := coeffs[i] - coeffs[8+i]
+		c := (coeffs[4+i]*c2)>>16 - (coeffs[12+i]*c1)>>16
+		d := (coeffs[4+i]*c1)>>16 + (coeffs[12+i]*c2)>>16
+		m[i][0], m[i][1], m[i][2], m[i][3] = a+d, b+c, b-c, a-d
+	}
+	for j := 0; j < 4; j++ {
+		dc := m[0][j] + 4
+		a, b := dc+m[2][j], dc-m[2][j]
+		c := (m[1][j]*c2)>>16 - (m[3][j]*c1)>>16
+		d := (m[1][j]*c1)>>16 + (m[3][j]*c2)>>16
+		for i, v := range [4]int32{a + d, b + c, b - c, a - d} {
+			block[j*stride+i] = uint8(max(0, min(int32(block[j*stride+i])+v>>3, 255)))
+		}
+	}
+}
+
+// writeTokens codes the coefficients of every macroblock that is not skipped,
+// tracking which neighbouring blocks had coefficients as the decoder does.
+func (e *vp8Encoder) writeTokens(w *vp8TokenWriter) {
+	upY2 := make([]int, e.mbw)
+	up := make([][8]int, e.mbw) // 4 luma columns, then 2 Cb and 2 Cr
+	for mby := 0; mby < e.mbh; mby++ {
+		var (
+			leftY2 int
+			left   [8]int // 4 luma rows, then 2 Cb and 2 Cr
+		)
+		for mbx := 0; mbx < e.mbw; mbx++ {
+			mb := &e.mbs[mby*e.mbw+mbx]
+			if mb.skip {
+				leftY2, upY2[mbx] = 0, 0
+				left, up[mbx] = [8]int{}, [8]int{}
+				continue
+			}
+
+			nz := w.block(vp8PlaneY2, leftY2+upY2[mbx], &mb.coeffs[vp8Y2Block], 0)
+			leftY2, upY2[mbx] = nz, nz
+
+			for n := 0; n < 16; n++ {
+				x, y := n%4, n/4
+				nz := w.block(vp8PlaneY1WithY2, left[y]+up[mbx][x], &mb.coeffs[n], 1)
+				left[y], up[mbx][x] = nz, nz
+			}
+			for n := 0; n < 8; n++ {
+				x, y := 4+2*(n/4)+n%2, 4+2*(n/4)+(n%4)/2
+				nz := w.block(vp8PlaneUV, left[y]+up[mbx][x], &mb.coeffs[16+n], 0)
+				left[y], up[mbx][x] = nz, nz
+			}
+		}
+	}
+}
+
+// vp8TokenCounts counts the zeros and ones coded with each token probability.
+type vp8TokenCounts [vp8Planes][vp8Bands][vp8Contexts][vp8Probs][2]int
+
+// vp8TokenWriter codes coefficient tokens (section 13.2) into enc, or only
+// counts them when enc is nil.
+type vp8TokenWriter struct {
+	probs  *vp8TokenProbs
+	counts *vp8TokenCounts
+	enc    *boolEncoder
+}
+
+func (w *vp8TokenWriter) put(plane, band, ctx, i int, bit bool) {
+	if w.counts != nil {
+		w.counts[plane][band][ctx][i][btoi(bit)]++
+	}
+	if w.enc != nil {
+		w.enc.put(w.probs[plane][band][ctx][i], bit)
+	}
+}
+
+func (w *vp8TokenWriter) putFixed(prob uint8, bit bool) {
+	if w.enc != nil {
+		w.enc.put(prob, bit)
+	}
+}
+
+// block codes the levels of one 4x4 block from position first on and reports
+// whether any of them was non-zero.
+func (w *vp8TokenWriter) block(plane, ctx int, levels *[16]int16, first int) int {
+	last := -1
+	for n := 15; n >= first; n-- {
+		if levels[vp8Zigzag[n]] != 0 {
+			last = n
+			break
+		}
+	}
+
+	n, band := first, int(vp8CoeffBands[first])
+	w.put(plane, band, ctx, 0, last >= 0)
+	if last < 0 {
+		return 0
+	}
+
+	for {
+		level := int(levels[vp8Zigzag[n]])
+		n++
+		if level == 0 {
+			w.put(plane, band, ctx, 1, false)
+			band, ctx = int(vp8CoeffBands[n]), 0
+			continue
+		}
+		w.put(plane, band, ctx, 1, true)
+
+		v := abs(level)
+		if v == 1 {
+			w.put(plane, band, ctx, 2, false)
+		} else {
+			w.put(plane, band, ctx, 2, true)
+			switch {
+			case v <= 4:
+				w.put(plane, band, ctx, 3, false)
+				w.put(plane, band, ctx, 4, v > 2)
+				if v > 2 {
+					w.put(plane, band, ctx, 5, v == 4)
+				}
+			case v <= 10:
+				w.put(plane, band, ctx, 3, true)
+				w.put(plane, band, ctx, 6, false)
+				w.put(plane, band, ctx, 7, v > 6)
+				if v <= 6 {
+					w.putFixed(159, v == 6)
+				} else {
+					w.putFixed(165, v >= 9)
+					w.putFixed(145, (v-7)&1 == 1)
+				}
+			default:
+				w.put(plane, band, ctx, 3, true)
+				w.put(plane, band, ctx, 6, true)
+				cat := 3
+				switch {
+				case v < 19:
+					cat = 0
+				case v < 35:
+					cat = 1
+				case v < 67:
+					cat = 2
+				}
+				w.put(plane, band, ctx, 8, cat >= 2)
+				w.put(plane, band, ctx, 9+cat/2, cat&1 == 1)
+
+				probs := vp8CatProbs[cat]
+				bits := 0
+				for probs[bits] != 0 {
+					bits++
+				}
+				extra := v - (3 + 8<<cat)
+				for i := 0; i < bits; i++ {
+					w.putFixed(probs[i], extra>>(bits-1-i)&1 == 1)
+				}
+			}
+		}
+		w.putFixed(128, level < 0)
+
+		band, ctx = int(vp8CoeffBands[n]), 2
+		if v == 1 {
+			ctx = 1
+		}
+		if n == 16 {
+			return 1
+		}
+		w.put(plane, band, ctx, 0, n <= last)
+		if n > last {
+			return 1
+		}
+	}
+}
+
+// fitTokenProbs returns the token probabilities that code counts best, and
+// which of them differ from the defaults by enough to be worth sending.
+func fitTokenProbs(counts *vp8TokenCounts) (probs vp8TokenProbs, updated [vp8Planes][vp8Bands][vp8Contexts][vp8Probs]bool) {
+	probs = vp8DefaultTokenProbs
+	for i := range counts {
+		for j := range counts[i] {
+			for k := range counts[i][j] {
+				for l, c := range counts[i][j][k] {
+					total := c[0] + c[1]
+					if total == 0 {
+						continue
+					}
+					fitted := uint8(max(1, min(255, (255*c[0]+total/2)/total)))
+					old, update := probs[i][j][k][l], vp8TokenUpdateProbs[i][j][k][l]
+
+					keep := bitCost(old, c[0], c[1]) + bitCost(update, 1, 0)
+					send := bitCost(fitted, c[0], c[1]) + bitCost(update, 0, 1) + 8
+					if send < keep {
+						probs[i][j][k][l], updated[i][j][k][l] = fitted, true
+					}
+				}
+			}
+		}
+	}
+	return probs, updated
+}
+
+// bitCost is the number of bits it takes to code zeros and ones with prob,
+// the probability of a zero in 1/256.
+func bitCost(prob uint8, zeros, ones int) float64 {
+	p := float64(prob) / 256
+	return -float64(zeros)*math.Log2(p) - float64(ones)*math.Log2(1-p)
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// boolEncoder is the boolean entropy encoder of section 7.3.
+type boolEncoder struct {
+	out      []byte
+	rng      uint32
+	bottom   uint32
+	bitCount int
+}
+
+func newBoolEncoder() *boolEncoder {
+	return &boolEncoder{rng: 255, bitCount: 24}
+}
+
+// put codes bit, whose probability of being false is prob/256.
+func (e *boolEncoder) put(prob uint8, bit bool) {
+	split := 1 + (e.rng-1)*uint32(prob)>>8
+	if bit {
+		e.bottom += split
+		e.rng -= split
+	} else {
+		e.rng = split
+	}
+	for e.rng < 128 {
+		e.rng <<= 1
+		if e.bottom&(1<<31) != 0 {
+			e.carry()
+		}
+		e.bottom <<= 1
+		e.bitCount--
+		if e.bitCount == 0 {
+			e.out = append(e.out, byte(e.bottom>>24))
+			e.bottom &= 1<<24 - 1
+			e.bitCount = 8
+		}
+	}
+}
+
+func (e *boolEncoder) carry() {
+	for i := len(e.out) - 1; i >= 0; i-- {
+		e.out[i]++
+		if e.out[i] != 0 {
+			return
+		}
+	}
+}
+
+// putLiteral codes the n low bits of v, most significant first, each with
+// even odds.
+func (e *boolEncoder) putLiteral(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		e.put(128, v>>i&1 == 1)
+	}
+}
+
+// flush pads the output so the decoder can read every coded bit, as libvpx
+// does, and returns it.
+func (e *boolEncoder) flush() []byte {
+	for i := 0; i < 32; i++ {
+		e.put(128, false)
+	}
+	return e.out
+}
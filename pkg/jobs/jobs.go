@@ -0,0 +1,75 @@
+// Package jobs holds the types of background jobs, their payloads and the
+// retry policy of the job queue.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"food/pkg/backoff"
+	"time"
+)
+
+// Types of background jobs.
+const (
+	// TypeOrderNotification notifies the customer of an order event.
+	TypeOrderNotification = "order.notify"
+	// TypeHandoverCode texts the customer the handover code of an order.
+	TypeHandoverCode = "order.handover_code"
+	// TypeCourierAcceptTimeout alerts the admins when a courier has not
+	// accepted an assigned job in time.
+	TypeCourierAcceptTimeout = "courier.accept_timeout"
+	// TypeCashShortage alerts the admins of a short cash reconciliation.
+	TypeCashShortage = "courier.cash_shortage"
+	// TypeReleaseScheduledOrders releases due pre-orders to the kitchen.
+	TypeReleaseScheduledOrders = "orders.release_scheduled"
+	// TypeApplyScheduledPrices applies scheduled price changes.
+	TypeApplyScheduledPrices = "prices.apply_scheduled"
+	// TypePruneJobs deletes old finished jobs.
+	TypePruneJobs = "jobs.prune"
+)
+
+// OrderNotification is the payload of TypeOrderNotification.
+type OrderNotification struct {
+	OrderId string `json:"order_id"`
+	Event   string `json:"event"`
+}
+
+// Order is the payload of jobs about one order.
+type Order struct {
+	OrderId string `json:"order_id"`
+}
+
+// Assignment is the payload of jobs about one courier assignment.
+type Assignment struct {
+	AssignmentId string `json:"assignment_id"`
+}
+
+// ErrPermanent marks a failure that retrying cannot fix, so the job fails
+// at once.
+var ErrPermanent = errors.New("permanent job failure")
+
+// Permanent wraps err so the job is not retried.
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %v", ErrPermanent, err)
+}
+
+// Handler runs a job with its raw payload.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Typed adapts fn to a Handler that decodes the payload into T first. A
+// payload that does not decode fails the job permanently.
+func Typed[T any](fn func(ctx context.Context, payload T) error) Handler {
+	return func(ctx context.Context, raw json.RawMessage) error {
+		var payload T
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return Permanent(err)
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// Backoff is how long to wait before running a job again that failed: 10
+// seconds doubling up to half an hour.
+var Backoff = backoff.Policy{First: 10 * time.Second, Max: 30 * time.Minute}
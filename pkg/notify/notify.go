@@ -0,0 +1,58 @@
+// Package notify holds the notification channels, the events users are
+// notified about and the senders that deliver them.
+package notify
+
+import (
+	"context"
+	"errors"
+	"food/pkg/backoff"
+	"time"
+)
+
+// Channels a notification can be delivered to.
+const (
+	InApp = "in_app"
+	SMS   = "sms"
+	Email = "email"
+	Push  = "push"
+)
+
+// Events users are notified about.
+const (
+	EventOrderConfirmed  = "order_confirmed"
+	EventCourierAssigned = "courier_assigned"
+	EventOrderDelivered  = "order_delivered"
+)
+
+// Events lists every event that has a template.
+var Events = []string{EventOrderConfirmed, EventCourierAssigned, EventOrderDelivered}
+
+// ErrInvalidRecipient is returned by a sender when the address can never
+// receive the message, so the delivery is not retried.
+var ErrInvalidRecipient = errors.New("invalid recipient")
+
+// Message is one notification rendered for one channel.
+type Message struct {
+	UserId string
+	To     string
+	Title  string
+	Body   string
+	Data   map[string]string
+}
+
+// Sender delivers messages over one channel.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SenderFunc adapts a function to a Sender.
+type SenderFunc func(ctx context.Context, msg Message) error
+
+// Send calls f.
+func (f SenderFunc) Send(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// Backoff is how long to wait before retrying a delivery that failed: 30
+// seconds doubling up to an hour.
+var Backoff = backoff.Policy{First: 30 * time.Second, Max: time.Hour}
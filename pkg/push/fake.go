@@ -0,0 +1,51 @@
+package push
+
+import (
+	"context"
+	"sync"
+)
+
+// Sent is a message the fake sender accepted.
+type Sent struct {
+	Token   string
+	Message Message
+}
+
+// FakeSender records messages instead of sending them, for development and
+// tests. Tokens marked invalid are refused with ErrInvalidToken.
+type FakeSender struct {
+	mu      sync.Mutex
+	sent    []Sent
+	invalid map[string]bool
+}
+
+func NewFake() *FakeSender {
+	return &FakeSender{invalid: make(map[string]bool)}
+}
+
+func (f *FakeSender) Send(ctx context.Context, token string, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.invalid[token] {
+		return ErrInvalidToken
+	}
+	f.sent = append(f.sent, Sent{Token: token, Message: msg})
+	return nil
+}
+
+// Invalidate makes later sends to token fail as if the app was uninstalled.
+func (f *FakeSender) Invalidate(token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.invalid[token] = true
+}
+
+// Sent returns the messages accepted so far.
+func (f *FakeSender) Sent() []Sent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Sent(nil), f.sent...)
+}
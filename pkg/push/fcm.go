@@ -0,0 +1,138 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	fcmScope    = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmEndpoint = "https://fcm.googleapis.com"
+)
+
+// FCMSender sends through the Firebase Cloud Messaging HTTP v1 API with the
+// project's service account.
+type FCMSender struct {
+	projectId string
+	endpoint  string
+	client    *http.Client
+}
+
+// NewFCM reads the service account from credentialsFile. An empty
+// credentialsFile uses the application default credentials.
+func NewFCM(ctx context.Context, credentialsFile string) (*FCMSender, error) {
+	creds, err := credentials(ctx, credentialsFile)
+	if err != nil {
+		return nil, err
+	}
+	if creds.ProjectID == "" {
+		return nil, fmt.Errorf("push credentials have no project id")
+	}
+
+	client := oauth2.NewClient(ctx, creds.TokenSource)
+	client.Timeout = 30 * time.Second
+	return &FCMSender{
+		projectId: creds.ProjectID,
+		endpoint:  fcmEndpoint,
+		client:    client,
+	}, nil
+}
+
+func credentials(ctx context.Context, credentialsFile string) (*google.Credentials, error) {
+	if credentialsFile == "" {
+		creds, err := google.FindDefaultCredentials(ctx, fcmScope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find push credentials: %w", err)
+		}
+		return creds, nil
+	}
+
+	data, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read push credentials: %w", err)
+	}
+	creds, err := google.CredentialsFromJSON(ctx, data, fcmScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse push credentials: %w", err)
+	}
+	return creds, nil
+}
+
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+func (f *FCMSender) Send(ctx context.Context, token string, msg Message) error {
+	body, err := json.Marshal(fcmRequest{Message: fcmMessage{
+		Token:        token,
+		Notification: fcmNotification{Title: msg.Title, Body: msg.Body},
+		Data:         msg.Data,
+	}})
+	if err != nil {
+		return err
+	}
+
+	url := f.endpoint + "/v1/projects/" + f.projectId + "/messages:send"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send push: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var fe fcmError
+	_ = json.Unmarshal(data, &fe)
+
+	// UNREGISTERED tokens belong to uninstalled apps; INVALID_ARGUMENT on a
+	// well-formed message means the token itself is malformed.
+	for _, detail := range fe.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" || detail.ErrorCode == "INVALID_ARGUMENT" {
+			return fmt.Errorf("%w: %s", ErrInvalidToken, fe.Error.Message)
+		}
+	}
+	if fe.Error.Status == "NOT_FOUND" {
+		return fmt.Errorf("%w: %s", ErrInvalidToken, fe.Error.Message)
+	}
+	return fmt.Errorf("push failed with status %d: %s", resp.StatusCode, fe.Error.Message)
+}
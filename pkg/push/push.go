@@ -0,0 +1,44 @@
+// Package push sends push notifications to the devices of users, through
+// Firebase Cloud Messaging or an in-memory fake selected by configuration.
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"food/config"
+)
+
+const (
+	FCM  = "fcm"
+	Fake = "fake"
+)
+
+// ErrInvalidToken is returned when a device token is unknown to the push
+// service or no longer registered, so it should be removed.
+var ErrInvalidToken = errors.New("invalid device token")
+
+// Message is a notification shown on a device. Data is passed to the app
+// alongside it.
+type Message struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// Sender delivers messages to single device tokens.
+type Sender interface {
+	Send(ctx context.Context, token string, msg Message) error
+}
+
+// New returns the sender selected by cfg.PushBackend.
+func New(ctx context.Context, cfg *config.Config) (Sender, error) {
+	switch cfg.PushBackend {
+	case FCM:
+		return NewFCM(ctx, cfg.PushCredentialsFile)
+	case Fake:
+		return NewFake(), nil
+	default:
+		return nil, fmt.Errorf("unknown push backend %q", cfg.PushBackend)
+	}
+}
@@ -0,0 +1,130 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"food/config"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// fcmServer answers sends like FCM would, with status and body, and keeps the
+// last request it got.
+func fcmServer(t *testing.T, status int, body string, got *fcmRequest) *FCMSender {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/projects/demo/messages:send" {
+			t.Errorf("request = %s %s, want POST /v1/projects/demo/messages:send", r.Method, r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	return &FCMSender{projectId: "demo", endpoint: srv.URL, client: srv.Client()}
+}
+
+func TestFCMSend(t *testing.T) {
+	var got fcmRequest
+	sender := fcmServer(t, http.StatusOK, `{"name":"projects/demo/messages/1"}`, &got)
+
+	msg := Message{Title: "Buyurtma", Body: "Yetkazildi", Data: map[string]string{"order_id": "42"}}
+	if err := sender.Send(context.Background(), "token-1", msg); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+
+	want := fcmRequest{Message: fcmMessage{
+		Token:        "token-1",
+		Notification: fcmNotification{Title: "Buyurtma", Body: "Yetkazildi"},
+		Data:         map[string]string{"order_id": "42"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("request = %+v, want %+v", got, want)
+	}
+}
+
+func TestFCMSendErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		status  int
+		body    string
+		invalid bool
+	}{
+		"unregistered": {
+			status:  http.StatusNotFound,
+			body:    `{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`,
+			invalid: true,
+		},
+		"malformed token": {
+			status:  http.StatusBadRequest,
+			body:    `{"error":{"code":400,"message":"The registration token is not a valid FCM registration token","status":"INVALID_ARGUMENT","details":[{"errorCode":"INVALID_ARGUMENT"}]}}`,
+			invalid: true,
+		},
+		"not found": {
+			status:  http.StatusNotFound,
+			body:    `{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND"}}`,
+			invalid: true,
+		},
+		"unavailable": {
+			status: http.StatusServiceUnavailable,
+			body:   `{"error":{"code":503,"message":"The service is currently unavailable.","status":"UNAVAILABLE","details":[{"errorCode":"UNAVAILABLE"}]}}`,
+		},
+		"not json": {
+			status: http.StatusBadGateway,
+			body:   `<html>Bad Gateway</html>`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sender := fcmServer(t, tc.status, tc.body, &fcmRequest{})
+
+			err := sender.Send(context.Background(), "token-1", Message{Title: "t", Body: "b"})
+			if err == nil {
+				t.Fatal("Send() = nil, want an error")
+			}
+			if got := errors.Is(err, ErrInvalidToken); got != tc.invalid {
+				t.Errorf("errors.Is(%v, ErrInvalidToken) = %v, want %v", err, got, tc.invalid)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	sender, err := New(context.Background(), &config.Config{PushBackend: Fake})
+	if err != nil {
+		t.Fatalf("New(fake) = %v", err)
+	}
+	if _, ok := sender.(*FakeSender); !ok {
+		t.Errorf("New(fake) = %T, want *FakeSender", sender)
+	}
+
+	if _, err := New(context.Background(), &config.Config{PushBackend: "apns"}); err == nil {
+		t.Error("New(apns) = nil error, want an unknown backend error")
+	}
+}
+
+func TestFakeSender(t *testing.T) {
+	fake := NewFake()
+	fake.Invalidate("gone")
+
+	msg := Message{Title: "t", Body: "b"}
+	if err := fake.Send(context.Background(), "token-1", msg); err != nil {
+		t.Errorf("Send(token-1) = %v", err)
+	}
+	if err := fake.Send(context.Background(), "gone", msg); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Send(gone) = %v, want ErrInvalidToken", err)
+	}
+
+	want := []Sent{{Token: "token-1", Message: msg}}
+	if got := fake.Sent(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sent() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"food/api/models"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ESC/POS command bytes used by the kitchen ticket.
+var (
+	escInit        = []byte{0x1B, 0x40}
+	escCodePage    = []byte{0x1B, 0x74, 0x2E} // WPC1251, matches cp1251
+	escAlignLeft   = []byte{0x1B, 0x61, 0x00}
+	escAlignCenter = []byte{0x1B, 0x61, 0x01}
+	escBoldOn      = []byte{0x1B, 0x45, 0x01}
+	escBoldOff     = []byte{0x1B, 0x45, 0x00}
+	gsSizeNormal   = []byte{0x1D, 0x21, 0x00}
+	gsSizeTall     = []byte{0x1D, 0x21, 0x01}
+	gsSizeDouble   = []byte{0x1D, 0x21, 0x11}
+	escFeed4       = []byte{0x1B, 0x64, 0x04}
+	gsPartialCut   = []byte{0x1D, 0x56, 0x42, 0x00}
+)
+
+// KitchenTicket renders the cook's copy of an order as an ESC/POS byte
+// stream for 80 mm thermal printers: items and modifiers only, no prices.
+func KitchenTicket(r *models.Receipt) []byte {
+	var out bytes.Buffer
+
+	out.Write(escInit)
+	out.Write(escCodePage)
+
+	out.Write(escAlignCenter)
+	out.Write(gsSizeDouble)
+	writeLine(&out, "#"+OrderNumber(r.OrderId))
+	out.Write(gsSizeNormal)
+	writeLine(&out, r.BranchName)
+	writeLine(&out, r.DeliveryStatus)
+	if r.ScheduledAt != "" {
+		out.Write(escBoldOn)
+		writeLine(&out, "DUE "+displayTime(r.ScheduledAt))
+		out.Write(escBoldOff)
+	} else {
+		writeLine(&out, displayTime(r.CreatedAt))
+	}
+
+	out.Write(escAlignLeft)
+	writeLine(&out, rule('-'))
+	for _, item := range r.Items {
+		out.Write(gsSizeTall)
+		out.Write(escBoldOn)
+		for _, part := range wrap(fmt.Sprintf("%d x %s", item.Quantity, item.Name), Width) {
+			writeLine(&out, part)
+		}
+		out.Write(escBoldOff)
+		out.Write(gsSizeNormal)
+		for _, modifier := range item.Modifiers {
+			for _, part := range wrap("+ "+modifier, Width-4) {
+				writeLine(&out, "    "+part)
+			}
+		}
+	}
+	writeLine(&out, rule('-'))
+
+	out.Write(escFeed4)
+	out.Write(gsPartialCut)
+
+	return out.Bytes()
+}
+
+func writeLine(out *bytes.Buffer, text string) {
+	out.Write(cp1251(text))
+	out.WriteByte('\n')
+}
+
+// cp1251Fallbacks stands in for the letters Windows-1251 lacks: the Uzbek
+// Cyrillic letters print as the Russian ones they are usually typed as, and
+// the modifier apostrophes as a plain quote.
+var cp1251Fallbacks = map[rune]byte{
+	'Қ': 0xCA, 'қ': 0xEA, // К к
+	'Ғ': 0xC3, 'ғ': 0xE3, // Г г
+	'Ҳ': 0xD5, 'ҳ': 0xF5, // Х х
+	'ʻ': '\'', 'ʼ': '\'',
+}
+
+// cp1251 maps text to Windows-1251, which has both the Latin and the
+// Cyrillic alphabet, for the printer codepage selected by escCodePage.
+// Anything else becomes '?'.
+func cp1251(text string) []byte {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		if b, ok := charmap.Windows1251.EncodeRune(r); ok {
+			out = append(out, b)
+		} else if b, ok := cp1251Fallbacks[r]; ok {
+			out = append(out, b)
+		} else {
+			out = append(out, '?')
+		}
+	}
+	return out
+}
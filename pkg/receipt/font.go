@@ -0,0 +1,130 @@
+package receipt
+
+import (
+	"bytes"
+	"compress/zlib"
+	_ "embed"
+	"fmt"
+	"hash/crc32"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// DejaVu Sans Mono covers Latin and Cyrillic, including the Uzbek letters,
+// so names print as they were typed. See fonts/LICENSE.
+//
+//go:embed fonts/DejaVuSansMono.ttf
+var fontData []byte
+
+const fontName = "DejaVuSansMono"
+
+// pdfFont holds what the PDF needs to embed the receipt font. Metrics are in
+// thousandths of the font size, as PDF font dictionaries expect.
+type pdfFont struct {
+	sfnt        *sfnt.Font
+	advance     int
+	ascent      int
+	descent     int
+	capHeight   int
+	bbox        [4]int
+	missing     sfnt.GlyphIndex
+	missingRune rune
+}
+
+var (
+	loadFontOnce sync.Once
+	loadedFont   *pdfFont
+)
+
+// receiptFont parses the embedded font the first time it is needed.
+func receiptFont() *pdfFont {
+	loadFontOnce.Do(func() {
+		f, err := newPDFFont(fontData)
+		if err != nil {
+			panic(fmt.Sprintf("receipt: bad embedded font: %v", err))
+		}
+		loadedFont = f
+	})
+	return loadedFont
+}
+
+func newPDFFont(data []byte) (*pdfFont, error) {
+	parsed, err := sfnt.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		buf  sfnt.Buffer
+		ppem = fixed.I(1000)
+	)
+	f := &pdfFont{sfnt: parsed, missingRune: '?'}
+
+	f.missing, err = parsed.GlyphIndex(&buf, f.missingRune)
+	if err != nil {
+		return nil, err
+	}
+	advance, err := parsed.GlyphAdvance(&buf, f.missing, ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	f.advance = advance.Round()
+
+	metrics, err := parsed.Metrics(&buf, ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	f.ascent = metrics.Ascent.Round()
+	f.descent = -metrics.Descent.Round()
+	f.capHeight = metrics.CapHeight.Round()
+	if f.capHeight < 0 {
+		f.capHeight = -f.capHeight
+	}
+
+	// sfnt bounds grow downwards, PDF ones upwards.
+	bounds, err := parsed.Bounds(&buf, ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	f.bbox = [4]int{bounds.Min.X.Round(), -bounds.Max.Y.Round(), bounds.Max.X.Round(), -bounds.Min.Y.Round()}
+
+	return f, nil
+}
+
+// fontProgram returns the font file to embed for a document using glyphs, with
+// the PDF name of the subset, and its zlib-compressed form.
+func fontProgram(glyphs []sfnt.GlyphIndex) (name string, subset, compressed []byte) {
+	subset, err := subsetFont(fontData, glyphs)
+	if err != nil {
+		// The embedded font is known to subset; keep receipts printable
+		// with the whole font should that ever change.
+		subset = fontData
+	}
+
+	var buf bytes.Buffer
+	w, _ := zlib.NewWriterLevel(&buf, zlib.BestCompression)
+	w.Write(subset)
+	w.Close()
+
+	// Subsets are named with a tag of six capital letters that differs
+	// between subsets of the same font.
+	tag := make([]byte, 6)
+	for sum, i := crc32.ChecksumIEEE(subset), 0; i < len(tag); i, sum = i+1, sum/26 {
+		tag[i] = 'A' + byte(sum%26)
+	}
+
+	return string(tag) + "+" + fontName, subset, buf.Bytes()
+}
+
+// glyph returns the glyph of r and the rune it stands for, which is '?'
+// for characters the font does not have.
+func (f *pdfFont) glyph(buf *sfnt.Buffer, r rune) (sfnt.GlyphIndex, rune) {
+	g, err := f.sfnt.GlyphIndex(buf, r)
+	if err != nil || g == 0 {
+		return f.missing, f.missingRune
+	}
+	return g, r
+}
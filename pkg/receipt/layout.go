@@ -0,0 +1,173 @@
+package receipt
+
+import (
+	"fmt"
+	"food/api/models"
+	"math"
+	"strings"
+	"time"
+)
+
+// Width is the number of monospaced characters on an 80 mm receipt line.
+const Width = 42
+
+// Lines lays the customer receipt out as fixed-width text lines.
+func Lines(r *models.Receipt) []string {
+	var lines []string
+
+	lines = append(lines, center(r.BranchName))
+	for _, part := range wrap(r.BranchAddress, Width) {
+		lines = append(lines, center(part))
+	}
+	lines = append(lines, rule('='))
+
+	lines = append(lines, columns("Order", OrderNumber(r.OrderId)))
+	lines = append(lines, columns("Date", displayTime(r.CreatedAt)))
+	if r.ScheduledAt != "" {
+		lines = append(lines, columns("Scheduled for", displayTime(r.ScheduledAt)))
+	}
+	lines = append(lines, columns("Type", r.DeliveryStatus))
+	lines = append(lines, rule('-'))
+
+	for _, item := range r.Items {
+		name := fmt.Sprintf("%d x %s", item.Quantity, item.Name)
+		total := Money(item.Total)
+		wrapped := wrap(name, Width-len(total)-1)
+		for i, part := range wrapped {
+			if i == len(wrapped)-1 {
+				lines = append(lines, columns(part, total))
+			} else {
+				lines = append(lines, part)
+			}
+		}
+		if item.Quantity > 1 {
+			lines = append(lines, "    @ "+Money(item.Price))
+		}
+		for _, modifier := range item.Modifiers {
+			for _, part := range wrap("+ "+modifier, Width-4) {
+				lines = append(lines, "    "+part)
+			}
+		}
+	}
+
+	lines = append(lines, rule('-'))
+	lines = append(lines, columns("TOTAL", Money(r.Total)))
+
+	paid := "not paid"
+	if r.IsPaid {
+		paid = "paid"
+	}
+	if r.PaymentMethod != "" {
+		lines = append(lines, columns("Payment", r.PaymentMethod+", "+paid))
+	} else {
+		lines = append(lines, columns("Payment", paid))
+	}
+
+	if r.AddressName != "" {
+		lines = append(lines, rule('-'))
+		lines = append(lines, "Delivery address:")
+		lines = append(lines, wrap(r.AddressName, Width)...)
+	}
+
+	lines = append(lines, rule('='))
+	lines = append(lines, center("Thank you!"))
+
+	return lines
+}
+
+// Money formats an amount in sum with spaces between thousands.
+func Money(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	whole := math.Floor(amount)
+	cents := int(math.Round((amount - whole) * 100))
+	if cents == 100 {
+		whole++
+		cents = 0
+	}
+
+	digits := fmt.Sprintf("%.0f", whole)
+	var grouped strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte(' ')
+		}
+		grouped.WriteRune(d)
+	}
+
+	if cents > 0 {
+		return fmt.Sprintf("%s%s.%02d", sign, grouped.String(), cents)
+	}
+	return sign + grouped.String()
+}
+
+func columns(left, right string) string {
+	gap := Width - len([]rune(left)) - len([]rune(right))
+	if gap < 1 {
+		gap = 1
+	}
+	return left + strings.Repeat(" ", gap) + right
+}
+
+func center(text string) string {
+	pad := (Width - len([]rune(text))) / 2
+	if pad < 0 {
+		pad = 0
+	}
+	return strings.Repeat(" ", pad) + text
+}
+
+func rule(ch byte) string {
+	return strings.Repeat(string(ch), Width)
+}
+
+// wrap splits text into lines of at most width runes, breaking on spaces.
+func wrap(text string, width int) []string {
+	var (
+		lines   []string
+		current []rune
+	)
+	for _, word := range strings.Fields(text) {
+		w := []rune(word)
+		for len(w) > width {
+			if len(current) > 0 {
+				lines = append(lines, string(current))
+				current = nil
+			}
+			lines = append(lines, string(w[:width]))
+			w = w[width:]
+		}
+		if len(current) > 0 && len(current)+1+len(w) > width {
+			lines = append(lines, string(current))
+			current = nil
+		}
+		if len(current) > 0 {
+			current = append(current, ' ')
+		}
+		current = append(current, w...)
+	}
+	if len(current) > 0 {
+		lines = append(lines, string(current))
+	}
+	return lines
+}
+
+// OrderNumber is the short number of an order shown to customers and staff.
+func OrderNumber(id string) string {
+	if len(id) > 8 {
+		return strings.ToUpper(id[:8])
+	}
+	return strings.ToUpper(id)
+}
+
+func displayTime(value string) string {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return value
+	}
+	return t.Format("02.01.2006 15:04")
+}
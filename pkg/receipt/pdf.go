@@ -0,0 +1,135 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"food/api/models"
+	"sort"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+const (
+	pdfFontSize  = 8.0
+	pdfLeading   = 10.0
+	pdfMargin    = 12.0
+	pdfPageWidth = 227.0 // 80 mm fits Width monospaced characters at 8 pt
+	pdfMinHeight = 200.0
+)
+
+// PDF renders the customer receipt as a single narrow PDF page sized to its
+// content, set in the embedded Unicode font. The output only depends on the
+// receipt, so it is byte-for-byte reproducible.
+func PDF(r *models.Receipt) []byte {
+	lines := Lines(r)
+	f := receiptFont()
+
+	height := 2*pdfMargin + pdfLeading*float64(len(lines))
+	if height < pdfMinHeight {
+		height = pdfMinHeight
+	}
+
+	// Text is written as two-byte glyph ids. used maps every glyph back to
+	// its character so the text can be searched and copied.
+	var (
+		buf     sfnt.Buffer
+		used    = make(map[sfnt.GlyphIndex]rune)
+		content bytes.Buffer
+	)
+	content.WriteString("BT\n")
+	fmt.Fprintf(&content, "/F1 %.1f Tf\n%.1f TL\n", pdfFontSize, pdfLeading)
+	fmt.Fprintf(&content, "%.1f %.1f Td\n", pdfMargin, height-pdfMargin-pdfFontSize)
+	for _, line := range lines {
+		content.WriteByte('<')
+		for _, ch := range line {
+			g, shown := f.glyph(&buf, ch)
+			used[g] = shown
+			fmt.Fprintf(&content, "%04X", uint16(g))
+		}
+		content.WriteString("> Tj T*\n")
+	}
+	content.WriteString("ET\n")
+
+	glyphs := make([]sfnt.GlyphIndex, 0, len(used))
+	for g := range used {
+		glyphs = append(glyphs, g)
+	}
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i] < glyphs[j] })
+	name, subset, file := fontProgram(glyphs)
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.1f %.1f] /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+			pdfPageWidth, height),
+		pdfStream("", content.Bytes()),
+		"<< /Type /Font /Subtype /Type0 /BaseFont /" + name + " /Encoding /Identity-H /DescendantFonts [6 0 R] /ToUnicode 9 0 R >>",
+		fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor 7 0 R /DW %d /CIDToGIDMap /Identity >>",
+			name, f.advance),
+		fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 33 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 8 0 R >>",
+			name, f.bbox[0], f.bbox[1], f.bbox[2], f.bbox[3], f.ascent, f.descent, f.capHeight),
+		pdfStream(fmt.Sprintf(" /Length1 %d /Filter /FlateDecode", len(subset)), file),
+		pdfStream("", pdfToUnicode(glyphs, used)),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return out.Bytes()
+}
+
+func pdfStream(extra string, data []byte) string {
+	return fmt.Sprintf("<< /Length %d%s >>\nstream\n%s\nendstream", len(data), extra, data)
+}
+
+// pdfToUnicode builds the CMap that maps the glyphs used on the page, in
+// glyph order, back to characters.
+func pdfToUnicode(glyphs []sfnt.GlyphIndex, used map[sfnt.GlyphIndex]rune) []byte {
+	var cmap bytes.Buffer
+	cmap.WriteString(`/CIDInit /ProcSet findresource begin
+12 dict begin
+begincmap
+/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def
+/CMapName /Adobe-Identity-UCS def
+/CMapType 2 def
+1 begincodespacerange
+<0000> <FFFF>
+endcodespacerange
+`)
+	// A bfchar block holds at most 100 entries.
+	for len(glyphs) > 0 {
+		n := len(glyphs)
+		if n > 100 {
+			n = 100
+		}
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", n)
+		for _, g := range glyphs[:n] {
+			fmt.Fprintf(&cmap, "<%04X> <%s>\n", uint16(g), utf16Hex(used[g]))
+		}
+		cmap.WriteString("endbfchar\n")
+		glyphs = glyphs[n:]
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend")
+	return cmap.Bytes()
+}
+
+func utf16Hex(r rune) string {
+	if r < 0x10000 {
+		return fmt.Sprintf("%04X", r)
+	}
+	r -= 0x10000
+	return fmt.Sprintf("%04X%04X", 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+}
@@ -0,0 +1,142 @@
+package receipt
+
+import (
+	"bytes"
+	"flag"
+	"food/api/models"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func deliveryReceipt() *models.Receipt {
+	return &models.Receipt{
+		OrderId:        "3f2a9c1e-7b4d-4e8a-9c2f-1a2b3c4d5e6f",
+		Status:         "confirmed",
+		DeliveryStatus: "yetkazib berish",
+		BranchName:     "Чилонзор филиали",
+		BranchAddress:  "Toshkent, Chilonzor tumani, Bunyodkor ko‘chasi 12",
+		AddressName:    "Юнусобод, 4-мавзе, 17-уй, 23-хонадон",
+		CreatedAt:      "2026-10-19T12:30:00+05:00",
+		Total:          187500,
+		PaymentMethod:  "click",
+		IsPaid:         true,
+		Items: []models.ReceiptItem{
+			{Name: "Палов (тўй оши)", Quantity: 2, Price: 45000, Total: 90000, Modifiers: []string{"қази билан", "пиёзсиз"}},
+			{Name: "Qovurma lag‘mon", Quantity: 1, Price: 38000, Total: 38000},
+			{Name: "Ҳасип ва қўзиқорин қўшилган катта оилавий пицца", Quantity: 1, Price: 59500.5, Total: 59500.5, Modifiers: []string{"extra cheese"}},
+		},
+	}
+}
+
+func pickupReceipt() *models.Receipt {
+	return &models.Receipt{
+		OrderId:        "0b7e4d2a-1c3f-4a5b-8d6e-9f0a1b2c3d4e",
+		Status:         "scheduled",
+		DeliveryStatus: "olib ketish",
+		BranchName:     "Yunusobod",
+		BranchAddress:  "Amir Temur (shoh) ko'chasi 108",
+		CreatedAt:      "2026-10-19T09:05:00+05:00",
+		ScheduledAt:    "2026-10-19T13:00:00+05:00",
+		Total:          24000,
+		Items: []models.ReceiptItem{
+			{Name: "Somsa", Quantity: 3, Price: 8000, Total: 24000},
+		},
+	}
+}
+
+// golden compares got with testdata/name, or rewrites the file with -update.
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from the golden file (%d bytes, want %d); run go test -update if the change is intended", name, len(got), len(want))
+	}
+}
+
+func TestLines(t *testing.T) {
+	for name, r := range map[string]*models.Receipt{"delivery": deliveryReceipt(), "pickup": pickupReceipt()} {
+		t.Run(name, func(t *testing.T) {
+			golden(t, name+".txt.golden", []byte(strings.Join(Lines(r), "\n")+"\n"))
+		})
+	}
+}
+
+func TestPDF(t *testing.T) {
+	for name, r := range map[string]*models.Receipt{"delivery": deliveryReceipt(), "pickup": pickupReceipt()} {
+		t.Run(name, func(t *testing.T) {
+			out := PDF(r)
+			golden(t, name+".pdf.golden", out)
+
+			if got, want := pdfText(t, out), Lines(r); strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("PDF text does not match the receipt lines:\n%s", strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestKitchenTicket(t *testing.T) {
+	for name, r := range map[string]*models.Receipt{"delivery": deliveryReceipt(), "pickup": pickupReceipt()} {
+		t.Run(name, func(t *testing.T) {
+			golden(t, name+".escpos.golden", KitchenTicket(r))
+		})
+	}
+}
+
+func TestCP1251(t *testing.T) {
+	got := cp1251("Қовурма ғишт ҳам ўзи, o‘g‘il 😀")
+	want := []byte("\xca\xee\xe2\xf3\xf0\xec\xe0 \xe3\xe8\xf8\xf2 \xf5\xe0\xec \xa2\xe7\xe8, o\x91g\x91il ?")
+	if !bytes.Equal(got, want) {
+		t.Errorf("cp1251() = %q, want %q", got, want)
+	}
+}
+
+var (
+	pdfShowText = regexp.MustCompile(`<([0-9A-F]*)> Tj T\*`)
+	pdfCMapChar = regexp.MustCompile(`<([0-9A-F]{4})> <([0-9A-F]{4})>`)
+)
+
+// pdfText reads the lines back from a receipt PDF through its ToUnicode map.
+func pdfText(t *testing.T, pdf []byte) []string {
+	t.Helper()
+
+	chars := make(map[string]rune)
+	for _, m := range pdfCMapChar.FindAllSubmatch(pdf, -1) {
+		r, err := strconv.ParseUint(string(m[2]), 16, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chars[string(m[1])] = rune(r)
+	}
+
+	var lines []string
+	for _, m := range pdfShowText.FindAllSubmatch(pdf, -1) {
+		var line []rune
+		for hex := string(m[1]); hex != ""; hex = hex[4:] {
+			r, ok := chars[hex[:4]]
+			if !ok {
+				t.Fatalf("glyph %s is missing from the ToUnicode map", hex[:4])
+			}
+			line = append(line, r)
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
@@ -0,0 +1,220 @@
+package receipt
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// subsetTables are the TrueType tables a PDF reader needs to draw glyphs by
+// id, plus cmap, OS/2 and post so the subset is still a valid font on its
+// own. The rest, such as the layout tables, is dropped.
+var subsetTables = []string{"OS/2", "cmap", "cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "post", "prep"}
+
+var errBadFont = errors.New("malformed TrueType font")
+
+// subsetFont returns a copy of a TrueType font in which every glyph other
+// than keep, .notdef and the glyphs they are built from is empty. Glyph ids
+// do not change, so text can keep addressing glyphs by their id in the full
+// font.
+func subsetFont(data []byte, keep []sfnt.GlyphIndex) ([]byte, error) {
+	tables, err := fontTables(data)
+	if err != nil {
+		return nil, err
+	}
+	head, maxp, loca, glyf := tables["head"], tables["maxp"], tables["loca"], tables["glyf"]
+	if len(head) < 54 || len(maxp) < 6 || len(tables["post"]) < 32 || glyf == nil {
+		return nil, errBadFont
+	}
+
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	longLoca := binary.BigEndian.Uint16(head[50:]) == 1
+	offsets := make([]int, numGlyphs+1)
+	for i := range offsets {
+		switch {
+		case longLoca && len(loca) >= 4*(i+1):
+			offsets[i] = int(binary.BigEndian.Uint32(loca[4*i:]))
+		case !longLoca && len(loca) >= 2*(i+1):
+			offsets[i] = 2 * int(binary.BigEndian.Uint16(loca[2*i:]))
+		default:
+			return nil, errBadFont
+		}
+		if offsets[i] > len(glyf) || (i > 0 && offsets[i] < offsets[i-1]) {
+			return nil, errBadFont
+		}
+	}
+	glyph := func(g int) []byte { return glyf[offsets[g]:offsets[g+1]] }
+
+	// Composite glyphs are drawn from other glyphs, which must be kept too.
+	kept := make([]bool, numGlyphs)
+	queue := []int{0}
+	for _, g := range keep {
+		queue = append(queue, int(g))
+	}
+	for len(queue) > 0 {
+		g := queue[0]
+		queue = queue[1:]
+		if g >= numGlyphs || kept[g] {
+			continue
+		}
+		kept[g] = true
+		queue = append(queue, glyphComponents(glyph(g))...)
+	}
+
+	var newGlyf []byte
+	newLoca := make([]byte, 4*(numGlyphs+1))
+	for g := 0; g < numGlyphs; g++ {
+		binary.BigEndian.PutUint32(newLoca[4*g:], uint32(len(newGlyf)))
+		if kept[g] {
+			newGlyf = append(newGlyf, glyph(g)...)
+			for len(newGlyf)%4 != 0 {
+				newGlyf = append(newGlyf, 0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(newLoca[4*numGlyphs:], uint32(len(newGlyf)))
+
+	newHead := append([]byte(nil), head...)
+	binary.BigEndian.PutUint32(newHead[8:], 0)  // checkSumAdjustment, set below
+	binary.BigEndian.PutUint16(newHead[50:], 1) // long loca offsets
+
+	// Version 3 of post has no glyph names, which take most of the table.
+	newPost := append([]byte(nil), tables["post"][:32]...)
+	binary.BigEndian.PutUint32(newPost, 0x00030000)
+
+	tables["head"], tables["loca"], tables["glyf"], tables["post"] = newHead, newLoca, newGlyf, newPost
+
+	out := writeFontTables(binary.BigEndian.Uint32(data), tables)
+	binary.BigEndian.PutUint32(out[headOffset(out)+8:], 0xB1B0AFBA-fontChecksum(out))
+	return out, nil
+}
+
+// fontTables returns the subsetTables present in a font by tag.
+func fontTables(data []byte) (map[string][]byte, error) {
+	if len(data) < 12 {
+		return nil, errBadFont
+	}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	if len(data) < 12+16*numTables {
+		return nil, errBadFont
+	}
+
+	wanted := make(map[string]bool, len(subsetTables))
+	for _, tag := range subsetTables {
+		wanted[tag] = true
+	}
+
+	tables := make(map[string][]byte)
+	for i := 0; i < numTables; i++ {
+		record := data[12+16*i:]
+		tag := string(record[:4])
+		offset := int(binary.BigEndian.Uint32(record[8:]))
+		length := int(binary.BigEndian.Uint32(record[12:]))
+		if offset+length > len(data) {
+			return nil, errBadFont
+		}
+		if wanted[tag] {
+			tables[tag] = data[offset : offset+length]
+		}
+	}
+	return tables, nil
+}
+
+// writeFontTables lays tables out as a font file, in tag order.
+func writeFontTables(version uint32, tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= len(tags) {
+		entrySelector++
+	}
+	searchRange := 16 << entrySelector
+
+	out := make([]byte, 12+16*len(tags))
+	binary.BigEndian.PutUint32(out, version)
+	binary.BigEndian.PutUint16(out[4:], uint16(len(tags)))
+	binary.BigEndian.PutUint16(out[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(out[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(out[10:], uint16(16*len(tags)-searchRange))
+
+	for i, tag := range tags {
+		table := tables[tag]
+		record := out[12+16*i:]
+		copy(record, tag)
+		binary.BigEndian.PutUint32(record[4:], fontChecksum(table))
+		binary.BigEndian.PutUint32(record[8:], uint32(len(out)))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(table)))
+
+		out = append(out, table...)
+		for len(out)%4 != 0 {
+			out = append(out, 0)
+		}
+	}
+	return out
+}
+
+func headOffset(font []byte) int {
+	numTables := int(binary.BigEndian.Uint16(font[4:]))
+	for i := 0; i < numTables; i++ {
+		record := font[12+16*i:]
+		if string(record[:4]) == "head" {
+			return int(binary.BigEndian.Uint32(record[8:]))
+		}
+	}
+	return 0
+}
+
+func fontChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
+
+// glyphComponents returns the glyphs a composite glyph is built from.
+func glyphComponents(glyph []byte) []int {
+	const (
+		argsAreWords   = 0x0001
+		haveScale      = 0x0008
+		moreComponents = 0x0020
+		haveXYScale    = 0x0040
+		haveTwoByTwo   = 0x0080
+	)
+
+	if len(glyph) < 10 || int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+		return nil
+	}
+
+	var components []int
+	for p := 10; p+4 <= len(glyph); {
+		flags := binary.BigEndian.Uint16(glyph[p:])
+		components = append(components, int(binary.BigEndian.Uint16(glyph[p+2:])))
+		p += 4
+		if flags&argsAreWords != 0 {
+			p += 4
+		} else {
+			p += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			p += 2
+		case flags&haveXYScale != 0:
+			p += 4
+		case flags&haveTwoByTwo != 0:
+			p += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return components
+}
@@ -0,0 +1,43 @@
+package smtp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FileMailer writes every email as an .eml file to a local outbox directory
+// instead of sending it, so emails can be opened in a mail client during
+// development.
+type FileMailer struct {
+	dir  string
+	from Address
+}
+
+func NewFile(dir string, from Address) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail outbox: %w", err)
+	}
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+func (f *FileMailer) Send(ctx context.Context, msg *Message) error {
+	now := time.Now()
+	data, err := Build(f.from, msg, now)
+	if err != nil {
+		return err
+	}
+
+	name := now.Format("20060102-150405") + "-" + uuid.New().String() + ".eml"
+	if err := os.WriteFile(filepath.Join(f.dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write email to outbox: %w", err)
+	}
+	return nil
+}
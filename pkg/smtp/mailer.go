@@ -0,0 +1,49 @@
+// Package smtp builds MIME emails from templates and sends them through an
+// SMTP server, or writes them to a local outbox directory for development,
+// selected by configuration.
+package smtp
+
+import (
+	"context"
+	"fmt"
+	"food/config"
+)
+
+const (
+	SMTP = "smtp"
+	File = "file"
+)
+
+// Attachment is a file sent with an email.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Message is an email with a plain text and an optional HTML body.
+type Message struct {
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Mailer sends emails.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New returns the mailer selected by cfg.MailBackend.
+func New(cfg *config.Config) (Mailer, error) {
+	from := Address{Name: cfg.MailFromName, Email: cfg.MailFrom}
+	switch cfg.MailBackend {
+	case SMTP:
+		return NewSMTP(cfg.SmtpHost, cfg.SmtpPort, cfg.SmtpUsername, cfg.SmtpPassword, from), nil
+	case File:
+		return NewFile(cfg.MailOutboxDir, from)
+	default:
+		return nil, fmt.Errorf("unknown mail backend %q", cfg.MailBackend)
+	}
+}
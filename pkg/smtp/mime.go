@@ -0,0 +1,167 @@
+package smtp
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNoRecipients is returned for messages without a To address.
+var ErrNoRecipients = errors.New("email has no recipients")
+
+// Address is the sender of the emails.
+type Address struct {
+	Name  string
+	Email string
+}
+
+func (a Address) String() string {
+	return (&mail.Address{Name: a.Name, Address: a.Email}).String()
+}
+
+// Build renders msg as a MIME message: the text and HTML bodies as
+// multipart/alternative, wrapped in multipart/mixed when there are
+// attachments.
+func Build(from Address, msg *Message, now time.Time) ([]byte, error) {
+	if len(msg.To) == 0 {
+		return nil, ErrNoRecipients
+	}
+	for _, to := range msg.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", to, err)
+		}
+	}
+
+	domain := "localhost"
+	if at := strings.LastIndex(from.Email, "@"); at >= 0 {
+		domain = from.Email[at+1:]
+	}
+
+	var out bytes.Buffer
+	header := func(key, value string) {
+		out.WriteString(key + ": " + value + "\r\n")
+	}
+	header("From", from.String())
+	header("To", strings.Join(msg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", "<"+uuid.New().String()+"@"+domain+">")
+	header("MIME-Version", "1.0")
+
+	body, bodyHeader, err := buildBody(msg)
+	if err != nil {
+		return nil, err
+	}
+	if len(msg.Attachments) == 0 {
+		for _, key := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+			if value := bodyHeader.Get(key); value != "" {
+				header(key, value)
+			}
+		}
+		out.WriteString("\r\n")
+		out.Write(body)
+		return out.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(&out)
+	header("Content-Type", `multipart/mixed; boundary="`+mixed.Boundary()+`"`)
+	out.WriteString("\r\n")
+
+	part, err := mixed.CreatePart(bodyHeader)
+	if err != nil {
+		return nil, err
+	}
+	part.Write(body)
+
+	for _, attachment := range msg.Attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": attachment.Filename})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(part, attachment.Data)
+	}
+
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// buildBody returns the text body alone, or the text and HTML bodies as
+// multipart/alternative, with the headers of the body part.
+func buildBody(msg *Message) ([]byte, textproto.MIMEHeader, error) {
+	if msg.HTML == "" {
+		body, err := quotedPrintable(msg.Text)
+		return body, textproto.MIMEHeader{
+			"Content-Type":              {"text/plain; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		}, err
+	}
+
+	var out bytes.Buffer
+	alternative := multipart.NewWriter(&out)
+	for _, body := range []struct{ contentType, text string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		part, err := alternative.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {body.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		encoded, err := quotedPrintable(body.text)
+		if err != nil {
+			return nil, nil, err
+		}
+		part.Write(encoded)
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, nil, err
+	}
+	return out.Bytes(), textproto.MIMEHeader{
+		"Content-Type": {`multipart/alternative; boundary="` + alternative.Boundary() + `"`},
+	}, nil
+}
+
+func quotedPrintable(text string) ([]byte, error) {
+	var out bytes.Buffer
+	w := quotedprintable.NewWriter(&out)
+	if _, err := w.Write([]byte(text)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// writeBase64 writes data base64 encoded in lines of 76 characters.
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
@@ -0,0 +1,251 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	from = Address{Name: "Khorezm Shashlik", Email: "orders@shashlik.uz"}
+	now  = time.Date(2024, 3, 8, 14, 30, 0, 0, time.FixedZone("UZT", 5*60*60))
+)
+
+// part is a part of a parsed email, with its transfer encoding undone.
+// Multipart parts come before the parts they contain and have no body.
+type part struct {
+	contentType string
+	disposition string
+	filename    string
+	body        string
+}
+
+// parse reads an email back with net/mail and mime/multipart and returns its
+// header and its parts, depth first.
+func parse(t *testing.T, data []byte) (mail.Header, []part) {
+	t.Helper()
+
+	for i, line := range strings.Split(strings.TrimSuffix(string(data), "\r\n"), "\r\n") {
+		if len(line) > 998 {
+			t.Errorf("line %d is %d characters long, want at most 998", i+1, len(line))
+		}
+		if strings.ContainsAny(line, "\r\n") {
+			t.Errorf("line %d ends with a bare CR or LF: %q", i+1, line)
+		}
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to parse email: %v", err)
+	}
+	return msg.Header, parseParts(t, msg.Header, msg.Body)
+}
+
+func parseParts(t *testing.T, header map[string][]string, body io.Reader) []part {
+	t.Helper()
+
+	get := func(key string) string {
+		if values := header[key]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	mediaType, params, err := mime.ParseMediaType(get("Content-Type"))
+	if err != nil {
+		t.Fatalf("invalid Content-Type %q: %v", get("Content-Type"), err)
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		parts := []part{{contentType: mediaType}}
+		r := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := r.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("failed to read %s part: %v", mediaType, err)
+			}
+			parts = append(parts, parseParts(t, p.Header, p)...)
+		}
+		return parts
+	}
+
+	switch encoding := get("Content-Transfer-Encoding"); encoding {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		var lines []string
+		raw, _ := io.ReadAll(body)
+		for _, line := range strings.Split(strings.TrimSuffix(string(raw), "\r\n"), "\r\n") {
+			if len(line) > 76 {
+				t.Errorf("base64 line of %s is %d characters long, want at most 76", mediaType, len(line))
+			}
+			lines = append(lines, line)
+		}
+		body = base64.NewDecoder(base64.StdEncoding, strings.NewReader(strings.Join(lines, "")))
+	default:
+		t.Errorf("%s is sent as %q, want quoted-printable or base64", mediaType, encoding)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("failed to decode %s: %v", mediaType, err)
+	}
+
+	p := part{contentType: mediaType, body: string(data)}
+	if strings.HasPrefix(mediaType, "text/") {
+		// Text is sent with CRLF line breaks, as MIME requires.
+		p.contentType += "; charset=" + params["charset"]
+		p.body = strings.ReplaceAll(p.body, "\r\n", "\n")
+	}
+	if disposition := get("Content-Disposition"); disposition != "" {
+		var dispositionParams map[string]string
+		p.disposition, dispositionParams, err = mime.ParseMediaType(disposition)
+		if err != nil {
+			t.Fatalf("invalid Content-Disposition %q: %v", disposition, err)
+		}
+		p.filename = dispositionParams["filename"]
+	}
+	return []part{p}
+}
+
+func TestBuildHeader(t *testing.T) {
+	subject := "Buyurtma №1042 qabul qilindi — Ташкент, Чорсу филиали, спасибо за заказ!"
+	data, err := Build(from, &Message{To: []string{"ali@example.com", "Vali <vali@example.com>"}, Subject: subject, Text: "Salom"}, now)
+	if err != nil {
+		t.Fatalf("Build() = %v", err)
+	}
+	header, _ := parse(t, data)
+
+	raw := header.Get("Subject")
+	if strings.ContainsFunc(raw, func(r rune) bool { return r > 127 }) {
+		t.Errorf("Subject %q is not encoded", raw)
+	}
+	if got, err := new(mime.WordDecoder).DecodeHeader(raw); err != nil || got != subject {
+		t.Errorf("Subject decodes to %q, %v, want %q", got, err, subject)
+	}
+
+	for key, want := range map[string]string{
+		"From":         `"Khorezm Shashlik" <orders@shashlik.uz>`,
+		"To":           "ali@example.com, Vali <vali@example.com>",
+		"Date":         "Fri, 08 Mar 2024 14:30:00 +0500",
+		"MIME-Version": "1.0",
+	} {
+		if got := header.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if to, err := header.AddressList("To"); err != nil || len(to) != 2 {
+		t.Errorf("To parses as %v, %v, want two addresses", to, err)
+	}
+	if id := header.Get("Message-ID"); !strings.HasPrefix(id, "<") || !strings.HasSuffix(id, "@shashlik.uz>") {
+		t.Errorf("Message-ID = %q, want <...@shashlik.uz>", id)
+	}
+}
+
+func TestBuildParts(t *testing.T) {
+	// Long enough for quoted-printable to wrap and base64 to span lines.
+	text := "Buyurtmangiz qabul qilindi.\n" + strings.Repeat("Oʻzbekiston — Ташкент ", 20) + "\n"
+	html := "<p>" + strings.Repeat("Buyurtmangiz qabul qilindi. ", 10) + "</p>\n"
+	pdf := bytes.Repeat([]byte("%PDF-1.4\x00\xff\xfe binary\r\n"), 40)
+
+	for name, tc := range map[string]struct {
+		msg  *Message
+		want []part
+	}{
+		"text": {
+			msg:  &Message{To: []string{"ali@example.com"}, Subject: "Salom", Text: text},
+			want: []part{{contentType: "text/plain; charset=utf-8", body: text}},
+		},
+		"text and html": {
+			msg: &Message{To: []string{"ali@example.com"}, Subject: "Salom", Text: text, HTML: html},
+			want: []part{
+				{contentType: "multipart/alternative"},
+				{contentType: "text/plain; charset=utf-8", body: text},
+				{contentType: "text/html; charset=utf-8", body: html},
+			},
+		},
+		"attachments": {
+			msg: &Message{To: []string{"ali@example.com"}, Subject: "Chek", Text: text, HTML: html, Attachments: []Attachment{
+				{Filename: "chek №1042.pdf", ContentType: "application/pdf", Data: pdf},
+				{Filename: "data.bin", Data: []byte{0, 1, 2}},
+			}},
+			want: []part{
+				{contentType: "multipart/mixed"},
+				{contentType: "multipart/alternative"},
+				{contentType: "text/plain; charset=utf-8", body: text},
+				{contentType: "text/html; charset=utf-8", body: html},
+				{contentType: "application/pdf", disposition: "attachment", filename: "chek №1042.pdf", body: string(pdf)},
+				{contentType: "application/octet-stream", disposition: "attachment", filename: "data.bin", body: "\x00\x01\x02"},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := Build(from, tc.msg, now)
+			if err != nil {
+				t.Fatalf("Build() = %v", err)
+			}
+			_, got := parse(t, data)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parts = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	if _, err := Build(from, &Message{Subject: "Salom", Text: "Salom"}, now); !errors.Is(err, ErrNoRecipients) {
+		t.Errorf("Build() without recipients = %v, want ErrNoRecipients", err)
+	}
+	if _, err := Build(from, &Message{To: []string{"not an address"}, Text: "Salom"}, now); err == nil {
+		t.Error("Build() with an invalid recipient = nil, want an error")
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	mailer, err := NewFile(dir, from)
+	if err != nil {
+		t.Fatalf("NewFile() = %v", err)
+	}
+
+	msg := &Message{To: []string{"ali@example.com"}, Subject: "Buyurtma qabul qilindi", Text: "Salom"}
+	if err := mailer.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("outbox has %v, %v, want one .eml file", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, parts := parse(t, data)
+	if got := header.Get("To"); got != "ali@example.com" {
+		t.Errorf("To = %q, want ali@example.com", got)
+	}
+	if len(parts) != 1 || parts[0].body != "Salom" {
+		t.Errorf("parts = %+v, want the text body", parts)
+	}
+
+	if err := mailer.Send(context.Background(), &Message{Text: "Salom"}); !errors.Is(err, ErrNoRecipients) {
+		t.Errorf("Send() without recipients = %v, want ErrNoRecipients", err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.eml")); len(files) != 1 {
+		t.Errorf("outbox has %d files after a failed send, want 1", len(files))
+	}
+}
@@ -1,32 +1,55 @@
 package smtp
 
 import (
-	"food/config"
-	"log"
+	"context"
+	"fmt"
+	"net"
 	"net/smtp"
+	"time"
 )
 
-func SendMail(toEmail string, msg string) error {
-
-	// Compose the email message
-	from := "sharofiddinbobomurodov7011@gmail.com"
-	to := []string{toEmail}
-	subject := "Register for Khorezm_Shashlik"
-	message := msg
+// SMTPMailer sends through an SMTP server, upgrading to TLS with STARTTLS
+// when the server offers it. An empty username sends without
+// authentication.
+type SMTPMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     Address
+}
 
-	// Create the email message
-	body := "To: " + to[0] + "\r\n" +
-		"Subject: " + subject + "\r\n" +
-		"\r\n" + message
+func NewSMTP(host, port, username, password string, from Address) *SMTPMailer {
+	if from.Email == "" {
+		from.Email = username
+	}
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
 
-	auth := smtp.PlainAuth("", config.SmtpUsername, config.SmtpPassword, config.SmtpServer)
+// Send delivers msg. net/smtp takes no context, so ctx is only checked
+// before connecting.
+func (s *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-	log.Println("Connecting to SMTP server...")
-	err := smtp.SendMail(config.SmtpServer+":"+config.SmtpPort, auth, from, to, []byte(body))
+	data, err := Build(s.from, msg, time.Now())
 	if err != nil {
-		log.Println("Error sending mail:", err)
 		return err
 	}
-	log.Println("Email sent successfully")
+
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+	if err := smtp.SendMail(net.JoinHostPort(s.host, s.port), auth, s.from.Email, msg.To, data); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
 	return nil
 }
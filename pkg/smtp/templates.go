@@ -0,0 +1,89 @@
+package smtp
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Email templates. Each has a text version, templates/name.txt.tmpl, that
+// defines the subject as {{define "subject"}}, and an optional HTML version,
+// templates/name.html.tmpl.
+const (
+	TemplateReceipt      = "receipt"
+	TemplateAlert        = "alert"
+	TemplateNotification = "notification"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// ReceiptEmail is the data of the receipt template. Amounts are formatted.
+type ReceiptEmail struct {
+	OrderNumber   string
+	BranchName    string
+	CreatedAt     string
+	Items         []ReceiptEmailItem
+	Total         string
+	PaymentMethod string
+	IsPaid        bool
+}
+
+type ReceiptEmailItem struct {
+	Name     string
+	Quantity int
+	Total    string
+}
+
+// AlertEmail is the data of the alert template sent to admins.
+type AlertEmail struct {
+	Title   string
+	Details []string
+}
+
+// NotificationEmail is the data of the template of user notifications.
+type NotificationEmail struct {
+	Title string
+	Body  string
+}
+
+// Render fills the template with data and returns the message without
+// recipients.
+func Render(name string, data interface{}) (*Message, error) {
+	text, err := texttemplate.ParseFS(templateFiles, "templates/"+name+".txt.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("unknown email template %q: %w", name, err)
+	}
+
+	var subject, body bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("failed to render %s email subject: %w", name, err)
+	}
+	if err := text.Execute(&body, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+	msg := &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(body.String()) + "\n",
+	}
+
+	html, err := htmltemplate.ParseFS(templateFiles, "templates/"+name+".html.tmpl")
+	if errors.Is(err, fs.ErrNotExist) {
+		return msg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s email: %w", name, err)
+	}
+
+	var out bytes.Buffer
+	if err := html.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+	msg.HTML = out.String()
+	return msg, nil
+}
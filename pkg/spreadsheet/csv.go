@@ -0,0 +1,96 @@
+package spreadsheet
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// utf8BOM makes Excel read the file as UTF-8 instead of the system code page.
+const utf8BOM = "\ufeff"
+
+type csvWriter struct {
+	w       *csv.Writer
+	columns []Column
+}
+
+// NewCSV writes the byte order mark and the header row.
+func NewCSV(w io.Writer, columns []Column) (Writer, error) {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return nil, err
+	}
+
+	c := &csvWriter{w: csv.NewWriter(w), columns: columns}
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	if err := c.w.Write(header); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Write adds a row. Text cells that would start a formula get a leading
+// apostrophe, and so do text cells that start with one, so Read can tell the
+// apostrophes it has to remove.
+func (c *csvWriter) Write(cells []string) error {
+	if len(cells) != len(c.columns) {
+		return fmt.Errorf("spreadsheet: row has %d cells, want %d", len(cells), len(c.columns))
+	}
+
+	row := make([]string, len(cells))
+	for i, value := range cells {
+		if !c.columns[i].Numeric && escaped(value) {
+			value = "'" + value
+		}
+		row[i] = value
+	}
+	return c.w.Write(row)
+}
+
+// escaped reports whether Write puts an apostrophe before a text cell.
+func escaped(value string) bool {
+	return isFormula(value) || strings.HasPrefix(value, "'")
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// CSVReader reads tables written by NewCSV or saved by a spreadsheet
+// application.
+type CSVReader struct {
+	r *csv.Reader
+}
+
+// NewCSVReader skips the byte order mark at the start of r, if there is one.
+func NewCSVReader(r io.Reader) *CSVReader {
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(len(utf8BOM)); err == nil && string(bom) == utf8BOM {
+		br.Discard(len(utf8BOM))
+	}
+
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	return &CSVReader{r: cr}
+}
+
+// Read returns the next row, without the apostrophes Write adds, and io.EOF
+// after the last one. Apostrophes Write would not have added are kept.
+func (c *CSVReader) Read() ([]string, error) {
+	row, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range row {
+		if len(value) > 1 && value[0] == '\'' && escaped(value[1:]) {
+			row[i] = value[1:]
+		}
+	}
+	return row, nil
+}
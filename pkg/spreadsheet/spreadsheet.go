@@ -0,0 +1,58 @@
+// Package spreadsheet streams tables as CSV or XLSX one row at a time, so
+// exports of any size use constant memory.
+package spreadsheet
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// Formats of the writers.
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+// ErrUnknownFormat is returned by New for formats other than CSV and XLSX.
+var ErrUnknownFormat = errors.New("unknown spreadsheet format")
+
+// Column describes one column of the table. Numeric cells are stored as
+// numbers in XLSX and are never treated as formulas in CSV.
+type Column struct {
+	Name    string
+	Numeric bool
+}
+
+// Writer writes the rows of one table. The header row is written by the
+// constructor; Close finishes the file but does not close the underlying
+// writer.
+type Writer interface {
+	Write(cells []string) error
+	Close() error
+}
+
+// New returns a writer for format, csv or xlsx. sheet names the XLSX sheet.
+func New(format string, w io.Writer, sheet string, columns []Column) (Writer, error) {
+	switch format {
+	case CSV:
+		return NewCSV(w, columns)
+	case XLSX:
+		return NewXLSX(w, sheet, columns)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// ContentType returns the MIME type of format.
+func ContentType(format string) string {
+	if format == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// isFormula reports whether a spreadsheet application would evaluate the
+// text cell instead of showing it.
+func isFormula(value string) bool {
+	return value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0]))
+}
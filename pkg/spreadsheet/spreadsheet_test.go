@@ -0,0 +1,226 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var columns = []Column{{Name: "Name"}, {Name: "Price", Numeric: true}}
+
+// rows are the values a cell must survive a round trip with.
+var rows = [][]string{
+	{"Osh", "45000"},
+	{"=SUM(A1:A9)", "-5"},
+	{"+998 90 123 45 67", "1.5"},
+	{"-Chegirma", "0"},
+	{"@import", "-0.25"},
+	{"'90s", "12"},
+	{"'=x", "3"},
+	{"''", "4"},
+	{"'", "5"},
+	{"Ташкент, \"Chorsu\"", "6"},
+	{"tab\tinside", "7"},
+	{"", ""},
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewCSV(&buf, columns)
+	if err != nil {
+		t.Fatalf("NewCSV() = %v", err)
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("Write(%q) = %v", row, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), utf8BOM) {
+		t.Error("CSV does not start with the byte order mark")
+	}
+
+	r := NewCSVReader(&buf)
+	header, err := r.Read()
+	if err != nil {
+		t.Fatalf("Read() header = %v", err)
+	}
+	if want := []string{"Name", "Price"}; !reflect.DeepEqual(header, want) {
+		t.Errorf("header = %q, want %q", header, want)
+	}
+	for _, want := range rows {
+		got, err := r.Read()
+		if err != nil {
+			t.Fatalf("Read() = %v, want %q", err, want)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Read() = %q, want %q", got, want)
+		}
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Read() after the last row = %v, want io.EOF", err)
+	}
+}
+
+func TestCSVEscape(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewCSV(&buf, columns)
+	if err != nil {
+		t.Fatalf("NewCSV() = %v", err)
+	}
+	w.Write([]string{"=1+1", "-5"})
+	w.Write([]string{"'90s", "5"})
+	w.Write([]string{"Osh", "5"})
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+
+	want := utf8BOM + "Name,Price\n'=1+1,-5\n''90s,5\nOsh,5\n"
+	if got := buf.String(); got != want {
+		t.Errorf("CSV = %q, want %q", got, want)
+	}
+}
+
+func TestCSVReaderKeepsApostrophes(t *testing.T) {
+	// Saved by hand, not by NewCSV: only apostrophes Write would have added
+	// are removed.
+	r := NewCSVReader(strings.NewReader("Name,Note\n'90s,'=x\n'Osh,''\n"))
+
+	var got [][]string
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Read() = %v", err)
+		}
+		got = append(got, row)
+	}
+
+	want := [][]string{{"Name", "Note"}, {"'90s", "=x"}, {"'Osh", "'"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+}
+
+// xlsxCell is a cell of a worksheet, with its value either inline or in v.
+type xlsxCell struct {
+	Ref    string `xml:"r,attr"`
+	Type   string `xml:"t,attr"`
+	Style  string `xml:"s,attr"`
+	Value  string `xml:"v"`
+	Inline *struct {
+		Text string `xml:"t"`
+	} `xml:"is"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Ref   string     `xml:"r,attr"`
+		Cells []xlsxCell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readSheet(t *testing.T, data []byte) xlsxSheet {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("failed to open XLSX: %v", err)
+	}
+	f, err := zr.Open("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatalf("failed to open sheet: %v", err)
+	}
+	defer f.Close()
+
+	var sheet xlsxSheet
+	if err := xml.NewDecoder(f).Decode(&sheet); err != nil {
+		t.Fatalf("failed to parse sheet: %v", err)
+	}
+	return sheet
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewXLSX(&buf, "Menu", columns)
+	if err != nil {
+		t.Fatalf("NewXLSX() = %v", err)
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatalf("Write(%q) = %v", row, err)
+		}
+	}
+	w.Write([]string{"Not a price", "about 5"})
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+
+	sheet := readSheet(t, buf.Bytes())
+	if len(sheet.Rows) != len(rows)+2 {
+		t.Fatalf("sheet has %d rows, want %d", len(sheet.Rows), len(rows)+2)
+	}
+
+	header := sheet.Rows[0].Cells
+	if len(header) != 2 || header[0].Inline == nil || header[0].Inline.Text != "Name" || header[0].Style != "1" {
+		t.Errorf("header = %+v, want bold Name and Price", header)
+	}
+
+	for i, want := range rows {
+		row := sheet.Rows[i+1]
+		if row.Ref != strconv.Itoa(i+2) {
+			t.Errorf("row %d is numbered %s", i+2, row.Ref)
+		}
+		got := map[string]xlsxCell{}
+		for _, c := range row.Cells {
+			got[c.Ref[:1]] = c
+		}
+
+		// Text keeps its value exactly, formulas included, as an inline
+		// string; it is never evaluated.
+		name, ok := got["A"]
+		switch {
+		case want[0] == "":
+			if ok {
+				t.Errorf("row %d: empty text written as %+v", i+2, name)
+			}
+		case !ok || name.Type != "inlineStr" || name.Inline == nil:
+			t.Errorf("row %d: text = %+v, want an inline string", i+2, name)
+		case name.Inline.Text != want[0]:
+			t.Errorf("row %d: text = %q, want %q", i+2, name.Inline.Text, want[0])
+		}
+
+		price, ok := got["B"]
+		switch {
+		case want[1] == "":
+			if ok {
+				t.Errorf("row %d: empty number written as %+v", i+2, price)
+			}
+		case !ok || price.Type != "" || price.Inline != nil:
+			t.Errorf("row %d: number = %+v, want a numeric cell", i+2, price)
+		case price.Value != want[1]:
+			t.Errorf("row %d: number = %q, want %q", i+2, price.Value, want[1])
+		}
+	}
+
+	// A numeric column with text in it keeps the text.
+	last := sheet.Rows[len(sheet.Rows)-1].Cells
+	if len(last) != 2 || last[1].Type != "inlineStr" || last[1].Inline == nil || last[1].Inline.Text != "about 5" {
+		t.Errorf("text in a numeric column = %+v, want an inline string", last)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New("ods", io.Discard, "Menu", columns); err != ErrUnknownFormat {
+		t.Errorf("New(ods) = %v, want ErrUnknownFormat", err)
+	}
+}
@@ -0,0 +1,141 @@
+// Package translit converts Uzbek text between the Latin and Cyrillic
+// alphabets so a search typed in one script finds menu items written in the
+// other.
+package translit
+
+import "strings"
+
+// apostrophes lists the characters people type for the Uzbek tutuq belgisi
+// and the o‘/g‘ modifier. They are all folded into a plain quote.
+const apostrophes = "‘’ʻʼ`´"
+
+var latinPairs = []struct{ latin, cyrillic string }{
+	{"o'", "ў"},
+	{"g'", "ғ"},
+	{"sh", "ш"},
+	{"ch", "ч"},
+	{"yo", "ё"},
+	{"yu", "ю"},
+	{"ya", "я"},
+	{"ye", "е"},
+}
+
+var latinLetters = map[rune]string{
+	'a': "а", 'b': "б", 'd': "д", 'e': "е", 'f': "ф", 'g': "г", 'h': "ҳ",
+	'i': "и", 'j': "ж", 'k': "к", 'l': "л", 'm': "м", 'n': "н", 'o': "о",
+	'p': "п", 'q': "қ", 'r': "р", 's': "с", 't': "т", 'u': "у", 'v': "в",
+	'x': "х", 'y': "й", 'z': "з", 'c': "с", 'w': "в", '\'': "ъ",
+}
+
+var cyrillicLetters = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'ғ': "g'", 'д': "d", 'е': "e",
+	'ё': "yo", 'ж': "j", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'қ': "q",
+	'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s",
+	'т': "t", 'у': "u", 'ў': "o'", 'ф': "f", 'х': "x", 'ҳ': "h", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "sh", 'ъ': "'", 'ы': "i", 'ь': "", 'э': "e",
+	'ю': "yu", 'я': "ya",
+}
+
+// Normalize lower-cases text and folds every apostrophe variant into '.
+func Normalize(text string) string {
+	text = strings.ToLower(text)
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(apostrophes, r) {
+			return '\''
+		}
+		return r
+	}, text)
+}
+
+// ToCyrillic transliterates Latin Uzbek to Cyrillic. Characters that are not
+// Latin letters are kept as they are.
+func ToCyrillic(text string) string {
+	text = Normalize(text)
+
+	var out strings.Builder
+	wordStart := true
+	for i := 0; i < len(text); {
+		rest := text[i:]
+
+		// A word-initial e is э; everywhere else it is е.
+		if wordStart && rest[0] == 'e' {
+			out.WriteString("э")
+			i++
+			wordStart = false
+			continue
+		}
+
+		matched := false
+		for _, pair := range latinPairs {
+			if strings.HasPrefix(rest, pair.latin) {
+				out.WriteString(pair.cyrillic)
+				i += len(pair.latin)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			wordStart = false
+			continue
+		}
+
+		r := []rune(rest)[0]
+		size := len(string(r))
+		if letter, ok := latinLetters[r]; ok {
+			out.WriteString(letter)
+		} else {
+			out.WriteRune(r)
+		}
+		wordStart = !isLetter(r)
+		i += size
+	}
+	return out.String()
+}
+
+// ToLatin transliterates Cyrillic Uzbek (and Russian) to Latin Uzbek.
+// Characters that are not Cyrillic letters are kept as they are.
+func ToLatin(text string) string {
+	text = Normalize(text)
+
+	var out strings.Builder
+	for _, r := range text {
+		if letter, ok := cyrillicLetters[r]; ok {
+			out.WriteString(letter)
+		} else {
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
+
+// Variants returns the normalized text together with its Latin and Cyrillic
+// spellings, without duplicates.
+func Variants(text string) []string {
+	normalized := Normalize(strings.TrimSpace(text))
+	if normalized == "" {
+		return nil
+	}
+
+	variants := []string{normalized}
+	for _, v := range []string{ToLatin(normalized), ToCyrillic(normalized)} {
+		seen := false
+		for _, existing := range variants {
+			if existing == v {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			variants = append(variants, v)
+		}
+	}
+	return variants
+}
+
+func isLetter(r rune) bool {
+	if r == '\'' || (r >= 'a' && r <= 'z') {
+		return true
+	}
+	_, ok := cyrillicLetters[r]
+	return ok
+}
@@ -0,0 +1,122 @@
+// Package webhook holds the events partners can subscribe to and sends them
+// as signed HTTP requests.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"food/pkg/backoff"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Events partners can subscribe to.
+const (
+	EventOrderCreated       = "order.created"
+	EventOrderStatusChanged = "order.status_changed"
+	EventPaymentPaid        = "payment.paid"
+)
+
+// Events lists every event a subscription can name.
+var Events = []string{EventOrderCreated, EventOrderStatusChanged, EventPaymentPaid}
+
+// Headers of every request. SignatureHeader is "t=<unix time>,v1=<hex>",
+// where the hex is the HMAC-SHA256 of "<unix time>.<body>" keyed with the
+// subscription secret.
+const (
+	IdHeader        = "X-Webhook-Id"
+	EventHeader     = "X-Webhook-Event"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// Valid reports whether event is one of Events.
+func Valid(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is the body of a request. Id stays the same across retries so
+// receivers can drop duplicates.
+type Event struct {
+	Id        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt string          `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewSecret returns a random signing secret for a subscription.
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// Sign returns the signature header of a body sent at t.
+func Sign(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff is how long to wait before retrying a delivery that failed: a
+// minute doubling up to six hours.
+var Backoff = backoff.Policy{First: time.Minute, Max: 6 * time.Hour}
+
+// Client posts events to subscriber URLs.
+type Client struct {
+	http *http.Client
+}
+
+// NewClient returns a client that gives up on a request after timeout.
+func NewClient(timeout time.Duration) *Client {
+	return &Client{http: &http.Client{Timeout: timeout}}
+}
+
+// Send posts event to url signed with secret and returns the response
+// status. Any status outside 2xx is an error.
+func (c *Client) Send(ctx context.Context, url, secret string, event Event) (int, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "food-webhooks/1.0")
+	req.Header.Set(IdHeader, event.Id)
+	req.Header.Set(EventHeader, event.Type)
+	req.Header.Set(SignatureHeader, Sign(secret, time.Now(), body))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(snippet)))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
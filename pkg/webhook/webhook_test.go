@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// Computed with: printf '%s' '1700000000.{"id":"evt_1","type":"order.created"}' | openssl dgst -sha256 -hmac whsec_test
+	want := "t=1700000000,v1=b4299661e5387b192e6a17c961991dce11e3adb10aabc368d96f3b7a148387e3"
+
+	got := Sign("whsec_test", time.Unix(1700000000, 999), []byte(`{"id":"evt_1","type":"order.created"}`))
+	if got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		0:    time.Minute,
+		1:    time.Minute,
+		2:    2 * time.Minute,
+		3:    4 * time.Minute,
+		9:    256 * time.Minute,
+		10:   6 * time.Hour,
+		11:   6 * time.Hour,
+		1000: 6 * time.Hour,
+	} {
+		if got := Backoff.Wait(attempts); got != want {
+			t.Errorf("Backoff.Wait(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+// verify checks a signature header as a receiver would.
+func verify(header, secret string, body []byte) bool {
+	ts, sig, ok := strings.Cut(header, ",")
+	if !ok || !strings.HasPrefix(ts, "t=") || !strings.HasPrefix(sig, "v1=") {
+		return false
+	}
+	if _, err := strconv.ParseInt(ts[2:], 10, 64); err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts[2:] + "." + string(body)))
+	want := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(sig[3:]), []byte(want))
+}
+
+func TestClientSend(t *testing.T) {
+	event := Event{Id: "evt_1", Type: EventOrderCreated, CreatedAt: "2024-03-08T14:30:00+05:00", Data: json.RawMessage(`{"order_id":"42"}`)}
+
+	for name, tc := range map[string]struct {
+		status  int
+		wantErr bool
+	}{
+		"ok":       {status: http.StatusNoContent},
+		"rejected": {status: http.StatusGone, wantErr: true},
+		"down":     {status: http.StatusBadGateway, wantErr: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+					t.Errorf("request = %s %s, want POST application/json", r.Method, r.Header.Get("Content-Type"))
+				}
+				if r.Header.Get(IdHeader) != "evt_1" || r.Header.Get(EventHeader) != EventOrderCreated {
+					t.Errorf("%s = %q, %s = %q, want evt_1 and %s", IdHeader, r.Header.Get(IdHeader), EventHeader, r.Header.Get(EventHeader), EventOrderCreated)
+				}
+				if !verify(r.Header.Get(SignatureHeader), "whsec_test", body) {
+					t.Errorf("%s %q does not verify", SignatureHeader, r.Header.Get(SignatureHeader))
+				}
+				var got Event
+				if err := json.Unmarshal(body, &got); err != nil || got.Id != event.Id || string(got.Data) != string(event.Data) {
+					t.Errorf("body = %s, %v, want %+v", body, err, event)
+				}
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			status, err := NewClient(time.Second).Send(context.Background(), srv.URL, "whsec_test", event)
+			if status != tc.status || (err != nil) != tc.wantErr {
+				t.Errorf("Send() = %d, %v, want %d and error %v", status, err, tc.status, tc.wantErr)
+			}
+		})
+	}
+}
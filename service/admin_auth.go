@@ -7,6 +7,7 @@ import (
 	"food/api/models"
 	"food/config"
 	"food/pkg"
+	"food/pkg/i18n"
 	"food/pkg/jwt"
 	"food/pkg/logger"
 
@@ -64,12 +65,12 @@ func (a adminAuthService) AdminLogin(ctx context.Context, loginRequest models.Ad
 	}, nil
 }
 
-func (a adminAuthService) AdminRegister(ctx context.Context, loginRequest models.AdminRegisterRequest) error {
+func (a adminAuthService) AdminRegister(ctx context.Context, loginRequest models.AdminRegisterRequest, locale string) error {
 	fmt.Println(" loginRequest.Login: ", loginRequest.MobilePhone)
 
 	otpCode := pkg.GenerateOTP()
 
-	msg := fmt.Sprintf("iBron ilovasi ro‘yxatdan o‘tish uchun tasdiqlash kodi: %v", otpCode)
+	msg := i18n.Message(locale, i18n.MsgOtpSms, otpCode)
 
 	err := a.redis.SetX(ctx, loginRequest.MobilePhone, otpCode, time.Minute*2)
 	if err != nil {
@@ -95,7 +96,7 @@ func (a adminAuthService) AdminRegisterConfirm(ctx context.Context, req models.U
 	}
 	if req.Otp != otp {
 		a.log.Error("incorrect otp code for user register confirm", logger.Error(err))
-		return resp, errors.New("incorrect otp code")
+		return resp, ErrOtpIncorrect
 	}
 	req.User.Phone = req.MobilePhone
 	id, err := a.storage.User().Create(ctx, req.User)
@@ -126,15 +127,15 @@ func (a adminAuthService) AdminLoginByPhoneConfirm(ctx context.Context, req mode
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
 			a.log.Error("OTP code not found or expired", logger.Error(err))
-			return resp, errors.New("OTP kod topilmadi yoki muddati tugagan")
+			return resp, ErrOtpNotFound
 		}
 		a.log.Error("error while getting OTP code from redis", logger.Error(err))
-		return resp, errors.New("tizim xatosi yuz berdi")
+		return resp, err
 	}
 
 	if req.SmsCode != storedOTP {
 		a.log.Error("incorrect OTP code", logger.Error(errors.New("OTP code mismatch")))
-		return resp, errors.New("noto'g'ri OTP kod")
+		return resp, ErrOtpIncorrect
 	}
 
 	err = a.redis.Del(ctx, req.MobilePhone)
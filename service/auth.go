@@ -7,6 +7,7 @@ import (
 	"food/api/models"
 	"food/config"
 	"food/pkg"
+	"food/pkg/i18n"
 	"food/pkg/jwt"
 	"food/pkg/logger"
 
@@ -18,6 +19,13 @@ import (
 	"github.com/go-redis/redis"
 )
 
+// ErrOtpNotFound is returned when no OTP code was sent to the phone or it
+// has expired.
+var ErrOtpNotFound = errors.New("otp code not found or expired")
+
+// ErrOtpIncorrect is returned when the OTP code does not match.
+var ErrOtpIncorrect = errors.New("incorrect otp code")
+
 type authService struct {
 	storage storage.IStorage
 	log     logger.LoggerI
@@ -62,12 +70,13 @@ func (a authService) UserLogin(ctx context.Context, loginRequest models.UserLogi
 	}, nil
 }
 
-func (a authService) UserRegister(ctx context.Context, loginRequest models.UserRegisterRequest) error {
+// UserRegister sends the OTP code by SMS in the customer's locale.
+func (a authService) UserRegister(ctx context.Context, loginRequest models.UserRegisterRequest, locale string) error {
 	fmt.Println(" loginRequest.Login: ", loginRequest.MobilePhone)
 
 	otpCode := pkg.GenerateOTP()
 
-	msg := fmt.Sprintf("iBron ilovasi ro‘yxatdan o‘tish uchun tasdiqlash kodi: %v", otpCode)
+	msg := i18n.Message(locale, i18n.MsgOtpSms, otpCode)
 
 	err := a.redis.SetX(ctx, loginRequest.MobilePhone, otpCode, time.Minute*2)
 	if err != nil {
@@ -93,7 +102,7 @@ func (a authService) UserRegisterConfirm(ctx context.Context, req models.UserReg
 	}
 	if req.Otp != otp {
 		a.log.Error("incorrect otp code for user register confirm", logger.Error(err))
-		return resp, errors.New("incorrect otp code")
+		return resp, ErrOtpIncorrect
 	}
 	req.User.Phone = req.MobilePhone
 	id, err := a.storage.User().Create(ctx, req.User)
@@ -124,15 +133,15 @@ func (a authService) UserLoginByPhoneConfirm(ctx context.Context, req models.Use
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
 			a.log.Error("OTP code not found or expired", logger.Error(err))
-			return resp, errors.New("OTP kod topilmadi yoki muddati tugagan")
+			return resp, ErrOtpNotFound
 		}
 		a.log.Error("error while getting OTP code from redis", logger.Error(err))
-		return resp, errors.New("tizim xatosi yuz berdi")
+		return resp, err
 	}
 
 	if req.SmsCode != storedOTP {
 		a.log.Error("incorrect OTP code", logger.Error(errors.New("OTP code mismatch")))
-		return resp, errors.New("noto'g'ri OTP kod")
+		return resp, ErrOtpIncorrect
 	}
 
 	err = a.redis.Del(ctx, req.MobilePhone)
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"food/api/models"
+	"food/config"
+	"food/pkg"
+	"food/pkg/i18n"
+	"food/pkg/logger"
+	"food/pkg/receipt"
+	"food/pkg/smtp"
+	"food/storage"
+	"strconv"
+
+	"github.com/jackc/pgx/v4"
+)
+
+type courierService struct {
+	storage       storage.IStorage
+	log           logger.LoggerI
+	notifications notificationService
+}
+
+func NewCourierService(storage storage.IStorage, log logger.LoggerI, notifications notificationService) courierService {
+	return courierService{
+		storage:       storage,
+		log:           log,
+		notifications: notifications,
+	}
+}
+
+// Assign gives an order to a courier. The customer is told, and the admins
+// are alerted if the courier does not accept the job in time, by the jobs
+// queued with the assignment.
+func (c courierService) Assign(ctx context.Context, req *models.CourierAssignment) (*models.CourierAssignment, error) {
+	return c.storage.CourierAssignment().Create(ctx, req)
+}
+
+// Reconcile records the cash a courier handed in. Admins get an alert when
+// it is short of what the courier collected.
+func (c courierService) Reconcile(ctx context.Context, courierId string, req *models.ReconcileCashRequest) (*models.CourierReconciliation, error) {
+	return c.storage.CourierPay().Reconcile(ctx, courierId, req)
+}
+
+// ChangeStatus moves an assignment forward. Once the order is picked up the
+// customer gets the handover code by SMS, so they can give it to the courier
+// at the door, and they are notified when it is delivered.
+func (c courierService) ChangeStatus(ctx context.Context, req *models.CourierStatusChange) (*models.CourierAssignment, error) {
+	return c.storage.CourierAssignment().ChangeStatus(ctx, req)
+}
+
+func (c courierService) sendHandoverCode(ctx context.Context, orderId string) error {
+	phone, code, err := c.storage.Order().HandoverCode(ctx, orderId)
+	if err != nil {
+		return fmt.Errorf("failed to get handover code: %w", err)
+	}
+	if code == "" {
+		return nil
+	}
+
+	return pkg.SendSms(phone, i18n.Message(i18n.Default, i18n.MsgHandoverSms, code))
+}
+
+// alertCashShortage tells the admins a reconciliation came up short.
+func (c courierService) alertCashShortage(ctx context.Context, reconciliation *models.CourierReconciliation) error {
+	return c.notifications.AlertAdmins(ctx, smtp.AlertEmail{
+		Title: "Courier cash shortage of " + receipt.Money(-reconciliation.Difference),
+		Details: []string{
+			"Courier: " + reconciliation.CourierId,
+			"Expected: " + receipt.Money(reconciliation.Expected),
+			"Received: " + receipt.Money(reconciliation.Received),
+			"Orders: " + strconv.Itoa(reconciliation.Orders),
+			"Reconciliation: " + reconciliation.Id,
+		},
+	})
+}
+
+// checkAccepted alerts the admins when a courier has not accepted the job
+// config.CourierAcceptMinutes after it was assigned, so they can reassign
+// the order. Deleted assignments are left alone.
+func (c courierService) checkAccepted(ctx context.Context, assignmentId string) error {
+	assignment, err := c.storage.CourierAssignment().GetByID(ctx, assignmentId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if assignment.AcceptedAt != "" || assignment.Status != "assigned" {
+		return nil
+	}
+
+	return c.notifications.AlertAdmins(ctx, smtp.AlertEmail{
+		Title: "Order #" + receipt.OrderNumber(assignment.OrderId) + " is not accepted by its courier",
+		Details: []string{
+			"Courier: " + assignment.CourierId,
+			"Assigned: " + assignment.AssignedAt,
+			"Waiting: over " + strconv.Itoa(config.CourierAcceptMinutes) + " minutes",
+			"Assignment: " + assignment.Id,
+		},
+	})
+}
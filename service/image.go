@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"food/api/models"
+	"food/config"
+	"food/pkg/blob"
+	"food/pkg/imageproc"
+	"food/pkg/logger"
+	"food/storage"
+
+	"github.com/google/uuid"
+)
+
+type imageService struct {
+	storage storage.IStorage
+	log     logger.LoggerI
+	blob    blob.BlobStore
+}
+
+func NewImageService(storage storage.IStorage, log logger.LoggerI, blobs blob.BlobStore) imageService {
+	return imageService{
+		storage: storage,
+		log:     log,
+		blob:    blobs,
+	}
+}
+
+// Upload stores the renditions of processed photos and adds the images, in
+// order, to the end of the product or combo gallery. Either every photo is
+// added or, when one cannot be, none: the images added before it are removed
+// again. Exactly one of productId and comboId must be set.
+func (i imageService) Upload(ctx context.Context, productId, comboId string, photos [][]imageproc.Rendition) ([]models.ProductImage, error) {
+	images := make([]models.ProductImage, 0, len(photos))
+	for _, renditions := range photos {
+		image, err := i.upload(ctx, productId, comboId, renditions)
+		if err != nil {
+			// Clean up even when the client is gone.
+			cleanupCtx := context.WithoutCancel(ctx)
+			for _, added := range images {
+				if err := i.Delete(cleanupCtx, added.Id); err != nil {
+					i.log.Error("error while removing uploaded image "+added.Id, logger.Error(err))
+				}
+			}
+			return nil, err
+		}
+		images = append(images, *image)
+	}
+
+	return images, nil
+}
+
+// upload stores every rendition of one photo, in both formats, and adds it
+// to the gallery.
+func (i imageService) upload(ctx context.Context, productId, comboId string, renditions []imageproc.Rendition) (*models.ProductImage, error) {
+	image := &models.ProductImage{
+		Id:        uuid.New().String(),
+		ProductId: productId,
+		ComboId:   comboId,
+	}
+
+	var uploaded []string
+	for _, rendition := range renditions {
+		name := imageObjectName(image.Id, rendition.Name, "jpg")
+		url, err := i.blob.Put(ctx, name, imageproc.ContentType, rendition.Data)
+		if err != nil {
+			i.log.Error("error while uploading image rendition", logger.Error(err))
+			i.deleteObjects(context.WithoutCancel(ctx), uploaded)
+			return nil, err
+		}
+		uploaded = append(uploaded, name)
+
+		webpName := imageObjectName(image.Id, rendition.Name, "webp")
+		webpURL, err := i.blob.Put(ctx, webpName, imageproc.WebPContentType, rendition.WebP)
+		if err != nil {
+			i.log.Error("error while uploading image rendition", logger.Error(err))
+			i.deleteObjects(context.WithoutCancel(ctx), uploaded)
+			return nil, err
+		}
+		uploaded = append(uploaded, webpName)
+
+		switch rendition.Name {
+		case "thumb":
+			image.ThumbURL, image.ThumbWebPURL = url, webpURL
+		case "card":
+			image.CardURL, image.CardWebPURL = url, webpURL
+		case "full":
+			image.FullURL, image.FullWebPURL = url, webpURL
+			image.Width = rendition.Width
+			image.Height = rendition.Height
+		}
+	}
+
+	created, err := i.storage.Image().Create(ctx, image, config.MaxGalleryImages)
+	if err != nil {
+		i.log.Error("error while saving image", logger.Error(err))
+		i.deleteObjects(context.WithoutCancel(ctx), uploaded)
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// Delete removes the image from its gallery and deletes its files. Failing
+// to delete a file only leaves an orphan in the bucket, so it is logged.
+func (i imageService) Delete(ctx context.Context, id string) error {
+	image, err := i.storage.Image().Delete(ctx, id)
+	if err != nil {
+		i.log.Error("error while deleting image", logger.Error(err))
+		return err
+	}
+
+	// Images uploaded before WebP renditions existed have no .webp files,
+	// which deleteObjects ignores.
+	var names []string
+	for _, size := range imageproc.Sizes {
+		names = append(names, imageObjectName(image.Id, size.Name, "jpg"), imageObjectName(image.Id, size.Name, "webp"))
+	}
+	i.deleteObjects(ctx, names)
+
+	return nil
+}
+
+func (i imageService) deleteObjects(ctx context.Context, names []string) {
+	for _, name := range names {
+		if err := i.blob.Delete(ctx, name); err != nil && !errors.Is(err, blob.ErrNotFound) {
+			i.log.Error("error while deleting image file "+name, logger.Error(err))
+		}
+	}
+}
+
+func imageObjectName(imageId, size, ext string) string {
+	return fmt.Sprintf("images/%s/%s.%s", imageId, size, ext)
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"food/api/models"
+	"food/pkg/jobs"
+	"food/pkg/logger"
+	"food/storage"
+	"sync"
+	"time"
+)
+
+// jobTimeout is how long a job may run, and jobOutcomeTimeout how long
+// recording its outcome may take after that. The lease is longer than both,
+// so a job is only claimed again when its worker died.
+const (
+	jobTimeout        = 2 * time.Minute
+	jobOutcomeTimeout = 10 * time.Second
+	jobLease          = jobTimeout + time.Minute
+)
+
+type jobService struct {
+	storage  storage.IStorage
+	log      logger.LoggerI
+	handlers map[string]jobs.Handler
+	types    []string
+}
+
+func NewJobService(storage storage.IStorage, log logger.LoggerI, scheduler schedulerService, notifications notificationService, courier courierService) jobService {
+	j := jobService{
+		storage: storage,
+		log:     log,
+		handlers: map[string]jobs.Handler{
+			jobs.TypeOrderNotification: jobs.Typed(func(ctx context.Context, p jobs.OrderNotification) error {
+				return notifications.OrderEvent(ctx, p.OrderId, p.Event)
+			}),
+			jobs.TypeHandoverCode: jobs.Typed(func(ctx context.Context, p jobs.Order) error {
+				return courier.sendHandoverCode(ctx, p.OrderId)
+			}),
+			jobs.TypeCourierAcceptTimeout: jobs.Typed(func(ctx context.Context, p jobs.Assignment) error {
+				return courier.checkAccepted(ctx, p.AssignmentId)
+			}),
+			jobs.TypeCashShortage: jobs.Typed(func(ctx context.Context, p models.CourierReconciliation) error {
+				return courier.alertCashShortage(ctx, &p)
+			}),
+			jobs.TypeReleaseScheduledOrders: func(ctx context.Context, _ json.RawMessage) error {
+				return scheduler.ReleaseScheduledOrders(ctx)
+			},
+			jobs.TypeApplyScheduledPrices: func(ctx context.Context, _ json.RawMessage) error {
+				return scheduler.ApplyScheduledPrices(ctx)
+			},
+			jobs.TypePruneJobs: func(ctx context.Context, _ json.RawMessage) error {
+				return scheduler.PruneJobs(ctx)
+			},
+		},
+	}
+	for typ := range j.handlers {
+		j.types = append(j.types, typ)
+	}
+	return j
+}
+
+// Run runs jobs on the given number of workers, each looking for a due job
+// every poll while it has none, until ctx is done. Jobs already running are
+// finished before Run returns, so the caller can wait for it on shutdown.
+func (j jobService) Run(ctx context.Context, workers int, poll time.Duration) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			j.work(ctx, poll)
+		}()
+	}
+	wg.Wait()
+}
+
+func (j jobService) work(ctx context.Context, poll time.Duration) {
+	for ctx.Err() == nil {
+		if j.RunNext(ctx) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(poll):
+		}
+	}
+}
+
+// RunNext claims one due job and runs it. It reports whether there was one.
+// The job keeps running when ctx is cancelled, up to jobTimeout, and its
+// outcome is still recorded, with a context of its own so a job that used
+// all of jobTimeout is not left running until its lease runs out.
+func (j jobService) RunNext(ctx context.Context) bool {
+	job, err := j.storage.Job().Claim(ctx, j.types, jobLease)
+	if err != nil {
+		if ctx.Err() == nil {
+			j.log.Error("error while claiming job", logger.Error(err))
+		}
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	runCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jobTimeout)
+	defer cancel()
+
+	err = j.run(runCtx, job)
+
+	outcomeCtx, cancelOutcome := context.WithTimeout(context.WithoutCancel(ctx), jobOutcomeTimeout)
+	defer cancelOutcome()
+
+	switch {
+	case err == nil:
+		err = j.storage.Job().Complete(outcomeCtx, job)
+	case errors.Is(err, jobs.ErrPermanent) || job.Attempts >= job.MaxAttempts:
+		j.log.Error("job "+job.Type+" failed", logger.String("job_id", job.Id), logger.Error(err))
+		err = j.storage.Job().Fail(outcomeCtx, job, err.Error())
+	default:
+		j.log.Warn("job "+job.Type+" will be retried", logger.String("job_id", job.Id), logger.Error(err))
+		err = j.storage.Job().Retry(outcomeCtx, job, err.Error(), time.Now().Add(jobs.Backoff.Wait(job.Attempts)))
+	}
+	switch {
+	case errors.Is(err, storage.ErrLeaseLost):
+		j.log.Warn("job "+job.Type+" outlived its lease, its outcome is not recorded", logger.String("job_id", job.Id))
+	case err != nil:
+		j.log.Error("error while recording job outcome", logger.String("job_id", job.Id), logger.Error(err))
+	}
+	return true
+}
+
+// run calls the handler of the job, turning a panic into an error.
+func (j jobService) run(ctx context.Context, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return j.handlers[job.Type](ctx, job.Payload)
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"food/pkg/logger"
+	"food/storage"
+	"sync"
+)
+
+type kitchenService struct {
+	storage storage.IStorage
+	log     logger.LoggerI
+	hub     *kitchenHub
+}
+
+func NewKitchenService(storage storage.IStorage, log logger.LoggerI) kitchenService {
+	return kitchenService{
+		storage: storage,
+		log:     log,
+		hub:     &kitchenHub{subscribers: make(map[string]map[chan struct{}]struct{})},
+	}
+}
+
+// kitchenHub wakes up the live kitchen screens of a branch whenever one of
+// its orders changes on this instance.
+type kitchenHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]struct{}
+}
+
+func (k kitchenService) BumpOrder(ctx context.Context, branchId, orderId, status string) error {
+	err := k.storage.Kitchen().UpdateOrderStatus(ctx, branchId, orderId, status)
+	if err != nil {
+		k.log.Error("error while bumping kitchen order", logger.Error(err))
+		return err
+	}
+
+	k.Notify(branchId)
+	return nil
+}
+
+func (k kitchenService) BumpItem(ctx context.Context, branchId, itemId, status string) error {
+	err := k.storage.Kitchen().UpdateItemStatus(ctx, branchId, itemId, status)
+	if err != nil {
+		k.log.Error("error while bumping kitchen item", logger.Error(err))
+		return err
+	}
+
+	k.Notify(branchId)
+	return nil
+}
+
+// OrderChanged wakes up the kitchen screens of the branch of an order whose
+// status was changed outside the kitchen, such as an admin confirming it.
+func (k kitchenService) OrderChanged(ctx context.Context, orderId string) {
+	order, err := k.storage.Order().GetOrder(ctx, orderId)
+	if err != nil {
+		k.log.Error("error while getting changed kitchen order", logger.Error(err))
+		return
+	}
+	if order.Order.BranchId != "" {
+		k.Notify(order.Order.BranchId)
+	}
+}
+
+// Subscribe returns a channel that receives a signal after every change to
+// the branch queue, and a function that must be called to stop listening.
+func (k kitchenService) Subscribe(branchId string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	k.hub.mu.Lock()
+	if k.hub.subscribers[branchId] == nil {
+		k.hub.subscribers[branchId] = make(map[chan struct{}]struct{})
+	}
+	k.hub.subscribers[branchId][ch] = struct{}{}
+	k.hub.mu.Unlock()
+
+	return ch, func() {
+		k.hub.mu.Lock()
+		delete(k.hub.subscribers[branchId], ch)
+		if len(k.hub.subscribers[branchId]) == 0 {
+			delete(k.hub.subscribers, branchId)
+		}
+		k.hub.mu.Unlock()
+	}
+}
+
+func (k kitchenService) Notify(branchId string) {
+	k.hub.mu.Lock()
+	defer k.hub.mu.Unlock()
+
+	for ch := range k.hub.subscribers[branchId] {
+		// A pending signal already makes the screen reload, so never block.
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
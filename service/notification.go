@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"food/api/models"
+	"food/config"
+	"food/pkg"
+	"food/pkg/i18n"
+	"food/pkg/logger"
+	"food/pkg/notify"
+	"food/pkg/push"
+	"food/pkg/receipt"
+	"food/pkg/smtp"
+	"food/storage"
+	"time"
+)
+
+// notificationLease is how long a delivery being sent is hidden from the
+// retry worker.
+const notificationLease = 5 * time.Minute
+
+type notificationService struct {
+	storage storage.IStorage
+	log     logger.LoggerI
+	mailer  smtp.Mailer
+	alertTo []string
+	senders map[string]notify.Sender
+}
+
+func NewNotificationService(storage storage.IStorage, log logger.LoggerI, pusher push.Sender, mailer smtp.Mailer, alertTo []string) notificationService {
+	n := notificationService{
+		storage: storage,
+		log:     log,
+		mailer:  mailer,
+		alertTo: alertTo,
+		senders: map[string]notify.Sender{
+			notify.SMS: notify.SenderFunc(func(ctx context.Context, msg notify.Message) error {
+				return pkg.SendSms(msg.To, msg.Body)
+			}),
+			notify.Email: notify.SenderFunc(func(ctx context.Context, msg notify.Message) error {
+				email, err := smtp.Render(smtp.TemplateNotification, smtp.NotificationEmail{Title: msg.Title, Body: msg.Body})
+				if err != nil {
+					return err
+				}
+				email.To = []string{msg.To}
+				return mailer.Send(ctx, email)
+			}),
+		},
+	}
+	if pusher != nil {
+		n.senders[notify.Push] = pushSender{storage: storage, log: log, pusher: pusher}
+	}
+	return n
+}
+
+// pushSender sends a notification to every device of the user and removes
+// the tokens the push service no longer accepts.
+type pushSender struct {
+	storage storage.IStorage
+	log     logger.LoggerI
+	pusher  push.Sender
+}
+
+// Send succeeds when at least one device got the message. A user without
+// valid devices is an invalid recipient, so the delivery is not retried.
+func (p pushSender) Send(ctx context.Context, msg notify.Message) error {
+	devices, err := p.storage.DeviceToken().GetByUser(ctx, msg.UserId)
+	if err != nil {
+		return err
+	}
+
+	var (
+		sent    int
+		invalid []string
+		lastErr error
+	)
+	for _, device := range devices.Devices {
+		err := p.pusher.Send(ctx, device.Token, push.Message{Title: msg.Title, Body: msg.Body, Data: msg.Data})
+		switch {
+		case err == nil:
+			sent++
+		case errors.Is(err, push.ErrInvalidToken):
+			invalid = append(invalid, device.Token)
+		default:
+			lastErr = err
+		}
+	}
+
+	if err := p.storage.DeviceToken().Prune(ctx, invalid); err != nil {
+		p.log.Error("error while pruning device tokens", logger.Error(err))
+	}
+	if len(invalid) > 0 {
+		p.log.Info("invalid device tokens pruned", logger.Int("count", len(invalid)))
+	}
+
+	switch {
+	case sent > 0:
+		return nil
+	case lastErr != nil:
+		return lastErr
+	default:
+		return fmt.Errorf("%w: no registered devices", notify.ErrInvalidRecipient)
+	}
+}
+
+// Dispatch renders the event in the user's language, stores it in their
+// in-app inbox and sends it on every other channel they enabled. Failed
+// sends are left pending for Run to retry.
+func (n notificationService) Dispatch(ctx context.Context, event *models.NotificationEvent) (*models.Notification, error) {
+	var (
+		recipient *models.NotificationRecipient
+		err       error
+	)
+	if event.UserId != "" {
+		recipient, err = n.storage.Notification().Recipient(ctx, event.UserId)
+	} else {
+		recipient, err = n.storage.Notification().OrderRecipient(ctx, event.OrderId)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := recipient.Preferences
+	var deliveries []models.NotificationDelivery
+	if prefs.Sms && recipient.Phone != "" {
+		deliveries = append(deliveries, models.NotificationDelivery{Channel: notify.SMS, Recipient: recipient.Phone})
+	}
+	if prefs.Email && recipient.Email != "" {
+		deliveries = append(deliveries, models.NotificationDelivery{Channel: notify.Email, Recipient: recipient.Email})
+	}
+	if prefs.Push {
+		deliveries = append(deliveries, models.NotificationDelivery{Channel: notify.Push})
+	}
+
+	notification, deliveries, err := n.storage.Notification().Dispatch(ctx, &models.Notification{
+		UserId:  recipient.UserId,
+		Event:   event.Event,
+		OrderId: event.OrderId,
+		Title:   i18n.Message(prefs.Locale, event.Event+"_title", event.Args...),
+		Message: i18n.Message(prefs.Locale, event.Event+"_body", event.Args...),
+	}, deliveries, notificationLease)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range deliveries {
+		n.deliver(ctx, &deliveries[i])
+	}
+	return notification, nil
+}
+
+// OrderEvent notifies the customer of an order. The order is referred to by
+// its number on the receipt. It runs as a job, so it is retried when the
+// notification could not be stored.
+func (n notificationService) OrderEvent(ctx context.Context, orderId, event string) error {
+	number := receipt.OrderNumber(orderId)
+	_, err := n.Dispatch(ctx, &models.NotificationEvent{
+		Event:   event,
+		OrderId: orderId,
+		Args:    []interface{}{number},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to dispatch %s notification: %w", event, err)
+	}
+	return nil
+}
+
+// AlertAdmins emails an alert to the admins listed in ADMIN_ALERT_EMAILS.
+// Without any it only logs the alert.
+func (n notificationService) AlertAdmins(ctx context.Context, alert smtp.AlertEmail) error {
+	n.log.Info("admin alert: " + alert.Title)
+	if len(n.alertTo) == 0 {
+		return nil
+	}
+
+	email, err := smtp.Render(smtp.TemplateAlert, alert)
+	if err != nil {
+		return fmt.Errorf("failed to render admin alert: %w", err)
+	}
+	email.To = n.alertTo
+	if err := n.mailer.Send(ctx, email); err != nil {
+		return fmt.Errorf("failed to send admin alert: %w", err)
+	}
+	return nil
+}
+
+// Run retries the pending deliveries every interval until ctx is done.
+func (n notificationService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n.RetryDeliveries(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (n notificationService) RetryDeliveries(ctx context.Context) {
+	deliveries, err := n.storage.Notification().ClaimDueDeliveries(ctx, 100, notificationLease)
+	if err != nil {
+		n.log.Error("error while claiming notification deliveries", logger.Error(err))
+		return
+	}
+
+	for i := range deliveries {
+		n.deliver(ctx, &deliveries[i])
+	}
+	if len(deliveries) > 0 {
+		n.log.Info("notification deliveries retried", logger.Int("count", len(deliveries)))
+	}
+}
+
+// deliver sends one delivery and records the outcome. A failed send is
+// retried with backoff until MaxNotificationAttempts; channels without a
+// sender are skipped.
+func (n notificationService) deliver(ctx context.Context, d *models.NotificationDelivery) {
+	next := time.Now()
+	sender, ok := n.senders[d.Channel]
+	if !ok {
+		d.Status = "skipped"
+		d.LastError = d.Channel + " is not configured"
+	} else {
+		err := sender.Send(ctx, notify.Message{
+			UserId: d.UserId,
+			To:     d.Recipient,
+			Title:  d.Title,
+			Body:   d.Message,
+			Data:   map[string]string{"notification_id": d.NotificationId, "order_id": d.OrderId},
+		})
+		d.Attempts++
+		switch {
+		case err == nil:
+			d.Status = "sent"
+			d.LastError = ""
+		case errors.Is(err, notify.ErrInvalidRecipient) || d.Attempts >= config.MaxNotificationAttempts:
+			d.Status = "failed"
+			d.LastError = err.Error()
+		default:
+			d.Status = "pending"
+			d.LastError = err.Error()
+			next = next.Add(notify.Backoff.Wait(d.Attempts))
+		}
+	}
+
+	if err := n.storage.Notification().UpdateDelivery(ctx, d, next); err != nil {
+		n.log.Error("error while updating notification delivery", logger.Error(err))
+	}
+}
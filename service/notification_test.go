@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"food/api/models"
+	"food/pkg/logger"
+	"food/pkg/notify"
+	"food/pkg/push"
+	"food/storage"
+	"reflect"
+	"testing"
+)
+
+// deviceStorage serves the devices of one user and records pruned tokens.
+// Anything else it is asked for panics.
+type deviceStorage struct {
+	storage.IStorage
+	storage.IDeviceTokenStorage
+
+	devices []models.DeviceToken
+	pruned  []string
+}
+
+func (s *deviceStorage) DeviceToken() storage.IDeviceTokenStorage { return s }
+
+func (s *deviceStorage) GetByUser(ctx context.Context, userId string) (*models.GetDeviceTokensResponse, error) {
+	return &models.GetDeviceTokensResponse{Devices: s.devices}, nil
+}
+
+func (s *deviceStorage) Prune(ctx context.Context, tokens []string) error {
+	s.pruned = append(s.pruned, tokens...)
+	return nil
+}
+
+func TestPushSender(t *testing.T) {
+	msg := notify.Message{UserId: "user-1", Title: "Buyurtma", Body: "Yetkazildi", Data: map[string]string{"order_id": "42"}}
+	pushed := push.Message{Title: "Buyurtma", Body: "Yetkazildi", Data: map[string]string{"order_id": "42"}}
+
+	for name, tc := range map[string]struct {
+		tokens      []string
+		invalid     []string
+		wantSent    []push.Sent
+		wantInvalid bool
+	}{
+		"all devices": {
+			tokens:   []string{"phone", "tablet"},
+			wantSent: []push.Sent{{Token: "phone", Message: pushed}, {Token: "tablet", Message: pushed}},
+		},
+		"some uninstalled": {
+			tokens:   []string{"phone", "old-phone"},
+			invalid:  []string{"old-phone"},
+			wantSent: []push.Sent{{Token: "phone", Message: pushed}},
+		},
+		"all uninstalled": {
+			tokens:      []string{"old-phone"},
+			invalid:     []string{"old-phone"},
+			wantInvalid: true,
+		},
+		"no devices": {
+			wantInvalid: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			store := &deviceStorage{}
+			for _, token := range tc.tokens {
+				store.devices = append(store.devices, models.DeviceToken{UserId: msg.UserId, Token: token})
+			}
+			fake := push.NewFake()
+			for _, token := range tc.invalid {
+				fake.Invalidate(token)
+			}
+
+			sender := pushSender{storage: store, log: logger.NewLogger("test", logger.LevelError), pusher: fake}
+			err := sender.Send(context.Background(), msg)
+			if got := errors.Is(err, notify.ErrInvalidRecipient); got != tc.wantInvalid || (err != nil && !got) {
+				t.Errorf("Send() = %v, want invalid recipient %v", err, tc.wantInvalid)
+			}
+			if got := fake.Sent(); !reflect.DeepEqual(got, tc.wantSent) {
+				t.Errorf("sent %+v, want %+v", got, tc.wantSent)
+			}
+			if !reflect.DeepEqual(store.pruned, tc.invalid) {
+				t.Errorf("pruned %v, want %v", store.pruned, tc.invalid)
+			}
+		})
+	}
+}
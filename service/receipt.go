@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"food/api/models"
+	"food/pkg/logger"
+	"food/pkg/receipt"
+	"food/pkg/smtp"
+	"food/storage"
+	"time"
+)
+
+// ErrNoEmail is returned when the customer of an order has no email address.
+var ErrNoEmail = errors.New("customer has no email address")
+
+type receiptService struct {
+	storage storage.IStorage
+	log     logger.LoggerI
+	mailer  smtp.Mailer
+}
+
+func NewReceiptService(storage storage.IStorage, log logger.LoggerI, mailer smtp.Mailer) receiptService {
+	return receiptService{
+		storage: storage,
+		log:     log,
+		mailer:  mailer,
+	}
+}
+
+// Build collects everything printed on a receipt or kitchen ticket from the
+// order, its branch, the ordered products and combos and the order payment.
+func (r receiptService) Build(ctx context.Context, orderId string) (*models.Receipt, error) {
+	order, err := r.storage.Order().GetOrder(ctx, orderId)
+	if err != nil {
+		r.log.Error("error while getting order for receipt", logger.Error(err))
+		return nil, err
+	}
+
+	receipt := &models.Receipt{
+		OrderId:        order.Order.Id,
+		Status:         order.Order.Status,
+		DeliveryStatus: order.Order.DeliveryStatus,
+		AddressName:    order.Order.AddressName,
+		ScheduledAt:    order.Order.ScheduledAt,
+		CreatedAt:      order.Order.CreatedAt,
+		Total:          order.Order.TotalPrice,
+	}
+
+	if order.Order.BranchId != "" {
+		branch, err := r.storage.Branch().GetByID(ctx, order.Order.BranchId)
+		if err != nil {
+			r.log.Error("error while getting branch for receipt", logger.Error(err))
+			return nil, err
+		}
+		receipt.BranchName = branch.Name
+		receipt.BranchAddress = branch.Address
+	}
+
+	names := make(map[string]string)
+	for _, item := range order.Items {
+		id := item.ProductId + item.ComboId
+		name, ok := names[id]
+		if !ok && item.ComboId != "" {
+			combo, err := r.storage.Combo().GetCombo(ctx, item.ComboId)
+			if err != nil {
+				r.log.Error("error while getting combo for receipt", logger.Error(err))
+				return nil, err
+			}
+			name = combo.Combo.Name
+			names[id] = name
+		} else if !ok {
+			product, err := r.storage.Product().GetByID(ctx, item.ProductId)
+			if err != nil {
+				r.log.Error("error while getting product for receipt", logger.Error(err))
+				return nil, err
+			}
+			name = product.Name
+			names[id] = name
+		}
+
+		receipt.Items = append(receipt.Items, models.ReceiptItem{
+			Name:      name,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+			Total:     item.TotalPrice,
+			Modifiers: item.Modifiers,
+		})
+	}
+
+	payment, err := r.storage.Payment().GetByOrderID(ctx, orderId)
+	if err != nil {
+		r.log.Error("error while getting payment for receipt", logger.Error(err))
+		return nil, err
+	}
+	if payment != nil {
+		receipt.PaymentMethod = payment.PaymentMethod
+		receipt.IsPaid = payment.IsPaid
+	}
+
+	return receipt, nil
+}
+
+// Email sends the receipt to the customer's email address, with the PDF
+// receipt attached, and returns the address.
+func (r receiptService) Email(ctx context.Context, orderId string) (string, error) {
+	data, err := r.Build(ctx, orderId)
+	if err != nil {
+		return "", err
+	}
+
+	recipient, err := r.storage.Notification().OrderRecipient(ctx, orderId)
+	if err != nil {
+		r.log.Error("error while getting customer for receipt email", logger.Error(err))
+		return "", err
+	}
+	if recipient.Email == "" {
+		return "", ErrNoEmail
+	}
+
+	number := receipt.OrderNumber(data.OrderId)
+	createdAt := data.CreatedAt
+	if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
+		createdAt = t.Format("02.01.2006 15:04")
+	}
+	email := smtp.ReceiptEmail{
+		OrderNumber:   number,
+		BranchName:    data.BranchName,
+		CreatedAt:     createdAt,
+		Total:         receipt.Money(data.Total),
+		PaymentMethod: data.PaymentMethod,
+		IsPaid:        data.IsPaid,
+	}
+	for _, item := range data.Items {
+		email.Items = append(email.Items, smtp.ReceiptEmailItem{
+			Name:     item.Name,
+			Quantity: item.Quantity,
+			Total:    receipt.Money(item.Total),
+		})
+	}
+
+	msg, err := smtp.Render(smtp.TemplateReceipt, email)
+	if err != nil {
+		return "", err
+	}
+	msg.To = []string{recipient.Email}
+	msg.Attachments = []smtp.Attachment{{
+		Filename:    "receipt-" + number + ".pdf",
+		ContentType: "application/pdf",
+		Data:        receipt.PDF(data),
+	}}
+
+	if err := r.mailer.Send(ctx, msg); err != nil {
+		r.log.Error("error while sending receipt email", logger.Error(err))
+		return "", err
+	}
+	return recipient.Email, nil
+}
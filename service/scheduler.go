@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"food/api/models"
+	"food/pkg/jobs"
+	"food/pkg/logger"
+	"food/storage"
+	"time"
+)
+
+// jobRetention is how long finished jobs are kept.
+const jobRetention = 7 * 24 * time.Hour
+
+type schedulerService struct {
+	storage storage.IStorage
+	log     logger.LoggerI
+	kitchen kitchenService
+}
+
+func NewSchedulerService(storage storage.IStorage, log logger.LoggerI, kitchen kitchenService) schedulerService {
+	return schedulerService{
+		storage: storage,
+		log:     log,
+		kitchen: kitchen,
+	}
+}
+
+// Run queues the periodic jobs every interval until ctx is done: releasing
+// due pre-orders to the kitchen, applying scheduled price changes and, once
+// an hour, pruning old jobs. Their unique keys keep one of each in the queue
+// however many servers run.
+func (s schedulerService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		for _, job := range []models.EnqueueJob{
+			{Type: jobs.TypeReleaseScheduledOrders, UniqueKey: jobs.TypeReleaseScheduledOrders},
+			{Type: jobs.TypeApplyScheduledPrices, UniqueKey: jobs.TypeApplyScheduledPrices},
+			{Type: jobs.TypePruneJobs, UniqueKey: jobs.TypePruneJobs, RunAt: now.Truncate(time.Hour).Add(time.Hour)},
+		} {
+			if _, err := s.storage.Job().Enqueue(ctx, &job); err != nil {
+				s.log.Error("error while queuing "+job.Type+" job", logger.Error(err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s schedulerService) ReleaseScheduledOrders(ctx context.Context) error {
+	branches, err := s.storage.Order().ReleaseScheduled(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(branches) > 0 {
+		s.log.Info("scheduled orders released to the kitchen", logger.Int("count", len(branches)))
+	}
+	for _, branchId := range branches {
+		s.kitchen.Notify(branchId)
+	}
+	return nil
+}
+
+func (s schedulerService) ApplyScheduledPrices(ctx context.Context) error {
+	changed, err := s.storage.Product().ApplyDuePrices(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if changed > 0 {
+		s.log.Info("scheduled prices applied", logger.Int("count", int(changed)))
+	}
+	return nil
+}
+
+func (s schedulerService) PruneJobs(ctx context.Context) error {
+	pruned, err := s.storage.Job().Prune(ctx, time.Now().Add(-jobRetention))
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		s.log.Info("finished jobs pruned", logger.Int("count", int(pruned)))
+	}
+	return nil
+}
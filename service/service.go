@@ -1,26 +1,57 @@
 package service
 
 import (
+	"food/pkg/blob"
 	"food/pkg/logger"
+	"food/pkg/push"
+	"food/pkg/smtp"
 	"food/storage"
 )
 
 type IServiceManager interface {
 	Auth() authService
 	AdminAuth() adminAuthService
+	Scheduler() schedulerService
+	Kitchen() kitchenService
+	Receipt() receiptService
+	Image() imageService
+	Courier() courierService
+	Notification() notificationService
+	Webhook() webhookService
+	Job() jobService
 }
 
 type Service struct {
-	auth      authService
-	logger    logger.LoggerI
-	adminAuth adminAuthService
+	auth         authService
+	logger       logger.LoggerI
+	adminAuth    adminAuthService
+	scheduler    schedulerService
+	kitchen      kitchenService
+	receipt      receiptService
+	image        imageService
+	courier      courierService
+	notification notificationService
+	webhook      webhookService
+	job          jobService
 }
 
-func New(storage storage.IStorage, log logger.LoggerI, redis storage.IRedisStorage) Service {
+func New(storage storage.IStorage, log logger.LoggerI, redis storage.IRedisStorage, blobs blob.BlobStore, pusher push.Sender, mailer smtp.Mailer, alertTo []string) Service {
+	notification := NewNotificationService(storage, log, pusher, mailer, alertTo)
+	kitchen := NewKitchenService(storage, log)
+	scheduler := NewSchedulerService(storage, log, kitchen)
+	courier := NewCourierService(storage, log, notification)
 	return Service{
-		auth:      NewAuthService(storage, log, redis),
-		adminAuth: NewAuthAdminService(storage, log, redis),
-		logger:    log,
+		auth:         NewAuthService(storage, log, redis),
+		adminAuth:    NewAuthAdminService(storage, log, redis),
+		scheduler:    scheduler,
+		kitchen:      kitchen,
+		receipt:      NewReceiptService(storage, log, mailer),
+		image:        NewImageService(storage, log, blobs),
+		courier:      courier,
+		notification: notification,
+		webhook:      NewWebhookService(storage, log),
+		job:          NewJobService(storage, log, scheduler, notification, courier),
+		logger:       log,
 	}
 }
 
@@ -31,3 +62,35 @@ func (s Service) Auth() authService {
 func (s Service) AdminAuth() adminAuthService {
 	return s.adminAuth
 }
+
+func (s Service) Scheduler() schedulerService {
+	return s.scheduler
+}
+
+func (s Service) Kitchen() kitchenService {
+	return s.kitchen
+}
+
+func (s Service) Receipt() receiptService {
+	return s.receipt
+}
+
+func (s Service) Image() imageService {
+	return s.image
+}
+
+func (s Service) Courier() courierService {
+	return s.courier
+}
+
+func (s Service) Notification() notificationService {
+	return s.notification
+}
+
+func (s Service) Webhook() webhookService {
+	return s.webhook
+}
+
+func (s Service) Job() jobService {
+	return s.job
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"food/api/models"
+	"food/config"
+	"food/pkg/logger"
+	"food/pkg/webhook"
+	"food/storage"
+	"time"
+)
+
+const (
+	// webhookTimeout bounds a single send.
+	webhookTimeout = 15 * time.Second
+
+	// webhookLease is how long a claimed delivery is hidden from other
+	// workers.
+	webhookLease = 2 * time.Minute
+
+	// webhookBatch deliveries are claimed at a time. They are sent one
+	// after the other, so even if every send times out the last one ends
+	// well inside the lease.
+	webhookBatch = int(webhookLease/webhookTimeout) - 2
+)
+
+type webhookService struct {
+	storage storage.IStorage
+	log     logger.LoggerI
+	client  *webhook.Client
+}
+
+func NewWebhookService(storage storage.IStorage, log logger.LoggerI) webhookService {
+	return webhookService{
+		storage: storage,
+		log:     log,
+		client:  webhook.NewClient(webhookTimeout),
+	}
+}
+
+// Run sends the due deliveries every interval until ctx is done.
+func (w webhookService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.DeliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue sends the due deliveries a batch at a time until none are left.
+func (w webhookService) DeliverDue(ctx context.Context) {
+	sent := 0
+	for ctx.Err() == nil {
+		deliveries, err := w.storage.Webhook().ClaimDueDeliveries(ctx, webhookBatch, webhookLease)
+		if err != nil {
+			w.log.Error("error while claiming webhook deliveries", logger.Error(err))
+			break
+		}
+
+		for i := range deliveries {
+			w.deliver(ctx, &deliveries[i])
+		}
+		sent += len(deliveries)
+		if len(deliveries) < webhookBatch {
+			break
+		}
+	}
+	if sent > 0 {
+		w.log.Info("webhook deliveries sent", logger.Int("count", sent))
+	}
+}
+
+// deliver posts one delivery and records the outcome. A failed delivery is
+// retried with backoff and dead-lettered after MaxWebhookAttempts.
+func (w webhookService) deliver(ctx context.Context, d *models.WebhookDelivery) {
+	status, err := w.client.Send(ctx, d.Url, d.Secret, webhook.Event{
+		Id:        d.EventId,
+		Type:      d.Event,
+		CreatedAt: d.EventCreatedAt,
+		Data:      d.Payload,
+	})
+
+	next := time.Now()
+	d.Attempts++
+	d.ResponseStatus = status
+	switch {
+	case err == nil:
+		d.Status = "delivered"
+		d.LastError = ""
+	case d.Attempts >= config.MaxWebhookAttempts:
+		d.Status = "dead"
+		d.LastError = err.Error()
+	default:
+		d.Status = "pending"
+		d.LastError = err.Error()
+		next = next.Add(webhook.Backoff.Wait(d.Attempts))
+	}
+
+	if err := w.storage.Webhook().UpdateDelivery(ctx, d, next); err != nil {
+		w.log.Error("error while updating webhook delivery", logger.Error(err))
+	}
+}
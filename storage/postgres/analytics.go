@@ -0,0 +1,320 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"food/api/models"
+	"food/pkg/logger"
+	"math"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type AnalyticsRepo struct {
+	db  *pgxpool.Pool
+	log logger.LoggerI
+}
+
+func NewAnalyticsRepo(db *pgxpool.Pool, log logger.LoggerI) *AnalyticsRepo {
+	return &AnalyticsRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// analyticsFilter keeps the orders of "order" o selected by analyticsArgs:
+// $1 and $2 bound the creation time and $3 is the branch id or empty.
+const analyticsFilter = `o.deleted_at IS NULL
+	AND o.created_at >= $1 AND o.created_at < $2
+	AND ($3::text = '' OR o.branch_id = NULLIF($3, '')::uuid)`
+
+// analyticsTips is the tip of "order" o: the checkout tip and a paid tip
+// added after delivery.
+const analyticsTips = `o.tip + coalesce((SELECT sum(pm.amount) FROM "payment" pm WHERE pm.order_id = o.id AND pm.kind = 'tip' AND pm.is_paid), 0)`
+
+// Revenue groups the orders by period and, when req.ByBranch is set, by
+// branch. Periods without orders are left out.
+func (a *AnalyticsRepo) Revenue(ctx context.Context, req *models.AnalyticsRequest) (*models.RevenueReport, error) {
+	args, err := analyticsArgs(req)
+	if err != nil {
+		return nil, err
+	}
+
+	branch := `''`
+	if req.ByBranch {
+		branch = `coalesce(o.branch_id::text, '')`
+	}
+	query := `
+		SELECT to_char(date_trunc($4, o.created_at), 'YYYY-MM-DD'), ` + branch + `,
+			count(*),
+			count(*) FILTER (WHERE o.status = 'cancelled'),
+			coalesce(sum(o.total_price) FILTER (WHERE o.status <> 'cancelled'), 0),
+			coalesce(sum(` + analyticsTips + `) FILTER (WHERE o.status <> 'cancelled'), 0)
+		FROM "order" o
+		WHERE ` + analyticsFilter + `
+		GROUP BY 1, 2
+		ORDER BY 1, 2`
+
+	rows, err := a.db.Query(ctx, query, append(args, req.GroupBy)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to report revenue: %w", err)
+	}
+	defer rows.Close()
+
+	report := &models.RevenueReport{From: req.From, To: req.To, GroupBy: req.GroupBy, Periods: []models.RevenuePeriod{}}
+	for rows.Next() {
+		var period models.RevenuePeriod
+		if err := rows.Scan(&period.Period, &period.BranchId, &period.Orders, &period.CancelledOrders, &period.Revenue, &period.Tips); err != nil {
+			return nil, fmt.Errorf("failed to scan revenue: %w", err)
+		}
+		report.Total.Orders += period.Orders
+		report.Total.CancelledOrders += period.CancelledOrders
+		report.Total.Revenue += period.Revenue
+		report.Total.Tips += period.Tips
+
+		summarizeSales(&period.SalesSummary)
+		report.Periods = append(report.Periods, period)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate revenue: %w", err)
+	}
+	summarizeSales(&report.Total)
+
+	return report, nil
+}
+
+// TopProducts ranks the products and, separately, the combos of orders that
+// were not cancelled by quantity or revenue.
+func (a *AnalyticsRepo) TopProducts(ctx context.Context, req *models.AnalyticsRequest) (*models.TopProductsReport, error) {
+	args, err := analyticsArgs(req)
+	if err != nil {
+		return nil, err
+	}
+	args = append(args, req.Limit)
+
+	report := &models.TopProductsReport{From: req.From, To: req.To, SortBy: req.SortBy, Products: []models.TopProduct{}, Combos: []models.TopCombo{}}
+
+	rows, err := a.db.Query(ctx, topItemsQuery("product", req.SortBy), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to report top products: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var product models.TopProduct
+		if err := rows.Scan(&product.ProductId, &product.Name, &product.Quantity, &product.Revenue, &product.Orders); err != nil {
+			return nil, fmt.Errorf("failed to scan top product: %w", err)
+		}
+		report.Products = append(report.Products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate top products: %w", err)
+	}
+
+	rows, err = a.db.Query(ctx, topItemsQuery("combo", req.SortBy), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to report top combos: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var combo models.TopCombo
+		if err := rows.Scan(&combo.ComboId, &combo.Name, &combo.Quantity, &combo.Revenue, &combo.Orders); err != nil {
+			return nil, fmt.Errorf("failed to scan top combo: %w", err)
+		}
+		report.Combos = append(report.Combos, combo)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate top combos: %w", err)
+	}
+
+	return report, nil
+}
+
+// topItemsQuery ranks the order items that reference table, "product" or
+// "combo", with the analyticsArgs and the limit as $4.
+func topItemsQuery(table, sortBy string) string {
+	order := "quantity DESC, revenue DESC"
+	if sortBy == "revenue" {
+		order = "revenue DESC, quantity DESC"
+	}
+	return `
+		SELECT t.id, t.name, sum(oi.quantity) AS quantity, sum(oi.total) AS revenue, count(DISTINCT oi.order_id)
+		FROM "orderiteam" oi
+		JOIN "order" o ON o.id = oi.order_id
+		JOIN "` + table + `" t ON t.id = oi.` + table + `_id
+		WHERE ` + analyticsFilter + ` AND o.status <> 'cancelled' AND oi.deleted_at IS NULL
+		GROUP BY t.id, t.name
+		ORDER BY ` + order + `, t.name
+		LIMIT $4`
+}
+
+// Branches compares every branch, also those without orders, best revenue
+// first.
+func (a *AnalyticsRepo) Branches(ctx context.Context, req *models.AnalyticsRequest) (*models.BranchPerformanceReport, error) {
+	args, err := analyticsArgs(req)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT b.id, b.name,
+			count(o.id),
+			count(o.id) FILTER (WHERE o.status = 'cancelled'),
+			coalesce(sum(o.total_price) FILTER (WHERE o.status <> 'cancelled'), 0) AS revenue,
+			coalesce(sum(` + analyticsTips + `) FILTER (WHERE o.status <> 'cancelled'), 0),
+			coalesce(avg(extract(epoch FROM o.delivered_at - o.created_at) / 60) FILTER (WHERE o.status = 'delivered' AND o.delivered_at IS NOT NULL), 0)
+		FROM "branch" b
+		LEFT JOIN "order" o ON o.branch_id = b.id
+			AND o.deleted_at IS NULL AND o.created_at >= $1 AND o.created_at < $2
+		WHERE $3::text = '' OR b.id = NULLIF($3, '')::uuid
+		GROUP BY b.id, b.name
+		ORDER BY revenue DESC, b.name`
+
+	rows, err := a.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to report branches: %w", err)
+	}
+	defer rows.Close()
+
+	report := &models.BranchPerformanceReport{From: req.From, To: req.To, Branches: []models.BranchPerformance{}}
+	for rows.Next() {
+		var branch models.BranchPerformance
+		if err := rows.Scan(&branch.BranchId, &branch.Name, &branch.Orders, &branch.CancelledOrders, &branch.Revenue, &branch.Tips, &branch.AverageDeliveryMinutes); err != nil {
+			return nil, fmt.Errorf("failed to scan branch: %w", err)
+		}
+		summarizeSales(&branch.SalesSummary)
+		branch.AverageDeliveryMinutes = math.Round(branch.AverageDeliveryMinutes*10) / 10
+		report.Branches = append(report.Branches, branch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate branches: %w", err)
+	}
+
+	return report, nil
+}
+
+// Hourly counts the orders that were not cancelled by the hour of day they
+// were placed.
+func (a *AnalyticsRepo) Hourly(ctx context.Context, req *models.AnalyticsRequest) (*models.HourlyReport, error) {
+	args, err := analyticsArgs(req)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT h.hour, count(o.id), coalesce(sum(o.total_price), 0)
+		FROM generate_series(0, 23) AS h(hour)
+		LEFT JOIN "order" o ON extract(hour FROM o.created_at) = h.hour
+			AND ` + analyticsFilter + ` AND o.status <> 'cancelled'
+		GROUP BY h.hour
+		ORDER BY h.hour`
+
+	rows, err := a.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to report orders by hour: %w", err)
+	}
+	defer rows.Close()
+
+	report := &models.HourlyReport{From: req.From, To: req.To, Hours: make([]models.HourlyOrders, 0, 24)}
+	for rows.Next() {
+		var hour models.HourlyOrders
+		if err := rows.Scan(&hour.Hour, &hour.Orders, &hour.Revenue); err != nil {
+			return nil, fmt.Errorf("failed to scan hour: %w", err)
+		}
+		report.Hours = append(report.Hours, hour)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate hours: %w", err)
+	}
+
+	return report, nil
+}
+
+// Customers counts, per period, the customers who placed an order that was
+// not cancelled, split by whether it was their first order at any branch.
+func (a *AnalyticsRepo) Customers(ctx context.Context, req *models.AnalyticsRequest) (*models.CustomerReport, error) {
+	args, err := analyticsArgs(req)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		WITH active AS (
+			SELECT date_trunc($4, o.created_at) AS period, o.user_id
+			FROM "order" o
+			WHERE ` + analyticsFilter + ` AND o.status <> 'cancelled'
+			GROUP BY 1, 2
+		), first_order AS (
+			SELECT o.user_id, min(o.created_at) AS first_at
+			FROM "order" o
+			WHERE o.deleted_at IS NULL AND o.status <> 'cancelled'
+				AND o.user_id IN (SELECT user_id FROM active)
+			GROUP BY o.user_id
+		)
+		SELECT to_char(a.period, 'YYYY-MM-DD'),
+			count(*) FILTER (WHERE f.first_at >= a.period),
+			count(*) FILTER (WHERE f.first_at < a.period)
+		FROM active a
+		JOIN first_order f ON f.user_id = a.user_id
+		GROUP BY a.period
+		ORDER BY a.period`
+
+	rows, err := a.db.Query(ctx, query, append(args, req.GroupBy)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to report customers: %w", err)
+	}
+	defer rows.Close()
+
+	report := &models.CustomerReport{From: req.From, To: req.To, GroupBy: req.GroupBy, Periods: []models.CustomerPeriod{}}
+	for rows.Next() {
+		var period models.CustomerPeriod
+		if err := rows.Scan(&period.Period, &period.New, &period.Returning); err != nil {
+			return nil, fmt.Errorf("failed to scan customers: %w", err)
+		}
+		report.Periods = append(report.Periods, period)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate customers: %w", err)
+	}
+
+	return report, nil
+}
+
+// analyticsArgs returns the parameters of analyticsFilter.
+func analyticsArgs(req *models.AnalyticsRequest) ([]interface{}, error) {
+	from, to, err := dateRange(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{from, to, req.BranchId}, nil
+}
+
+// dateRange parses local dates formatted 2006-01-02 into the bounds of a
+// half-open range. to is inclusive, so the range ends at the following
+// midnight.
+func dateRange(from, to string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01-02", from, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from date %q: %w", from, err)
+	}
+	end, err := time.ParseInLocation("2006-01-02", to, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to date %q: %w", to, err)
+	}
+	return start, end.AddDate(0, 0, 1), nil
+}
+
+// summarizeSales derives the average basket and cancellation rate from the
+// counts and revenue.
+func summarizeSales(s *models.SalesSummary) {
+	if paid := s.Orders - s.CancelledOrders; paid > 0 {
+		s.AverageBasket = math.Round(s.Revenue/float64(paid)*100) / 100
+	}
+	if s.Orders > 0 {
+		s.CancellationRate = math.Round(float64(s.CancelledOrders)/float64(s.Orders)*10000) / 10000
+	}
+	s.Revenue = math.Round(s.Revenue*100) / 100
+	s.Tips = math.Round(s.Tips*100) / 100
+}
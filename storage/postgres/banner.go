@@ -3,10 +3,16 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"food/api/models"
 	"food/pkg/logger"
+	"food/storage"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -23,65 +29,171 @@ func NewBannerRepo(db *pgxpool.Pool, log logger.LoggerI) *BannerRepo {
 }
 
 func (b *BannerRepo) Create(ctx context.Context, banner *models.Banner) (*models.Banner, error) {
+	startsAt, endsAt, err := bannerWindowArgs(banner)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
 
+	id := uuid.New().String()
 	query := `INSERT INTO "banner" (
+		id,
+		title,
 		image_url,
-		created_at)
-		VALUES ($1, CURRENT_TIMESTAMP)
+		target_type,
+		target_id,
+		sort_order,
+		is_active,
+		starts_at,
+		ends_at,
+		created_at,
+		updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8, $9, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 	`
 
-	_, err := b.db.Exec(ctx, query,
+	_, err = tx.Exec(ctx, query,
+		id,
+		banner.Title,
 		banner.ImageUrl,
+		banner.TargetType,
+		banner.TargetId,
+		banner.SortOrder,
+		banner.IsActive,
+		startsAt,
+		endsAt,
 	)
 	if err != nil {
 		b.log.Error("Error creating banner: " + err.Error())
 		return nil, err
 	}
 
+	if err := setBannerBranches(ctx, tx, id, banner.BranchIds); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
 	b.log.Info("Banner created successfully!")
-	return &models.Banner{
-		ImageUrl:   banner.ImageUrl,
-		Created_at: banner.Created_at,
-	}, nil
+	return b.GetByID(ctx, id)
+}
+
+func (b *BannerRepo) Update(ctx context.Context, banner *models.Banner) (*models.Banner, error) {
+	startsAt, endsAt, err := bannerWindowArgs(banner)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE "banner" SET
+		title=$1,
+		image_url=$2,
+		target_type=NULLIF($3, ''),
+		target_id=NULLIF($4, ''),
+		sort_order=$5,
+		is_active=$6,
+		starts_at=$7,
+		ends_at=$8,
+		updated_at=CURRENT_TIMESTAMP
+		WHERE id = $9
+	`
+	tag, err := tx.Exec(ctx, query,
+		banner.Title,
+		banner.ImageUrl,
+		banner.TargetType,
+		banner.TargetId,
+		banner.SortOrder,
+		banner.IsActive,
+		startsAt,
+		endsAt,
+		banner.Id,
+	)
+	if err != nil {
+		b.log.Error("Error updating banner: " + err.Error())
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM "banner_branch" WHERE banner_id = $1`, banner.Id); err != nil {
+		return nil, err
+	}
+	if err := setBannerBranches(ctx, tx, banner.Id, banner.BranchIds); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	b.log.Info("Banner updated successfully!")
+	return b.GetByID(ctx, banner.Id)
 }
 
+func (b *BannerRepo) GetByID(ctx context.Context, id string) (*models.Banner, error) {
+	return scanBanner(b.db.QueryRow(ctx, `SELECT `+bannerColumns+` FROM "banner" WHERE id = $1`, id))
+}
+
+// GetAll lists banners in display order. Unless req.IncludeInactive is set
+// only active banners inside their campaign window are returned, and a
+// branch filter keeps the banners targeted at that branch or at all of them.
 func (b *BannerRepo) GetAll(ctx context.Context, req *models.GetAllBannerRequest) (*models.GetAllBannerResponse, error) {
 	var (
-		resp   = &models.GetAllBannerResponse{}
-		filter = ""
+		resp   = &models.GetAllBannerResponse{Banners: []models.Banner{}}
+		filter = " WHERE true"
+		args   []interface{}
 	)
-	offset := (req.Page - 1) * req.Limit
+
+	if !req.IncludeInactive {
+		args = append(args, time.Now())
+		filter += fmt.Sprintf(` AND is_active AND (starts_at IS NULL OR starts_at <= $%[1]d) AND (ends_at IS NULL OR ends_at > $%[1]d)`, len(args))
+	}
+
+	if req.BranchId != "" {
+		args = append(args, req.BranchId)
+		filter += fmt.Sprintf(` AND (NOT EXISTS (SELECT 1 FROM "banner_branch" bb WHERE bb.banner_id = "banner".id)
+			OR EXISTS (SELECT 1 FROM "banner_branch" bb WHERE bb.banner_id = "banner".id AND bb.branch_id = $%d::uuid))`, len(args))
+	}
 
 	if req.Search != "" {
-		filter += fmt.Sprintf(` WHERE image_url ILIKE '%%%v%%' `, req.Search)
+		args = append(args, "%"+req.Search+"%")
+		filter += fmt.Sprintf(` AND title ILIKE $%d`, len(args))
 	}
 
-	filter += fmt.Sprintf(" OFFSET %v LIMIT %v", offset, req.Limit)
+	args = append(args, (req.Page-1)*req.Limit, req.Limit)
+	query := `SELECT count(id) OVER(), ` + bannerColumns + ` FROM "banner"` + filter +
+		fmt.Sprintf(` ORDER BY sort_order, created_at DESC OFFSET $%d LIMIT $%d`, len(args)-1, len(args))
 
-	query := fmt.Sprintf(`SELECT count(image_url) OVER(), image_url, created_at FROM "banner" %s`, filter)
-	rows, err := b.db.Query(ctx, query)
+	rows, err := b.db.Query(ctx, query, args...)
 	if err != nil {
 		b.log.Error("Error retrieving banners: " + err.Error())
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		var (
-			imageUrl   sql.NullString
-			created_at sql.NullString
-		)
-
-		if err := rows.Scan(
-			&resp.Count,
-			&imageUrl,
-			&created_at); err != nil {
+		var count int64
+		banner, err := scanBanner(rows, &count)
+		if err != nil {
 			return nil, err
 		}
-
-		resp.Banners = append(resp.Banners, models.Banner{
-			ImageUrl:   imageUrl.String,
-			Created_at: created_at.String,
-		})
+		resp.Count = count
+		resp.Banners = append(resp.Banners, *banner)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
 	b.log.Info("Banners retrieved successfully!")
@@ -89,13 +201,130 @@ func (b *BannerRepo) GetAll(ctx context.Context, req *models.GetAllBannerRequest
 }
 
 func (b *BannerRepo) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM "banner" WHERE image_url = $1`
-	_, err := b.db.Exec(ctx, query, id)
+	query := `DELETE FROM "banner" WHERE id = $1`
+	tag, err := b.db.Exec(ctx, query, id)
 	if err != nil {
 		b.log.Error("Error deleting banner: " + err.Error())
 		return err
 	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
 
 	b.log.Info("Banner deleted successfully!")
 	return nil
 }
+
+// Track adds one impression or click to each banner and returns how many
+// banners were counted. Unknown ids are ignored.
+func (b *BannerRepo) Track(ctx context.Context, event string, ids []string) (int64, error) {
+	column := "impressions"
+	if event == "click" {
+		column = "clicks"
+	}
+
+	tag, err := b.db.Exec(ctx, `UPDATE "banner" SET `+column+` = `+column+` + 1 WHERE id = ANY($1::uuid[])`, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to track banner %s: %w", event, err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+const bannerColumns = `
+	id,
+	title,
+	image_url,
+	target_type,
+	target_id,
+	sort_order,
+	is_active,
+	starts_at,
+	ends_at,
+	coalesce((SELECT array_agg(bb.branch_id::text ORDER BY bb.branch_id) FROM "banner_branch" bb WHERE bb.banner_id = "banner".id), '{}'),
+	impressions,
+	clicks,
+	created_at,
+	updated_at`
+
+// scanBanner reads bannerColumns, preceded by the extra destinations.
+func scanBanner(row pgx.Row, extra ...interface{}) (*models.Banner, error) {
+	var (
+		banner     models.Banner
+		imageUrl   sql.NullString
+		targetType sql.NullString
+		targetId   sql.NullString
+		startsAt   sql.NullTime
+		endsAt     sql.NullTime
+		createdAt  sql.NullTime
+		updatedAt  sql.NullTime
+	)
+	dest := append(extra,
+		&banner.Id,
+		&banner.Title,
+		&imageUrl,
+		&targetType,
+		&targetId,
+		&banner.SortOrder,
+		&banner.IsActive,
+		&startsAt,
+		&endsAt,
+		&banner.BranchIds,
+		&banner.Impressions,
+		&banner.Clicks,
+		&createdAt,
+		&updatedAt,
+	)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	banner.ImageUrl = imageUrl.String
+	banner.TargetType = targetType.String
+	banner.TargetId = targetId.String
+	banner.StartsAt = formatLocalTime(startsAt)
+	banner.EndsAt = formatLocalTime(endsAt)
+	banner.Created_at = formatLocalTime(createdAt)
+	banner.UpdatedAt = formatLocalTime(updatedAt)
+	if banner.Impressions > 0 {
+		banner.CTR = float64(banner.Clicks) / float64(banner.Impressions)
+	}
+
+	return &banner, nil
+}
+
+func setBannerBranches(ctx context.Context, tx pgx.Tx, bannerId string, branchIds []string) error {
+	if len(branchIds) == 0 {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, `INSERT INTO "banner_branch" (banner_id, branch_id)
+		SELECT $1, unnest($2::uuid[]) ON CONFLICT DO NOTHING`, bannerId, branchIds)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return storage.ErrInvalidBranch
+	}
+	return err
+}
+
+// bannerWindowArgs parses the RFC 3339 campaign window into local-time
+// TIMESTAMP parameters; an empty bound is NULL.
+func bannerWindowArgs(banner *models.Banner) (startsAt, endsAt interface{}, err error) {
+	parse := func(value string) (interface{}, error) {
+		if value == "" {
+			return nil, nil
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid banner time %q: %w", value, err)
+		}
+		return t.In(time.Local), nil
+	}
+
+	if startsAt, err = parse(banner.StartsAt); err != nil {
+		return nil, nil, err
+	}
+	if endsAt, err = parse(banner.EndsAt); err != nil {
+		return nil, nil, err
+	}
+	return startsAt, endsAt, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"food/api/models"
 	"food/pkg/logger"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4/pgxpool"
@@ -31,30 +32,58 @@ func (b *BranchRepo) Create(ctx context.Context, branch *models.Branch) (*models
 		address,
 		latitude,
 		longitude,
+		opening_time,
+		closing_time,
+		slot_minutes,
+		slot_capacity,
+		lead_minutes,
 		created_at,
 		updated_at)
-		VALUES($1, $2, $3, $4, $5, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		VALUES($1, $2, $3, $4, $5,
+			COALESCE(NULLIF($6, '')::time, '09:00'),
+			COALESCE(NULLIF($7, '')::time, '23:00'),
+			COALESCE(NULLIF($8, 0), 30),
+			COALESCE(NULLIF($9, 0), 10),
+			COALESCE(NULLIF($10, 0), 45),
+			CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		RETURNING to_char(opening_time, 'HH24:MI'), to_char(closing_time, 'HH24:MI'), slot_minutes, slot_capacity, lead_minutes
 	`
 
-	_, err := b.db.Exec(context.Background(), query,
+	err := b.db.QueryRow(context.Background(), query,
 		id.String(),
 		branch.Name,
 		branch.Address,
 		branch.Latitude,
 		branch.Longitude,
+		branch.OpeningTime,
+		branch.ClosingTime,
+		branch.SlotMinutes,
+		branch.SlotCapacity,
+		branch.LeadMinutes,
+	).Scan(
+		&branch.OpeningTime,
+		&branch.ClosingTime,
+		&branch.SlotMinutes,
+		&branch.SlotCapacity,
+		&branch.LeadMinutes,
 	)
 
 	if err != nil {
 		return &models.Branch{}, err
 	}
 	return &models.Branch{
-		Id:        id.String(),
-		Name:      branch.Name,
-		Address:   branch.Address,
-		Latitude:  branch.Latitude,
-		Longitude: branch.Longitude,
-		CreatedAt: branch.CreatedAt,
-		UpdatedAt: branch.UpdatedAt,
+		Id:           id.String(),
+		Name:         branch.Name,
+		Address:      branch.Address,
+		Latitude:     branch.Latitude,
+		Longitude:    branch.Longitude,
+		OpeningTime:  branch.OpeningTime,
+		ClosingTime:  branch.ClosingTime,
+		SlotMinutes:  branch.SlotMinutes,
+		SlotCapacity: branch.SlotCapacity,
+		LeadMinutes:  branch.LeadMinutes,
+		CreatedAt:    branch.CreatedAt,
+		UpdatedAt:    branch.UpdatedAt,
 	}, nil
 }
 
@@ -64,27 +93,42 @@ func (b *BranchRepo) Update(ctx context.Context, branch *models.Branch) (*models
 		address=$2,
 		latitude=$3,
 		longitude=$4,
+		opening_time=$5::time,
+		closing_time=$6::time,
+		slot_minutes=$7,
+		slot_capacity=$8,
+		lead_minutes=$9,
 		updated_at=CURRENT_TIMESTAMP
-		WHERE id = $5
+		WHERE id = $10
 	`
 	_, err := b.db.Exec(context.Background(), query,
 		branch.Name,
 		branch.Address,
 		branch.Latitude,
 		branch.Longitude,
+		branch.OpeningTime,
+		branch.ClosingTime,
+		branch.SlotMinutes,
+		branch.SlotCapacity,
+		branch.LeadMinutes,
 		branch.Id,
 	)
 	if err != nil {
 		return &models.Branch{}, err
 	}
 	return &models.Branch{
-		Id:        branch.Id,
-		Name:      branch.Name,
-		Address:   branch.Address,
-		Latitude:  branch.Latitude,
-		Longitude: branch.Longitude,
-		CreatedAt: branch.CreatedAt,
-		UpdatedAt: branch.UpdatedAt,
+		Id:           branch.Id,
+		Name:         branch.Name,
+		Address:      branch.Address,
+		Latitude:     branch.Latitude,
+		Longitude:    branch.Longitude,
+		OpeningTime:  branch.OpeningTime,
+		ClosingTime:  branch.ClosingTime,
+		SlotMinutes:  branch.SlotMinutes,
+		SlotCapacity: branch.SlotCapacity,
+		LeadMinutes:  branch.LeadMinutes,
+		CreatedAt:    branch.CreatedAt,
+		UpdatedAt:    branch.UpdatedAt,
 	}, nil
 }
 
@@ -108,6 +152,11 @@ func (b *BranchRepo) GetAll(ctx context.Context, req *models.GetAllBranchesReque
         address,
         latitude,
         longitude,
+        to_char(opening_time, 'HH24:MI'),
+        to_char(closing_time, 'HH24:MI'),
+        slot_minutes,
+        slot_capacity,
+        lead_minutes,
         created_at,
         updated_at FROM "branch"`+filter)
 	if err != nil {
@@ -131,19 +180,29 @@ func (b *BranchRepo) GetAll(ctx context.Context, req *models.GetAllBranchesReque
 			&address,
 			&latitude,
 			&longitude,
+			&branch.OpeningTime,
+			&branch.ClosingTime,
+			&branch.SlotMinutes,
+			&branch.SlotCapacity,
+			&branch.LeadMinutes,
 			&created_at,
 			&updated_at); err != nil {
 			return resp, err
 		}
 
 		resp.Branches = append(resp.Branches, models.Branch{
-			Id:        branch.Id,
-			Name:      name.String,
-			Address:   address.String,
-			Latitude:  latitude.Float64,
-			Longitude: longitude.Float64,
-			CreatedAt: created_at.String,
-			UpdatedAt: updated_at.String,
+			Id:           branch.Id,
+			Name:         name.String,
+			Address:      address.String,
+			Latitude:     latitude.Float64,
+			Longitude:    longitude.Float64,
+			OpeningTime:  branch.OpeningTime,
+			ClosingTime:  branch.ClosingTime,
+			SlotMinutes:  branch.SlotMinutes,
+			SlotCapacity: branch.SlotCapacity,
+			LeadMinutes:  branch.LeadMinutes,
+			CreatedAt:    created_at.String,
+			UpdatedAt:    updated_at.String,
 		})
 	}
 	return resp, nil
@@ -159,25 +218,37 @@ func (b *BranchRepo) GetByID(ctx context.Context, id string) (*models.Branch, er
 		created_at sql.NullString
 		updated_at sql.NullString
 	)
-	if err := b.db.QueryRow(context.Background(), `SELECT id, name, address, latitude, longitude, created_at, updated_at FROM "branch" WHERE id = $1`, id).Scan(
+	if err := b.db.QueryRow(context.Background(), `SELECT id, name, address, latitude, longitude,
+		to_char(opening_time, 'HH24:MI'), to_char(closing_time, 'HH24:MI'), slot_minutes, slot_capacity, lead_minutes,
+		created_at, updated_at FROM "branch" WHERE id = $1`, id).Scan(
 		&branch.Id,
 		&name,
 		&address,
 		&latitude,
 		&longitude,
+		&branch.OpeningTime,
+		&branch.ClosingTime,
+		&branch.SlotMinutes,
+		&branch.SlotCapacity,
+		&branch.LeadMinutes,
 		&created_at,
 		&updated_at,
 	); err != nil {
 		return &models.Branch{}, err
 	}
 	return &models.Branch{
-		Id:        branch.Id,
-		Name:      name.String,
-		Address:   address.String,
-		Latitude:  latitude.Float64,
-		Longitude: longitude.Float64,
-		CreatedAt: created_at.String,
-		UpdatedAt: updated_at.String,
+		Id:           branch.Id,
+		Name:         name.String,
+		Address:      address.String,
+		Latitude:     latitude.Float64,
+		Longitude:    longitude.Float64,
+		OpeningTime:  branch.OpeningTime,
+		ClosingTime:  branch.ClosingTime,
+		SlotMinutes:  branch.SlotMinutes,
+		SlotCapacity: branch.SlotCapacity,
+		LeadMinutes:  branch.LeadMinutes,
+		CreatedAt:    created_at.String,
+		UpdatedAt:    updated_at.String,
 	}, nil
 }
 
@@ -189,3 +260,110 @@ func (b *BranchRepo) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+func (b *BranchRepo) GetSlots(ctx context.Context, req *models.GetBranchSlotsRequest) (*models.GetBranchSlotsResponse, error) {
+	branch, err := b.GetByID(ctx, req.BranchId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	from := now.Add(time.Duration(branch.LeadMinutes) * time.Minute)
+	to := startOfDay(now).AddDate(0, 0, req.Days)
+
+	starts, err := branchSlots(branch, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	booked, err := b.bookedSlots(ctx, branch.Id, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.GetBranchSlotsResponse{BranchId: branch.Id}
+	for _, start := range starts {
+		count := booked[start.Format(slotKeyLayout)]
+		resp.Slots = append(resp.Slots, models.DeliverySlot{
+			StartsAt:  start.Format(time.RFC3339),
+			EndsAt:    start.Add(time.Duration(branch.SlotMinutes) * time.Minute).Format(time.RFC3339),
+			Capacity:  branch.SlotCapacity,
+			Booked:    count,
+			Available: count < branch.SlotCapacity,
+		})
+	}
+
+	return resp, nil
+}
+
+func (b *BranchRepo) bookedSlots(ctx context.Context, branchId string, from, to time.Time) (map[string]int, error) {
+	rows, err := b.db.Query(ctx, `
+		SELECT to_char(scheduled_at, 'YYYY-MM-DD HH24:MI'), count(id)
+		FROM "order"
+		WHERE branch_id = $1 AND scheduled_at >= $2 AND scheduled_at < $3 AND status <> 'cancelled' AND deleted_at IS NULL
+		GROUP BY scheduled_at
+	`, branchId, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count booked slots: %w", err)
+	}
+	defer rows.Close()
+
+	booked := make(map[string]int)
+	for rows.Next() {
+		var (
+			key   string
+			count int
+		)
+		if err := rows.Scan(&key, &count); err != nil {
+			return nil, err
+		}
+		booked[key] = count
+	}
+
+	return booked, rows.Err()
+}
+
+// slotKeyLayout matches the to_char format used to group orders by slot.
+const slotKeyLayout = "2006-01-02 15:04"
+
+// branchSlots lists the start times of every slot of the branch that begins
+// within [from, to) and fits inside the branch opening hours.
+func branchSlots(branch *models.Branch, from, to time.Time) ([]time.Time, error) {
+	opening, err := time.Parse("15:04", branch.OpeningTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid opening time %q: %w", branch.OpeningTime, err)
+	}
+	closing, err := time.Parse("15:04", branch.ClosingTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid closing time %q: %w", branch.ClosingTime, err)
+	}
+	if branch.SlotMinutes <= 0 {
+		return nil, fmt.Errorf("branch %s has no slot length", branch.Id)
+	}
+
+	openFor := closing.Sub(opening)
+	if openFor <= 0 {
+		// Closes after midnight.
+		openFor += 24 * time.Hour
+	}
+	step := time.Duration(branch.SlotMinutes) * time.Minute
+
+	var starts []time.Time
+	for day := startOfDay(from).AddDate(0, 0, -1); day.Before(to); day = day.AddDate(0, 0, 1) {
+		open := day.Add(time.Duration(opening.Hour())*time.Hour + time.Duration(opening.Minute())*time.Minute)
+		close := open.Add(openFor)
+		for start := open; !start.Add(step).After(close); start = start.Add(step) {
+			if start.Before(from) || !start.Before(to) {
+				continue
+			}
+			starts = append(starts, start)
+		}
+	}
+
+	return starts, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
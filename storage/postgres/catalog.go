@@ -0,0 +1,246 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"food/api/models"
+	"food/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type CatalogRepo struct {
+	db  *pgxpool.Pool
+	log logger.LoggerI
+}
+
+func NewCatalogRepo(db *pgxpool.Pool, log logger.LoggerI) *CatalogRepo {
+	return &CatalogRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Import upserts the categories and products of rows in one transaction. The
+// changes are committed only when every row can be imported and dryRun is
+// false; otherwise the result tells what would have happened.
+func (c *CatalogRepo) Import(ctx context.Context, rows []models.CatalogRow, dryRun bool) (*models.CatalogImportResult, error) {
+	result := &models.CatalogImportResult{
+		DryRun: dryRun,
+		Rows:   len(rows),
+		Errors: []models.CatalogRowError{},
+	}
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Categories first, so parents in the file can be resolved whatever the
+	// order of their rows.
+	categories := make(map[string]string)
+	for _, row := range rows {
+		if _, ok := categories[row.CategorySKU]; ok {
+			continue
+		}
+		id, created, err := upsertCatalogCategory(ctx, tx, &row)
+		if err != nil {
+			return nil, err
+		}
+		categories[row.CategorySKU] = id
+		if created {
+			result.CategoriesCreated++
+		} else {
+			result.CategoriesUpdated++
+		}
+	}
+
+	parents := make(map[string]bool)
+	for i, row := range rows {
+		if row.ParentCategorySKU == "" || parents[row.CategorySKU] {
+			continue
+		}
+		parents[row.CategorySKU] = true
+
+		parentId, ok := categories[row.ParentCategorySKU]
+		if !ok {
+			parentId, err = catalogCategoryId(ctx, tx, row.ParentCategorySKU)
+			if errors.Is(err, pgx.ErrNoRows) {
+				result.Errors = append(result.Errors, models.CatalogRowError{
+					Row: i + 1, SKU: row.SKU,
+					Message: fmt.Sprintf("parent category %q does not exist", row.ParentCategorySKU),
+				})
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		moved, err := moveCatalogCategory(ctx, tx, categories[row.CategorySKU], parentId)
+		if err != nil {
+			return nil, err
+		}
+		if !moved {
+			result.Errors = append(result.Errors, models.CatalogRowError{
+				Row: i + 1, SKU: row.SKU,
+				Message: fmt.Sprintf("category %q cannot be placed under %q", row.CategorySKU, row.ParentCategorySKU),
+			})
+		}
+	}
+
+	now := time.Now()
+	for _, row := range rows {
+		created, err := upsertCatalogProduct(ctx, tx, &row, categories[row.CategorySKU], now)
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			result.ProductsCreated++
+		} else {
+			result.ProductsUpdated++
+		}
+	}
+
+	if dryRun || len(result.Errors) > 0 {
+		return result, nil
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	result.Applied = true
+	return result, nil
+}
+
+// Export streams every product with its category, ordered by category and
+// name. Categories without products are left out.
+func (c *CatalogRepo) Export(ctx context.Context, fn func(*models.CatalogRow) error) error {
+	rows, err := c.db.Query(ctx, `
+		SELECT coalesce(p.sku, p.id::text), p.name, coalesce(p.description, ''), p.price, coalesce(p.image_url, ''),
+			coalesce(c.sku, c.id::text), c.name, coalesce(pc.sku, pc.id::text, ''), p.translations
+		FROM "product" p
+		JOIN "category" c ON c.id = p.category_id
+		LEFT JOIN "category" pc ON pc.id = c.parent_id
+		ORDER BY c.name, c.id, p.name, p.id`)
+	if err != nil {
+		return fmt.Errorf("failed to export catalog: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.CatalogRow
+		if err := rows.Scan(
+			&row.SKU, &row.Name, &row.Description, &row.Price, &row.ImageURL,
+			&row.CategorySKU, &row.CategoryName, &row.ParentCategorySKU, &row.Translations,
+		); err != nil {
+			return fmt.Errorf("failed to scan catalog export: %w", err)
+		}
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// catalogCategoryId finds a category by SKU, or by id when it has no SKU.
+func catalogCategoryId(ctx context.Context, tx pgx.Tx, sku string) (string, error) {
+	var id string
+	err := tx.QueryRow(ctx, `SELECT id FROM "category" WHERE sku = $1 OR (sku IS NULL AND id::text = $1) FOR UPDATE`, sku).Scan(&id)
+	return id, err
+}
+
+func upsertCatalogCategory(ctx context.Context, tx pgx.Tx, row *models.CatalogRow) (string, bool, error) {
+	id, err := catalogCategoryId(ctx, tx, row.CategorySKU)
+	if errors.Is(err, pgx.ErrNoRows) {
+		id = uuid.New().String()
+		_, err = tx.Exec(ctx, `INSERT INTO "category" (id, sku, name, created_at, updated_at)
+			VALUES ($1, $2, $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
+			id, row.CategorySKU, row.CategoryName)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to create category %q: %w", row.CategorySKU, err)
+		}
+		return id, true, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE "category" SET sku = $1, name = $2, updated_at = CURRENT_TIMESTAMP WHERE id = $3`,
+		row.CategorySKU, row.CategoryName, id)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to update category %q: %w", row.CategorySKU, err)
+	}
+	return id, false, nil
+}
+
+// moveCatalogCategory sets the parent of a category. It reports false when
+// the parent is the category itself or one of its subcategories.
+func moveCatalogCategory(ctx context.Context, tx pgx.Tx, id, parentId string) (bool, error) {
+	var cycle bool
+	err := tx.QueryRow(ctx, `
+		WITH RECURSIVE ancestor AS (
+			SELECT id, parent_id FROM "category" WHERE id = $1
+			UNION ALL
+			SELECT p.id, p.parent_id FROM "category" p JOIN ancestor a ON p.id = a.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestor WHERE id = $2)
+	`, parentId, id).Scan(&cycle)
+	if err != nil {
+		return false, err
+	}
+	if cycle {
+		return false, nil
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE "category" SET parent_id = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, parentId, id)
+	return err == nil, err
+}
+
+// upsertCatalogProduct creates or updates the product of row. A product with
+// a gallery keeps its first image as image_url.
+func upsertCatalogProduct(ctx context.Context, tx pgx.Tx, row *models.CatalogRow, categoryId string, now time.Time) (bool, error) {
+	var (
+		id       string
+		oldPrice float64
+	)
+	err := tx.QueryRow(ctx, `SELECT id, price FROM "product" WHERE sku = $1 OR (sku IS NULL AND id::text = $1) FOR UPDATE`,
+		row.SKU).Scan(&id, &oldPrice)
+	if errors.Is(err, pgx.ErrNoRows) {
+		id = uuid.New().String()
+		_, err = tx.Exec(ctx, `INSERT INTO "product" (id, sku, category_id, name, description, price, image_url, translations, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
+			id, row.SKU, categoryId, row.Name, row.Description, row.Price, row.ImageURL, translationsArg(row.Translations))
+		if err != nil {
+			return false, fmt.Errorf("failed to create product %q: %w", row.SKU, err)
+		}
+		return true, recordPrice(ctx, tx, id, row.Price, now)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE "product" SET
+		sku = $1,
+		category_id = $2,
+		name = $3,
+		description = $4,
+		price = $5,
+		image_url = CASE WHEN EXISTS (SELECT 1 FROM "product_image" WHERE product_id = $8) THEN image_url ELSE NULLIF($6, '') END,
+		translations = COALESCE($7, translations),
+		updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8`,
+		row.SKU, categoryId, row.Name, row.Description, row.Price, row.ImageURL, optionalTranslationsArg(row.Translations), id)
+	if err != nil {
+		return false, fmt.Errorf("failed to update product %q: %w", row.SKU, err)
+	}
+	if row.Price != oldPrice {
+		return false, recordPrice(ctx, tx, id, row.Price, now)
+	}
+	return false, nil
+}
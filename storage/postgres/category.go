@@ -3,11 +3,18 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"food/api/models"
 	"food/pkg/logger"
+	"food/storage"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -23,123 +30,182 @@ func NewCategory(db *pgxpool.Pool, log logger.LoggerI) CategoryRepo {
 	}
 }
 
+// visibleCategoriesCTE defines visible_category, the ids of the categories
+// customers can see at a time of day: active, inside their daily window and
+// under visible parents only. %[1]d is the index of the time parameter.
+const visibleCategoriesCTE = `WITH RECURSIVE visible_category AS (
+		SELECT c.id FROM "category" c
+		WHERE c.parent_id IS NULL AND ` + categoryShownCond + `
+		UNION ALL
+		SELECT c.id FROM "category" c
+		JOIN visible_category v ON c.parent_id = v.id
+		WHERE ` + categoryShownCond + `
+	)
+	`
+
+const categoryShownCond = `c.is_active AND (c.visible_from IS NULL OR CASE
+			WHEN c.visible_from <= c.visible_to THEN $%[1]d::time >= c.visible_from AND $%[1]d::time < c.visible_to
+			ELSE $%[1]d::time >= c.visible_from OR $%[1]d::time < c.visible_to
+		END)`
+
+// timeOfDay formats t as a TIME parameter for visibleCategoriesCTE.
+func timeOfDay(t time.Time) string {
+	return t.Format("15:04:05")
+}
+
 func (c *CategoryRepo) Create(ctx context.Context, category *models.Category) (*models.Category, error) {
 
 	id := uuid.New()
 	query := `INSERT INTO "category" (
 		id,
 		name,
+		parent_id,
+		sort_order,
+		icon_url,
+		is_active,
+		visible_from,
+		visible_to,
+		translations,
 		created_at,
 		updated_at)
-		VALUES($1, $2, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		VALUES($1, $2, NULLIF($3, '')::uuid, $4, NULLIF($5, ''), $6, NULLIF($7, '')::time, NULLIF($8, '')::time, $9, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 	`
 
 	_, err := c.db.Exec(context.Background(), query,
 		id.String(),
 		category.Name,
+		category.ParentId,
+		category.SortOrder,
+		category.IconURL,
+		category.IsActive,
+		category.VisibleFrom,
+		category.VisibleTo,
+		translationsArg(category.Translations),
 	)
-
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return &models.Category{}, storage.ErrInvalidParent
+	}
 	if err != nil {
 		return &models.Category{}, err
 	}
-	return &models.Category{
-		Id:        id.String(),
-		Name:      category.Name,
-		CreatedAt: category.CreatedAt,
-		UpdatedAt: category.UpdatedAt,
-	}, nil
+	return c.GetByID(ctx, id.String())
 }
 
 func (c *CategoryRepo) Update(ctx context.Context, category *models.Category) (*models.Category, error) {
-	query := `UPDATE "category" SET 
+	if category.ParentId != "" {
+		var cycle bool
+		err := c.db.QueryRow(ctx, `
+			WITH RECURSIVE ancestor AS (
+				SELECT id, parent_id FROM "category" WHERE id = $1
+				UNION ALL
+				SELECT p.id, p.parent_id FROM "category" p JOIN ancestor a ON p.id = a.parent_id
+			)
+			SELECT EXISTS (SELECT 1 FROM ancestor WHERE id = $2)
+		`, category.ParentId, category.Id).Scan(&cycle)
+		if err != nil {
+			return &models.Category{}, err
+		}
+		if cycle {
+			return &models.Category{}, storage.ErrInvalidParent
+		}
+	}
+
+	query := `UPDATE "category" SET
 		name=$1,
+		parent_id=NULLIF($2, '')::uuid,
+		sort_order=$3,
+		icon_url=NULLIF($4, ''),
+		is_active=$5,
+		visible_from=NULLIF($6, '')::time,
+		visible_to=NULLIF($7, '')::time,
+		translations=$8,
 		updated_at=CURRENT_TIMESTAMP
-		WHERE id = $2
+		WHERE id = $9
 	`
 	_, err := c.db.Exec(context.Background(), query,
 		category.Name,
+		category.ParentId,
+		category.SortOrder,
+		category.IconURL,
+		category.IsActive,
+		category.VisibleFrom,
+		category.VisibleTo,
+		translationsArg(category.Translations),
 		category.Id,
 	)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return &models.Category{}, storage.ErrInvalidParent
+	}
 	if err != nil {
 		c.log.Error("error while updating category in strg" + err.Error())
 		return &models.Category{}, err
 	}
-	return &models.Category{
-		Id:        category.Id,
-		Name:      category.Name,
-		CreatedAt: category.CreatedAt,
-		UpdatedAt: category.UpdatedAt,
-	}, nil
+	return c.GetByID(ctx, category.Id)
 }
 
+// GetAll returns the categories as a tree ordered by sort_order and name.
+// Paging applies to the top-level categories, product counts include the
+// products of subcategories, and a search keeps the branches that contain a
+// matching category.
 func (c *CategoryRepo) GetAll(ctx context.Context, req *models.GetAllCategoriesRequest) (*models.GetAllCategoriesResponse, error) {
 	var (
-		resp   = &models.GetAllCategoriesResponse{}
+		resp   = &models.GetAllCategoriesResponse{Categories: []models.Category{}}
 		filter = ""
 	)
-	offset := (req.Page - 1) * req.Limit
 
-	if req.Search != "" {
-	    filter += fmt.Sprintf(` WHERE name ILIKE '%%%v%%'`, req.Search)
+	if !req.IncludeHidden {
+		filter = ` WHERE id IN (SELECT id FROM visible_category)`
 	}
 
-	// Order by created_at DESC
-	filter += " ORDER BY created_at DESC"
-
-	// Append OFFSET and LIMIT to the filter
-	filter += fmt.Sprintf(" OFFSET %v LIMIT %v", offset, req.Limit)
-
-	query := `SELECT count(id) OVER(), id, name, created_at, updated_at FROM "category"` + filter
-	rows, err := c.db.Query(context.Background(), query)
+	query := fmt.Sprintf(visibleCategoriesCTE, 1) + `SELECT ` + categoryColumns + ` FROM "category"` + filter
+	rows, err := c.db.Query(ctx, query, timeOfDay(time.Now()))
 	if err != nil {
 		return resp, err
 	}
+	defer rows.Close()
+
+	var categories []models.Category
 	for rows.Next() {
-		var (
-			category   = models.Category{}
-			name       sql.NullString
-			created_at sql.NullString
-			updated_at sql.NullString
-		)
-		if err := rows.Scan(
-			&resp.Count,
-			&category.Id,
-			&name,
-			&created_at,
-			&updated_at); err != nil {
+		category, err := scanCategory(rows)
+		if err != nil {
 			return resp, err
 		}
-		resp.Categories = append(resp.Categories, models.Category{
-			Id:        category.Id,
-			Name:      name.String,
-			CreatedAt: created_at.String,
-			UpdatedAt: updated_at.String,
-		})
+		categories = append(categories, *category)
+	}
+	if err := rows.Err(); err != nil {
+		return resp, err
+	}
+
+	roots := buildCategoryTree(categories)
+	if req.Search != "" {
+		roots = filterCategoryTree(roots, strings.ToLower(req.Search))
 	}
+
+	resp.Count = int64(len(roots))
+
+	offset := (req.Page - 1) * req.Limit
+	if offset > uint64(len(roots)) {
+		offset = uint64(len(roots))
+	}
+	end := offset + req.Limit
+	if end > uint64(len(roots)) {
+		end = uint64(len(roots))
+	}
+	resp.Categories = roots[offset:end]
+
 	return resp, nil
 }
 
 func (c *CategoryRepo) GetByID(ctx context.Context, id string) (*models.Category, error) {
-	var (
-		category   = models.Category{}
-		name       sql.NullString
-		created_at sql.NullString
-		updated_at sql.NullString
-	)
-	if err := c.db.QueryRow(context.Background(), `SELECT id, name, created_at, updated_at FROM "category" WHERE id = $1`, id).Scan(
-		&category.Id,
-		&name,
-		&created_at,
-		&updated_at,
-	); err != nil {
+	query := fmt.Sprintf(visibleCategoriesCTE, 1) + `SELECT ` + categoryColumns + ` FROM "category" WHERE id = $2`
+
+	category, err := scanCategory(c.db.QueryRow(ctx, query, timeOfDay(time.Now()), id))
+	if err != nil {
 		return &models.Category{}, err
 	}
-	return &models.Category{
-		Id:        category.Id,
-		Name:      name.String,
-		CreatedAt: created_at.String,
-		UpdatedAt: updated_at.String,
-	}, nil
+	return category, nil
 }
 
 func (c *CategoryRepo) Delete(ctx context.Context, id string) error {
@@ -150,3 +216,113 @@ func (c *CategoryRepo) Delete(ctx context.Context, id string) error {
 	}
 	return nil
 }
+
+// categoryColumns must be selected after visibleCategoriesCTE.
+const categoryColumns = `
+	id,
+	name,
+	parent_id,
+	sort_order,
+	icon_url,
+	is_active,
+	to_char(visible_from, 'HH24:MI'),
+	to_char(visible_to, 'HH24:MI'),
+	id IN (SELECT id FROM visible_category),
+	(SELECT count(p.id) FROM "product" p WHERE p.category_id = "category".id),
+	translations,
+	created_at,
+	updated_at`
+
+func scanCategory(row pgx.Row) (*models.Category, error) {
+	var (
+		category    models.Category
+		name        sql.NullString
+		parentId    sql.NullString
+		iconURL     sql.NullString
+		visibleFrom sql.NullString
+		visibleTo   sql.NullString
+		createdAt   sql.NullTime
+		updatedAt   sql.NullTime
+	)
+	if err := row.Scan(
+		&category.Id,
+		&name,
+		&parentId,
+		&category.SortOrder,
+		&iconURL,
+		&category.IsActive,
+		&visibleFrom,
+		&visibleTo,
+		&category.IsVisible,
+		&category.ProductCount,
+		&category.Translations,
+		&createdAt,
+		&updatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	category.Name = name.String
+	category.ParentId = parentId.String
+	category.IconURL = iconURL.String
+	category.VisibleFrom = visibleFrom.String
+	category.VisibleTo = visibleTo.String
+	category.CreatedAt = formatLocalTime(createdAt)
+	category.UpdatedAt = formatLocalTime(updatedAt)
+
+	return &category, nil
+}
+
+// buildCategoryTree nests categories under their parents. A category whose
+// parent is not in the list becomes a root.
+func buildCategoryTree(categories []models.Category) []models.Category {
+	present := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		present[category.Id] = true
+	}
+
+	children := make(map[string][]models.Category)
+	var roots []models.Category
+	for _, category := range categories {
+		if category.ParentId != "" && present[category.ParentId] {
+			children[category.ParentId] = append(children[category.ParentId], category)
+		} else {
+			roots = append(roots, category)
+		}
+	}
+
+	var attach func(nodes []models.Category) []models.Category
+	attach = func(nodes []models.Category) []models.Category {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			if nodes[i].SortOrder != nodes[j].SortOrder {
+				return nodes[i].SortOrder < nodes[j].SortOrder
+			}
+			return nodes[i].Name < nodes[j].Name
+		})
+		for i := range nodes {
+			nodes[i].Children = attach(children[nodes[i].Id])
+			for _, child := range nodes[i].Children {
+				nodes[i].ProductCount += child.ProductCount
+			}
+		}
+		return nodes
+	}
+
+	return attach(roots)
+}
+
+// filterCategoryTree keeps the categories whose name contains search, with
+// their whole subtree, and the ancestors leading to them.
+func filterCategoryTree(nodes []models.Category, search string) []models.Category {
+	var kept []models.Category
+	for _, node := range nodes {
+		if strings.Contains(strings.ToLower(node.Name), search) {
+			kept = append(kept, node)
+			continue
+		}
+		if node.Children = filterCategoryTree(node.Children, search); len(node.Children) > 0 {
+			kept = append(kept, node)
+		}
+	}
+	return kept
+}
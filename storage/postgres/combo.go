@@ -3,11 +3,17 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"food/api/models"
 	"food/pkg/logger"
+	"food/storage"
+	"math"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -22,282 +28,453 @@ func NewCombo(db *pgxpool.Pool, log logger.LoggerI) ComboRepo {
 		log: log,
 	}
 }
+
+// comboAvailableCond holds for combos customers can order at a time of day:
+// not archived, active and inside their daily window. %[1]d is the index of
+// the time parameter.
+const comboAvailableCond = `(c.archived_at IS NULL AND c.is_active AND (c.available_from IS NULL OR CASE
+			WHEN c.available_from <= c.available_to THEN $%[1]d::time >= c.available_from AND $%[1]d::time < c.available_to
+			ELSE $%[1]d::time >= c.available_from OR $%[1]d::time < c.available_to
+		END))`
+
+// comboColumns selects a combo from "combo" c. %[1]d is the index of the
+// time parameter of comboAvailableCond.
+const comboColumns = `
+	c.id,
+	c.name,
+	c.description,
+	c.price,
+	c.is_active,
+	to_char(c.available_from, 'HH24:MI'),
+	to_char(c.available_to, 'HH24:MI'),
+	` + comboAvailableCond + `,
+	c.archived_at,
+	c.translations,
+	c.created_at,
+	c.updated_at,
+	coalesce((SELECT sum(p.price * ci.quantity) FROM "combo_items" ci JOIN "product" p ON p.id = ci.product_id WHERE ci.combo_id = c.id), 0)`
+
 func (c *ComboRepo) Create(ctx context.Context, combo *models.ComboCreateRequest) (*models.ComboCreateRequest, error) {
-	tx, err := c.db.Begin(context.Background())
+	tx, err := c.db.Begin(ctx)
 	if err != nil {
 		return &models.ComboCreateRequest{}, err
 	}
-	defer func() {
-		if err != nil {
-			tx.Rollback(context.Background())
-		}
-	}()
+	defer tx.Rollback(ctx)
 
-	// Generate a new UUID for the combo
 	comboId := uuid.New().String()
 
-	// var totalSum float64
-	for i, item := range combo.Items {
-		if item.Quantity <= 0 {
-			return &models.ComboCreateRequest{}, fmt.Errorf("quantity must be greater than 0 for product %s", item.ProductId)
-		}
-
-		var productPrice float64
-		productQuery := `SELECT price FROM "product" WHERE id = $1`
-		err = c.db.QueryRow(context.Background(), productQuery, item.ProductId).Scan(&productPrice)
-		if err != nil {
-			return &models.ComboCreateRequest{}, fmt.Errorf("failed to retrieve price for product %s: %w", item.ProductId, err)
-		}
-
-		combo.Items[i].Price = productPrice
-		combo.Items[i].TotalPrice = productPrice * float64(item.Quantity)
-		// totalSum += combo.Items[i].TotalPrice
-		combo.Items[i].ComboId = comboId
-		combo.Items[i].CreatedAt = item.CreatedAt
-	}
-
-	// Insert the combo
-	comboQuery := `INSERT INTO "combo" (id, name, price, description, created_at) 
-					  VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP) RETURNING id`
-
-	_, err = tx.Exec(context.Background(), comboQuery, comboId, combo.Combo.Name, combo.Combo.Price, combo.Combo.Description)
+	comboQuery := `INSERT INTO "combo" (id, name, price, description, is_active, available_from, available_to, translations, created_at, updated_at)
+					  VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::time, NULLIF($7, '')::time, $8, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+
+	_, err = tx.Exec(ctx, comboQuery,
+		comboId,
+		combo.Combo.Name,
+		combo.Combo.Price,
+		combo.Combo.Description,
+		combo.Combo.IsActive,
+		combo.Combo.AvailableFrom,
+		combo.Combo.AvailableTo,
+		translationsArg(combo.Combo.Translations),
+	)
 	if err != nil {
 		return &models.ComboCreateRequest{}, err
 	}
 
-	// Insert the combo items
-	itemQuery := `INSERT INTO "combo_items" (id, quantity, combo_id, product_id, price, total_price, created_at) 
-					 VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP)`
-
-	for _, item := range combo.Items {
-		itemId := uuid.New().String()
-		_, err = tx.Exec(context.Background(), itemQuery, itemId, item.Quantity, comboId, item.ProductId, item.Price, item.TotalPrice)
-		for i := range combo.Items {
-			combo.Items[i].Id = itemId
-			combo.Items[i].CreatedAt = item.CreatedAt
-		}
-		if err != nil {
-			return &models.ComboCreateRequest{}, err
-		}
+	if err := insertComboItems(ctx, tx, comboId, combo.Items); err != nil {
+		return &models.ComboCreateRequest{}, err
 	}
 
-	combo.Combo.Id = comboId
-	// combo.Combo.TotalPrice = totalSum
+	if err := tx.Commit(ctx); err != nil {
+		return &models.ComboCreateRequest{}, err
+	}
 
-	return combo, tx.Commit(context.Background())
+	return c.GetCombo(ctx, comboId)
 }
 
+// GetAll lists combos newest first. Unless request.IncludeInactive is set only
+// combos that can be ordered right now are returned.
 func (c *ComboRepo) GetAll(ctx context.Context, request *models.GetAllCombosRequest) (*[]models.ComboCreateRequest, error) {
 	var (
-		combos     []models.ComboCreateRequest
-		created_at sql.NullString
-		updated_at sql.NullString
+		combos = []models.ComboCreateRequest{}
+		args   = []interface{}{timeOfDay(time.Now())}
+		filter = " WHERE true"
 	)
 
-	comboQuery := `
-		SELECT id, name, description, price, created_at, updated_at
-		FROM "combo"
-		ORDER BY created_at DESC
-	`
-	rows, err := c.db.Query(ctx, comboQuery)
+	if !request.IncludeInactive {
+		filter += " AND " + fmt.Sprintf(comboAvailableCond, 1)
+	}
+
+	if request.Search != "" {
+		args = append(args, "%"+request.Search+"%")
+		filter += fmt.Sprintf(" AND (c.name ILIKE $%[1]d OR c.description ILIKE $%[1]d)", len(args))
+	}
+
+	args = append(args, (request.Page-1)*request.Limit, request.Limit)
+	comboQuery := `SELECT ` + fmt.Sprintf(comboColumns, 1) + ` FROM "combo" c` + filter +
+		fmt.Sprintf(` ORDER BY c.created_at DESC OFFSET $%d LIMIT $%d`, len(args)-1, len(args))
+
+	rows, err := c.db.Query(ctx, comboQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve combos: %w", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var combo models.Combo
-		err = rows.Scan(&combo.Id, &combo.Name, &combo.Description, &combo.Price, &created_at, &updated_at)
+		combo, err := scanCombo(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan combo: %w", err)
 		}
+		combos = append(combos, models.ComboCreateRequest{Combo: *combo})
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 
-		comboItemQuery := `
-			SELECT id, product_id, combo_id, quantity, price, total_price, created_at, updated_at
-			FROM "combo_items"
-			WHERE combo_id = $1
-		`
-		itemRows, err := c.db.Query(ctx, comboItemQuery, combo.Id)
-		if err != nil {
-			return nil, fmt.Errorf("failed to retrieve items for combo %s: %w", combo.Id, err)
-		}
-		defer itemRows.Close()
-
-		var comboItems []models.ComboItem
-		for itemRows.Next() {
-			var item models.ComboItem
-			err = itemRows.Scan(&item.Id, &item.ProductId, &item.ComboId, &item.Quantity, &item.Price, &item.TotalPrice, &created_at, &updated_at)
-			if err != nil {
-				return nil, fmt.Errorf("failed to scan combo item: %w", err)
-			}
-			comboItems = append(comboItems, models.ComboItem{
-				Id:         item.Id,
-				ProductId:  item.ProductId,
-				ComboId:    item.ComboId,
-				Quantity:   item.Quantity,
-				Price:      item.Price,
-				TotalPrice: item.TotalPrice,
-				CreatedAt:  created_at.String,
-				UpdatedAt:  updated_at.String,
-			})
-		}
-
-		combos = append(combos, models.ComboCreateRequest{
-			Combo: models.Combo{
-				Id:          combo.Id,
-				Name:        combo.Name,
-				Description: combo.Description,
-				Price:       combo.Price,
-				CreatedAt:   created_at.String,
-				UpdatedAt:   updated_at.String,
-			},
-			Items: comboItems,
-		})
+	ids := make([]string, 0, len(combos))
+	for _, combo := range combos {
+		ids = append(ids, combo.Combo.Id)
 	}
 
-	if err = rows.Err(); err != nil {
+	items, err := loadComboItems(ctx, c.db, ids)
+	if err != nil {
 		return nil, err
 	}
+	galleries, err := loadImages(ctx, c.db, "combo_id", ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range combos {
+		combos[i].Items = items[combos[i].Combo.Id]
+		combos[i].Combo.Images = galleries[combos[i].Combo.Id]
+	}
 
 	return &combos, nil
 }
 
+// GetCombo returns the combo with its items at current product prices, also
+// when it is archived. It returns pgx.ErrNoRows for an unknown id.
 func (r *ComboRepo) GetCombo(ctx context.Context, id string) (*models.ComboCreateRequest, error) {
-	var (
-		created_at sql.NullString
-		updated_at sql.NullString
-	)
+	comboQuery := `SELECT ` + fmt.Sprintf(comboColumns, 1) + ` FROM "combo" c WHERE c.id = $2`
 
-	comboQuery := `
-		SELECT id, name, description, price, created_at, updated_at
-		FROM "combo"
-		WHERE id = $1
-	`
+	combo, err := scanCombo(r.db.QueryRow(ctx, comboQuery, timeOfDay(time.Now()), id))
+	if err != nil {
+		return nil, err
+	}
 
-	var combo models.Combo
-	err := r.db.QueryRow(ctx, comboQuery, id).Scan(&combo.Id, &combo.Name, &combo.Description, &combo.Price, &created_at, &updated_at)
+	items, err := loadComboItems(ctx, r.db, []string{combo.Id})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("combo not found")
-		}
-		return nil, fmt.Errorf("failed to retrieve combo: %w", err)
+		return nil, err
 	}
 
-	combo.CreatedAt = created_at.String
-	combo.UpdatedAt = updated_at.String
+	galleries, err := loadImages(ctx, r.db, "combo_id", []string{combo.Id})
+	if err != nil {
+		return nil, err
+	}
+	combo.Images = galleries[combo.Id]
+
+	return &models.ComboCreateRequest{
+		Combo: *combo,
+		Items: items[combo.Id],
+	}, nil
+}
+
+// Update saves the combo fields. When updatedCombo.ComboItems is not nil it
+// also replaces all items, in the same transaction.
+func (r *ComboRepo) Update(ctx context.Context, id string, updatedCombo *models.Combo) (*models.ComboCreateRequest, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
-	comboItemQuery := `
-		SELECT id, combo_id, product_id, quantity, price, total_price, created_at, updated_at
-		FROM "combo_items"
-		WHERE combo_id = $1
+	comboUpdateQuery := `
+		UPDATE "combo"
+		SET name = $1, description = $2, price = $3, is_active = $4,
+			available_from = NULLIF($5, '')::time, available_to = NULLIF($6, '')::time,
+			translations = COALESCE($7, translations), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8
 	`
-	itemRows, err := r.db.Query(ctx, comboItemQuery, combo.Id)
+	tag, err := tx.Exec(ctx, comboUpdateQuery,
+		updatedCombo.Name,
+		updatedCombo.Description,
+		updatedCombo.Price,
+		updatedCombo.IsActive,
+		updatedCombo.AvailableFrom,
+		updatedCombo.AvailableTo,
+		optionalTranslationsArg(updatedCombo.Translations),
+		id,
+	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to retrieve items for combo %s: %w", combo.Id, err)
+		return nil, fmt.Errorf("failed to update combo: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, pgx.ErrNoRows
 	}
-	defer itemRows.Close()
 
-	var comboItems []models.ComboItem
-	for itemRows.Next() {
-		var item models.ComboItem
-		err = itemRows.Scan(&item.Id, &item.ComboId, &item.ProductId, &item.Quantity, &item.Price, &item.TotalPrice, &created_at, &updated_at)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan combo item: %w", err)
+	if updatedCombo.ComboItems != nil {
+		if err := replaceComboItems(ctx, tx, id, updatedCombo.ComboItems); err != nil {
+			return nil, err
 		}
+	}
 
-		item.CreatedAt = created_at.String
-		item.UpdatedAt = updated_at.String
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
 
-		comboItems = append(comboItems, item)
+	return r.GetCombo(ctx, id)
+}
+
+// AddItem adds a product to the combo, or raises its quantity when the combo
+// already contains it.
+func (r *ComboRepo) AddItem(ctx context.Context, comboId string, item *models.ComboItem) (*models.ComboCreateRequest, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
 	}
+	defer tx.Rollback(ctx)
 
-	if err = itemRows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating combo items: %w", err)
+	if err := lockCombo(ctx, tx, comboId); err != nil {
+		return nil, err
 	}
 
-	return &models.ComboCreateRequest{
-		Combo: combo,
-		Items: comboItems,
-	}, nil
+	tag, err := tx.Exec(ctx, `
+		UPDATE "combo_items" ci
+		SET quantity = ci.quantity + $3::int, price = p.price, total_price = p.price * (ci.quantity + $3::int), updated_at = CURRENT_TIMESTAMP
+		FROM "product" p
+		WHERE ci.combo_id = $1 AND ci.product_id = $2 AND p.id = ci.product_id`,
+		comboId, item.ProductId, item.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		if err := insertComboItems(ctx, tx, comboId, []models.ComboItem{*item}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := touchCombo(ctx, tx, comboId); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return r.GetCombo(ctx, comboId)
 }
 
-func (r *ComboRepo) Update(ctx context.Context, id string, updatedCombo *models.Combo) (*models.ComboCreateRequest, error) {
+// RemoveItem deletes one item. It returns storage.ErrEmptyCombo instead of
+// removing the last item.
+func (r *ComboRepo) RemoveItem(ctx context.Context, comboId, itemId string) (*models.ComboCreateRequest, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockCombo(ctx, tx, comboId); err != nil {
+		return nil, err
+	}
+
+	tag, err := tx.Exec(ctx, `DELETE FROM "combo_items" WHERE combo_id = $1 AND id = $2`, comboId, itemId)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, pgx.ErrNoRows
+	}
 
+	var left int
+	if err := tx.QueryRow(ctx, `SELECT count(*) FROM "combo_items" WHERE combo_id = $1`, comboId).Scan(&left); err != nil {
+		return nil, err
+	}
+	if left == 0 {
+		return nil, storage.ErrEmptyCombo
+	}
+
+	if err := touchCombo(ctx, tx, comboId); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return r.GetCombo(ctx, comboId)
+}
+
+// ReplaceItems swaps all items of the combo for items.
+func (r *ComboRepo) ReplaceItems(ctx context.Context, comboId string, items []models.ComboItem) (*models.ComboCreateRequest, error) {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockCombo(ctx, tx, comboId); err != nil {
+		return nil, err
+	}
+	if err := replaceComboItems(ctx, tx, comboId, items); err != nil {
+		return nil, err
+	}
+	if err := touchCombo(ctx, tx, comboId); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return r.GetCombo(ctx, comboId)
+}
+
+// Archive hides the combo from customers but keeps it for reporting.
+func (r *ComboRepo) Archive(ctx context.Context, id string) error {
+	tag, err := r.db.Exec(ctx, `UPDATE "combo" SET archived_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = $1 AND archived_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// Delete removes the combo with its items and images. Combos that have been
+// ordered stay for the order history and return storage.ErrComboOrdered.
+func (r *ComboRepo) Delete(ctx context.Context, id string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM "combo" WHERE id = $1`, id)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return storage.ErrComboOrdered
+	}
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func lockCombo(ctx context.Context, tx pgx.Tx, comboId string) error {
+	var id string
+	return tx.QueryRow(ctx, `SELECT id FROM "combo" WHERE id = $1 FOR UPDATE`, comboId).Scan(&id)
+}
+
+func touchCombo(ctx context.Context, tx pgx.Tx, comboId string) error {
+	_, err := tx.Exec(ctx, `UPDATE "combo" SET updated_at = CURRENT_TIMESTAMP WHERE id = $1`, comboId)
+	return err
+}
+
+func replaceComboItems(ctx context.Context, tx pgx.Tx, comboId string, items []models.ComboItem) error {
+	if len(items) == 0 {
+		return storage.ErrEmptyCombo
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM "combo_items" WHERE combo_id = $1`, comboId); err != nil {
+		return err
 	}
-	defer func() {
+	return insertComboItems(ctx, tx, comboId, items)
+}
+
+// insertComboItems adds the items at the current product prices. It returns
+// storage.ErrInvalidProduct when a product does not exist.
+func insertComboItems(ctx context.Context, tx pgx.Tx, comboId string, items []models.ComboItem) error {
+	itemQuery := `INSERT INTO "combo_items" (id, quantity, combo_id, product_id, price, total_price, created_at, updated_at)
+		SELECT $1, $2::int, $3, p.id, p.price, p.price * $2::int, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+		FROM "product" p WHERE p.id = $4`
+
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return fmt.Errorf("%w for product %s", storage.ErrInvalidQuantity, item.ProductId)
+		}
+
+		tag, err := tx.Exec(ctx, itemQuery, uuid.New().String(), item.Quantity, comboId, item.ProductId)
 		if err != nil {
-			tx.Rollback(ctx)
-		} else {
-			tx.Commit(ctx)
+			return fmt.Errorf("failed to add product %s: %w", item.ProductId, err)
 		}
-	}()
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("%w: %s", storage.ErrInvalidProduct, item.ProductId)
+		}
+	}
+	return nil
+}
 
-	// Update the combo
-	comboUpdateQuery := `
-		UPDATE "combo"
-		SET name = $1, description = $2, price = $3, updated_at = CURRENT_TIMESTAMP
-		WHERE id = $4 
-		RETURNING id, name, description, price, created_at, updated_at
-	`
-	var combo models.Combo
-	err = tx.QueryRow(ctx, comboUpdateQuery, updatedCombo.Name, updatedCombo.Description, updatedCombo.Price, id).Scan(
-		&combo.Id, &combo.Name, &combo.Description, &combo.Price, &combo.CreatedAt, &combo.UpdatedAt,
-	)
+// loadComboItems returns the items of the combos keyed by combo id, priced at
+// the current product prices.
+func loadComboItems(ctx context.Context, db *pgxpool.Pool, comboIds []string) (map[string][]models.ComboItem, error) {
+	items := make(map[string][]models.ComboItem)
+	if len(comboIds) == 0 {
+		return items, nil
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT ci.id, ci.combo_id, ci.product_id, ci.quantity, p.price, p.price * ci.quantity, ci.created_at, ci.updated_at
+		FROM "combo_items" ci
+		JOIN "product" p ON p.id = ci.product_id
+		WHERE ci.combo_id = ANY($1::uuid[])
+		ORDER BY ci.created_at, ci.id`, comboIds)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update and retrieve combo: %w", err)
-	}
-
-	// Update associated combo items
-	var comboItems []models.ComboItem
-	for _, item := range updatedCombo.ComboItems {
-		if item.Id != "" {
-			// Update existing combo item
-			itemUpdateQuery := `
-				UPDATE "comboitem"
-				SET product_id = $1, quantity = $2, price = $3, total_price = $4, updated_at = CURRENT_TIMESTAMP
-				WHERE combo_id = $5 AND id = $6 
-				RETURNING id, product_id, combo_id, quantity, price, total_price, created_at, updated_at
-			`
-			var updatedItem models.ComboItem
-			err := tx.QueryRow(ctx, itemUpdateQuery, item.ProductId, item.Quantity, item.Price, item.TotalPrice, id, item.Id).Scan(
-				&updatedItem.Id, &updatedItem.ProductId, &updatedItem.ComboId, &updatedItem.Quantity, &updatedItem.Price, &updatedItem.TotalPrice, &updatedItem.CreatedAt, &updatedItem.UpdatedAt,
-			)
-			if err != nil {
-				return nil, fmt.Errorf("failed to update and retrieve combo item with ID %s: %w", item.Id, err)
-			}
-			comboItems = append(comboItems, updatedItem)
-		} else {
-			// Insert new combo item
-			itemInsertQuery := `
-				INSERT INTO "comboitem" (id, combo_id, product_id, quantity, price, total_price, created_at, updated_at)
-				VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
-				RETURNING id, product_id, combo_id, quantity, price, total_price, created_at, updated_at
-			`
-			var newItem models.ComboItem
-			err := tx.QueryRow(ctx, itemInsertQuery, id, item.ProductId, item.Quantity, item.Price, item.TotalPrice).Scan(
-				&newItem.Id, &newItem.ProductId, &newItem.ComboId, &newItem.Quantity, &newItem.Price, &newItem.TotalPrice, &newItem.CreatedAt, &newItem.UpdatedAt,
-			)
-			if err != nil {
-				return nil, fmt.Errorf("failed to insert new combo item: %w", err)
-			}
-			comboItems = append(comboItems, newItem)
+		return nil, fmt.Errorf("failed to retrieve combo items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			item       models.ComboItem
+			created_at sql.NullTime
+			updated_at sql.NullTime
+		)
+		if err := rows.Scan(&item.Id, &item.ComboId, &item.ProductId, &item.Quantity, &item.Price, &item.TotalPrice, &created_at, &updated_at); err != nil {
+			return nil, fmt.Errorf("failed to scan combo item: %w", err)
 		}
+		item.CreatedAt = formatLocalTime(created_at)
+		item.UpdatedAt = formatLocalTime(updated_at)
+		items[item.ComboId] = append(items[item.ComboId], item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating combo items: %w", err)
 	}
 
-	comboCreateRequest := &models.ComboCreateRequest{
-		Combo: models.Combo{
-			Id:          combo.Id,
-			Name:        combo.Name,
-			Description: combo.Description,
-			Price:       combo.Price,
-			CreatedAt:   combo.CreatedAt,
-			UpdatedAt:   combo.UpdatedAt,
-		},
-		Items: comboItems,
+	return items, nil
+}
+
+func scanCombo(row pgx.Row) (*models.Combo, error) {
+	var (
+		combo         models.Combo
+		name          sql.NullString
+		description   sql.NullString
+		price         sql.NullFloat64
+		availableFrom sql.NullString
+		availableTo   sql.NullString
+		archivedAt    sql.NullTime
+		created_at    sql.NullTime
+		updated_at    sql.NullTime
+	)
+	if err := row.Scan(
+		&combo.Id,
+		&name,
+		&description,
+		&price,
+		&combo.IsActive,
+		&availableFrom,
+		&availableTo,
+		&combo.IsAvailable,
+		&archivedAt,
+		&combo.Translations,
+		&created_at,
+		&updated_at,
+		&combo.ItemsTotal,
+	); err != nil {
+		return nil, err
 	}
 
-	return comboCreateRequest, nil
+	combo.Name = name.String
+	combo.Description = description.String
+	combo.Price = price.Float64
+	combo.AvailableFrom = availableFrom.String
+	combo.AvailableTo = availableTo.String
+	combo.ArchivedAt = formatLocalTime(archivedAt)
+	combo.CreatedAt = formatLocalTime(created_at)
+	combo.UpdatedAt = formatLocalTime(updated_at)
+	combo.Savings = math.Round((combo.ItemsTotal-combo.Price)*100) / 100
+
+	return &combo, nil
 }
@@ -2,12 +2,23 @@ package postgres
 
 import (
 	"context"
+	"crypto/subtle"
 	"database/sql"
+	"errors"
 	"fmt"
 	"food/api/models"
+	"food/config"
+	"food/pkg/geo"
+	"food/pkg/jobs"
 	"food/pkg/logger"
+	"food/pkg/notify"
+	"food/pkg/webhook"
+	"food/storage"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -23,10 +34,18 @@ func NewCourierAssignment(db *pgxpool.Pool, log logger.LoggerI) CourierAssignmen
 	}
 }
 
+// Create assigns an order to a courier. The customer is told, and the admins
+// are alerted when the courier has not accepted the job within
+// config.CourierAcceptMinutes.
 func (c *CourierAssignmentRepo) Create(ctx context.Context, assignment *models.CourierAssignment) (*models.CourierAssignment, error) {
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return &models.CourierAssignment{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
 	id := uuid.New()
-	query := `INSERT INTO "courier_assignments" (
+	query := `INSERT INTO "courierassignment" (
 		id,
 		order_id,
 		courier_id,
@@ -36,28 +55,49 @@ func (c *CourierAssignmentRepo) Create(ctx context.Context, assignment *models.C
 		VALUES($1,$2,$3,CURRENT_TIMESTAMP,$4,CURRENT_TIMESTAMP) 
 	`
 
-	_, err := c.db.Exec(context.Background(), query,
+	_, err = tx.Exec(ctx, query,
 		id.String(),
 		assignment.OrderId,
 		assignment.CourierId,
 		assignment.Status,
 	)
 
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		if pgErr.ConstraintName == "courierassignment_courier_id_fkey" {
+			return &models.CourierAssignment{}, storage.ErrInvalidCourier
+		}
+		return &models.CourierAssignment{}, pgx.ErrNoRows
+	}
 	if err != nil {
 		return &models.CourierAssignment{}, err
 	}
-	return &models.CourierAssignment{
-		Id:         id.String(),
-		OrderId:    assignment.OrderId,
-		CourierId:  assignment.CourierId,
-		AssignedAt: assignment.AssignedAt,
-		Status:     assignment.Status,
-		UpdatedAt:  assignment.UpdatedAt,
-	}, nil
+
+	_, err = enqueueJob(ctx, tx, &models.EnqueueJob{
+		Type:    jobs.TypeOrderNotification,
+		Payload: jobs.OrderNotification{OrderId: assignment.OrderId, Event: notify.EventCourierAssigned},
+	})
+	if err != nil {
+		return &models.CourierAssignment{}, err
+	}
+	_, err = enqueueJob(ctx, tx, &models.EnqueueJob{
+		Type:      jobs.TypeCourierAcceptTimeout,
+		Payload:   jobs.Assignment{AssignmentId: id.String()},
+		UniqueKey: jobs.TypeCourierAcceptTimeout + ":" + id.String(),
+		RunAt:     time.Now().Add(config.CourierAcceptMinutes * time.Minute),
+	})
+	if err != nil {
+		return &models.CourierAssignment{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return &models.CourierAssignment{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return c.GetByID(ctx, id.String())
 }
 
 func (c *CourierAssignmentRepo) Update(ctx context.Context, assignment *models.CourierAssignment) (*models.CourierAssignment, error) {
-	query := `UPDATE "courier_assignments" SET 
+	query := `UPDATE "courierassignment" SET 
 		order_id=$1,
 		courier_id=$2,
 		status=$3,
@@ -83,99 +123,462 @@ func (c *CourierAssignmentRepo) Update(ctx context.Context, assignment *models.C
 	}, nil
 }
 
+// courierAssignmentColumns are read by scanCourierAssignment.
+const courierAssignmentColumns = `a.id, a.order_id, a.courier_id, a.status, a.assigned_at, a.accepted_at, a.delivered_at,
+	coalesce(a.proof_type, ''), coalesce(a.proof_photo_url, ''), a.proof_latitude, a.proof_longitude, a.proof_distance_m,
+	coalesce(a.proof_note, ''), a.updated_at`
+
+// scanCourierAssignment scans courierAssignmentColumns preceded by head and
+// followed by tail.
+func scanCourierAssignment(row pgx.Row, head []interface{}, tail ...interface{}) (*models.CourierAssignment, error) {
+	var (
+		assignment  models.CourierAssignment
+		proof       models.DeliveryProof
+		assignedAt  sql.NullTime
+		acceptedAt  sql.NullTime
+		deliveredAt sql.NullTime
+		updatedAt   sql.NullTime
+		latitude    sql.NullFloat64
+		longitude   sql.NullFloat64
+		distance    sql.NullFloat64
+	)
+	dest := append(head,
+		&assignment.Id,
+		&assignment.OrderId,
+		&assignment.CourierId,
+		&assignment.Status,
+		&assignedAt,
+		&acceptedAt,
+		&deliveredAt,
+		&proof.Type,
+		&proof.PhotoURL,
+		&latitude,
+		&longitude,
+		&distance,
+		&proof.Note,
+		&updatedAt,
+	)
+	if err := row.Scan(append(dest, tail...)...); err != nil {
+		return nil, err
+	}
+
+	assignment.AssignedAt = formatLocalTime(assignedAt)
+	assignment.AcceptedAt = formatLocalTime(acceptedAt)
+	assignment.DeliveredAt = formatLocalTime(deliveredAt)
+	assignment.UpdatedAt = formatLocalTime(updatedAt)
+	if proof.Type != "" {
+		proof.Latitude = latitude.Float64
+		proof.Longitude = longitude.Float64
+		proof.DistanceM = distance.Float64
+		assignment.Proof = &proof
+	}
+	return &assignment, nil
+}
+
 func (c *CourierAssignmentRepo) GetAll(ctx context.Context, req *models.GetAllCourierAssignmentsRequest) (*models.GetAllCourierAssignmentsResponse, error) {
 	var (
 		resp   = &models.GetAllCourierAssignmentsResponse{}
 		filter = ""
+		args   []interface{}
 	)
 	offset := (req.Page - 1) * req.Limit
 
 	if req.Search != "" {
-		filter += fmt.Sprintf(` AND (order_id ILIKE '%%%v%%' OR courier_id ILIKE '%%%v%%' OR status ILIKE '%%%v%%') `, req.Search, req.Search, req.Search)
+		filter += ` WHERE (a.order_id::text ILIKE $1 OR a.courier_id::text ILIKE $1 OR a.status ILIKE $1) `
+		args = append(args, "%"+req.Search+"%")
 	}
 
-	filter += fmt.Sprintf(" OFFSET %v LIMIT %v", offset, req.Limit)
-	fmt.Println("filter: ", filter)
+	filter += fmt.Sprintf(" ORDER BY a.assigned_at DESC OFFSET %v LIMIT %v", offset, req.Limit)
 
-	rows, err := c.db.Query(context.Background(), `SELECT count(id) OVER(),
-        id,
-        order_id,
-        courier_id,
-        assigned_at,
-        status,
-        updated_at FROM courier_assignments FROM "courier_assignment"`+filter)
+	rows, err := c.db.Query(ctx, `SELECT count(a.id) OVER(), `+courierAssignmentColumns+` FROM "courierassignment" a`+filter, args...)
 	if err != nil {
 		return resp, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		var (
-			assignment models.CourierAssignment
-			orderID    sql.NullString
-			courierID  sql.NullString
-			assignedAt sql.NullString
-			status     sql.NullString
-			updatedAt  sql.NullString
-		)
-		if err := rows.Scan(
-			&resp.Count,
-			&assignment.Id,
-			&orderID,
-			&courierID,
-			&assignedAt,
-			&status,
-			&updatedAt); err != nil {
+		assignment, err := scanCourierAssignment(rows, []interface{}{&resp.Count})
+		if err != nil {
 			return resp, err
 		}
-
-		resp.CourierAssignments = append(resp.CourierAssignments, models.CourierAssignment{
-			Id:         assignment.Id,
-			OrderId:    orderID.String,
-			CourierId:  courierID.String,
-			AssignedAt: assignedAt.String,
-			Status:     status.String,
-			UpdatedAt:  updatedAt.String,
-		})
+		resp.CourierAssignments = append(resp.CourierAssignments, *assignment)
 	}
-	return resp, nil
+	return resp, rows.Err()
 }
 
 func (c *CourierAssignmentRepo) GetByID(ctx context.Context, id string) (*models.CourierAssignment, error) {
-	var (
-		assignment models.CourierAssignment
-		orderID    sql.NullString
-		courierID  sql.NullString
-		assignedAt sql.NullString
-		status     sql.NullString
-		createdAt  sql.NullString
-		updatedAt  sql.NullString
-	)
-	if err := c.db.QueryRow(context.Background(), `SELECT id, order_id, courier_id, assigned_at, status, created_at, updated_at FROM "courier_assignments" WHERE id = $1`, id).Scan(
-		&assignment.Id,
-		&orderID,
-		&courierID,
-		&assignedAt,
-		&status,
-		&createdAt,
-		&updatedAt,
-	); err != nil {
+	assignment, err := scanCourierAssignment(c.db.QueryRow(ctx,
+		`SELECT `+courierAssignmentColumns+` FROM "courierassignment" a WHERE a.id = $1`, id), nil)
+	if err != nil {
 		return &models.CourierAssignment{}, err
 	}
-	return &models.CourierAssignment{
-		Id:         assignment.Id,
-		OrderId:    orderID.String,
-		CourierId:  courierID.String,
-		AssignedAt: assignedAt.String,
-		Status:     status.String,
-		UpdatedAt:  updatedAt.String,
-	}, nil
+	return assignment, nil
 }
 
 func (c *CourierAssignmentRepo) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM courier_assignments WHERE id = $1`
+	query := `DELETE FROM "courierassignment" WHERE id = $1`
 	_, err := c.db.Exec(context.Background(), query, id)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// courierSteps orders the assignment statuses; an assignment only moves
+// forward.
+var courierSteps = map[string]int{
+	"assigned":          1,
+	"picked_up":         2,
+	"en_route":          3,
+	"delivered":         4,
+	"payment_collected": 5,
+}
+
+// courierOrderStatus is the order status that follows each assignment
+// status.
+var courierOrderStatus = map[string]string{
+	"picked_up":         "picked_up",
+	"en_route":          "en_route",
+	"delivered":         "delivered",
+	"payment_collected": "delivered",
+}
+
+// Accept records that the courier took the job. Couriers must accept an
+// assignment before picking the order up.
+func (c *CourierAssignmentRepo) Accept(ctx context.Context, id, courierId string) (*models.CourierAssignment, error) {
+	tag, err := c.db.Exec(ctx, `UPDATE "courierassignment" a SET accepted_at = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE a.id = $1 AND a.courier_id = $2 AND a.status = 'assigned' AND a.accepted_at IS NULL
+			AND EXISTS (SELECT 1 FROM "order" o WHERE o.id = a.order_id AND o.status <> 'cancelled')`,
+		id, courierId, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept courier assignment: %w", err)
+	}
+
+	assignment, err := c.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.CourierId != courierId {
+		return nil, pgx.ErrNoRows
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, storage.ErrInvalidStatus
+	}
+	return assignment, nil
+}
+
+// Jobs lists the assignments a courier still has to work on, oldest first:
+// the ones not delivered yet and the delivered cash orders whose payment is
+// not collected.
+func (c *CourierAssignmentRepo) Jobs(ctx context.Context, courierId string) (*models.GetCourierJobsResponse, error) {
+	resp := &models.GetCourierJobsResponse{Jobs: []models.CourierJob{}}
+
+	rows, err := c.db.Query(ctx, `
+		SELECT `+courierAssignmentColumns+`,
+			o.status, o.total_price, o.tip, coalesce(p.payment_method, ''), coalesce(p.is_paid, false),
+			o.address_name, o.latitude, o.longitude, u.name, u.phone,
+			coalesce(b.id::text, ''), coalesce(b.name, ''), coalesce(b.address, '')
+		FROM "courierassignment" a
+		JOIN "order" o ON o.id = a.order_id
+		JOIN "user" u ON u.id = o.user_id
+		LEFT JOIN "branch" b ON b.id = o.branch_id
+		LEFT JOIN LATERAL (
+			SELECT payment_method, is_paid FROM "payment" WHERE order_id = o.id AND kind = 'order' ORDER BY created_at DESC LIMIT 1
+		) p ON true
+		WHERE a.courier_id = $1
+			AND o.status <> 'cancelled'
+			AND o.deleted_at IS NULL
+			AND (a.status IN ('assigned', 'picked_up', 'en_route')
+				OR (a.status = 'delivered' AND p.payment_method = 'naxt pul'))
+		ORDER BY a.assigned_at, a.id`, courierId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courier jobs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var job models.CourierJob
+		assignment, err := scanCourierAssignment(rows, nil,
+			&job.OrderStatus, &job.TotalPrice, &job.Tip, &job.PaymentMethod, &job.IsPaid,
+			&job.AddressName, &job.Latitude, &job.Longitude, &job.CustomerName, &job.CustomerPhone,
+			&job.BranchId, &job.BranchName, &job.BranchAddress,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan courier job: %w", err)
+		}
+		job.CourierAssignment = *assignment
+		resp.Jobs = append(resp.Jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	resp.Count = int64(len(resp.Jobs))
+	return resp, nil
+}
+
+// ChangeStatus moves an assignment forward and the order with it. Reaching
+// delivered records the delivery with its earnings, and payment_collected
+// records the cash of a 'naxt pul' order as held by the courier.
+//
+// A courier moves one step at a time, only after accepting the job, picks up
+// only orders the kitchen has marked ready, and must prove the delivery; see
+// checkHandover. Admins deliver orders that have a handover code only by
+// overriding it with a note.
+func (c *CourierAssignmentRepo) ChangeStatus(ctx context.Context, req *models.CourierStatusChange) (*models.CourierAssignment, error) {
+	next, ok := courierSteps[req.Status]
+	if !ok {
+		return nil, storage.ErrInvalidStatus
+	}
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		current      string
+		courierId    string
+		orderId      string
+		accepted     bool
+		orderStatus  string
+		handoverCode sql.NullString
+		latitude     float64
+		longitude    float64
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT a.status, a.courier_id, a.order_id, a.accepted_at IS NOT NULL, o.status, o.handover_code, o.latitude, o.longitude
+		FROM "courierassignment" a
+		JOIN "order" o ON o.id = a.order_id
+		WHERE a.id = $1
+		FOR UPDATE`, req.Id).Scan(
+		&current, &courierId, &orderId, &accepted, &orderStatus, &handoverCode, &latitude, &longitude,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if req.CourierId != "" && req.CourierId != courierId {
+		return nil, pgx.ErrNoRows
+	}
+	if courierSteps[current] >= next || orderStatus == "cancelled" {
+		return nil, storage.ErrInvalidStatus
+	}
+
+	proof := req.Proof
+	delivering := next >= courierSteps["delivered"] && courierSteps[current] < courierSteps["delivered"]
+	if req.CourierId != "" {
+		if !accepted || next != courierSteps[current]+1 {
+			return nil, storage.ErrInvalidStatus
+		}
+		if req.Status == "picked_up" && orderStatus != "ready" {
+			return nil, storage.ErrInvalidStatus
+		}
+		if delivering {
+			ok, err := checkHandover(ctx, tx, req, orderId, handoverCode.String, latitude, longitude)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				// Keep the failed attempt.
+				if err := tx.Commit(ctx); err != nil {
+					return nil, fmt.Errorf("failed to commit transaction: %w", err)
+				}
+				return nil, storage.ErrInvalidProof
+			}
+		}
+	} else if delivering && handoverCode.String != "" && (proof == nil || proof.Type != "override") {
+		return nil, storage.ErrInvalidProof
+	}
+	if !delivering {
+		proof = nil
+	}
+
+	now := time.Now()
+	var (
+		proofType, photoURL, note  string
+		proofLat, proofLng, meters *float64
+	)
+	if proof != nil {
+		proofType, photoURL, note = proof.Type, proof.PhotoURL, proof.Note
+		if proof.Latitude != 0 || proof.Longitude != 0 {
+			proofLat, proofLng, meters = &proof.Latitude, &proof.Longitude, &proof.DistanceM
+		}
+	}
+	_, err = tx.Exec(ctx, `UPDATE "courierassignment" SET
+		status = $1,
+		delivered_at = CASE WHEN $2 THEN COALESCE(delivered_at, $3) ELSE delivered_at END,
+		proof_type = COALESCE(NULLIF($4, ''), proof_type),
+		proof_photo_url = COALESCE(NULLIF($5, ''), proof_photo_url),
+		proof_latitude = COALESCE($6, proof_latitude),
+		proof_longitude = COALESCE($7, proof_longitude),
+		proof_distance_m = COALESCE($8, proof_distance_m),
+		proof_note = COALESCE(NULLIF($9, ''), proof_note),
+		updated_at = CURRENT_TIMESTAMP
+		WHERE id = $10`,
+		req.Status, next >= courierSteps["delivered"], now, proofType, photoURL, proofLat, proofLng, meters, note, req.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update courier assignment status: %w", err)
+	}
+
+	if proofType == "override" {
+		_, err = tx.Exec(ctx, `INSERT INTO "handover_attempt" (id, order_id, assignment_id, kind, success, note, created_at)
+			VALUES ($1, $2, $3, 'override', true, $4, $5)`,
+			uuid.New().String(), orderId, req.Id, note, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record handover override: %w", err)
+		}
+	}
+
+	tag, err := tx.Exec(ctx, `UPDATE "order" SET
+		status = $1,
+		delivered_at = CASE WHEN $1 = 'delivered' THEN COALESCE(delivered_at, $2) ELSE delivered_at END,
+		updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status <> $1`,
+		courierOrderStatus[req.Status], now, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update order status: %w", err)
+	}
+	if tag.RowsAffected() > 0 {
+		err = publishWebhookEvent(ctx, tx, webhook.EventOrderStatusChanged, models.WebhookOrderStatusChanged{
+			OrderId:        orderId,
+			Status:         courierOrderStatus[req.Status],
+			PreviousStatus: orderStatus,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Once the order is picked up the customer gets the handover code to
+	// give the courier at the door, and they hear when it is delivered.
+	switch req.Status {
+	case "picked_up":
+		_, err = enqueueJob(ctx, tx, &models.EnqueueJob{Type: jobs.TypeHandoverCode, Payload: jobs.Order{OrderId: orderId}})
+	case "delivered":
+		_, err = enqueueJob(ctx, tx, &models.EnqueueJob{
+			Type:    jobs.TypeOrderNotification,
+			Payload: jobs.OrderNotification{OrderId: orderId, Event: notify.EventOrderDelivered},
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if next >= courierSteps["delivered"] {
+		if err := recordDelivery(ctx, tx, req.Id, now); err != nil {
+			return nil, err
+		}
+	}
+	if req.Status == "payment_collected" {
+		if err := collectCash(ctx, tx, req.Id, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return c.GetByID(ctx, req.Id)
+}
+
+// checkHandover checks a courier's proof of delivery. Orders with a handover
+// code need the code, and every code entered is recorded as an attempt; once
+// config.MaxHandoverAttempts codes were wrong the order is locked until an
+// admin overrides the handover. Orders without a code take any proof.
+func checkHandover(ctx context.Context, tx pgx.Tx, req *models.CourierStatusChange, orderId, code string, latitude, longitude float64) (bool, error) {
+	if code == "" {
+		return checkDeliveryProof(req.Proof, "", latitude, longitude), nil
+	}
+	if req.Proof == nil || req.Proof.Type != "pin" {
+		return false, nil
+	}
+
+	var failed int
+	err := tx.QueryRow(ctx, `SELECT count(*) FROM "handover_attempt" WHERE order_id = $1 AND kind = 'code' AND NOT success`,
+		orderId).Scan(&failed)
+	if err != nil {
+		return false, fmt.Errorf("failed to count handover attempts: %w", err)
+	}
+	if failed >= config.MaxHandoverAttempts {
+		return false, storage.ErrHandoverLocked
+	}
+
+	ok := checkDeliveryProof(req.Proof, code, latitude, longitude)
+	_, err = tx.Exec(ctx, `INSERT INTO "handover_attempt" (id, order_id, assignment_id, courier_id, kind, success, created_at)
+		VALUES ($1, $2, $3, $4, 'code', $5, $6)`,
+		uuid.New().String(), orderId, req.Id, req.CourierId, ok, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to record handover attempt: %w", err)
+	}
+	return ok, nil
+}
+
+// HandoverAttempts lists the handover code checks and overrides of an order,
+// latest first.
+func (c *CourierAssignmentRepo) HandoverAttempts(ctx context.Context, orderId string) (*models.GetHandoverAttemptsResponse, error) {
+	var exists bool
+	if err := c.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM "order" WHERE id = $1)`, orderId).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, pgx.ErrNoRows
+	}
+
+	rows, err := c.db.Query(ctx, `
+		SELECT id, order_id, coalesce(assignment_id::text, ''), coalesce(courier_id::text, ''), kind, success, coalesce(note, ''), created_at
+		FROM "handover_attempt"
+		WHERE order_id = $1
+		ORDER BY created_at DESC, id`, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get handover attempts: %w", err)
+	}
+	defer rows.Close()
+
+	resp := &models.GetHandoverAttemptsResponse{Attempts: []models.HandoverAttempt{}}
+	for rows.Next() {
+		var (
+			attempt   models.HandoverAttempt
+			createdAt sql.NullTime
+		)
+		if err := rows.Scan(&attempt.Id, &attempt.OrderId, &attempt.AssignmentId, &attempt.CourierId,
+			&attempt.Kind, &attempt.Success, &attempt.Note, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan handover attempt: %w", err)
+		}
+		attempt.CreatedAt = formatLocalTime(createdAt)
+		if attempt.Kind == "code" && !attempt.Success {
+			resp.Failed++
+		}
+		resp.Attempts = append(resp.Attempts, attempt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	resp.Locked = resp.Failed >= config.MaxHandoverAttempts
+	return resp, nil
+}
+
+// checkDeliveryProof tells whether proof shows the order was handed over: a
+// photo was taken, the customer's code matches, or the courier is within
+// config.DeliveryGeofenceMeters of the drop-off. It fills in the distance
+// whenever the courier's position is known.
+func checkDeliveryProof(proof *models.DeliveryProof, code string, latitude, longitude float64) bool {
+	if proof == nil {
+		return false
+	}
+	located := proof.Latitude != 0 || proof.Longitude != 0
+	if located {
+		proof.DistanceM = roundAmount(geo.Distance(latitude, longitude, proof.Latitude, proof.Longitude))
+	}
+
+	switch proof.Type {
+	case "photo":
+		return proof.PhotoURL != ""
+	case "pin":
+		return code != "" && subtle.ConstantTimeCompare([]byte(proof.Code), []byte(code)) == 1
+	case "geofence":
+		return located && proof.DistanceM <= config.DeliveryGeofenceMeters
+	}
+	return false
+}
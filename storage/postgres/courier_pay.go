@@ -0,0 +1,619 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"food/api/models"
+	"food/pkg/geo"
+	"food/pkg/jobs"
+	"food/pkg/logger"
+	"food/pkg/webhook"
+	"food/storage"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// CourierPayRepo keeps courier pay rules, earnings, cash and payouts.
+type CourierPayRepo struct {
+	db  *pgxpool.Pool
+	log logger.LoggerI
+}
+
+func NewCourierPayRepo(db *pgxpool.Pool, log logger.LoggerI) *CourierPayRepo {
+	return &CourierPayRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+const courierRateColumns = `id, coalesce(branch_id::text, ''), base_rate, per_km_rate, peak_surcharge,
+	coalesce(to_char(peak_from, 'HH24:MI'), ''), coalesce(to_char(peak_to, 'HH24:MI'), ''), tip_share, created_at, updated_at`
+
+const courierPayoutColumns = `id, courier_id, amount, deliveries, period_to, status, coalesce(reference, ''), created_at, paid_at`
+
+// GetRates returns the default rate first, then the branch rates.
+func (c *CourierPayRepo) GetRates(ctx context.Context) ([]models.CourierRate, error) {
+	rows, err := c.db.Query(ctx, `SELECT `+courierRateColumns+` FROM "courier_rate" ORDER BY branch_id IS NOT NULL, created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courier rates: %w", err)
+	}
+	defer rows.Close()
+
+	rates := []models.CourierRate{}
+	for rows.Next() {
+		rate, err := scanCourierRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, *rate)
+	}
+	return rates, rows.Err()
+}
+
+// SetRate replaces the rate of a branch, or the default rate.
+func (c *CourierPayRepo) SetRate(ctx context.Context, req *models.SetCourierRate) (*models.CourierRate, error) {
+	args := []interface{}{req.BranchId, req.BaseRate, req.PerKmRate, req.PeakSurcharge, req.PeakFrom, req.PeakTo, req.TipShare}
+
+	res, err := c.db.Exec(ctx, `UPDATE "courier_rate" SET
+		base_rate = $2,
+		per_km_rate = $3,
+		peak_surcharge = $4,
+		peak_from = NULLIF($5, '')::time,
+		peak_to = NULLIF($6, '')::time,
+		tip_share = $7,
+		updated_at = CURRENT_TIMESTAMP
+		WHERE branch_id IS NOT DISTINCT FROM NULLIF($1, '')::uuid`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update courier rate: %w", err)
+	}
+	if res.RowsAffected() == 0 {
+		_, err = c.db.Exec(ctx, `INSERT INTO "courier_rate" (id, branch_id, base_rate, per_km_rate, peak_surcharge, peak_from, peak_to, tip_share)
+			VALUES ($8, NULLIF($1, '')::uuid, $2, $3, $4, NULLIF($5, '')::time, NULLIF($6, '')::time, $7)`,
+			append(args, uuid.New().String())...)
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, storage.ErrInvalidBranch
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create courier rate: %w", err)
+		}
+	}
+
+	return scanCourierRate(c.db.QueryRow(ctx, `SELECT `+courierRateColumns+` FROM "courier_rate"
+		WHERE branch_id IS NOT DISTINCT FROM NULLIF($1, '')::uuid`, req.BranchId))
+}
+
+// Earnings sums up the deliveries of a courier in a range and lists a page
+// of them, latest first.
+func (c *CourierPayRepo) Earnings(ctx context.Context, req *models.CourierEarningsRequest) (*models.CourierEarningsResponse, error) {
+	from, to, err := dateRange(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.CourierEarningsResponse{
+		CourierId: req.CourierId,
+		From:      req.From,
+		To:        req.To,
+		Items:     []models.CourierDelivery{},
+	}
+	err = c.db.QueryRow(ctx, `
+		SELECT count(*) FILTER (WHERE kind = 'delivery'), coalesce(sum(distance_km), 0), coalesce(sum(base_amount), 0), coalesce(sum(distance_amount), 0),
+			coalesce(sum(peak_amount), 0), coalesce(sum(tip_amount), 0), coalesce(sum(earnings), 0),
+			(SELECT coalesce(sum(earnings), 0) FROM "deliveryhistory" WHERE courier_id = $1 AND payout_id IS NULL),
+			(SELECT coalesce(sum(amount), 0) FROM "courier_cash" WHERE courier_id = $1 AND reconciliation_id IS NULL)
+		FROM "deliveryhistory"
+		WHERE courier_id = $1 AND delivered_at >= $2 AND delivered_at < $3`,
+		req.CourierId, from, to).Scan(
+		&resp.Deliveries, &resp.DistanceKm, &resp.BaseAmount, &resp.DistanceAmount,
+		&resp.PeakAmount, &resp.TipAmount, &resp.Earnings, &resp.Unpaid, &resp.CashOnHand,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum up courier earnings: %w", err)
+	}
+	for _, v := range []*float64{&resp.DistanceKm, &resp.BaseAmount, &resp.DistanceAmount, &resp.PeakAmount, &resp.TipAmount, &resp.Earnings, &resp.Unpaid, &resp.CashOnHand} {
+		*v = roundAmount(*v)
+	}
+
+	offset := (req.Page - 1) * req.Limit
+	rows, err := c.db.Query(ctx, fmt.Sprintf(`
+		SELECT id, kind, order_id, coalesce(assignment_id::text, ''), distance_km, base_amount, distance_amount,
+			peak_amount, tip_amount, earnings, coalesce(payout_id::text, ''), delivered_at
+		FROM "deliveryhistory"
+		WHERE courier_id = $1 AND delivered_at >= $2 AND delivered_at < $3
+		ORDER BY delivered_at DESC, id
+		OFFSET %d LIMIT %d`, offset, req.Limit),
+		req.CourierId, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courier deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			item        models.CourierDelivery
+			deliveredAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&item.Id, &item.Kind, &item.OrderId, &item.AssignmentId, &item.DistanceKm, &item.BaseAmount, &item.DistanceAmount,
+			&item.PeakAmount, &item.TipAmount, &item.Earnings, &item.PayoutId, &deliveredAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan courier delivery: %w", err)
+		}
+		item.DeliveredAt = formatLocalTime(deliveredAt)
+		resp.Items = append(resp.Items, item)
+	}
+
+	return resp, rows.Err()
+}
+
+// Cash returns the cash a courier collected and has not handed in yet.
+func (c *CourierPayRepo) Cash(ctx context.Context, courierId string) (*models.CourierCashResponse, error) {
+	rows, err := c.db.Query(ctx, `
+		SELECT id, order_id, amount, collected_at
+		FROM "courier_cash"
+		WHERE courier_id = $1 AND reconciliation_id IS NULL
+		ORDER BY collected_at, id`, courierId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courier cash: %w", err)
+	}
+	defer rows.Close()
+
+	resp := &models.CourierCashResponse{CourierId: courierId, Items: []models.CourierCash{}}
+	for rows.Next() {
+		var (
+			item        models.CourierCash
+			collectedAt sql.NullTime
+		)
+		if err := rows.Scan(&item.Id, &item.OrderId, &item.Amount, &collectedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan courier cash: %w", err)
+		}
+		item.CollectedAt = formatLocalTime(collectedAt)
+		resp.Total += item.Amount
+		resp.Items = append(resp.Items, item)
+	}
+	resp.Total = roundAmount(resp.Total)
+
+	return resp, rows.Err()
+}
+
+// Reconcile records the cash a courier handed in at the end of a shift
+// against everything collected since the last reconciliation.
+func (c *CourierPayRepo) Reconcile(ctx context.Context, courierId string, req *models.ReconcileCashRequest) (*models.CourierReconciliation, error) {
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `SELECT id, amount FROM "courier_cash" WHERE courier_id = $1 AND reconciliation_id IS NULL FOR UPDATE`, courierId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courier cash: %w", err)
+	}
+	var (
+		ids      []string
+		expected float64
+	)
+	for rows.Next() {
+		var (
+			id     string
+			amount float64
+		)
+		if err := rows.Scan(&id, &amount); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan courier cash: %w", err)
+		}
+		ids = append(ids, id)
+		expected += amount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	resp := &models.CourierReconciliation{
+		Id:        uuid.New().String(),
+		CourierId: courierId,
+		Expected:  roundAmount(expected),
+		Received:  req.Received,
+		Orders:    len(ids),
+		Note:      req.Note,
+		CreatedAt: now.Format(time.RFC3339),
+	}
+	resp.Difference = roundAmount(resp.Received - resp.Expected)
+
+	_, err = tx.Exec(ctx, `INSERT INTO "courier_reconciliation" (id, courier_id, expected, received, orders, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)`,
+		resp.Id, courierId, resp.Expected, resp.Received, resp.Orders, resp.Note, now)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return nil, storage.ErrInvalidCourier
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reconciliation: %w", err)
+	}
+
+	if len(ids) > 0 {
+		_, err = tx.Exec(ctx, `UPDATE "courier_cash" SET reconciliation_id = $1 WHERE id = ANY($2::uuid[])`, resp.Id, ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reconcile courier cash: %w", err)
+		}
+	}
+
+	if resp.Difference < 0 {
+		_, err = enqueueJob(ctx, tx, &models.EnqueueJob{Type: jobs.TypeCashShortage, Payload: resp})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return resp, nil
+}
+
+func (c *CourierPayRepo) GetReconciliations(ctx context.Context, req *models.GetCourierReconciliationsRequest) (*models.GetCourierReconciliationsResponse, error) {
+	offset := (req.Page - 1) * req.Limit
+	rows, err := c.db.Query(ctx, fmt.Sprintf(`
+		SELECT count(id) OVER(), id, courier_id, expected, received, orders, coalesce(note, ''), created_at
+		FROM "courier_reconciliation"
+		WHERE ($1::text = '' OR courier_id = NULLIF($1, '')::uuid)
+		ORDER BY created_at DESC, id
+		OFFSET %d LIMIT %d`, offset, req.Limit), req.CourierId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reconciliations: %w", err)
+	}
+	defer rows.Close()
+
+	resp := &models.GetCourierReconciliationsResponse{Reconciliations: []models.CourierReconciliation{}}
+	for rows.Next() {
+		var (
+			r         models.CourierReconciliation
+			createdAt sql.NullTime
+		)
+		if err := rows.Scan(&resp.Count, &r.Id, &r.CourierId, &r.Expected, &r.Received, &r.Orders, &r.Note, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan reconciliation: %w", err)
+		}
+		r.Difference = roundAmount(r.Received - r.Expected)
+		r.CreatedAt = formatLocalTime(createdAt)
+		resp.Reconciliations = append(resp.Reconciliations, r)
+	}
+
+	return resp, rows.Err()
+}
+
+// CreatePayouts puts the unpaid deliveries made through req.Until into one
+// pending payout per courier. Couriers without such deliveries get none.
+func (c *CourierPayRepo) CreatePayouts(ctx context.Context, req *models.CreatePayoutsRequest) ([]models.CourierPayout, error) {
+	_, until, err := dateRange(req.Until, req.Until)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT DISTINCT courier_id FROM "deliveryhistory"
+		WHERE payout_id IS NULL AND delivered_at < $1
+			AND ($2::text = '' OR courier_id = NULLIF($2, '')::uuid)`, until, req.CourierId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unpaid deliveries: %w", err)
+	}
+	var couriers []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan courier: %w", err)
+		}
+		couriers = append(couriers, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	payouts := []models.CourierPayout{}
+	for _, courierId := range couriers {
+		payout := models.CourierPayout{
+			Id:        uuid.New().String(),
+			CourierId: courierId,
+			PeriodTo:  until.Format(time.RFC3339),
+			Status:    "pending",
+			CreatedAt: now.Format(time.RFC3339),
+		}
+		_, err := tx.Exec(ctx, `INSERT INTO "courier_payout" (id, courier_id, period_to, created_at) VALUES ($1, $2, $3, $4)`,
+			payout.Id, courierId, until, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create payout: %w", err)
+		}
+
+		// Deliveries claimed by a concurrent batch are skipped by the
+		// payout_id condition.
+		var linked int
+		err = tx.QueryRow(ctx, `
+			WITH linked AS (
+				UPDATE "deliveryhistory" SET payout_id = $1
+				WHERE payout_id IS NULL AND courier_id = $2 AND delivered_at < $3
+				RETURNING kind, earnings
+			)
+			SELECT coalesce(sum(earnings), 0), count(*) FILTER (WHERE kind = 'delivery'), count(*) FROM linked`,
+			payout.Id, courierId, until).Scan(&payout.Amount, &payout.Deliveries, &linked)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add deliveries to payout: %w", err)
+		}
+		if linked == 0 {
+			if _, err := tx.Exec(ctx, `DELETE FROM "courier_payout" WHERE id = $1`, payout.Id); err != nil {
+				return nil, fmt.Errorf("failed to delete empty payout: %w", err)
+			}
+			continue
+		}
+
+		payout.Amount = roundAmount(payout.Amount)
+		_, err = tx.Exec(ctx, `UPDATE "courier_payout" SET amount = $1, deliveries = $2 WHERE id = $3`,
+			payout.Amount, payout.Deliveries, payout.Id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update payout: %w", err)
+		}
+		payouts = append(payouts, payout)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return payouts, nil
+}
+
+func (c *CourierPayRepo) GetPayouts(ctx context.Context, req *models.GetCourierPayoutsRequest) (*models.GetCourierPayoutsResponse, error) {
+	offset := (req.Page - 1) * req.Limit
+	rows, err := c.db.Query(ctx, fmt.Sprintf(`
+		SELECT count(id) OVER(), `+courierPayoutColumns+`
+		FROM "courier_payout"
+		WHERE ($1::text = '' OR courier_id = NULLIF($1, '')::uuid)
+			AND ($2::text = '' OR status = $2)
+		ORDER BY created_at DESC, id
+		OFFSET %d LIMIT %d`, offset, req.Limit), req.CourierId, req.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payouts: %w", err)
+	}
+	defer rows.Close()
+
+	resp := &models.GetCourierPayoutsResponse{Payouts: []models.CourierPayout{}}
+	for rows.Next() {
+		var count int64
+		payout, err := scanCourierPayout(rows, &count)
+		if err != nil {
+			return nil, err
+		}
+		resp.Count = count
+		resp.Payouts = append(resp.Payouts, *payout)
+	}
+
+	return resp, rows.Err()
+}
+
+// MarkPayoutPaid closes a pending payout with the reference of the transfer.
+func (c *CourierPayRepo) MarkPayoutPaid(ctx context.Context, id, reference string) (*models.CourierPayout, error) {
+	res, err := c.db.Exec(ctx, `UPDATE "courier_payout" SET status = 'paid', reference = NULLIF($2, ''), paid_at = $3
+		WHERE id = $1 AND status = 'pending'`, id, reference, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to update payout: %w", err)
+	}
+
+	payout, err := scanCourierPayout(c.db.QueryRow(ctx, `SELECT `+courierPayoutColumns+` FROM "courier_payout" WHERE id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+	if res.RowsAffected() == 0 {
+		return nil, storage.ErrInvalidStatus
+	}
+	return payout, nil
+}
+
+// recordDelivery adds the delivery of an assignment to the delivery history
+// with earnings computed from the courier rate of the order's branch. It
+// does nothing when the delivery is already recorded.
+func recordDelivery(ctx context.Context, tx pgx.Tx, assignmentId string, now time.Time) error {
+	var (
+		courierId string
+		orderId   string
+		branchId  sql.NullString
+		orderLat  float64
+		orderLng  float64
+		branchLat sql.NullFloat64
+		branchLng sql.NullFloat64
+		tip       float64
+	)
+	err := tx.QueryRow(ctx, `
+		SELECT ca.courier_id, ca.order_id, o.branch_id, o.latitude, o.longitude, b.latitude, b.longitude, o.tip
+		FROM "courierassignment" ca
+		JOIN "order" o ON o.id = ca.order_id
+		LEFT JOIN "branch" b ON b.id = o.branch_id
+		WHERE ca.id = $1`, assignmentId).Scan(
+		&courierId, &orderId, &branchId, &orderLat, &orderLng, &branchLat, &branchLng, &tip,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve delivery of assignment %s: %w", assignmentId, err)
+	}
+
+	rate, err := branchCourierRate(ctx, tx, branchId.String)
+	if err != nil {
+		return err
+	}
+
+	d := models.CourierDelivery{
+		BaseAmount: rate.BaseRate,
+		TipAmount:  roundAmount(tip * float64(rate.TipShare) / 100),
+	}
+	if inDailyWindow(now.Format("15:04"), rate.PeakFrom, rate.PeakTo) {
+		d.PeakAmount = rate.PeakSurcharge
+	}
+	// Orders of branches without coordinates pay no distance.
+	if branchLat.Valid && branchLng.Valid {
+		d.DistanceKm = roundAmount(geo.Distance(branchLat.Float64, branchLng.Float64, orderLat, orderLng) / 1000)
+		d.DistanceAmount = roundAmount(d.DistanceKm * rate.PerKmRate)
+	}
+	d.Earnings = roundAmount(d.BaseAmount + d.DistanceAmount + d.PeakAmount + d.TipAmount)
+
+	_, err = tx.Exec(ctx, `INSERT INTO "deliveryhistory"
+		(id, courier_id, order_id, assignment_id, distance_km, base_amount, distance_amount, peak_amount, tip_amount, earnings, delivered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (assignment_id) DO NOTHING`,
+		uuid.New().String(), courierId, orderId, assignmentId,
+		d.DistanceKm, d.BaseAmount, d.DistanceAmount, d.PeakAmount, d.TipAmount, d.Earnings, now)
+	if err != nil {
+		return fmt.Errorf("failed to record delivery: %w", err)
+	}
+	return nil
+}
+
+// branchCourierRate returns the rate of a branch, or the default rate when
+// the branch has none. Without any rate couriers get whole tips only.
+func branchCourierRate(ctx context.Context, tx pgx.Tx, branchId string) (models.CourierRate, error) {
+	rate := models.CourierRate{TipShare: 100}
+	err := tx.QueryRow(ctx, `
+		SELECT base_rate, per_km_rate, peak_surcharge,
+			coalesce(to_char(peak_from, 'HH24:MI'), ''), coalesce(to_char(peak_to, 'HH24:MI'), ''), tip_share
+		FROM "courier_rate"
+		WHERE branch_id = NULLIF($1, '')::uuid OR branch_id IS NULL
+		ORDER BY branch_id IS NULL
+		LIMIT 1`, branchId).Scan(
+		&rate.BaseRate, &rate.PerKmRate, &rate.PeakSurcharge, &rate.PeakFrom, &rate.PeakTo, &rate.TipShare,
+	)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return rate, fmt.Errorf("failed to retrieve courier rate: %w", err)
+	}
+	return rate, nil
+}
+
+// collectCash records the cash of a 'naxt pul' order as held by the courier
+// and marks the payment paid. Orders paid otherwise have nothing to collect.
+func collectCash(ctx context.Context, tx pgx.Tx, assignmentId string, now time.Time) error {
+	var (
+		courierId string
+		orderId   string
+		amount    float64
+		cash      bool
+	)
+	err := tx.QueryRow(ctx, `
+		SELECT ca.courier_id, ca.order_id, o.total_price + o.tip,
+			EXISTS (SELECT 1 FROM "payment" p WHERE p.order_id = o.id AND p.kind = 'order' AND p.payment_method = 'naxt pul')
+		FROM "courierassignment" ca
+		JOIN "order" o ON o.id = ca.order_id
+		WHERE ca.id = $1`, assignmentId).Scan(&courierId, &orderId, &amount, &cash)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve order of assignment %s: %w", assignmentId, err)
+	}
+	if !cash {
+		return storage.ErrInvalidStatus
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO "courier_cash" (id, courier_id, order_id, assignment_id, amount, collected_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (assignment_id) DO NOTHING`,
+		uuid.New().String(), courierId, orderId, assignmentId, amount, now)
+	if err != nil {
+		return fmt.Errorf("failed to record collected cash: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `UPDATE "payment" SET is_paid = true
+		WHERE order_id = $1 AND kind = 'order' AND payment_method = 'naxt pul' AND is_paid = false
+		RETURNING id, user_id, payment_method`, orderId)
+	if err != nil {
+		return fmt.Errorf("failed to mark payment paid: %w", err)
+	}
+	var paid []models.WebhookPaymentPaid
+	for rows.Next() {
+		payment := models.WebhookPaymentPaid{OrderId: orderId, Kind: "order", Amount: amount}
+		if err := rows.Scan(&payment.PaymentId, &payment.UserId, &payment.PaymentMethod); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan paid payment: %w", err)
+		}
+		paid = append(paid, payment)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to mark payment paid: %w", err)
+	}
+
+	for _, payment := range paid {
+		if err := publishWebhookEvent(ctx, tx, webhook.EventPaymentPaid, payment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inDailyWindow reports whether the local time of day t, formatted 15:04,
+// falls in the window from-to. A window whose end is before its start runs
+// past midnight, and an empty window contains nothing.
+func inDailyWindow(t, from, to string) bool {
+	switch {
+	case from == "" || to == "":
+		return false
+	case from <= to:
+		return t >= from && t < to
+	default:
+		return t >= from || t < to
+	}
+}
+
+func roundAmount(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func scanCourierRate(row pgx.Row) (*models.CourierRate, error) {
+	var (
+		rate      models.CourierRate
+		createdAt sql.NullTime
+		updatedAt sql.NullTime
+	)
+	if err := row.Scan(
+		&rate.Id, &rate.BranchId, &rate.BaseRate, &rate.PerKmRate, &rate.PeakSurcharge,
+		&rate.PeakFrom, &rate.PeakTo, &rate.TipShare, &createdAt, &updatedAt,
+	); err != nil {
+		return nil, err
+	}
+	rate.CreatedAt = formatLocalTime(createdAt)
+	rate.UpdatedAt = formatLocalTime(updatedAt)
+	return &rate, nil
+}
+
+// scanCourierPayout reads courierPayoutColumns, preceded by the total count
+// when count is given.
+func scanCourierPayout(row pgx.Row, count ...*int64) (*models.CourierPayout, error) {
+	var (
+		payout    models.CourierPayout
+		periodTo  sql.NullTime
+		createdAt sql.NullTime
+		paidAt    sql.NullTime
+	)
+	dest := []interface{}{
+		&payout.Id, &payout.CourierId, &payout.Amount, &payout.Deliveries, &periodTo,
+		&payout.Status, &payout.Reference, &createdAt, &paidAt,
+	}
+	if len(count) > 0 {
+		dest = append([]interface{}{count[0]}, dest...)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	payout.PeriodTo = formatLocalTime(periodTo)
+	payout.CreatedAt = formatLocalTime(createdAt)
+	payout.PaidAt = formatLocalTime(paidAt)
+	return &payout, nil
+}
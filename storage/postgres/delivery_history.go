@@ -26,7 +26,7 @@ func NewDeliveryHistory(db *pgxpool.Pool, log logger.LoggerI) DeliveryHistoryRep
 func (r *DeliveryHistoryRepo) Create(ctx context.Context, deliveryHistory *models.DeliveryHistory) (*models.DeliveryHistory, error) {
 
 	id := uuid.New()
-	query := `INSERT INTO "delivery_history" (
+	query := `INSERT INTO "deliveryhistory" (
 		id,
 		courier_id,
 		order_id,
@@ -56,7 +56,7 @@ func (r *DeliveryHistoryRepo) Create(ctx context.Context, deliveryHistory *model
 }
 
 func (r *DeliveryHistoryRepo) Update(ctx context.Context, deliveryHistory *models.DeliveryHistory) (*models.DeliveryHistory, error) {
-	query := `UPDATE "delivery_history" SET 
+	query := `UPDATE "deliveryhistory" SET 
 		courier_id=$1,
 		order_id=$2,
 		earnings=$3,
@@ -101,7 +101,7 @@ func (r *DeliveryHistoryRepo) GetAll(ctx context.Context, req *models.GetAllDeli
         courier_id,
         order_id,
         earnings,
-        delivered_at FROM "delivery_history"`+filter)
+        delivered_at FROM "deliveryhistory"`+filter)
 	if err != nil {
 		return resp, err
 	}
@@ -142,7 +142,7 @@ func (r *DeliveryHistoryRepo) GetByID(ctx context.Context, id string) (*models.D
 		earnings        sql.NullFloat64
 		deliveredAt     sql.NullString
 	)
-	if err := r.db.QueryRow(context.Background(), `SELECT id, courier_id, order_id, earnings, delivered_at FROM "delivery_history" WHERE id = $1`, id).Scan(
+	if err := r.db.QueryRow(context.Background(), `SELECT id, courier_id, order_id, earnings, delivered_at FROM "deliveryhistory" WHERE id = $1`, id).Scan(
 		&deliveryHistory.Id,
 		&courierId,
 		&orderId,
@@ -161,7 +161,7 @@ func (r *DeliveryHistoryRepo) GetByID(ctx context.Context, id string) (*models.D
 }
 
 func (r *DeliveryHistoryRepo) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM "delivery_history" WHERE id = $1`
+	query := `DELETE FROM "deliveryhistory" WHERE id = $1`
 	_, err := r.db.Exec(context.Background(), query, id)
 	if err != nil {
 		return err
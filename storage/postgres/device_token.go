@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"food/api/models"
+	"food/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// DeviceTokenRepo keeps the push tokens of users' devices.
+type DeviceTokenRepo struct {
+	db  *pgxpool.Pool
+	log logger.LoggerI
+}
+
+func NewDeviceTokenRepo(db *pgxpool.Pool, log logger.LoggerI) *DeviceTokenRepo {
+	return &DeviceTokenRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+const deviceTokenColumns = `id, user_id, token, platform, app_version, created_at, updated_at`
+
+func scanDeviceToken(row pgx.Row) (*models.DeviceToken, error) {
+	var (
+		device               models.DeviceToken
+		createdAt, updatedAt sql.NullTime
+	)
+	if err := row.Scan(
+		&device.Id, &device.UserId, &device.Token, &device.Platform, &device.AppVersion, &createdAt, &updatedAt,
+	); err != nil {
+		return nil, err
+	}
+	device.CreatedAt = formatLocalTime(createdAt)
+	device.UpdatedAt = formatLocalTime(updatedAt)
+	return &device, nil
+}
+
+// Register stores the token for the user, or refreshes it when the app
+// registers again. A token registered by another user moves to this one.
+func (r *DeviceTokenRepo) Register(ctx context.Context, userId string, req *models.RegisterDeviceRequest) (*models.DeviceToken, error) {
+	now := time.Now()
+	device, err := scanDeviceToken(r.db.QueryRow(ctx, `
+		INSERT INTO "device_token" (id, user_id, token, platform, app_version, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6)
+		ON CONFLICT (token) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			platform = EXCLUDED.platform,
+			app_version = EXCLUDED.app_version,
+			updated_at = EXCLUDED.updated_at
+		RETURNING `+deviceTokenColumns,
+		uuid.New().String(), userId, req.Token, req.Platform, req.AppVersion, now))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return nil, pgx.ErrNoRows
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to register device: %w", err)
+	}
+	return device, nil
+}
+
+// Unregister removes the user's token. It returns pgx.ErrNoRows when the
+// user has no such token.
+func (r *DeviceTokenRepo) Unregister(ctx context.Context, userId, token string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM "device_token" WHERE user_id = $1 AND token = $2`, userId, token)
+	if err != nil {
+		return fmt.Errorf("failed to unregister device: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// GetByUser lists the user's devices, most recently registered first.
+func (r *DeviceTokenRepo) GetByUser(ctx context.Context, userId string) (*models.GetDeviceTokensResponse, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+deviceTokenColumns+`
+		FROM "device_token"
+		WHERE user_id = $1
+		ORDER BY updated_at DESC
+	`, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve devices: %w", err)
+	}
+	defer rows.Close()
+
+	resp := &models.GetDeviceTokensResponse{Devices: []models.DeviceToken{}}
+	for rows.Next() {
+		device, err := scanDeviceToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan device: %w", err)
+		}
+		resp.Devices = append(resp.Devices, *device)
+	}
+	return resp, rows.Err()
+}
+
+// Prune removes tokens the push service reported as invalid.
+func (r *DeviceTokenRepo) Prune(ctx context.Context, tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	_, err := r.db.Exec(ctx, `DELETE FROM "device_token" WHERE token = ANY($1)`, tokens)
+	if err != nil {
+		return fmt.Errorf("failed to prune devices: %w", err)
+	}
+	return nil
+}
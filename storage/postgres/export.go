@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"food/api/models"
+	"food/pkg/logger"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// ExportRepo reads records for spreadsheet exports. Every method streams
+// its rows to fn as they arrive from the database and stops at the first
+// error fn returns.
+type ExportRepo struct {
+	db  *pgxpool.Pool
+	log logger.LoggerI
+}
+
+func NewExportRepo(db *pgxpool.Pool, log logger.LoggerI) *ExportRepo {
+	return &ExportRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Orders exports order items, oldest order first. The courier filter
+// matches the courier the order was last assigned to.
+func (e *ExportRepo) Orders(ctx context.Context, req *models.ExportRequest, fn func(*models.OrderExportRow) error) error {
+	from, to, err := dateRange(req.From, req.To)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT o.id, o.created_at, o.user_id, coalesce(u.name, ''), coalesce(u.phone, ''), coalesce(b.name, ''),
+			o.status, o.delivery_status, o.address_name, coalesce(ca.courier_id::text, ''), o.total_price,
+			coalesce(oi.id::text, ''), coalesce(oi.product_id::text, ''), coalesce(oi.combo_id::text, ''), coalesce(p.name, c.name, ''),
+			coalesce(oi.quantity, 0), coalesce(oi.price, 0), coalesce(oi.total, 0), coalesce(oi.modifiers, '{}')
+		FROM "order" o
+		LEFT JOIN "user" u ON u.id = o.user_id
+		LEFT JOIN "branch" b ON b.id = o.branch_id
+		LEFT JOIN LATERAL (
+			SELECT courier_id FROM "courierassignment" WHERE order_id = o.id ORDER BY assigned_at DESC LIMIT 1
+		) ca ON true
+		LEFT JOIN "orderiteam" oi ON oi.order_id = o.id AND oi.deleted_at IS NULL
+		LEFT JOIN "product" p ON p.id = oi.product_id
+		LEFT JOIN "combo" c ON c.id = oi.combo_id
+		WHERE o.deleted_at IS NULL AND o.created_at >= $1 AND o.created_at < $2
+			AND ($3::text = '' OR o.branch_id = NULLIF($3, '')::uuid)
+			AND ($4::text = '' OR o.status = $4)
+			AND ($5::text = '' OR ca.courier_id = NULLIF($5, '')::uuid)
+		ORDER BY o.created_at, o.id, oi.created_at, oi.id`
+
+	rows, err := e.db.Query(ctx, query, from, to, req.BranchId, req.Status, req.CourierId)
+	if err != nil {
+		return fmt.Errorf("failed to export orders: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			row       models.OrderExportRow
+			createdAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&row.OrderId, &createdAt, &row.UserId, &row.CustomerName, &row.CustomerPhone, &row.BranchName,
+			&row.Status, &row.DeliveryStatus, &row.Address, &row.CourierId, &row.OrderTotal,
+			&row.ItemId, &row.ProductId, &row.ComboId, &row.ProductName,
+			&row.Quantity, &row.Price, &row.ItemTotal, &row.Modifiers,
+		); err != nil {
+			return fmt.Errorf("failed to scan order export: %w", err)
+		}
+		row.CreatedAt = formatLocalTime(createdAt)
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Payments exports payments by the time they were made. An order payment
+// amounts to the order total and the checkout tip, a tip payment to the tip.
+func (e *ExportRepo) Payments(ctx context.Context, req *models.ExportRequest, fn func(*models.PaymentExportRow) error) error {
+	from, to, err := dateRange(req.From, req.To)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT pm.id, pm.created_at, pm.order_id, pm.user_id, coalesce(u.name, ''), coalesce(b.name, ''),
+			o.status, pm.kind, pm.payment_method, pm.is_paid,
+			CASE WHEN pm.kind = 'tip' THEN pm.amount ELSE o.total_price + o.tip END,
+			CASE WHEN pm.kind = 'tip' THEN pm.amount ELSE o.tip END
+		FROM "payment" pm
+		JOIN "order" o ON o.id = pm.order_id
+		LEFT JOIN "user" u ON u.id = pm.user_id
+		LEFT JOIN "branch" b ON b.id = o.branch_id
+		WHERE o.deleted_at IS NULL AND pm.created_at >= $1 AND pm.created_at < $2
+			AND ($3::text = '' OR o.branch_id = NULLIF($3, '')::uuid)
+			AND ($4::text = '' OR o.status = $4)
+			AND ($5::text = '' OR EXISTS (
+				SELECT 1 FROM "courierassignment" ca WHERE ca.order_id = o.id AND ca.courier_id = NULLIF($5, '')::uuid))
+		ORDER BY pm.created_at, pm.id`
+
+	rows, err := e.db.Query(ctx, query, from, to, req.BranchId, req.Status, req.CourierId)
+	if err != nil {
+		return fmt.Errorf("failed to export payments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			row       models.PaymentExportRow
+			createdAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&row.PaymentId, &createdAt, &row.OrderId, &row.UserId, &row.CustomerName, &row.BranchName,
+			&row.OrderStatus, &row.Kind, &row.PaymentMethod, &row.IsPaid, &row.Amount, &row.Tip,
+		); err != nil {
+			return fmt.Errorf("failed to scan payment export: %w", err)
+		}
+		row.CreatedAt = formatLocalTime(createdAt)
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// CourierEarnings exports the delivery history by delivery time.
+func (e *ExportRepo) CourierEarnings(ctx context.Context, req *models.ExportRequest, fn func(*models.CourierEarningExportRow) error) error {
+	from, to, err := dateRange(req.From, req.To)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		SELECT dh.id, dh.delivered_at, dh.kind, dh.courier_id, coalesce(u.name, ''), dh.order_id, coalesce(b.name, ''),
+			o.total_price, dh.tip_amount, dh.earnings
+		FROM "deliveryhistory" dh
+		JOIN "order" o ON o.id = dh.order_id
+		LEFT JOIN "user" u ON u.id = dh.courier_id
+		LEFT JOIN "branch" b ON b.id = o.branch_id
+		WHERE dh.delivered_at >= $1 AND dh.delivered_at < $2
+			AND ($3::text = '' OR o.branch_id = NULLIF($3, '')::uuid)
+			AND ($4::text = '' OR o.status = $4)
+			AND ($5::text = '' OR dh.courier_id = NULLIF($5, '')::uuid)
+		ORDER BY dh.delivered_at, dh.id`
+
+	rows, err := e.db.Query(ctx, query, from, to, req.BranchId, req.Status, req.CourierId)
+	if err != nil {
+		return fmt.Errorf("failed to export courier earnings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			row         models.CourierEarningExportRow
+			deliveredAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&row.Id, &deliveredAt, &row.Kind, &row.CourierId, &row.CourierName, &row.OrderId, &row.BranchName,
+			&row.OrderTotal, &row.TipAmount, &row.Earnings,
+		); err != nil {
+			return fmt.Errorf("failed to scan courier earnings export: %w", err)
+		}
+		row.DeliveredAt = formatLocalTime(deliveredAt)
+		if err := fn(&row); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
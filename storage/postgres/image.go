@@ -0,0 +1,277 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"food/api/models"
+	"food/pkg/imageproc"
+	"food/pkg/logger"
+	"food/storage"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type ImageRepo struct {
+	db  *pgxpool.Pool
+	log logger.LoggerI
+}
+
+func NewImage(db *pgxpool.Pool, log logger.LoggerI) ImageRepo {
+	return ImageRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+const imageColumns = `id, product_id, combo_id, position, thumb_url, card_url, full_url, thumb_webp_url, card_webp_url, full_webp_url, width, height, created_at`
+
+// imageOwner returns the table and product_image column of the gallery an
+// image belongs to, either a product or a combo.
+func imageOwner(productId, comboId string) (table, column, id string) {
+	if productId != "" {
+		return "product", "product_id", productId
+	}
+	return "combo", "combo_id", comboId
+}
+
+// Create appends the image to the end of its gallery. It returns
+// pgx.ErrNoRows when the product or combo does not exist and
+// storage.ErrGalleryFull when the gallery already has maxImages images.
+func (i *ImageRepo) Create(ctx context.Context, image *models.ProductImage, maxImages int) (*models.ProductImage, error) {
+	table, column, ownerId := imageOwner(image.ProductId, image.ComboId)
+
+	tx, err := i.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Locking the owner row serializes concurrent uploads to one gallery.
+	var exists int
+	if err := tx.QueryRow(ctx, `SELECT 1 FROM "`+table+`" WHERE id = $1 FOR UPDATE`, ownerId).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	var count, position int
+	if err := tx.QueryRow(ctx, `SELECT count(*), coalesce(max(position) + 1, 0) FROM "product_image" WHERE `+column+` = $1`, ownerId).Scan(&count, &position); err != nil {
+		return nil, err
+	}
+	if count >= maxImages {
+		return nil, storage.ErrGalleryFull
+	}
+
+	created, err := scanImage(tx.QueryRow(ctx, `INSERT INTO "product_image" (
+		id,
+		`+column+`,
+		position,
+		thumb_url,
+		card_url,
+		full_url,
+		thumb_webp_url,
+		card_webp_url,
+		full_webp_url,
+		width,
+		height,
+		created_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, CURRENT_TIMESTAMP)
+		RETURNING `+imageColumns,
+		image.Id,
+		ownerId,
+		position,
+		image.ThumbURL,
+		image.CardURL,
+		image.FullURL,
+		image.ThumbWebPURL,
+		image.CardWebPURL,
+		image.FullWebPURL,
+		image.Width,
+		image.Height,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	if image.ProductId != "" {
+		if err := syncProductCover(ctx, tx, image.ProductId, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return created, tx.Commit(ctx)
+}
+
+func (i *ImageRepo) GetByID(ctx context.Context, id string) (*models.ProductImage, error) {
+	return scanImage(i.db.QueryRow(ctx, `SELECT `+imageColumns+` FROM "product_image" WHERE id = $1`, id))
+}
+
+// Reorder sets the gallery order to imageIds, which must list every image of
+// the product or combo exactly once.
+func (i *ImageRepo) Reorder(ctx context.Context, productId, comboId string, imageIds []string) ([]models.ProductImage, error) {
+	_, column, ownerId := imageOwner(productId, comboId)
+
+	tx, err := i.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE "product_image" pi SET position = o.position - 1
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS o(id, position)
+		WHERE pi.id = o.id AND pi.`+column+` = $1`, ownerId, imageIds)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int
+	if err := tx.QueryRow(ctx, `SELECT count(*) FROM "product_image" WHERE `+column+` = $1`, ownerId).Scan(&total); err != nil {
+		return nil, err
+	}
+	if int(tag.RowsAffected()) != len(imageIds) || total != len(imageIds) {
+		return nil, storage.ErrInvalidImageOrder
+	}
+
+	if productId != "" {
+		if err := syncProductCover(ctx, tx, productId, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	galleries, err := loadImages(ctx, i.db, column, []string{ownerId})
+	if err != nil {
+		return nil, err
+	}
+	return galleries[ownerId], nil
+}
+
+// Delete removes the image and returns it so its files can be deleted too.
+func (i *ImageRepo) Delete(ctx context.Context, id string) (*models.ProductImage, error) {
+	tx, err := i.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	image, err := scanImage(tx.QueryRow(ctx, `DELETE FROM "product_image" WHERE id = $1 RETURNING `+imageColumns, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if image.ProductId != "" {
+		if err := syncProductCover(ctx, tx, image.ProductId, image.CardURL); err != nil {
+			return nil, err
+		}
+	}
+
+	return image, tx.Commit(ctx)
+}
+
+// syncProductCover points product.image_url at the card rendition of the
+// first gallery image, so clients that only read image_url keep working. When
+// the gallery becomes empty, a cover equal to removedURL is cleared.
+func syncProductCover(ctx context.Context, tx pgx.Tx, productId, removedURL string) error {
+	_, err := tx.Exec(ctx, `UPDATE "product" SET image_url = coalesce(
+			(SELECT card_url FROM "product_image" WHERE product_id = $1 ORDER BY position, created_at LIMIT 1),
+			nullif(image_url, $2)
+		) WHERE id = $1`, productId, removedURL)
+	return err
+}
+
+// loadImages returns the ordered galleries of the given products or combos,
+// keyed by owner id. column is product_id or combo_id.
+func loadImages(ctx context.Context, db *pgxpool.Pool, column string, ownerIds []string) (map[string][]models.ProductImage, error) {
+	galleries := make(map[string][]models.ProductImage)
+	if len(ownerIds) == 0 {
+		return galleries, nil
+	}
+
+	rows, err := db.Query(ctx, `SELECT `+imageColumns+` FROM "product_image"
+		WHERE `+column+` = ANY($1::uuid[])
+		ORDER BY position, created_at`, ownerIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load images: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		image, err := scanImage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan image: %w", err)
+		}
+		owner := image.ProductId
+		if column == "combo_id" {
+			owner = image.ComboId
+		}
+		galleries[owner] = append(galleries[owner], *image)
+	}
+	return galleries, rows.Err()
+}
+
+func scanImage(row pgx.Row) (*models.ProductImage, error) {
+	var (
+		image     models.ProductImage
+		productId sql.NullString
+		comboId   sql.NullString
+		createdAt sql.NullTime
+	)
+	if err := row.Scan(
+		&image.Id,
+		&productId,
+		&comboId,
+		&image.Position,
+		&image.ThumbURL,
+		&image.CardURL,
+		&image.FullURL,
+		&image.ThumbWebPURL,
+		&image.CardWebPURL,
+		&image.FullWebPURL,
+		&image.Width,
+		&image.Height,
+		&createdAt,
+	); err != nil {
+		return nil, err
+	}
+
+	image.ProductId = productId.String
+	image.ComboId = comboId.String
+	image.CreatedAt = formatLocalTime(createdAt)
+	image.Srcset = imageSrcset(image.Width, image.ThumbURL, image.CardURL, image.FullURL)
+	image.WebPSrcset = imageSrcset(image.Width, image.ThumbWebPURL, image.CardWebPURL, image.FullWebPURL)
+
+	return &image, nil
+}
+
+// imageSrcset lists the renditions with their real widths. Small uploads are
+// never upscaled, so renditions that came out as wide as a smaller one are
+// left out.
+func imageSrcset(imageWidth int, thumbURL, cardURL, fullURL string) string {
+	urls := map[string]string{
+		"thumb": thumbURL,
+		"card":  cardURL,
+		"full":  fullURL,
+	}
+
+	var (
+		entries []string
+		last    int
+	)
+	for _, size := range imageproc.Sizes {
+		width := size.MaxWidth
+		if imageWidth < width {
+			width = imageWidth
+		}
+		if width <= last || urls[size.Name] == "" {
+			continue
+		}
+		entries = append(entries, fmt.Sprintf("%s %dw", urls[size.Name], width))
+		last = width
+	}
+	return strings.Join(entries, ", ")
+}
@@ -0,0 +1,267 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"food/api/models"
+	"food/config"
+	"food/pkg/logger"
+	"food/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// JobRepo is the background job queue.
+type JobRepo struct {
+	db  *pgxpool.Pool
+	log logger.LoggerI
+}
+
+func NewJobRepo(db *pgxpool.Pool, log logger.LoggerI) *JobRepo {
+	return &JobRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+const jobColumns = `id, type, payload, COALESCE(unique_key, ''), status, attempts, max_attempts, run_at,
+	COALESCE(last_error, ''), created_at, finished_at`
+
+func scanJob(row pgx.Row, count ...*int64) (*models.Job, error) {
+	var (
+		job                          models.Job
+		payload                      []byte
+		runAt, createdAt, finishedAt sql.NullTime
+	)
+	dest := []interface{}{
+		&job.Id, &job.Type, &payload, &job.UniqueKey, &job.Status, &job.Attempts, &job.MaxAttempts, &runAt,
+		&job.LastError, &createdAt, &finishedAt,
+	}
+	if len(count) > 0 {
+		dest = append([]interface{}{count[0]}, dest...)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	job.Payload = json.RawMessage(payload)
+	job.RunAt = formatLocalTime(runAt)
+	job.CreatedAt = formatLocalTime(createdAt)
+	job.FinishedAt = formatLocalTime(finishedAt)
+	return &job, nil
+}
+
+// rowQuerier is a pool or a transaction.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// enqueueJob adds a job through db, which is the transaction of the change
+// that needs the job when there is one, so the job exists if and only if
+// the change is committed. It returns "" when a job with the same unique
+// key is already pending or running.
+func enqueueJob(ctx context.Context, db rowQuerier, job *models.EnqueueJob) (string, error) {
+	payload := []byte(`{}`)
+	if job.Payload != nil {
+		var err error
+		if payload, err = json.Marshal(job.Payload); err != nil {
+			return "", fmt.Errorf("failed to encode %s job: %w", job.Type, err)
+		}
+	}
+
+	now := time.Now()
+	runAt := job.RunAt
+	if runAt.IsZero() {
+		runAt = now
+	}
+	maxAttempts := job.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = config.MaxJobAttempts
+	}
+
+	var id string
+	err := db.QueryRow(ctx, `
+		INSERT INTO "job" (id, type, payload, unique_key, max_attempts, run_at, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $7)
+		ON CONFLICT (unique_key) WHERE status IN ('pending', 'running') DO NOTHING
+		RETURNING id
+	`, uuid.New().String(), job.Type, payload, job.UniqueKey, maxAttempts, runAt, now).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to enqueue %s job: %w", job.Type, err)
+	}
+	return id, nil
+}
+
+// Enqueue adds a job outside of any other change. It returns "" when a job
+// with the same unique key is already pending or running.
+func (j *JobRepo) Enqueue(ctx context.Context, job *models.EnqueueJob) (string, error) {
+	return enqueueJob(ctx, j.db, job)
+}
+
+// Claim takes the job of one of types that is due the longest, or a running
+// job whose lease ran out, and leases it for lease. It returns nil when no
+// job is due. Workers skip the jobs other workers hold, so each job runs
+// once at a time. Due jobs without attempts left, such as a job whose
+// worker died during its last attempt, fail instead of running again.
+func (j *JobRepo) Claim(ctx context.Context, types []string, lease time.Duration) (*models.Job, error) {
+	now := time.Now()
+	// Timestamps are stored to the microsecond, so the lease reads back as
+	// it was written.
+	leasedUntil := now.Add(lease).Truncate(time.Microsecond)
+	_, err := j.db.Exec(ctx, `
+		UPDATE "job"
+		SET status = 'failed', last_error = COALESCE(last_error, 'no attempts left'),
+			locked_until = NULL, finished_at = $1, updated_at = $1
+		WHERE type = ANY($2) AND attempts >= max_attempts
+			AND ((status = 'pending' AND run_at <= $1) OR (status = 'running' AND locked_until < $1))`, now, types)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fail expired jobs: %w", err)
+	}
+
+	job, err := scanJob(j.db.QueryRow(ctx, `
+		UPDATE "job"
+		SET status = 'running', attempts = attempts + 1, locked_until = $2, updated_at = $1
+		WHERE id = (
+			SELECT id
+			FROM "job"
+			WHERE type = ANY($3)
+				AND ((status = 'pending' AND run_at <= $1) OR (status = 'running' AND locked_until < $1))
+				AND attempts < max_attempts
+			ORDER BY run_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns, now, leasedUntil, types))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	job.LeasedUntil = leasedUntil
+	return job, nil
+}
+
+// leaseHeld is the condition of the outcome updates: the job is still
+// running under the claim that returned it, $2 and $3 being its attempts and
+// lease. Once the lease ran out another worker may have claimed the job.
+const leaseHeld = `id = $1 AND status = 'running' AND attempts = $2 AND locked_until = $3`
+
+// Complete marks a running job done. It returns storage.ErrLeaseLost when
+// the lease of job ran out and the job was claimed again.
+func (j *JobRepo) Complete(ctx context.Context, job *models.Job) error {
+	tag, err := j.db.Exec(ctx, `UPDATE "job" SET status = 'done', last_error = NULL, locked_until = NULL, finished_at = $4, updated_at = $4
+		WHERE `+leaseHeld, job.Id, job.Attempts, job.LeasedUntil, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to complete job %s: %w", job.Id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrLeaseLost
+	}
+	return nil
+}
+
+// Retry puts a job that failed back in the queue to run again at runAt. It
+// returns storage.ErrLeaseLost like Complete.
+func (j *JobRepo) Retry(ctx context.Context, job *models.Job, lastError string, runAt time.Time) error {
+	tag, err := j.db.Exec(ctx, `UPDATE "job" SET status = 'pending', last_error = $4, run_at = $5, locked_until = NULL, updated_at = $6
+		WHERE `+leaseHeld, job.Id, job.Attempts, job.LeasedUntil, lastError, runAt, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to reschedule job %s: %w", job.Id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrLeaseLost
+	}
+	return nil
+}
+
+// Fail marks a job failed for good. It returns storage.ErrLeaseLost like
+// Complete.
+func (j *JobRepo) Fail(ctx context.Context, job *models.Job, lastError string) error {
+	tag, err := j.db.Exec(ctx, `UPDATE "job" SET status = 'failed', last_error = $4, locked_until = NULL, finished_at = $5, updated_at = $5
+		WHERE `+leaseHeld, job.Id, job.Attempts, job.LeasedUntil, lastError, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to fail job %s: %w", job.Id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return storage.ErrLeaseLost
+	}
+	return nil
+}
+
+// Requeue runs a failed job again now with a fresh set of attempts. It
+// returns storage.ErrInvalidStatus for jobs that have not failed, and
+// storage.ErrJobQueued when another job with the same unique key is
+// waiting.
+func (j *JobRepo) Requeue(ctx context.Context, id string) (*models.Job, error) {
+	now := time.Now()
+	job, err := scanJob(j.db.QueryRow(ctx, `
+		UPDATE "job"
+		SET status = 'pending', attempts = 0, run_at = $2, finished_at = NULL, updated_at = $2
+		WHERE id = $1 AND status = 'failed'
+		RETURNING `+jobColumns, id, now))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, storage.ErrJobQueued
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := j.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, storage.ErrInvalidStatus
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to requeue job %s: %w", id, err)
+	}
+	return job, nil
+}
+
+func (j *JobRepo) GetByID(ctx context.Context, id string) (*models.Job, error) {
+	return scanJob(j.db.QueryRow(ctx, `SELECT `+jobColumns+` FROM "job" WHERE id = $1`, id))
+}
+
+// GetAll lists jobs, the ones due last first.
+func (j *JobRepo) GetAll(ctx context.Context, req *models.GetAllJobsRequest) (*models.GetAllJobsResponse, error) {
+	offset := (req.Page - 1) * req.Limit
+	rows, err := j.db.Query(ctx, fmt.Sprintf(`
+		SELECT count(id) OVER(), `+jobColumns+`
+		FROM "job"
+		WHERE ($1::text = '' OR type = $1) AND ($2::text = '' OR status = $2)
+		ORDER BY run_at DESC, id
+		OFFSET %d LIMIT %d`, offset, req.Limit), req.Type, req.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
+	}
+	defer rows.Close()
+
+	resp := &models.GetAllJobsResponse{Jobs: []models.Job{}}
+	for rows.Next() {
+		var count int64
+		job, err := scanJob(rows, &count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		resp.Count = count
+		resp.Jobs = append(resp.Jobs, *job)
+	}
+	return resp, rows.Err()
+}
+
+// Prune deletes the jobs done before the given time and returns how many were deleted.
+// Failed jobs are kept to be looked into.
+func (j *JobRepo) Prune(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := j.db.Exec(ctx, `DELETE FROM "job" WHERE status = 'done' AND finished_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune jobs: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
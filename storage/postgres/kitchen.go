@@ -0,0 +1,307 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"food/api/models"
+	"food/pkg/logger"
+	"food/pkg/webhook"
+	"food/storage"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type KitchenRepo struct {
+	db  *pgxpool.Pool
+	log logger.LoggerI
+}
+
+func NewKitchen(db *pgxpool.Pool, log logger.LoggerI) KitchenRepo {
+	return KitchenRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// kitchenOrderSteps and kitchenItemSteps rank the statuses a cook can bump
+// to, so a status may only move forward.
+var (
+	kitchenOrderSteps = map[string]int{
+		"confirmed": 0,
+		"preparing": 1,
+		"ready":     2,
+	}
+	kitchenItemSteps = map[string]int{
+		"queued":    0,
+		"preparing": 1,
+		"ready":     2,
+	}
+)
+
+func (k *KitchenRepo) GetQueue(ctx context.Context, branchId string) (*models.KitchenQueueResponse, error) {
+	resp := &models.KitchenQueueResponse{BranchId: branchId}
+
+	orderQuery := `
+		SELECT o.id, o.status, o.delivery_status,
+			COALESCE(o.scheduled_at, o.created_at + make_interval(mins => COALESCE(b.lead_minutes, 0))) AS due_at,
+			o.created_at
+		FROM "order" o
+		LEFT JOIN "branch" b ON b.id = o.branch_id
+		WHERE o.branch_id = $1 AND o.status IN ('confirmed', 'preparing') AND o.deleted_at IS NULL
+		ORDER BY due_at, o.created_at
+	`
+	rows, err := k.db.Query(ctx, orderQuery, branchId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve kitchen queue: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		orderIds []string
+		position = make(map[string]int)
+	)
+	for rows.Next() {
+		var (
+			order      models.KitchenQueueOrder
+			due_at     sql.NullTime
+			created_at sql.NullTime
+		)
+		if err := rows.Scan(&order.Id, &order.Status, &order.DeliveryStatus, &due_at, &created_at); err != nil {
+			return nil, fmt.Errorf("failed to scan kitchen order: %w", err)
+		}
+		order.DueAt = formatLocalTime(due_at)
+		order.CreatedAt = formatLocalTime(created_at)
+
+		position[order.Id] = len(resp.Orders)
+		orderIds = append(orderIds, order.Id)
+		resp.Orders = append(resp.Orders, order)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(orderIds) == 0 {
+		return resp, nil
+	}
+
+	itemQuery := `
+		SELECT i.id, i.order_id, COALESCE(i.product_id::text, ''), COALESCE(i.combo_id::text, ''), COALESCE(p.name, c.name, ''), i.quantity, i.modifiers, i.kitchen_status, i.started_at, i.ready_at
+		FROM "orderiteam" i
+		LEFT JOIN "product" p ON p.id = i.product_id
+		LEFT JOIN "combo" c ON c.id = i.combo_id
+		WHERE i.order_id = ANY($1::uuid[])
+		ORDER BY i.created_at
+	`
+	itemRows, err := k.db.Query(ctx, itemQuery, orderIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve kitchen items: %w", err)
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var (
+			item       models.KitchenQueueItem
+			orderId    string
+			started_at sql.NullTime
+			ready_at   sql.NullTime
+		)
+		if err := itemRows.Scan(&item.Id, &orderId, &item.ProductId, &item.ComboId, &item.ProductName, &item.Quantity, &item.Modifiers, &item.Status, &started_at, &ready_at); err != nil {
+			return nil, fmt.Errorf("failed to scan kitchen item: %w", err)
+		}
+		item.StartedAt = formatLocalTime(started_at)
+		item.ReadyAt = formatLocalTime(ready_at)
+
+		i := position[orderId]
+		resp.Orders[i].Items = append(resp.Orders[i].Items, item)
+	}
+
+	return resp, itemRows.Err()
+}
+
+func (k *KitchenRepo) UpdateOrderStatus(ctx context.Context, branchId, orderId, status string) error {
+	next, ok := kitchenOrderSteps[status]
+	if !ok || next == 0 {
+		return storage.ErrInvalidStatus
+	}
+
+	tx, err := k.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	var current string
+	err = tx.QueryRow(ctx, `SELECT status FROM "order" WHERE id = $1 AND branch_id = $2 AND deleted_at IS NULL FOR UPDATE`,
+		orderId, branchId).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve order %s: %w", orderId, err)
+	}
+
+	step, ok := kitchenOrderSteps[current]
+	if !ok || step >= next {
+		err = storage.ErrInvalidStatus
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE "order" SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, status, orderId)
+	if err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+
+	err = publishWebhookEvent(ctx, tx, webhook.EventOrderStatusChanged, models.WebhookOrderStatusChanged{
+		OrderId:        orderId,
+		Status:         status,
+		PreviousStatus: current,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Bumping the whole order carries every item that is behind along with it.
+	itemQuery := `
+		UPDATE "orderiteam"
+		SET kitchen_status = 'preparing', started_at = COALESCE(started_at, CURRENT_TIMESTAMP), updated_at = CURRENT_TIMESTAMP
+		WHERE order_id = $1 AND kitchen_status = 'queued'
+	`
+	if status == "ready" {
+		itemQuery = `
+			UPDATE "orderiteam"
+			SET kitchen_status = 'ready', started_at = COALESCE(started_at, CURRENT_TIMESTAMP), ready_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			WHERE order_id = $1 AND kitchen_status <> 'ready'
+		`
+	}
+	_, err = tx.Exec(ctx, itemQuery, orderId)
+	if err != nil {
+		return fmt.Errorf("failed to update order items: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (k *KitchenRepo) UpdateItemStatus(ctx context.Context, branchId, itemId, status string) error {
+	next, ok := kitchenItemSteps[status]
+	if !ok || next == 0 {
+		return storage.ErrInvalidStatus
+	}
+
+	tx, err := k.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+		}
+	}()
+
+	var (
+		current     string
+		orderId     string
+		orderStatus string
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT i.kitchen_status, o.id, o.status
+		FROM "orderiteam" i
+		JOIN "order" o ON o.id = i.order_id
+		WHERE i.id = $1 AND o.branch_id = $2 AND o.deleted_at IS NULL
+		FOR UPDATE
+	`, itemId, branchId).Scan(&current, &orderId, &orderStatus)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve order item %s: %w", itemId, err)
+	}
+
+	if orderStatus != "confirmed" && orderStatus != "preparing" {
+		err = storage.ErrInvalidStatus
+		return err
+	}
+	if kitchenItemSteps[current] >= next {
+		err = storage.ErrInvalidStatus
+		return err
+	}
+
+	itemQuery := `
+		UPDATE "orderiteam"
+		SET kitchen_status = 'preparing', started_at = COALESCE(started_at, CURRENT_TIMESTAMP), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+	if status == "ready" {
+		itemQuery = `
+			UPDATE "orderiteam"
+			SET kitchen_status = 'ready', started_at = COALESCE(started_at, CURRENT_TIMESTAMP), ready_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+		`
+	}
+	_, err = tx.Exec(ctx, itemQuery, itemId)
+	if err != nil {
+		return fmt.Errorf("failed to update order item status: %w", err)
+	}
+
+	// The order is ready once its last item is, and preparing as soon as
+	// any item has been started.
+	var newStatus string
+	err = tx.QueryRow(ctx, `
+		UPDATE "order"
+		SET status = CASE
+				WHEN (SELECT bool_and(kitchen_status = 'ready') FROM "orderiteam" WHERE order_id = $1) THEN 'ready'
+				ELSE 'preparing'
+			END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING status
+	`, orderId).Scan(&newStatus)
+	if err != nil {
+		return fmt.Errorf("failed to update order status: %w", err)
+	}
+	if newStatus != orderStatus {
+		err = publishWebhookEvent(ctx, tx, webhook.EventOrderStatusChanged, models.WebhookOrderStatusChanged{
+			OrderId:        orderId,
+			Status:         newStatus,
+			PreviousStatus: orderStatus,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (k *KitchenRepo) GetStats(ctx context.Context, req *models.KitchenStatsRequest) (*models.KitchenStatsResponse, error) {
+	resp := &models.KitchenStatsResponse{BranchId: req.BranchId}
+
+	query := `
+		SELECT COALESCE(i.product_id::text, ''), COALESCE(i.combo_id::text, ''), COALESCE(p.name, c.name, ''), count(i.id),
+			avg(extract(epoch FROM i.ready_at - i.started_at))::float8,
+			max(extract(epoch FROM i.ready_at - i.started_at))::float8
+		FROM "orderiteam" i
+		JOIN "order" o ON o.id = i.order_id
+		LEFT JOIN "product" p ON p.id = i.product_id
+		LEFT JOIN "combo" c ON c.id = i.combo_id
+		WHERE o.branch_id = $1
+			AND i.started_at IS NOT NULL
+			AND i.ready_at IS NOT NULL
+			AND i.ready_at >= $2::date
+			AND i.ready_at < $3::date + 1
+		GROUP BY i.product_id, i.combo_id, p.name, c.name
+		ORDER BY 5 DESC
+	`
+	rows, err := k.db.Query(ctx, query, req.BranchId, req.From, req.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve kitchen stats: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stat models.KitchenProductStat
+		if err := rows.Scan(&stat.ProductId, &stat.ComboId, &stat.ProductName, &stat.Prepared, &stat.AvgPrepSeconds, &stat.MaxPrepSeconds); err != nil {
+			return nil, fmt.Errorf("failed to scan kitchen stats: %w", err)
+		}
+		resp.Products = append(resp.Products, stat)
+	}
+
+	return resp, rows.Err()
+}
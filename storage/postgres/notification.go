@@ -3,11 +3,21 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"food/api/models"
+	"food/pkg/i18n"
 	"food/pkg/logger"
+	"food/pkg/notify"
+	"food/storage"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -26,11 +36,11 @@ func NewNotification(db *pgxpool.Pool, log logger.LoggerI) NotificationRepo {
 func (n *NotificationRepo) Create(ctx context.Context, notification *models.Notification) (*models.Notification, error) {
 
 	id := uuid.New()
-	query := `INSERT INTO "notifications" (
+	query := `INSERT INTO "notification" (
 		id,
 		user_id,
 		message,
-		status,
+		is_read,
 		created_at,
 		updated_at)
 		VALUES($1,$2,$3,$4,CURRENT_TIMESTAMP,CURRENT_TIMESTAMP) 
@@ -56,10 +66,10 @@ func (n *NotificationRepo) Create(ctx context.Context, notification *models.Noti
 }
 
 func (n *NotificationRepo) Update(ctx context.Context, notification *models.Notification) (*models.Notification, error) {
-	query := `UPDATE "notifications" SET 
+	query := `UPDATE "notification" SET 
 		user_id=$1,
 		message=$2,
-		status=$3,
+		is_read=$3,
 		updated_at=CURRENT_TIMESTAMP
 		WHERE id = $4
 	`
@@ -99,9 +109,9 @@ func (n *NotificationRepo) GetAll(ctx context.Context, req *models.GetAllNotific
         id,
         user_id,
         message,
-        status,
+        is_read,
         created_at,
-        updated_at FROM "notifications"`+filter)
+        updated_at FROM "notification"`+filter)
 	if err != nil {
 		return resp, err
 	}
@@ -145,7 +155,7 @@ func (n *NotificationRepo) GetByID(ctx context.Context, id string) (*models.Noti
 		createdAt    sql.NullString
 		updatedAt    sql.NullString
 	)
-	if err := n.db.QueryRow(context.Background(), `SELECT id, user_id, message, status, created_at, updated_at FROM "notifications" WHERE id = $1`, id).Scan(
+	if err := n.db.QueryRow(context.Background(), `SELECT id, user_id, message, is_read, created_at, updated_at FROM "notification" WHERE id = $1`, id).Scan(
 		&notification.Id,
 		&userId,
 		&message,
@@ -165,10 +175,362 @@ func (n *NotificationRepo) GetByID(ctx context.Context, id string) (*models.Noti
 }
 
 func (n *NotificationRepo) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM "notifications" WHERE id = $1`
+	query := `DELETE FROM "notification" WHERE id = $1`
 	_, err := n.db.Exec(context.Background(), query, id)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+const notificationRecipientQuery = `
+	SELECT u.id, u.phone, u.email,
+		COALESCE(p.locale, ''), COALESCE(p.sms, true), COALESCE(p.email, false), COALESCE(p.push, true),
+		p.updated_at
+	FROM "user" u
+	LEFT JOIN "notification_preference" p ON p.user_id = u.id
+`
+
+func scanNotificationRecipient(row pgx.Row) (*models.NotificationRecipient, error) {
+	var (
+		recipient models.NotificationRecipient
+		prefs     = &recipient.Preferences
+		updatedAt sql.NullTime
+	)
+	if err := row.Scan(
+		&recipient.UserId, &recipient.Phone, &recipient.Email,
+		&prefs.Locale, &prefs.Sms, &prefs.Email, &prefs.Push, &updatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if prefs.Locale == "" {
+		prefs.Locale = i18n.Default
+	}
+	prefs.UserId = recipient.UserId
+	prefs.UpdatedAt = formatLocalTime(updatedAt)
+	return &recipient, nil
+}
+
+// Recipient returns the user's addresses and notification preferences.
+func (n *NotificationRepo) Recipient(ctx context.Context, userId string) (*models.NotificationRecipient, error) {
+	return scanNotificationRecipient(n.db.QueryRow(ctx, notificationRecipientQuery+`WHERE u.id = $1`, userId))
+}
+
+// OrderRecipient returns the addresses and notification preferences of the
+// customer of an order.
+func (n *NotificationRepo) OrderRecipient(ctx context.Context, orderId string) (*models.NotificationRecipient, error) {
+	return scanNotificationRecipient(n.db.QueryRow(ctx, notificationRecipientQuery+`
+		WHERE u.id = (SELECT user_id FROM "order" WHERE id = $1)`, orderId))
+}
+
+// SetPreferences replaces the user's notification preferences.
+func (n *NotificationRepo) SetPreferences(ctx context.Context, userId string, req *models.SetNotificationPreferences) (*models.NotificationPreferences, error) {
+	_, err := n.db.Exec(ctx, `
+		INSERT INTO "notification_preference" (user_id, locale, sms, email, push, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET
+			locale = EXCLUDED.locale,
+			sms = EXCLUDED.sms,
+			email = EXCLUDED.email,
+			push = EXCLUDED.push,
+			updated_at = EXCLUDED.updated_at
+	`, userId, req.Locale, req.Sms, req.Email, req.Push, time.Now())
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+		return nil, pgx.ErrNoRows
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set notification preferences: %w", err)
+	}
+
+	recipient, err := n.Recipient(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &recipient.Preferences, nil
+}
+
+// Dispatch stores an in-app notification together with a delivery row per
+// channel. The in-app delivery is sent by storing it; the others are pending
+// and only become due after lease, so the caller can send them first.
+func (n *NotificationRepo) Dispatch(ctx context.Context, notification *models.Notification, deliveries []models.NotificationDelivery, lease time.Duration) (*models.Notification, []models.NotificationDelivery, error) {
+	tx, err := n.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	leasedUntil := now.Add(lease).Truncate(time.Microsecond)
+	created := *notification
+	created.Id = uuid.New().String()
+	created.CreatedAt = formatLocalTime(sql.NullTime{Time: now, Valid: true})
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO "notification" (id, user_id, event, order_id, title, message, is_read, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, false, $7, $7)
+	`, created.Id, created.UserId, created.Event, created.OrderId, created.Title, created.Message, now)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to insert notification: %w", err)
+	}
+
+	stored := make([]models.NotificationDelivery, 0, len(deliveries)+1)
+	for _, d := range append([]models.NotificationDelivery{{Channel: notify.InApp, Status: "sent"}}, deliveries...) {
+		d.Id = uuid.New().String()
+		d.NotificationId = created.Id
+		d.UserId = created.UserId
+		d.Title = created.Title
+		d.Message = created.Message
+		d.OrderId = created.OrderId
+		d.CreatedAt = created.CreatedAt
+
+		var sentAt sql.NullTime
+		if d.Status == "" {
+			d.Status = "pending"
+			d.LeasedUntil = leasedUntil
+		}
+		if d.Status == "sent" {
+			sentAt = sql.NullTime{Time: now, Valid: true}
+			d.SentAt = created.CreatedAt
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO "notification_delivery" (id, notification_id, channel, recipient, status, next_attempt_at, sent_at, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		`, d.Id, d.NotificationId, d.Channel, d.Recipient, d.Status, leasedUntil, sentAt, now)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to insert %s delivery: %w", d.Channel, err)
+		}
+		stored = append(stored, d)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &created, stored[1:], nil
+}
+
+const notificationDeliveryColumns = `
+	d.id, d.notification_id, n.user_id, d.channel, d.recipient,
+	COALESCE(n.title, ''), n.message, COALESCE(n.order_id::text, ''),
+	d.status, d.attempts, COALESCE(d.last_error, ''), d.next_attempt_at, d.sent_at, d.created_at`
+
+func scanNotificationDelivery(row pgx.Row) (*models.NotificationDelivery, error) {
+	var (
+		d                                models.NotificationDelivery
+		nextAttemptAt, sentAt, createdAt sql.NullTime
+	)
+	if err := row.Scan(
+		&d.Id, &d.NotificationId, &d.UserId, &d.Channel, &d.Recipient,
+		&d.Title, &d.Message, &d.OrderId,
+		&d.Status, &d.Attempts, &d.LastError, &nextAttemptAt, &sentAt, &createdAt,
+	); err != nil {
+		return nil, err
+	}
+	if d.Status == "pending" {
+		d.NextAttemptAt = formatLocalTime(nextAttemptAt)
+	}
+	d.SentAt = formatLocalTime(sentAt)
+	d.CreatedAt = formatLocalTime(createdAt)
+	return &d, nil
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries whose next
+// attempt is due and pushes that attempt back by lease, so other workers
+// skip them while they are being sent.
+func (n *NotificationRepo) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.NotificationDelivery, error) {
+	now := time.Now()
+	// Timestamps are stored to the microsecond, so the lease reads back as
+	// it was written.
+	leasedUntil := now.Add(lease).Truncate(time.Microsecond)
+	rows, err := n.db.Query(ctx, `
+		UPDATE "notification_delivery" d
+		SET next_attempt_at = $2, updated_at = $1
+		FROM "notification" n
+		WHERE n.id = d.notification_id AND d.id IN (
+			SELECT id
+			FROM "notification_delivery"
+			WHERE status = 'pending' AND next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+notificationDeliveryColumns, now, leasedUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.NotificationDelivery
+	for rows.Next() {
+		d, err := scanNotificationDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification delivery: %w", err)
+		}
+		d.LeasedUntil = leasedUntil
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// UpdateDelivery records the outcome of a send attempt. Pending deliveries
+// are tried again at nextAttemptAt. Nothing is recorded once the lease of d
+// has run out and another worker may have claimed the delivery.
+func (n *NotificationRepo) UpdateDelivery(ctx context.Context, d *models.NotificationDelivery, nextAttemptAt time.Time) error {
+	now := time.Now()
+	var sentAt sql.NullTime
+	if d.Status == "sent" {
+		sentAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	tag, err := n.db.Exec(ctx, `
+		UPDATE "notification_delivery"
+		SET status = $2, attempts = $3, last_error = NULLIF($4, ''), next_attempt_at = $5, sent_at = $6, updated_at = $7
+		WHERE id = $1 AND status = 'pending' AND next_attempt_at = $8
+	`, d.Id, d.Status, d.Attempts, d.LastError, nextAttemptAt, sentAt, now, d.LeasedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to update notification delivery %s: %w", d.Id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("lease of notification delivery %s ran out before it was updated", d.Id)
+	}
+	return nil
+}
+
+// Deliveries lists the channels a notification was sent to and how each
+// delivery went.
+func (n *NotificationRepo) Deliveries(ctx context.Context, notificationId string) (*models.GetNotificationDeliveriesResponse, error) {
+	rows, err := n.db.Query(ctx, `
+		SELECT `+notificationDeliveryColumns+`
+		FROM "notification_delivery" d
+		JOIN "notification" n ON n.id = d.notification_id
+		WHERE d.notification_id = $1
+		ORDER BY d.created_at, d.channel
+	`, notificationId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	resp := &models.GetNotificationDeliveriesResponse{Deliveries: []models.NotificationDelivery{}}
+	for rows.Next() {
+		d, err := scanNotificationDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification delivery: %w", err)
+		}
+		resp.Deliveries = append(resp.Deliveries, *d)
+	}
+	return resp, rows.Err()
+}
+
+// encodeInboxCursor points after the given notification in the inbox order.
+func encodeInboxCursor(createdAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.Format(time.RFC3339Nano) + "," + id))
+}
+
+func decodeInboxCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", storage.ErrInvalidCursor
+	}
+	at, id, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return time.Time{}, "", storage.ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil || uuid.Validate(id) != nil {
+		return time.Time{}, "", storage.ErrInvalidCursor
+	}
+	return createdAt, id, nil
+}
+
+// Inbox lists the user's notifications newest first, a page at a time. The
+// cursor is the next_cursor of the previous page.
+func (n *NotificationRepo) Inbox(ctx context.Context, req *models.GetInboxRequest) (*models.GetInboxResponse, error) {
+	var (
+		args   = []interface{}{req.UserId}
+		filter = ""
+	)
+	if req.Unread {
+		filter += ` AND is_read = false`
+	}
+	if req.Cursor != "" {
+		createdAt, id, err := decodeInboxCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, createdAt, id)
+		filter += fmt.Sprintf(` AND (created_at, id) < ($%d, $%d)`, len(args)-1, len(args))
+	}
+	args = append(args, req.Limit+1)
+
+	rows, err := n.db.Query(ctx, `
+		SELECT id, user_id, COALESCE(event, ''), COALESCE(order_id::text, ''), COALESCE(title, ''), message, is_read, created_at
+		FROM "notification"
+		WHERE user_id = $1`+filter+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve inbox: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		resp = &models.GetInboxResponse{Notifications: []models.Notification{}}
+		last time.Time
+	)
+	for rows.Next() {
+		var (
+			notification models.Notification
+			createdAt    time.Time
+		)
+		if err := rows.Scan(
+			&notification.Id, &notification.UserId, &notification.Event, &notification.OrderId,
+			&notification.Title, &notification.Message, &notification.IsRead, &createdAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		if uint64(len(resp.Notifications)) == req.Limit {
+			resp.NextCursor = encodeInboxCursor(last, resp.Notifications[len(resp.Notifications)-1].Id)
+			break
+		}
+		notification.CreatedAt = formatLocalTime(sql.NullTime{Time: createdAt, Valid: true})
+		resp.Notifications = append(resp.Notifications, notification)
+		last = createdAt
+	}
+	return resp, rows.Err()
+}
+
+// UnreadCount counts the user's unread notifications.
+func (n *NotificationRepo) UnreadCount(ctx context.Context, userId string) (int64, error) {
+	var count int64
+	err := n.db.QueryRow(ctx, `
+		SELECT count(*)
+		FROM "notification"
+		WHERE user_id = $1 AND is_read = false
+	`, userId).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks the listed notifications of the user read, or all of them
+// when req.All is set, and returns how many were unread before.
+func (n *NotificationRepo) MarkRead(ctx context.Context, userId string, req *models.MarkNotificationsReadRequest) (int64, error) {
+	query := `
+		UPDATE "notification"
+		SET is_read = true, updated_at = $2
+		WHERE user_id = $1 AND is_read = false`
+	args := []interface{}{userId, time.Now()}
+	if !req.All {
+		query += ` AND id = ANY($3)`
+		args = append(args, req.Ids)
+	}
+
+	tag, err := n.db.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -3,11 +3,20 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"food/api/models"
+	"food/config"
+	"food/pkg"
+	"food/pkg/jobs"
 	"food/pkg/logger"
+	"food/pkg/notify"
+	"food/pkg/webhook"
+	"food/storage"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -44,10 +53,21 @@ func (o *OrderRepo) Create(ctx context.Context, order *models.OrderCreateRequest
 		}
 
 		var productPrice float64
-		productQuery := `SELECT price FROM "product" WHERE id = $1`
-		err = o.db.QueryRow(context.Background(), productQuery, item.ProductId).Scan(&productPrice)
-		if err != nil {
-			return &models.OrderCreateRequest{}, fmt.Errorf("failed to retrieve price for product %s: %w", item.ProductId, err)
+		if item.ComboId != "" {
+			// Combos are sold at their own price while they can be ordered.
+			comboQuery := `SELECT coalesce(c.price, 0) FROM "combo" c WHERE c.id = $1 AND ` + fmt.Sprintf(comboAvailableCond, 2)
+			err = tx.QueryRow(context.Background(), comboQuery, item.ComboId, timeOfDay(time.Now())).Scan(&productPrice)
+			if err != nil {
+				return &models.OrderCreateRequest{}, fmt.Errorf("failed to retrieve price for combo %s: %w", item.ComboId, err)
+			}
+		} else {
+			// FOR SHARE holds back a scheduled price change until the order
+			// has snapshotted the price in effect.
+			productQuery := `SELECT price FROM "product" WHERE id = $1 FOR SHARE`
+			err = tx.QueryRow(context.Background(), productQuery, item.ProductId).Scan(&productPrice)
+			if err != nil {
+				return &models.OrderCreateRequest{}, fmt.Errorf("failed to retrieve price for product %s: %w", item.ProductId, err)
+			}
 		}
 
 		order.Items[i].Price = productPrice
@@ -58,33 +78,221 @@ func (o *OrderRepo) Create(ctx context.Context, order *models.OrderCreateRequest
 		order.Items[i].CreatedAt = item.CreatedAt
 	}
 
+	// Pre-orders take a seat in their delivery slot and wait in the scheduled state
+	status := "pending"
+	var scheduledAt *time.Time
+	if order.Order.ScheduledAt != "" {
+		var slot time.Time
+		slot, err = o.reserveSlot(ctx, tx, &order.Order)
+		if err != nil {
+			return &models.OrderCreateRequest{}, err
+		}
+		scheduledAt = &slot
+		status = "scheduled"
+	}
+
+	// Orders placed without a branch go to the nearest one, so they reach a
+	// kitchen queue.
+	if order.Order.BranchId == "" {
+		order.Order.BranchId, err = nearestBranch(ctx, tx, order.Order.Latitude, order.Order.Longitude)
+		if err != nil {
+			return &models.OrderCreateRequest{}, err
+		}
+	}
+
+	// A percentage tip is taken of the total the customer pays for the food
+	tip := order.Order.Tip
+	if order.Order.TipPercent > 0 {
+		tip = roundAmount(totalSum * order.Order.TipPercent / 100)
+	}
+
 	// Insert the order
-	orderQuery := `INSERT INTO "order" (id, user_id, total_price, delivery_status, longitude, latitude, address_name, created_at, updated_at) 
-					  VALUES ($1, $2, $3, $4, $5, $6, $7, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) RETURNING id`
+	orderQuery := `INSERT INTO "order" (id, user_id, total_price, tip, status, delivery_status, longitude, latitude, address_name, branch_id, scheduled_at, handover_code, created_at, updated_at) 
+					  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, '')::uuid, $11, $12, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) RETURNING id`
 
-	_, err = tx.Exec(context.Background(), orderQuery, orderId, order.Order.UserId, totalSum, order.Order.DeliveryStatus, order.Order.Longitude, order.Order.Latitude, order.Order.AddressName)
+	_, err = tx.Exec(context.Background(), orderQuery, orderId, order.Order.UserId, totalSum, tip, status, order.Order.DeliveryStatus, order.Order.Longitude, order.Order.Latitude, order.Order.AddressName, order.Order.BranchId, scheduledAt, pkg.GenerateHandoverCode())
 	if err != nil {
 		return &models.OrderCreateRequest{}, err
 	}
 
 	// Insert the order items
-	itemQuery := `INSERT INTO "orderiteam" (id, quantity, order_id, product_id, price, total, created_at, updated_at) 
-					 VALUES ($1, $2, $3, $4, $5, $6, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
+	itemQuery := `INSERT INTO "orderiteam" (id, quantity, order_id, product_id, combo_id, price, total, modifiers, created_at, updated_at) 
+					 VALUES ($1, $2, $3, NULLIF($4, '')::uuid, NULLIF($5, '')::uuid, $6, $7, COALESCE($8::text[], '{}'), CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`
 
 	for _, item := range order.Items {
 		itemId := uuid.New().String()
-		_, err = tx.Exec(context.Background(), itemQuery, itemId, item.Quantity, orderId, item.ProductId, item.Price, item.TotalPrice)
+		_, err = tx.Exec(context.Background(), itemQuery, itemId, item.Quantity, orderId, item.ProductId, item.ComboId, item.Price, item.TotalPrice, item.Modifiers)
 		if err != nil {
 			return &models.OrderCreateRequest{}, err
 		}
 	}
 
+	err = publishWebhookEvent(ctx, tx, webhook.EventOrderCreated, models.WebhookOrderCreated{
+		OrderId:     orderId,
+		UserId:      order.Order.UserId,
+		BranchId:    order.Order.BranchId,
+		Status:      status,
+		TotalPrice:  totalSum,
+		Tip:         tip,
+		ScheduledAt: order.Order.ScheduledAt,
+	})
+	if err != nil {
+		return &models.OrderCreateRequest{}, err
+	}
+
 	order.Order.Id = orderId
 	order.Order.TotalPrice = totalSum
+	order.Order.Tip = tip
+	order.Order.Status = status
 
 	return order, tx.Commit(context.Background())
 }
 
+// nearestBranch returns the branch closest to the given point, or any branch
+// when none has coordinates, or "" when there are no branches.
+func nearestBranch(ctx context.Context, tx pgx.Tx, latitude, longitude float64) (string, error) {
+	var id string
+	err := tx.QueryRow(ctx, `
+		SELECT id
+		FROM "branch"
+		ORDER BY latitude IS NULL OR longitude IS NULL,
+			power(latitude - $1, 2) + power((longitude - $2) * cos(radians($1)), 2),
+			created_at
+		LIMIT 1
+	`, latitude, longitude).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find the nearest branch: %w", err)
+	}
+	return id, nil
+}
+
+// reserveSlot checks that the requested pre-order time is a free slot of the
+// branch. The branch row stays locked until the transaction ends so two
+// customers cannot take the last seat of a slot at the same time.
+func (o *OrderRepo) reserveSlot(ctx context.Context, tx pgx.Tx, order *models.Order) (time.Time, error) {
+	if order.BranchId == "" {
+		return time.Time{}, fmt.Errorf("branch_id is required for a scheduled order")
+	}
+
+	scheduledAt, err := time.Parse(time.RFC3339, order.ScheduledAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid scheduled_at %q: %w", order.ScheduledAt, err)
+	}
+	scheduledAt = scheduledAt.In(time.Local)
+
+	branch := models.Branch{Id: order.BranchId}
+	err = tx.QueryRow(ctx, `
+		SELECT to_char(opening_time, 'HH24:MI'), to_char(closing_time, 'HH24:MI'), slot_minutes, slot_capacity, lead_minutes
+		FROM "branch"
+		WHERE id = $1
+		FOR UPDATE
+	`, order.BranchId).Scan(&branch.OpeningTime, &branch.ClosingTime, &branch.SlotMinutes, &branch.SlotCapacity, &branch.LeadMinutes)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to retrieve branch %s: %w", order.BranchId, err)
+	}
+
+	now := time.Now()
+	if !scheduledAt.Before(startOfDay(now).AddDate(0, 0, config.MaxScheduleDays)) {
+		return time.Time{}, storage.ErrSlotUnavailable
+	}
+
+	starts, err := branchSlots(&branch, now.Add(time.Duration(branch.LeadMinutes)*time.Minute), scheduledAt.Add(time.Minute))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	found := false
+	for _, start := range starts {
+		if start.Equal(scheduledAt) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return time.Time{}, storage.ErrSlotUnavailable
+	}
+
+	var booked int
+	err = tx.QueryRow(ctx, `SELECT count(id) FROM "order" WHERE branch_id = $1 AND scheduled_at = $2 AND status <> 'cancelled' AND deleted_at IS NULL`,
+		order.BranchId, scheduledAt).Scan(&booked)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to count slot bookings: %w", err)
+	}
+	if booked >= branch.SlotCapacity {
+		return time.Time{}, storage.ErrSlotUnavailable
+	}
+
+	order.ScheduledAt = scheduledAt.Format(time.RFC3339)
+	return scheduledAt, nil
+}
+
+// ReleaseScheduled moves pre-orders whose kitchen lead time has started into
+// the confirmed state, letting their customers know, and returns the branch
+// of every released order.
+func (o *OrderRepo) ReleaseScheduled(ctx context.Context, now time.Time) ([]string, error) {
+	tx, err := o.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		UPDATE "order" o
+		SET status = 'confirmed', released_at = $1, updated_at = CURRENT_TIMESTAMP
+		FROM "branch" b
+		WHERE o.branch_id = b.id
+			AND o.status = 'scheduled'
+			AND o.deleted_at IS NULL
+			AND o.scheduled_at - make_interval(mins => b.lead_minutes) <= $1
+		RETURNING o.id, o.branch_id
+	`
+	rows, err := tx.Query(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release scheduled orders: %w", err)
+	}
+	var released, branches []string
+	for rows.Next() {
+		var id, branchId string
+		if err := rows.Scan(&id, &branchId); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan released order: %w", err)
+		}
+		released = append(released, id)
+		branches = append(branches, branchId)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to release scheduled orders: %w", err)
+	}
+
+	for _, id := range released {
+		err = publishWebhookEvent(ctx, tx, webhook.EventOrderStatusChanged, models.WebhookOrderStatusChanged{
+			OrderId:        id,
+			Status:         "confirmed",
+			PreviousStatus: "scheduled",
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = enqueueJob(ctx, tx, &models.EnqueueJob{
+			Type:    jobs.TypeOrderNotification,
+			Payload: jobs.OrderNotification{OrderId: id, Event: notify.EventOrderConfirmed},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return branches, nil
+}
+
 func (r *OrderRepo) Update(ctx context.Context, id string, updatedOrder *models.Order) (*models.OrderCreateRequest, error) {
 	// Start a transaction
 	tx, err := r.db.Begin(ctx)
@@ -121,11 +329,11 @@ func (r *OrderRepo) Update(ctx context.Context, id string, updatedOrder *models.
 			UPDATE "orderiteam"
 			SET product_id = $1, quantity = $2, price = $3, total = $4, updated_at = CURRENT_TIMESTAMP
 			WHERE order_id = $5 AND id = $6 AND deleted_at IS NULL
-			RETURNING id, product_id, order_id, quantity, price, total, created_at, updated_at
+			RETURNING id, coalesce(product_id::text, ''), coalesce(combo_id::text, ''), order_id, quantity, price, total, created_at, updated_at
 		`
 		var updatedItem models.OrderItem
 		err := tx.QueryRow(ctx, itemUpdateQuery, item.ProductId, item.Quantity, item.Price, item.TotalPrice, id, item.Id).Scan(
-			&updatedItem.Id, &updatedItem.ProductId, &updatedItem.OrderId, &updatedItem.Quantity, &updatedItem.Price, &updatedItem.TotalPrice, &updatedItem.CreatedAt, &updatedItem.UpdatedAt,
+			&updatedItem.Id, &updatedItem.ProductId, &updatedItem.ComboId, &updatedItem.OrderId, &updatedItem.Quantity, &updatedItem.Price, &updatedItem.TotalPrice, &updatedItem.CreatedAt, &updatedItem.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to update and retrieve order item with ID %s: %w", item.Id, err)
@@ -158,7 +366,8 @@ func (o *OrderRepo) GetAll(ctx context.Context, request *models.GetAllOrdersRequ
 
 	// Query to retrieve all orders, sorted by the latest created orders at the top
 	orderQuery := `
-		SELECT id, user_id, total_price, delivery_status, status, longitude, latitude, address_name, created_at, updated_at
+		SELECT id, user_id, total_price, delivery_status, status, longitude, latitude, address_name,
+			COALESCE(branch_id::text, ''), scheduled_at, created_at, updated_at
 		FROM "order"
 		ORDER BY created_at DESC
 	`
@@ -170,15 +379,18 @@ func (o *OrderRepo) GetAll(ctx context.Context, request *models.GetAllOrdersRequ
 
 	// Iterate over the retrieved orders
 	for rows.Next() {
-		var order models.Order
-		err = rows.Scan(&order.Id, &order.UserId, &order.TotalPrice, &order.DeliveryStatus, &order.Status, &order.Longitude, &order.Latitude, &order.AddressName, &created_at, &updated_at)
+		var (
+			order        models.Order
+			scheduled_at sql.NullTime
+		)
+		err = rows.Scan(&order.Id, &order.UserId, &order.TotalPrice, &order.DeliveryStatus, &order.Status, &order.Longitude, &order.Latitude, &order.AddressName, &order.BranchId, &scheduled_at, &created_at, &updated_at)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan order: %w", err)
 		}
 
 		// Query to retrieve order items for the current order
 		orderItemQuery := `
-			SELECT id, product_id, order_id, quantity, price, total, created_at, updated_at
+			SELECT id, coalesce(product_id::text, ''), coalesce(combo_id::text, ''), order_id, quantity, price, total, modifiers, created_at, updated_at
 			FROM "orderiteam"
 			WHERE order_id = $1
 		`
@@ -191,17 +403,19 @@ func (o *OrderRepo) GetAll(ctx context.Context, request *models.GetAllOrdersRequ
 		var orderItems []models.OrderItem
 		for itemRows.Next() {
 			var item models.OrderItem
-			err = itemRows.Scan(&item.Id, &item.ProductId, &item.OrderId, &item.Quantity, &item.Price, &item.TotalPrice, &created_at, &updated_at)
+			err = itemRows.Scan(&item.Id, &item.ProductId, &item.ComboId, &item.OrderId, &item.Quantity, &item.Price, &item.TotalPrice, &item.Modifiers, &created_at, &updated_at)
 			if err != nil {
 				return nil, fmt.Errorf("failed to scan order item: %w", err)
 			}
 			orderItems = append(orderItems, models.OrderItem{
 				Id:         item.Id,
 				ProductId:  item.ProductId,
+				ComboId:    item.ComboId,
 				OrderId:    item.OrderId,
 				Quantity:   item.Quantity,
 				Price:      item.Price,
 				TotalPrice: item.TotalPrice,
+				Modifiers:  item.Modifiers,
 				CreatedAt:  created_at.String,
 				UpdatedAt:  updated_at.String,
 			})
@@ -217,6 +431,8 @@ func (o *OrderRepo) GetAll(ctx context.Context, request *models.GetAllOrdersRequ
 				Longitude:      order.Longitude,
 				Latitude:       order.Latitude,
 				AddressName:    order.AddressName,
+				BranchId:       order.BranchId,
+				ScheduledAt:    formatLocalTime(scheduled_at),
 				CreatedAt:      created_at.String,
 				UpdatedAt:      updated_at.String,
 			},
@@ -238,13 +454,19 @@ func (r *OrderRepo) GetOrder(ctx context.Context, id string) (*models.OrderCreat
 	)
 
 	orderQuery := `
-		SELECT id, user_id, total_price, status, longitude, latitude, address_name, created_at, updated_at
+		SELECT id, user_id, total_price, tip, status, delivery_status, longitude, latitude, address_name,
+			COALESCE(branch_id::text, ''), scheduled_at,
+			CASE WHEN status IN ('delivered', 'cancelled') THEN '' ELSE COALESCE(handover_code, '') END,
+			created_at, updated_at
 		FROM "order"
 		WHERE id = $1
 	`
 
-	var order models.Order
-	err := r.db.QueryRow(ctx, orderQuery, id).Scan(&order.Id, &order.UserId, &order.TotalPrice, &order.Status, &order.Longitude, &order.Latitude, &order.AddressName, &created_at, &updated_at)
+	var (
+		order        models.Order
+		scheduled_at sql.NullTime
+	)
+	err := r.db.QueryRow(ctx, orderQuery, id).Scan(&order.Id, &order.UserId, &order.TotalPrice, &order.Tip, &order.Status, &order.DeliveryStatus, &order.Longitude, &order.Latitude, &order.AddressName, &order.BranchId, &scheduled_at, &order.HandoverCode, &created_at, &updated_at)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("order not found")
@@ -252,11 +474,12 @@ func (r *OrderRepo) GetOrder(ctx context.Context, id string) (*models.OrderCreat
 		return nil, fmt.Errorf("failed to retrieve order: %w", err)
 	}
 
+	order.ScheduledAt = formatLocalTime(scheduled_at)
 	order.CreatedAt = created_at.String
 	order.UpdatedAt = updated_at.String
 
 	orderItemQuery := `
-		SELECT id, product_id, order_id, quantity, price, total, created_at, updated_at
+		SELECT id, coalesce(product_id::text, ''), coalesce(combo_id::text, ''), order_id, quantity, price, total, modifiers, created_at, updated_at
 		FROM "orderiteam"
 		WHERE order_id = $1
 	`
@@ -269,7 +492,7 @@ func (r *OrderRepo) GetOrder(ctx context.Context, id string) (*models.OrderCreat
 	var orderItems []models.OrderItem
 	for itemRows.Next() {
 		var item models.OrderItem
-		err = itemRows.Scan(&item.Id, &item.ProductId, &item.OrderId, &item.Quantity, &item.Price, &item.TotalPrice, &created_at, &updated_at)
+		err = itemRows.Scan(&item.Id, &item.ProductId, &item.ComboId, &item.OrderId, &item.Quantity, &item.Price, &item.TotalPrice, &item.Modifiers, &created_at, &updated_at)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan order item: %w", err)
 		}
@@ -333,16 +556,41 @@ func (r *OrderRepo) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (o *OrderRepo) ChangeOrderStatus(ctx context.Context, req *models.PatchOrderStatusRequest, orderId string) (string, error) {
-	validStatuses := map[string]bool{
-		"pending":   true,
-		"confirmed": true,
-		"picked_up": true,
-		"delivered": true,
+// orderSteps orders the statuses an order goes through. By hand an order is
+// only moved forward or cancelled, and delivered and cancelled orders stay as
+// they are. Orders are only scheduled at checkout, where they get a delivery
+// slot, so no order is moved back to scheduled.
+var orderSteps = map[string]int{
+	"pending":   1,
+	"scheduled": 1,
+	"confirmed": 2,
+	"preparing": 3,
+	"ready":     4,
+	"picked_up": 5,
+	"en_route":  6,
+	"delivered": 7,
+	"cancelled": 8,
+}
+
+// orderStatusAllowed reports whether an order may be moved from one status
+// to another by hand. Setting the status an order already has changes
+// nothing and is allowed.
+func orderStatusAllowed(from, to string) bool {
+	step, ok := orderSteps[to]
+	switch {
+	case !ok || to == "scheduled":
+		return false
+	case from == to:
+		return true
+	case from == "delivered" || from == "cancelled":
+		return false
 	}
+	return step > orderSteps[from]
+}
 
-	if !validStatuses[req.Status] {
-		return "", fmt.Errorf("invalid status value: %s", req.Status)
+func (o *OrderRepo) ChangeOrderStatus(ctx context.Context, req *models.PatchOrderStatusRequest, orderId string) (string, error) {
+	if _, ok := orderSteps[req.Status]; !ok || req.Status == "scheduled" {
+		return "", fmt.Errorf("%w: %s", storage.ErrInvalidStatus, req.Status)
 	}
 
 	tx, err := o.db.Begin(ctx)
@@ -355,12 +603,62 @@ func (o *OrderRepo) ChangeOrderStatus(ctx context.Context, req *models.PatchOrde
 		}
 	}()
 
-	updateQuery := `UPDATE "order" SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+	var (
+		previous    string
+		courierOnly bool
+	)
+	// Orders a courier delivers are only delivered through the courier
+	// workflow, which checks the handover code and settles the courier's
+	// earnings and cash.
+	err = tx.QueryRow(ctx, `
+		SELECT o.status,
+			(o.delivery_status = 'yetkazib berish' AND coalesce(o.handover_code, '') <> '')
+			OR EXISTS (SELECT 1 FROM "courierassignment" a WHERE a.order_id = o.id AND a.status IN ('assigned', 'picked_up', 'en_route'))
+		FROM "order" o
+		WHERE o.id = $1
+		FOR UPDATE OF o`, orderId).Scan(&previous, &courierOnly)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve order %s: %w", orderId, err)
+	}
+	if !orderStatusAllowed(previous, req.Status) || (req.Status == "delivered" && previous != "delivered" && courierOnly) {
+		err = fmt.Errorf("%w: %s to %s", storage.ErrInvalidStatus, previous, req.Status)
+		return "", err
+	}
+
+	updateQuery := `UPDATE "order" SET
+		status = $1,
+		delivered_at = CASE WHEN $1 = 'delivered' THEN COALESCE(delivered_at, CURRENT_TIMESTAMP) ELSE delivered_at END,
+		cancelled_at = CASE WHEN $1 = 'cancelled' THEN COALESCE(cancelled_at, CURRENT_TIMESTAMP) ELSE cancelled_at END,
+		updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`
 	_, err = tx.Exec(ctx, updateQuery, req.Status, orderId)
 	if err != nil {
 		return "", fmt.Errorf("failed to update order status: %w", err)
 	}
 
+	if previous != req.Status {
+		err = publishWebhookEvent(ctx, tx, webhook.EventOrderStatusChanged, models.WebhookOrderStatusChanged{
+			OrderId:        orderId,
+			Status:         req.Status,
+			PreviousStatus: previous,
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// The customer hears when the order is confirmed and when it arrives.
+	event := map[string]string{"confirmed": notify.EventOrderConfirmed, "delivered": notify.EventOrderDelivered}[req.Status]
+	if event != "" && previous != req.Status {
+		_, err = enqueueJob(ctx, tx, &models.EnqueueJob{
+			Type:    jobs.TypeOrderNotification,
+			Payload: jobs.OrderNotification{OrderId: orderId, Event: event},
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
 	err = tx.Commit(ctx)
 	if err != nil {
 		return "", fmt.Errorf("failed to commit transaction: %w", err)
@@ -368,3 +666,24 @@ func (o *OrderRepo) ChangeOrderStatus(ctx context.Context, req *models.PatchOrde
 
 	return "Status changed successfully", nil
 }
+
+// HandoverCode returns the code the customer of an order gives the courier,
+// with the customer's phone to send it to. Orders created before codes were
+// introduced have none.
+func (o *OrderRepo) HandoverCode(ctx context.Context, id string) (string, string, error) {
+	var phone, code string
+	err := o.db.QueryRow(ctx, `SELECT u.phone, coalesce(o.handover_code, '')
+		FROM "order" o JOIN "user" u ON u.id = o.user_id
+		WHERE o.id = $1`, id).Scan(&phone, &code)
+	return phone, code, err
+}
+
+// formatLocalTime renders a TIMESTAMP column, which holds local wall-clock
+// time, as RFC 3339 in the server time zone.
+func formatLocalTime(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	v := t.Time
+	return time.Date(v.Year(), v.Month(), v.Day(), v.Hour(), v.Minute(), v.Second(), 0, time.Local).Format(time.RFC3339)
+}
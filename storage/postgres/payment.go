@@ -2,11 +2,13 @@ package postgres
 
 import (
 	"context"
+	"fmt"
 	"food/api/models"
 	"food/pkg/logger"
+	"food/pkg/webhook"
 	"time"
 
-	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
@@ -22,8 +24,14 @@ func NewPayment(db *pgxpool.Pool, log logger.LoggerI) PaymentRepo {
 	}
 }
 
-// Create implements storage.IPaymentStorage.
+// Create implements storage.IPaymentStorage. A payment created paid is
+// published to webhook subscribers in the same transaction.
 func (p *PaymentRepo) Create(ctx context.Context, payment *models.Payment) (*models.Payment, error) {
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
 	query := `INSERT INTO "payment" (
 		id,
@@ -34,7 +42,7 @@ func (p *PaymentRepo) Create(ctx context.Context, payment *models.Payment) (*mod
         created_at)
 		VALUES ($1, $2, $3, $4, $5, CURRENT_TIMESTAMP)
 	`
-	_, err := p.db.Exec(ctx, query,
+	_, err = tx.Exec(ctx, query,
 		payment.Id,
 		payment.UserId,
 		payment.OrderId,
@@ -46,6 +54,27 @@ func (p *PaymentRepo) Create(ctx context.Context, payment *models.Payment) (*mod
 		return nil, err
 	}
 
+	if payment.IsPaid {
+		paid := models.WebhookPaymentPaid{
+			PaymentId:     payment.Id,
+			OrderId:       payment.OrderId,
+			UserId:        payment.UserId,
+			Kind:          "order",
+			PaymentMethod: payment.PaymentMethod,
+		}
+		err = tx.QueryRow(ctx, `SELECT COALESCE(total_price, 0) + COALESCE(tip, 0) FROM "order" WHERE id = $1`, payment.OrderId).Scan(&paid.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve order %s: %w", payment.OrderId, err)
+		}
+		if err := publishWebhookEvent(ctx, tx, webhook.EventPaymentPaid, paid); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	// Return the newly created payment model
 	return &models.Payment{
 		Id:            payment.Id,
@@ -103,6 +132,37 @@ func (p *PaymentRepo) GetByID(ctx context.Context, id string) (*models.Payment,
 	return &payment, nil
 }
 
+// GetByOrderID returns the latest payment of an order, or nil if the order
+// has none yet. Tip payments are left out.
+func (p *PaymentRepo) GetByOrderID(ctx context.Context, orderId string) (*models.Payment, error) {
+
+	query := `SELECT id, user_id, order_id, is_paid, payment_method, created_at 
+	          FROM "payment" WHERE order_id = $1 AND kind = 'order'
+	          ORDER BY created_at DESC
+	          LIMIT 1`
+
+	var payment models.Payment
+
+	err := p.db.QueryRow(ctx, query, orderId).Scan(
+		&payment.Id,
+		&payment.UserId,
+		&payment.OrderId,
+		&payment.IsPaid,
+		&payment.PaymentMethod,
+		&payment.CreatedAt,
+	)
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		p.log.Error("Error retrieving payment by order ID: " + err.Error())
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
 // Update implements storage.IPaymentStorage.
 func (p *PaymentRepo) Update(context.Context, *models.Payment) (*models.Payment, error) {
 	panic("unimplemented")
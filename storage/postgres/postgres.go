@@ -29,6 +29,18 @@ type Store struct {
 	notification       *NotificationRepo
 	delivery_history   *DeliveryHistoryRepo
 	courier_assignment *CourierAssignmentRepo
+	kitchen            *KitchenRepo
+	review             *ReviewRepo
+	search             *SearchRepo
+	image              *ImageRepo
+	analytics          *AnalyticsRepo
+	export             *ExportRepo
+	catalog            *CatalogRepo
+	courierPay         *CourierPayRepo
+	tip                *TipRepo
+	deviceToken        *DeviceTokenRepo
+	webhook            *WebhookRepo
+	job                *JobRepo
 	cfg                config.Config
 }
 
@@ -71,7 +83,7 @@ func NewConnectionPostgres(cfg *config.Config) (storage.IStorage, error) {
 }
 
 func (s *Store) Payment() storage.IPaymentStorage {
-	if s.db == nil {
+	if s.payment == nil {
 		s.payment = &PaymentRepo{
 			db:  s.db,
 			log: s.log,
@@ -210,3 +222,111 @@ func (s *Store) Notification() storage.INotificationStorage {
 	}
 	return s.notification
 }
+
+// Kitchen implements storage.IStorage.
+func (s *Store) Kitchen() storage.IKitchenStorage {
+	if s.kitchen == nil {
+		s.kitchen = &KitchenRepo{
+			db:  s.db,
+			log: s.log,
+		}
+	}
+	return s.kitchen
+}
+
+// Review implements storage.IStorage.
+func (s *Store) Review() storage.IReviewStorage {
+	if s.review == nil {
+		s.review = &ReviewRepo{
+			db:  s.db,
+			log: s.log,
+		}
+	}
+	return s.review
+}
+
+// Search implements storage.IStorage.
+func (s *Store) Search() storage.ISearchStorage {
+	if s.search == nil {
+		s.search = &SearchRepo{
+			db:  s.db,
+			log: s.log,
+		}
+	}
+	return s.search
+}
+
+// Image implements storage.IStorage.
+func (s *Store) Image() storage.IImageStorage {
+	if s.image == nil {
+		s.image = &ImageRepo{
+			db:  s.db,
+			log: s.log,
+		}
+	}
+	return s.image
+}
+
+// Analytics implements storage.IStorage.
+func (s *Store) Analytics() storage.IAnalyticsStorage {
+	if s.analytics == nil {
+		s.analytics = NewAnalyticsRepo(s.db, s.log)
+	}
+	return s.analytics
+}
+
+// Export implements storage.IStorage.
+func (s *Store) Export() storage.IExportStorage {
+	if s.export == nil {
+		s.export = NewExportRepo(s.db, s.log)
+	}
+	return s.export
+}
+
+// Catalog implements storage.IStorage.
+func (s *Store) Catalog() storage.ICatalogStorage {
+	if s.catalog == nil {
+		s.catalog = NewCatalogRepo(s.db, s.log)
+	}
+	return s.catalog
+}
+
+// CourierPay implements storage.IStorage.
+func (s *Store) CourierPay() storage.ICourierPayStorage {
+	if s.courierPay == nil {
+		s.courierPay = NewCourierPayRepo(s.db, s.log)
+	}
+	return s.courierPay
+}
+
+// Tip implements storage.IStorage.
+func (s *Store) Tip() storage.ITipStorage {
+	if s.tip == nil {
+		s.tip = NewTipRepo(s.db, s.log)
+	}
+	return s.tip
+}
+
+// DeviceToken implements storage.IStorage.
+func (s *Store) DeviceToken() storage.IDeviceTokenStorage {
+	if s.deviceToken == nil {
+		s.deviceToken = NewDeviceTokenRepo(s.db, s.log)
+	}
+	return s.deviceToken
+}
+
+// Webhook implements storage.IStorage.
+func (s *Store) Webhook() storage.IWebhookStorage {
+	if s.webhook == nil {
+		s.webhook = NewWebhookRepo(s.db, s.log)
+	}
+	return s.webhook
+}
+
+// Job implements storage.IStorage.
+func (s *Store) Job() storage.IJobStorage {
+	if s.job == nil {
+		s.job = NewJobRepo(s.db, s.log)
+	}
+	return s.job
+}
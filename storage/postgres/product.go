@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"food/api/models"
 	"food/pkg/logger"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4/pgxpool"
@@ -24,6 +25,11 @@ func NewProduct(db *pgxpool.Pool, log logger.LoggerI) ProductRepo {
 }
 
 func (p *ProductRepo) Create(ctx context.Context, product *models.Product) (*models.Product, error) {
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return &models.Product{}, err
+	}
+	defer tx.Rollback(ctx)
 
 	id := uuid.New()
 	query := `INSERT INTO "product" (
@@ -33,23 +39,33 @@ func (p *ProductRepo) Create(ctx context.Context, product *models.Product) (*mod
 		price,
 		image_url,
 		name,
+		translations,
 		created_at,
 		updated_at)
-		VALUES($1,$2,$3,$4,$5,$6, CURRENT_TIMESTAMP,CURRENT_TIMESTAMP) 
+		VALUES($1,$2,$3,$4,$5,$6,$7, CURRENT_TIMESTAMP,CURRENT_TIMESTAMP) 
 	`
 
-	_, err := p.db.Exec(context.Background(), query,
+	_, err = tx.Exec(ctx, query,
 		id.String(),
 		product.CategoryId,
 		product.Description,
 		product.Price,
 		product.ImageURL,
 		product.Name,
+		translationsArg(product.Translations),
 	)
 
 	if err != nil {
 		return &models.Product{}, err
 	}
+
+	if err := recordPrice(ctx, tx, id.String(), product.Price, time.Now()); err != nil {
+		return &models.Product{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return &models.Product{}, err
+	}
 	return &models.Product{
 		Id:          id.String(),
 		Name:        product.Name,
@@ -59,30 +75,57 @@ func (p *ProductRepo) Create(ctx context.Context, product *models.Product) (*mod
 		ImageURL:    product.ImageURL,
 		CreatedAt:   product.CreatedAt,
 		UpdatedAt:   product.UpdatedAt,
+
+		Translations: product.Translations,
 	}, nil
 }
 
+// Update saves the product. A changed price is added to the price history as
+// effective now; scheduled price changes stay planned.
 func (p *ProductRepo) Update(ctx context.Context, product *models.Product) (*models.Product, error) {
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		return &models.Product{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	var oldPrice float64
+	if err := tx.QueryRow(ctx, `SELECT price FROM "product" WHERE id = $1 FOR UPDATE`, product.Id).Scan(&oldPrice); err != nil {
+		return &models.Product{}, err
+	}
+
 	query := `UPDATE "product" SET 
 		name=$1,
 		category_id=$2,
 		description=$3,
 		price=$4,
 		image_url=$5,
+		translations=$6,
 		updated_at=CURRENT_TIMESTAMP
-		WHERE id = $6
+		WHERE id = $7
 		`
-	_, err := p.db.Exec(context.Background(), query,
+	_, err = tx.Exec(ctx, query,
 		product.Name,
 		product.CategoryId,
 		product.Description,
 		product.Price,
 		product.ImageURL,
+		translationsArg(product.Translations),
 		product.Id,
 	)
 	if err != nil {
 		return &models.Product{}, err
 	}
+
+	if product.Price != oldPrice {
+		if err := recordPrice(ctx, tx, product.Id, product.Price, time.Now()); err != nil {
+			return &models.Product{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return &models.Product{}, err
+	}
 	return &models.Product{
 		Id:          product.Id,
 		Name:        product.Name,
@@ -92,6 +135,8 @@ func (p *ProductRepo) Update(ctx context.Context, product *models.Product) (*mod
 		ImageURL:    product.ImageURL,
 		CreatedAt:   product.CreatedAt,
 		UpdatedAt:   product.UpdatedAt,
+
+		Translations: product.Translations,
 	}, nil
 }
 
@@ -104,8 +149,21 @@ func (p *ProductRepo) GetAll(ctx context.Context, req *models.GetAllProductsRequ
 	)
 	offset := (req.Page - 1) * req.Limit
 
+	// Products of hidden categories drop out unless an admin asks for them.
+	cte := ""
+	if !req.IncludeHidden {
+		cte = fmt.Sprintf(visibleCategoriesCTE, argIdx)
+		filter += " WHERE category_id IN (SELECT id FROM visible_category) "
+		args = append(args, timeOfDay(time.Now()))
+		argIdx++
+	}
+
 	if req.Search != "" {
-		filter += fmt.Sprintf(" WHERE (name ILIKE $%d) ", argIdx)
+		if filter == "" {
+			filter += fmt.Sprintf(" WHERE (name ILIKE $%d) ", argIdx)
+		} else {
+			filter += fmt.Sprintf(" AND (name ILIKE $%d) ", argIdx)
+		}
 		args = append(args, "%"+req.Search+"%")
 		argIdx++
 	}
@@ -123,13 +181,16 @@ func (p *ProductRepo) GetAll(ctx context.Context, req *models.GetAllProductsRequ
 	filter += fmt.Sprintf(" OFFSET %d LIMIT %d", offset, req.Limit)
 	fmt.Println("filter: ", filter)
 
-	query := `SELECT count(id) OVER(),
+	query := cte + `SELECT count(id) OVER(),
 		id,
 		category_id,
 		name,
 		description,
 		price,
 		image_url,
+		rating_avg,
+		rating_count,
+		translations,
 		created_at,
 		updated_at 
 		FROM "product"` + filter
@@ -158,6 +219,9 @@ func (p *ProductRepo) GetAll(ctx context.Context, req *models.GetAllProductsRequ
 			&description,
 			&price,
 			&image_url,
+			&product.RatingAvg,
+			&product.RatingCount,
+			&product.Translations,
 			&created_at,
 			&updated_at); err != nil {
 			return resp, err
@@ -170,10 +234,30 @@ func (p *ProductRepo) GetAll(ctx context.Context, req *models.GetAllProductsRequ
 			Description: description.String,
 			Price:       price.Float64,
 			ImageURL:    image_url.String,
+			RatingAvg:   product.RatingAvg,
+			RatingCount: product.RatingCount,
 			CreatedAt:   created_at.String,
 			UpdatedAt:   updated_at.String,
+
+			Translations: product.Translations,
 		})
 	}
+	if err := rows.Err(); err != nil {
+		return resp, err
+	}
+
+	ids := make([]string, 0, len(resp.Products))
+	for _, product := range resp.Products {
+		ids = append(ids, product.Id)
+	}
+	galleries, err := loadImages(ctx, p.db, "product_id", ids)
+	if err != nil {
+		return resp, err
+	}
+	for i := range resp.Products {
+		resp.Products[i].Images = galleries[resp.Products[i].Id]
+	}
+
 	return resp, nil
 }
 
@@ -188,18 +272,27 @@ func (p *ProductRepo) GetByID(ctx context.Context, id string) (*models.Product,
 		created_at  sql.NullString
 		updated_at  sql.NullString
 	)
-	if err := p.db.QueryRow(context.Background(), `SELECT id, category_id, name, description, price, image_url, created_at, updated_at FROM "product" WHERE id = $1`, id).Scan(
+	if err := p.db.QueryRow(context.Background(), `SELECT id, category_id, name, description, price, image_url, rating_avg, rating_count, translations, created_at, updated_at FROM "product" WHERE id = $1`, id).Scan(
 		&product.Id,
-		&name,
 		&category_id,
+		&name,
 		&description,
 		&price,
 		&image_url,
+		&product.RatingAvg,
+		&product.RatingCount,
+		&product.Translations,
 		&created_at,
 		&updated_at,
 	); err != nil {
 		return &models.Product{}, err
 	}
+
+	galleries, err := loadImages(ctx, p.db, "product_id", []string{product.Id})
+	if err != nil {
+		return &models.Product{}, err
+	}
+
 	return &models.Product{
 		Id:          product.Id,
 		CategoryId:  category_id.String,
@@ -207,8 +300,13 @@ func (p *ProductRepo) GetByID(ctx context.Context, id string) (*models.Product,
 		Description: description.String,
 		Price:       price.Float64,
 		ImageURL:    image_url.String,
+		RatingAvg:   product.RatingAvg,
+		RatingCount: product.RatingCount,
 		CreatedAt:   created_at.String,
 		UpdatedAt:   updated_at.String,
+
+		Images:       galleries[product.Id],
+		Translations: product.Translations,
 	}, nil
 }
 
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"food/api/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// SchedulePrice plans a price change of the product at effectiveFrom. A
+// change already planned for the same moment gets the new price. It returns
+// pgx.ErrNoRows for an unknown product.
+func (p *ProductRepo) SchedulePrice(ctx context.Context, productId string, price float64, effectiveFrom time.Time) (*models.ProductPrice, error) {
+	query := `INSERT INTO "product_price" (id, product_id, price, effective_from, created_at)
+		SELECT $1, pr.id, $3, $4, CURRENT_TIMESTAMP FROM "product" pr WHERE pr.id = $2
+		ON CONFLICT (product_id, effective_from) DO UPDATE SET price = EXCLUDED.price
+			WHERE "product_price".applied_at IS NULL
+		RETURNING id, product_id, price, effective_from, applied_at, created_at`
+
+	var (
+		entry       models.ProductPrice
+		effectiveAt sql.NullTime
+		appliedAt   sql.NullTime
+		createdAt   sql.NullTime
+	)
+	err := p.db.QueryRow(ctx, query, uuid.New().String(), productId, price, effectiveFrom.In(time.Local)).Scan(
+		&entry.Id,
+		&entry.ProductId,
+		&entry.Price,
+		&effectiveAt,
+		&appliedAt,
+		&createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.EffectiveFrom = formatLocalTime(effectiveAt)
+	entry.AppliedAt = formatLocalTime(appliedAt)
+	entry.CreatedAt = formatLocalTime(createdAt)
+	entry.Status = "scheduled"
+	return &entry, nil
+}
+
+// CancelScheduledPrice removes a price change that has not been applied yet.
+func (p *ProductRepo) CancelScheduledPrice(ctx context.Context, productId, priceId string) error {
+	tag, err := p.db.Exec(ctx, `DELETE FROM "product_price" WHERE id = $1 AND product_id = $2 AND applied_at IS NULL`, priceId, productId)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// PriceHistory returns the price timeline of the product, oldest first,
+// including scheduled changes. It returns pgx.ErrNoRows for an unknown
+// product.
+func (p *ProductRepo) PriceHistory(ctx context.Context, productId string) ([]models.ProductPrice, error) {
+	rows, err := p.db.Query(ctx, `
+		SELECT id, product_id, price, effective_from,
+			lead(effective_from) OVER (ORDER BY effective_from),
+			applied_at, created_at
+		FROM "product_price"
+		WHERE product_id = $1
+		ORDER BY effective_from`, productId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve price history: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		prices  = []models.ProductPrice{}
+		current = -1
+	)
+	for rows.Next() {
+		var (
+			entry       models.ProductPrice
+			effectiveAt sql.NullTime
+			effectiveTo sql.NullTime
+			appliedAt   sql.NullTime
+			createdAt   sql.NullTime
+		)
+		if err := rows.Scan(&entry.Id, &entry.ProductId, &entry.Price, &effectiveAt, &effectiveTo, &appliedAt, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan price: %w", err)
+		}
+		entry.EffectiveFrom = formatLocalTime(effectiveAt)
+		entry.EffectiveTo = formatLocalTime(effectiveTo)
+		entry.AppliedAt = formatLocalTime(appliedAt)
+		entry.CreatedAt = formatLocalTime(createdAt)
+		entry.Status = "scheduled"
+		if appliedAt.Valid {
+			entry.Status = "past"
+			current = len(prices)
+		}
+		prices = append(prices, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate price history: %w", err)
+	}
+
+	if len(prices) == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	// The last applied entry is the one product.price holds.
+	if current >= 0 {
+		prices[current].Status = "current"
+	}
+
+	return prices, nil
+}
+
+// ApplyDuePrices sets product.price to the latest scheduled price that took
+// effect by now, marks every due change applied and returns how many
+// products changed price. A due change older than a price already applied,
+// such as one set by hand while the change was overdue, would undo the newer
+// price, so it is dropped instead.
+func (p *ProductRepo) ApplyDuePrices(ctx context.Context, now time.Time) (int64, error) {
+	const superseded = `EXISTS (
+		SELECT 1 FROM "product_price" h
+		WHERE h.product_id = s.product_id AND h.applied_at IS NOT NULL AND h.effective_from > s.effective_from
+	)`
+	query := `
+		WITH stale AS (
+			DELETE FROM "product_price" s
+			WHERE s.applied_at IS NULL AND s.effective_from <= $1 AND ` + superseded + `
+		), due AS (
+			UPDATE "product_price" s
+			SET applied_at = $1
+			WHERE s.applied_at IS NULL AND s.effective_from <= $1 AND NOT ` + superseded + `
+			RETURNING product_id, price, effective_from
+		), latest AS (
+			SELECT DISTINCT ON (product_id) product_id, price
+			FROM due
+			ORDER BY product_id, effective_from DESC
+		)
+		UPDATE "product" pr
+		SET price = latest.price, updated_at = CURRENT_TIMESTAMP
+		FROM latest
+		WHERE pr.id = latest.product_id
+	`
+	tag, err := p.db.Exec(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to apply scheduled prices: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// recordPrice adds a price that takes effect at once to the history.
+func recordPrice(ctx context.Context, tx pgx.Tx, productId string, price float64, now time.Time) error {
+	_, err := tx.Exec(ctx, `INSERT INTO "product_price" (id, product_id, price, effective_from, applied_at, created_at)
+		VALUES ($1, $2, $3, $4, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (product_id, effective_from) DO UPDATE SET price = EXCLUDED.price, applied_at = EXCLUDED.applied_at`,
+		uuid.New().String(), productId, price, now)
+	if err != nil {
+		return fmt.Errorf("failed to record price: %w", err)
+	}
+	return nil
+}
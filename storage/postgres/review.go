@@ -0,0 +1,289 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"food/api/models"
+	"food/config"
+	"food/pkg/logger"
+	"food/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type ReviewRepo struct {
+	db  *pgxpool.Pool
+	log logger.LoggerI
+}
+
+func NewReview(db *pgxpool.Pool, log logger.LoggerI) ReviewRepo {
+	return ReviewRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+const reviewColumns = `
+	id,
+	order_id,
+	user_id,
+	courier_id,
+	food_rating,
+	courier_rating,
+	comment,
+	photo_urls,
+	status,
+	admin_reply,
+	replied_at,
+	created_at,
+	updated_at`
+
+// Create stores the customer's review of a delivered order. The order row is
+// locked so the delivery checks and the insert see the same state.
+func (r *ReviewRepo) Create(ctx context.Context, orderId string, review *models.CreateReview) (*models.Review, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		userId   string
+		status   string
+		inWindow bool
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT user_id, status, COALESCE(delivered_at >= CURRENT_TIMESTAMP - make_interval(days => $2), false)
+		FROM "order"
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`, orderId, config.ReviewWindowDays).Scan(&userId, &status, &inWindow)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrReviewNotAllowed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve order %s: %w", orderId, err)
+	}
+
+	if userId != review.UserId || status != "delivered" || !inWindow {
+		return nil, storage.ErrReviewNotAllowed
+	}
+
+	// Pickup orders have no courier, so their courier rating is dropped.
+	var courierId sql.NullString
+	err = tx.QueryRow(ctx, `
+		SELECT courier_id
+		FROM "courierassignment"
+		WHERE order_id = $1
+		ORDER BY assigned_at DESC
+		LIMIT 1
+	`, orderId).Scan(&courierId)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to retrieve courier of order %s: %w", orderId, err)
+	}
+
+	courierRating := sql.NullInt32{Int32: int32(review.CourierRating), Valid: courierId.Valid && review.CourierRating > 0}
+
+	photoUrls := review.PhotoUrls
+	if photoUrls == nil {
+		photoUrls = []string{}
+	}
+
+	id := uuid.New().String()
+	_, err = tx.Exec(ctx, `
+		INSERT INTO "review" (id, order_id, user_id, courier_id, food_rating, courier_rating, comment, photo_urls, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+	`, id, orderId, review.UserId, courierId, review.FoodRating, courierRating, review.Comment, photoUrls)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, storage.ErrAlreadyReviewed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert review: %w", err)
+	}
+
+	if err := refreshProductRatings(ctx, tx, orderId); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+func (r *ReviewRepo) GetByID(ctx context.Context, id string) (*models.Review, error) {
+	row := r.db.QueryRow(ctx, `SELECT`+reviewColumns+` FROM "review" WHERE id = $1`, id)
+
+	review, err := scanReview(row)
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (r *ReviewRepo) GetAll(ctx context.Context, req *models.GetAllReviewsRequest) (*models.GetAllReviewsResponse, error) {
+	var (
+		resp   = &models.GetAllReviewsResponse{Reviews: []models.Review{}}
+		filter = " WHERE true "
+		args   []interface{}
+		argIdx = 1
+	)
+	offset := (req.Page - 1) * req.Limit
+
+	if req.Status != "" {
+		filter += fmt.Sprintf(" AND status = $%d ", argIdx)
+		args = append(args, req.Status)
+		argIdx++
+	}
+
+	if req.CourierId != "" {
+		filter += fmt.Sprintf(" AND courier_id = $%d::uuid ", argIdx)
+		args = append(args, req.CourierId)
+		argIdx++
+	}
+
+	if req.ProductId != "" {
+		filter += fmt.Sprintf(` AND order_id IN (SELECT order_id FROM "orderiteam" WHERE product_id = $%d::uuid) `, argIdx)
+		args = append(args, req.ProductId)
+		argIdx++
+	}
+
+	query := `SELECT count(id) OVER(),` + reviewColumns + ` FROM "review"` + filter +
+		fmt.Sprintf(" ORDER BY created_at DESC OFFSET %d LIMIT %d", offset, req.Limit)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reviews: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var count int64
+		review, err := scanReview(rows, &count)
+		if err != nil {
+			return nil, err
+		}
+		resp.Count = count
+		resp.Reviews = append(resp.Reviews, *review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate reviews: %w", err)
+	}
+
+	return resp, nil
+}
+
+// Moderate publishes or hides a review and recomputes the ratings of the
+// products in the reviewed order, since hidden reviews do not count.
+func (r *ReviewRepo) Moderate(ctx context.Context, id, status string) (*models.Review, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var orderId string
+	err = tx.QueryRow(ctx, `
+		UPDATE "review" SET status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING order_id
+	`, status, id).Scan(&orderId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update review %s: %w", id, err)
+	}
+
+	if err := refreshProductRatings(ctx, tx, orderId); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+func (r *ReviewRepo) Reply(ctx context.Context, id, reply string) (*models.Review, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE "review" SET admin_reply = $1, replied_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+	`, reply, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reply to review %s: %w", id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, pgx.ErrNoRows
+	}
+
+	return r.GetByID(ctx, id)
+}
+
+// refreshProductRatings recomputes the average food rating and review count
+// of every product in the order from its published reviews. A review counts
+// once for a product however many lines of its order hold the product.
+func refreshProductRatings(ctx context.Context, tx pgx.Tx, orderId string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE "product" p SET
+			rating_avg = COALESCE(s.rating_avg, 0),
+			rating_count = s.rating_count
+		FROM (SELECT DISTINCT product_id FROM "orderiteam" WHERE order_id = $1) t
+		CROSS JOIN LATERAL (
+			SELECT round(avg(r.food_rating), 2) AS rating_avg, count(r.id) AS rating_count
+			FROM "review" r
+			WHERE r.status = 'published'
+				AND EXISTS (SELECT 1 FROM "orderiteam" i WHERE i.order_id = r.order_id AND i.product_id = t.product_id)
+		) s
+		WHERE p.id = t.product_id
+	`, orderId)
+	if err != nil {
+		return fmt.Errorf("failed to refresh product ratings: %w", err)
+	}
+	return nil
+}
+
+func scanReview(row pgx.Row, extra ...interface{}) (*models.Review, error) {
+	var (
+		review        models.Review
+		courierId     sql.NullString
+		courierRating sql.NullInt32
+		adminReply    sql.NullString
+		repliedAt     sql.NullTime
+		createdAt     sql.NullTime
+		updatedAt     sql.NullTime
+	)
+
+	dest := append(extra,
+		&review.Id,
+		&review.OrderId,
+		&review.UserId,
+		&courierId,
+		&review.FoodRating,
+		&courierRating,
+		&review.Comment,
+		&review.PhotoUrls,
+		&review.Status,
+		&adminReply,
+		&repliedAt,
+		&createdAt,
+		&updatedAt,
+	)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	review.CourierId = courierId.String
+	review.CourierRating = int(courierRating.Int32)
+	review.AdminReply = adminReply.String
+	review.RepliedAt = formatLocalTime(repliedAt)
+	review.CreatedAt = formatLocalTime(createdAt)
+	review.UpdatedAt = formatLocalTime(updatedAt)
+
+	return &review, nil
+}
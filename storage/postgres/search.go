@@ -0,0 +1,182 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"food/api/models"
+	"food/pkg/logger"
+	"food/pkg/translit"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type SearchRepo struct {
+	db  *pgxpool.Pool
+	log logger.LoggerI
+}
+
+func NewSearch(db *pgxpool.Pool, log logger.LoggerI) SearchRepo {
+	return SearchRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+const (
+	// searchMinSimilarity is the trigram word similarity a name needs to
+	// match a query that has no full-text hit, which is what lets "palov"
+	// find "plov".
+	searchMinSimilarity = "0.4"
+
+	// searchMarkStart and searchMarkStop stand in for <mark> and </mark> in
+	// the snippets until the text around them is HTML-escaped.
+	searchMarkStart = "\uE000"
+	searchMarkStop  = "\uE001"
+	searchHeadline  = "StartSel=" + searchMarkStart + ", StopSel=" + searchMarkStop + ", MaxWords=20, MinWords=5, MaxFragments=1"
+)
+
+// searchNames is the text the trigram search compares queries with: every
+// name of a row, in all languages. The *_names_trgm_idx indexes are built on
+// the same expression.
+func searchNames(alias string) string {
+	return `lower(` + alias + `.name || ' ' || jsonb_path_query_array(` + alias + `.translations, '$.*.name')::text)`
+}
+
+// searchCond matches rows with a full-text hit or a name similar to one of
+// the query variants. Both sides can use the table's indexes.
+func searchCond(alias string) string {
+	return `(` + alias + `.search_vector @@ to_tsquery('simple', $1) OR ` + searchNames(alias) + ` %> ANY($2::text[]))`
+}
+
+// Search ranks products, combos and categories against the query written in
+// either alphabet or any translated language, and returns names and snippets
+// in req.Lang. Full-text matches on names outweigh matches on descriptions,
+// and trigram similarity of the names adds to the score so misspelled
+// queries still find something. Snippets are HTML with the matched words in
+// <mark>.
+func (s *SearchRepo) Search(ctx context.Context, req *models.SearchRequest) (*models.SearchResponse, error) {
+	resp := &models.SearchResponse{Query: req.Query, Results: []models.SearchResult{}}
+
+	variants := translit.Variants(req.Query)
+	tsQuery := searchTsQuery(variants)
+	if tsQuery == "" {
+		return resp, nil
+	}
+
+	query := fmt.Sprintf(visibleCategoriesCTE, 7) + `
+		SELECT kind, id, name, snippet, price, image_url, score
+		FROM (
+			SELECT 'product' AS kind, p.id,
+				coalesce(nullif(p.translations->$6->>'name', ''), p.name) AS name,
+				ts_headline('simple', coalesce(nullif(p.translations->$6->>'description', ''), nullif(p.description, ''), p.name), to_tsquery('simple', $1), $3) AS snippet,
+				p.price, p.image_url,
+				ts_rank(p.search_vector, to_tsquery('simple', $1)) + sim.value AS score
+			FROM "product" p
+			CROSS JOIN LATERAL (SELECT max(word_similarity(v, ` + searchNames("p") + `)) AS value FROM unnest($2::text[]) v) sim
+			WHERE $4 IN ('', 'product') AND ` + searchCond("p") + `
+				AND p.category_id IN (SELECT id FROM visible_category)
+
+			UNION ALL
+
+			SELECT 'combo', c.id,
+				coalesce(nullif(c.translations->$6->>'name', ''), c.name),
+				ts_headline('simple', coalesce(nullif(c.translations->$6->>'description', ''), nullif(c.description, ''), c.name), to_tsquery('simple', $1), $3),
+				c.price, NULL,
+				ts_rank(c.search_vector, to_tsquery('simple', $1)) + sim.value
+			FROM "combo" c
+			CROSS JOIN LATERAL (SELECT max(word_similarity(v, ` + searchNames("c") + `)) AS value FROM unnest($2::text[]) v) sim
+			WHERE $4 IN ('', 'combo') AND ` + searchCond("c") + `
+				AND ` + fmt.Sprintf(comboAvailableCond, 7) + `
+
+			UNION ALL
+
+			SELECT 'category', ct.id,
+				coalesce(nullif(ct.translations->$6->>'name', ''), ct.name),
+				ts_headline('simple', coalesce(nullif(ct.translations->$6->>'name', ''), ct.name), to_tsquery('simple', $1), $3),
+				NULL, NULL,
+				ts_rank(ct.search_vector, to_tsquery('simple', $1)) + sim.value
+			FROM "category" ct
+			CROSS JOIN LATERAL (SELECT max(word_similarity(v, ` + searchNames("ct") + `)) AS value FROM unnest($2::text[]) v) sim
+			WHERE $4 IN ('', 'category') AND ` + searchCond("ct") + `
+				AND ct.id IN (SELECT id FROM visible_category)
+		) r
+		ORDER BY score DESC, name
+		LIMIT $5
+	`
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// The trigram operators compare with this setting rather than with a
+	// parameter, which is what lets them use the indexes.
+	_, err = tx.Exec(ctx, `SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)`, searchMinSimilarity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set search similarity: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, query, tsQuery, variants, searchHeadline, req.Type, req.Limit, req.Lang, timeOfDay(time.Now()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search menu: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			result   models.SearchResult
+			name     sql.NullString
+			snippet  sql.NullString
+			price    sql.NullFloat64
+			imageURL sql.NullString
+		)
+		if err := rows.Scan(&result.Type, &result.Id, &name, &snippet, &price, &imageURL, &result.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		result.Name = name.String
+		result.Snippet = searchSnippet(snippet.String)
+		result.Price = price.Float64
+		result.ImageURL = imageURL.String
+		resp.Results = append(resp.Results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate search results: %w", err)
+	}
+
+	return resp, nil
+}
+
+// searchSnippet escapes a ts_headline snippet for HTML and only then turns
+// its match markers into <mark> tags.
+func searchSnippet(snippet string) string {
+	snippet = html.EscapeString(snippet)
+	return strings.NewReplacer(searchMarkStart, "<mark>", searchMarkStop, "</mark>").Replace(snippet)
+}
+
+// searchTsQuery builds a prefix tsquery that matches any spelling variant,
+// e.g. (osh:* & xona:*) | (ош:* & хона:*). Apostrophes are dropped to match
+// how the search_vector columns are indexed, and everything that is not a
+// letter or digit separates words, so the result is always valid syntax.
+func searchTsQuery(variants []string) string {
+	var groups []string
+	for _, variant := range variants {
+		variant = strings.ReplaceAll(variant, "'", "")
+		words := strings.FieldsFunc(variant, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		if len(words) == 0 {
+			continue
+		}
+		for i, word := range words {
+			words[i] = word + ":*"
+		}
+		groups = append(groups, "("+strings.Join(words, " & ")+")")
+	}
+	return strings.Join(groups, " | ")
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"food/api/models"
+	"food/config"
+	"food/pkg/logger"
+	"food/pkg/webhook"
+	"food/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// TipRepo records tips customers add after their order is delivered.
+type TipRepo struct {
+	db  *pgxpool.Pool
+	log logger.LoggerI
+}
+
+func NewTipRepo(db *pgxpool.Pool, log logger.LoggerI) *TipRepo {
+	return &TipRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Create pays a tip for a delivered order and credits the courier's share as
+// a tip row of the delivery history. The order row is locked so the checks
+// and the inserts see the same state.
+func (r *TipRepo) Create(ctx context.Context, orderId string, req *models.CreateTipRequest) (*models.OrderTip, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		userId   string
+		status   string
+		branchId sql.NullString
+		total    float64
+		inWindow bool
+	)
+	err = tx.QueryRow(ctx, `
+		SELECT user_id, status, branch_id::text, COALESCE(total_price, 0),
+			COALESCE(delivered_at >= CURRENT_TIMESTAMP - make_interval(hours => $2), false)
+		FROM "order"
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`, orderId, config.TipWindowHours).Scan(&userId, &status, &branchId, &total, &inWindow)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrTipNotAllowed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve order %s: %w", orderId, err)
+	}
+
+	if userId != req.UserId || status != "delivered" || !inWindow {
+		return nil, storage.ErrTipNotAllowed
+	}
+
+	// Pickup orders have no courier to tip.
+	var courierId string
+	err = tx.QueryRow(ctx, `
+		SELECT courier_id
+		FROM "courierassignment"
+		WHERE order_id = $1 AND status IN ('delivered', 'payment_collected')
+		ORDER BY assigned_at DESC
+		LIMIT 1
+	`, orderId).Scan(&courierId)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, storage.ErrTipNotAllowed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve courier of order %s: %w", orderId, err)
+	}
+
+	amount := req.Amount
+	if req.Percent > 0 {
+		amount = roundAmount(total * req.Percent / 100)
+	}
+	if amount <= 0 {
+		return nil, storage.ErrTipNotAllowed
+	}
+
+	rate, err := branchCourierRate(ctx, tx, branchId.String)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tip := &models.OrderTip{
+		PaymentId:     uuid.New().String(),
+		OrderId:       orderId,
+		CourierId:     courierId,
+		Amount:        amount,
+		CourierAmount: roundAmount(amount * float64(rate.TipShare) / 100),
+		PaymentMethod: req.PaymentMethod,
+		CreatedAt:     formatLocalTime(sql.NullTime{Time: now, Valid: true}),
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO "payment" (id, user_id, order_id, kind, amount, is_paid, payment_method, created_at)
+		VALUES ($1, $2, $3, 'tip', $4, true, $5, $6)
+	`, tip.PaymentId, req.UserId, orderId, amount, req.PaymentMethod, now)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, storage.ErrAlreadyTipped
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert tip payment: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO "deliveryhistory" (id, courier_id, order_id, kind, tip_amount, earnings, delivered_at)
+		VALUES ($1, $2, $3, 'tip', $4, $4, $5)
+	`, uuid.New().String(), courierId, orderId, tip.CourierAmount, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to credit tip: %w", err)
+	}
+
+	err = publishWebhookEvent(ctx, tx, webhook.EventPaymentPaid, models.WebhookPaymentPaid{
+		PaymentId:     tip.PaymentId,
+		OrderId:       orderId,
+		UserId:        req.UserId,
+		Kind:          "tip",
+		Amount:        amount,
+		PaymentMethod: req.PaymentMethod,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return tip, nil
+}
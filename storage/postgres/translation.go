@@ -0,0 +1,21 @@
+package postgres
+
+import "food/api/models"
+
+// translationsArg makes sure a missing translation set is stored as an empty
+// JSON object rather than a JSON null.
+func translationsArg(translations map[string]models.Translation) map[string]models.Translation {
+	if translations == nil {
+		return map[string]models.Translation{}
+	}
+	return translations
+}
+
+// optionalTranslationsArg passes a missing translation set as SQL NULL, so
+// an update can keep the stored translations with COALESCE.
+func optionalTranslationsArg(translations map[string]models.Translation) interface{} {
+	if translations == nil {
+		return nil
+	}
+	return translations
+}
@@ -0,0 +1,322 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"food/api/models"
+	"food/pkg/logger"
+	"food/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// WebhookRepo keeps the partners' webhook subscriptions and the deliveries
+// of the events they subscribed to.
+type WebhookRepo struct {
+	db  *pgxpool.Pool
+	log logger.LoggerI
+}
+
+func NewWebhookRepo(db *pgxpool.Pool, log logger.LoggerI) *WebhookRepo {
+	return &WebhookRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+const webhookSubscriptionColumns = `id, url, events, COALESCE(description, ''), is_active, created_at, updated_at`
+
+const webhookDeliveryColumns = `d.id, d.subscription_id, d.event_id, e.type, e.payload, s.url, s.secret, d.status, d.attempts,
+	COALESCE(d.response_status, 0), COALESCE(d.last_error, ''), d.next_attempt_at, d.delivered_at, e.created_at, d.created_at`
+
+func scanWebhookSubscription(row pgx.Row, count ...*int64) (*models.WebhookSubscription, error) {
+	var (
+		sub                  models.WebhookSubscription
+		createdAt, updatedAt sql.NullTime
+	)
+	dest := []interface{}{&sub.Id, &sub.Url, &sub.Events, &sub.Description, &sub.IsActive, &createdAt, &updatedAt}
+	if len(count) > 0 {
+		dest = append([]interface{}{count[0]}, dest...)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	sub.CreatedAt = formatLocalTime(createdAt)
+	sub.UpdatedAt = formatLocalTime(updatedAt)
+	return &sub, nil
+}
+
+func scanWebhookDelivery(row pgx.Row, count ...*int64) (*models.WebhookDelivery, error) {
+	var (
+		d                                            models.WebhookDelivery
+		payload                                      []byte
+		nextAttemptAt, deliveredAt, eventAt, created sql.NullTime
+	)
+	dest := []interface{}{
+		&d.Id, &d.SubscriptionId, &d.EventId, &d.Event, &payload, &d.Url, &d.Secret, &d.Status, &d.Attempts,
+		&d.ResponseStatus, &d.LastError, &nextAttemptAt, &deliveredAt, &eventAt, &created,
+	}
+	if len(count) > 0 {
+		dest = append([]interface{}{count[0]}, dest...)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	d.Payload = json.RawMessage(payload)
+	if d.Status == "pending" {
+		d.NextAttemptAt = formatLocalTime(nextAttemptAt)
+	}
+	d.DeliveredAt = formatLocalTime(deliveredAt)
+	d.EventCreatedAt = formatLocalTime(eventAt)
+	d.CreatedAt = formatLocalTime(created)
+	return &d, nil
+}
+
+// publishWebhookEvent writes an event to the outbox in tx with a pending
+// delivery for every active subscription to its type, so partners hear of
+// a change exactly when it is committed.
+func publishWebhookEvent(ctx context.Context, tx pgx.Tx, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	now := time.Now()
+	eventId := uuid.New().String()
+	_, err = tx.Exec(ctx, `INSERT INTO "webhook_event" (id, type, payload, created_at) VALUES ($1, $2, $3, $4)`,
+		eventId, eventType, payload, now)
+	if err != nil {
+		return fmt.Errorf("failed to insert %s event: %w", eventType, err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO "webhook_delivery" (id, event_id, subscription_id, next_attempt_at, created_at, updated_at)
+		SELECT gen_random_uuid(), $1, id, $2, $2, $2
+		FROM "webhook_subscription"
+		WHERE is_active AND deleted_at IS NULL AND $3 = ANY(events)
+	`, eventId, now, eventType)
+	if err != nil {
+		return fmt.Errorf("failed to queue %s deliveries: %w", eventType, err)
+	}
+	return nil
+}
+
+// CreateSubscription stores a subscription. The returned subscription
+// carries its secret.
+func (w *WebhookRepo) CreateSubscription(ctx context.Context, req *models.CreateWebhookSubscription) (*models.WebhookSubscription, error) {
+	isActive := req.IsActive == nil || *req.IsActive
+	now := time.Now()
+	sub, err := scanWebhookSubscription(w.db.QueryRow(ctx, `
+		INSERT INTO "webhook_subscription" (id, url, secret, events, description, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $7)
+		RETURNING `+webhookSubscriptionColumns,
+		uuid.New().String(), req.Url, req.Secret, req.Events, req.Description, isActive, now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+	sub.Secret = req.Secret
+	return sub, nil
+}
+
+func (w *WebhookRepo) GetSubscription(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	return scanWebhookSubscription(w.db.QueryRow(ctx, `SELECT `+webhookSubscriptionColumns+`
+		FROM "webhook_subscription" WHERE id = $1 AND deleted_at IS NULL`, id))
+}
+
+// GetSubscriptions lists the subscriptions, latest first.
+func (w *WebhookRepo) GetSubscriptions(ctx context.Context, page, limit uint64) (*models.GetAllWebhookSubscriptionsResponse, error) {
+	offset := (page - 1) * limit
+	rows, err := w.db.Query(ctx, fmt.Sprintf(`
+		SELECT count(id) OVER(), `+webhookSubscriptionColumns+`
+		FROM "webhook_subscription"
+		WHERE deleted_at IS NULL
+		ORDER BY created_at DESC, id
+		OFFSET %d LIMIT %d`, offset, limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	resp := &models.GetAllWebhookSubscriptionsResponse{Subscriptions: []models.WebhookSubscription{}}
+	for rows.Next() {
+		var count int64
+		sub, err := scanWebhookSubscription(rows, &count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		resp.Count = count
+		resp.Subscriptions = append(resp.Subscriptions, *sub)
+	}
+	return resp, rows.Err()
+}
+
+// UpdateSubscription replaces a subscription. Its secret is only replaced
+// when req has one, and only then returned.
+func (w *WebhookRepo) UpdateSubscription(ctx context.Context, id string, req *models.UpdateWebhookSubscription) (*models.WebhookSubscription, error) {
+	sub, err := scanWebhookSubscription(w.db.QueryRow(ctx, `
+		UPDATE "webhook_subscription"
+		SET url = $2, secret = COALESCE(NULLIF($3, ''), secret), events = $4, description = NULLIF($5, ''), is_active = $6, updated_at = $7
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING `+webhookSubscriptionColumns,
+		id, req.Url, req.Secret, req.Events, req.Description, req.IsActive, time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	sub.Secret = req.Secret
+	return sub, nil
+}
+
+// DeleteSubscription stops a subscription. Its delivery log is kept and the
+// deliveries still pending are dead-lettered.
+func (w *WebhookRepo) DeleteSubscription(ctx context.Context, id string) error {
+	tx, err := w.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	tag, err := tx.Exec(ctx, `UPDATE "webhook_subscription" SET is_active = false, deleted_at = $2, updated_at = $2
+		WHERE id = $1 AND deleted_at IS NULL`, id, now)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE "webhook_delivery" SET status = 'dead', last_error = 'subscription deleted', updated_at = $2
+		WHERE subscription_id = $1 AND status = 'pending'`, id, now)
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter webhook deliveries: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries of active
+// subscriptions that are due, and hides them from other workers for lease.
+func (w *WebhookRepo) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	now := time.Now()
+	// Timestamps are stored to the microsecond, so the lease reads back as
+	// it was written.
+	leasedUntil := now.Add(lease).Truncate(time.Microsecond)
+	rows, err := w.db.Query(ctx, `
+		UPDATE "webhook_delivery" d
+		SET next_attempt_at = $2, updated_at = $1
+		FROM "webhook_event" e, "webhook_subscription" s
+		WHERE e.id = d.event_id AND s.id = d.subscription_id AND d.id IN (
+			SELECT pd.id
+			FROM "webhook_delivery" pd
+			JOIN "webhook_subscription" ps ON ps.id = pd.subscription_id
+			WHERE pd.status = 'pending' AND pd.next_attempt_at <= $1 AND ps.is_active AND ps.deleted_at IS NULL
+			ORDER BY pd.next_attempt_at
+			LIMIT $3
+			FOR UPDATE OF pd SKIP LOCKED
+		)
+		RETURNING `+webhookDeliveryColumns, now, leasedUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		d.LeasedUntil = leasedUntil
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// UpdateDelivery records the outcome of a send attempt. Pending deliveries
+// are tried again at nextAttemptAt. Nothing is recorded once the lease of d
+// has run out and another worker may have claimed the delivery.
+func (w *WebhookRepo) UpdateDelivery(ctx context.Context, d *models.WebhookDelivery, nextAttemptAt time.Time) error {
+	now := time.Now()
+	var deliveredAt sql.NullTime
+	if d.Status == "delivered" {
+		deliveredAt = sql.NullTime{Time: now, Valid: true}
+	}
+
+	tag, err := w.db.Exec(ctx, `
+		UPDATE "webhook_delivery"
+		SET status = $2, attempts = $3, response_status = NULLIF($4, 0), last_error = NULLIF($5, ''),
+			next_attempt_at = $6, delivered_at = $7, updated_at = $8
+		WHERE id = $1 AND status = 'pending' AND next_attempt_at = $9
+	`, d.Id, d.Status, d.Attempts, d.ResponseStatus, d.LastError, nextAttemptAt, deliveredAt, now, d.LeasedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery %s: %w", d.Id, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("lease of webhook delivery %s ran out before it was updated", d.Id)
+	}
+	return nil
+}
+
+// Deliveries is the delivery log of a subscription, latest first.
+func (w *WebhookRepo) Deliveries(ctx context.Context, req *models.GetWebhookDeliveriesRequest) (*models.GetWebhookDeliveriesResponse, error) {
+	offset := (req.Page - 1) * req.Limit
+	rows, err := w.db.Query(ctx, fmt.Sprintf(`
+		SELECT count(d.id) OVER(), `+webhookDeliveryColumns+`
+		FROM "webhook_delivery" d
+		JOIN "webhook_event" e ON e.id = d.event_id
+		JOIN "webhook_subscription" s ON s.id = d.subscription_id
+		WHERE d.subscription_id = $1 AND ($2::text = '' OR d.status = $2)
+		ORDER BY d.created_at DESC, d.id
+		OFFSET %d LIMIT %d`, offset, req.Limit), req.SubscriptionId, req.Status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	resp := &models.GetWebhookDeliveriesResponse{Deliveries: []models.WebhookDelivery{}}
+	for rows.Next() {
+		var count int64
+		d, err := scanWebhookDelivery(rows, &count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		resp.Count = count
+		resp.Deliveries = append(resp.Deliveries, *d)
+	}
+	return resp, rows.Err()
+}
+
+// Redeliver queues a delivered or dead-lettered delivery to be sent again
+// now with a fresh set of attempts. Deliveries of deleted subscriptions
+// cannot be sent again.
+func (w *WebhookRepo) Redeliver(ctx context.Context, id string) (*models.WebhookDelivery, error) {
+	now := time.Now()
+	tag, err := w.db.Exec(ctx, `
+		UPDATE "webhook_delivery" d
+		SET status = 'pending', attempts = 0, next_attempt_at = $2, updated_at = $2
+		FROM "webhook_subscription" s
+		WHERE d.id = $1 AND s.id = d.subscription_id AND s.deleted_at IS NULL AND d.status <> 'pending'
+	`, id, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to requeue webhook delivery: %w", err)
+	}
+
+	d, err := scanWebhookDelivery(w.db.QueryRow(ctx, `
+		SELECT `+webhookDeliveryColumns+`
+		FROM "webhook_delivery" d
+		JOIN "webhook_event" e ON e.id = d.event_id
+		JOIN "webhook_subscription" s ON s.id = d.subscription_id
+		WHERE d.id = $1`, id))
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, storage.ErrInvalidStatus
+	}
+	return d, nil
+}
@@ -2,10 +2,88 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"food/api/models"
 	"time"
 )
 
+// ErrSlotUnavailable is returned when a pre-order asks for a delivery slot
+// that is full, outside the branch opening hours or too soon to prepare.
+var ErrSlotUnavailable = errors.New("delivery slot is not available")
+
+// ErrInvalidStatus is returned when a status change is not allowed from the
+// current status of the record.
+var ErrInvalidStatus = errors.New("invalid status transition")
+
+// ErrReviewNotAllowed is returned when an order is not delivered, belongs to
+// someone else or was delivered too long ago to be reviewed.
+var ErrReviewNotAllowed = errors.New("order cannot be reviewed")
+
+// ErrAlreadyReviewed is returned when the order already has a review.
+var ErrAlreadyReviewed = errors.New("order is already reviewed")
+
+// ErrInvalidParent is returned when a category's parent does not exist or is
+// the category itself or one of its descendants.
+var ErrInvalidParent = errors.New("invalid parent category")
+
+// ErrInvalidBranch is returned when a record targets a branch that does not
+// exist.
+var ErrInvalidBranch = errors.New("invalid branch")
+
+// ErrGalleryFull is returned when a product or combo already has the maximum
+// number of images.
+var ErrGalleryFull = errors.New("gallery is full")
+
+// ErrInvalidImageOrder is returned when a reorder request does not list every
+// image of the gallery exactly once.
+var ErrInvalidImageOrder = errors.New("image order must list every image of the gallery once")
+
+// ErrInvalidProduct is returned when a combo item refers to a product that
+// does not exist.
+var ErrInvalidProduct = errors.New("invalid product")
+
+// ErrInvalidQuantity is returned when a combo item has a quantity below one.
+var ErrInvalidQuantity = errors.New("quantity must be greater than 0")
+
+// ErrInvalidCourier is returned when a record refers to a courier that does
+// not exist.
+var ErrInvalidCourier = errors.New("invalid courier")
+
+// ErrInvalidProof is returned when a delivery is missing its proof or the
+// proof does not match the order.
+var ErrInvalidProof = errors.New("invalid delivery proof")
+
+// ErrHandoverLocked is returned when an order's handover code was entered
+// wrong too many times.
+var ErrHandoverLocked = errors.New("handover is locked")
+
+// ErrTipNotAllowed is returned when an order is not delivered by a courier,
+// belongs to another user or was delivered too long ago to be tipped.
+var ErrTipNotAllowed = errors.New("order cannot be tipped")
+
+// ErrAlreadyTipped is returned when the order already has a tip added after
+// delivery.
+var ErrAlreadyTipped = errors.New("order is already tipped")
+
+// ErrInvalidCursor is returned when a pagination cursor was not issued by
+// the listing it is passed to.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrJobQueued is returned when a job cannot be queued again because
+// another job with its unique key is pending or running.
+var ErrJobQueued = errors.New("a job with the same unique key is queued")
+
+// ErrEmptyCombo is returned when a change would leave a combo without items.
+var ErrEmptyCombo = errors.New("combo must have at least one item")
+
+// ErrComboOrdered is returned when a combo that orders refer to is deleted;
+// such combos can only be archived.
+var ErrComboOrdered = errors.New("combo has been ordered")
+
+// ErrLeaseLost is returned when the outcome of a job is recorded after its
+// lease ran out, when another worker may have claimed it again.
+var ErrLeaseLost = errors.New("job lease ran out")
+
 type IStorage interface {
 	CloseDB()
 	Admin() IAdminStorage
@@ -20,12 +98,25 @@ type IStorage interface {
 	CourierAssignment() ICourierAssignmentStorage
 	Notification() INotificationStorage
 	DeliveryHistory() IDeliveryHistoryStorage
+	Kitchen() IKitchenStorage
+	Review() IReviewStorage
+	Search() ISearchStorage
+	Image() IImageStorage
+	Analytics() IAnalyticsStorage
+	Export() IExportStorage
+	Catalog() ICatalogStorage
+	CourierPay() ICourierPayStorage
+	Tip() ITipStorage
+	DeviceToken() IDeviceTokenStorage
+	Webhook() IWebhookStorage
+	Job() IJobStorage
 	Redis() IRedisStorage
 }
 
 type IPaymentStorage interface {
 	Create(context.Context, *models.Payment) (*models.Payment, error)
 	GetByID(ctx context.Context, id string) (*models.Payment, error)
+	GetByOrderID(ctx context.Context, orderId string) (*models.Payment, error)
 	GetAll(ctx context.Context, request *models.GetAllPaymentsRequest) (*models.GetAllPaymentsResponse, error)
 	Update(context.Context, *models.Payment) (*models.Payment, error)
 	Delete(context.Context, string) error
@@ -47,7 +138,11 @@ type IComboStorage interface {
 	GetAll(ctx context.Context, request *models.GetAllCombosRequest) (*[]models.ComboCreateRequest, error)
 	GetCombo(ctx context.Context, id string) (*models.ComboCreateRequest, error)
 	Update(ctx context.Context, id string, updatedCombo *models.Combo) (*models.ComboCreateRequest, error)
-	// Delete(context.Context, string) error
+	AddItem(ctx context.Context, comboId string, item *models.ComboItem) (*models.ComboCreateRequest, error)
+	RemoveItem(ctx context.Context, comboId, itemId string) (*models.ComboCreateRequest, error)
+	ReplaceItems(ctx context.Context, comboId string, items []models.ComboItem) (*models.ComboCreateRequest, error)
+	Archive(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string) error
 }
 
 type IAdminStorage interface {
@@ -64,7 +159,10 @@ type IAdminStorage interface {
 type IBannerStorage interface {
 	Create(context.Context, *models.Banner) (*models.Banner, error)
 	GetAll(ctx context.Context, request *models.GetAllBannerRequest) (*models.GetAllBannerResponse, error)
+	GetByID(ctx context.Context, id string) (*models.Banner, error)
+	Update(context.Context, *models.Banner) (*models.Banner, error)
 	Delete(ctx context.Context, id string) error
+	Track(ctx context.Context, event string, ids []string) (int64, error)
 }
 
 type IBranchStorage interface {
@@ -73,6 +171,7 @@ type IBranchStorage interface {
 	GetByID(ctx context.Context, id string) (*models.Branch, error)
 	Update(ctx context.Context, branch *models.Branch) (*models.Branch, error)
 	Delete(ctx context.Context, id string) error
+	GetSlots(ctx context.Context, request *models.GetBranchSlotsRequest) (*models.GetBranchSlotsResponse, error)
 }
 
 type ICategoryStorage interface {
@@ -89,6 +188,10 @@ type IProductStorage interface {
 	GetByID(ctx context.Context, id string) (*models.Product, error)
 	Update(context.Context, *models.Product) (*models.Product, error)
 	Delete(context.Context, string) error
+	SchedulePrice(ctx context.Context, productId string, price float64, effectiveFrom time.Time) (*models.ProductPrice, error)
+	CancelScheduledPrice(ctx context.Context, productId, priceId string) error
+	PriceHistory(ctx context.Context, productId string) ([]models.ProductPrice, error)
+	ApplyDuePrices(ctx context.Context, now time.Time) (int64, error)
 }
 
 type IOrderStorage interface {
@@ -98,6 +201,8 @@ type IOrderStorage interface {
 	Update(ctx context.Context, id string, updatedOrder *models.Order) (*models.OrderCreateRequest, error)
 	Delete(ctx context.Context, id string) error
 	ChangeOrderStatus(ctx context.Context, req *models.PatchOrderStatusRequest, orderId string) (string, error)
+	ReleaseScheduled(ctx context.Context, now time.Time) ([]string, error)
+	HandoverCode(ctx context.Context, id string) (phone, code string, err error)
 }
 
 type ICourierAssignmentStorage interface {
@@ -106,6 +211,10 @@ type ICourierAssignmentStorage interface {
 	GetByID(ctx context.Context, id string) (*models.CourierAssignment, error)
 	Update(context.Context, *models.CourierAssignment) (*models.CourierAssignment, error)
 	Delete(context.Context, string) error
+	ChangeStatus(ctx context.Context, req *models.CourierStatusChange) (*models.CourierAssignment, error)
+	Accept(ctx context.Context, id, courierId string) (*models.CourierAssignment, error)
+	Jobs(ctx context.Context, courierId string) (*models.GetCourierJobsResponse, error)
+	HandoverAttempts(ctx context.Context, orderId string) (*models.GetHandoverAttemptsResponse, error)
 }
 
 type INotificationStorage interface {
@@ -114,6 +223,16 @@ type INotificationStorage interface {
 	GetByID(ctx context.Context, id string) (*models.Notification, error)
 	Update(context.Context, *models.Notification) (*models.Notification, error)
 	Delete(context.Context, string) error
+	Recipient(ctx context.Context, userId string) (*models.NotificationRecipient, error)
+	OrderRecipient(ctx context.Context, orderId string) (*models.NotificationRecipient, error)
+	SetPreferences(ctx context.Context, userId string, req *models.SetNotificationPreferences) (*models.NotificationPreferences, error)
+	Dispatch(ctx context.Context, notification *models.Notification, deliveries []models.NotificationDelivery, lease time.Duration) (*models.Notification, []models.NotificationDelivery, error)
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.NotificationDelivery, error)
+	UpdateDelivery(ctx context.Context, d *models.NotificationDelivery, nextAttemptAt time.Time) error
+	Deliveries(ctx context.Context, notificationId string) (*models.GetNotificationDeliveriesResponse, error)
+	Inbox(ctx context.Context, req *models.GetInboxRequest) (*models.GetInboxResponse, error)
+	UnreadCount(ctx context.Context, userId string) (int64, error)
+	MarkRead(ctx context.Context, userId string, req *models.MarkNotificationsReadRequest) (int64, error)
 }
 
 type IDeliveryHistoryStorage interface {
@@ -124,6 +243,98 @@ type IDeliveryHistoryStorage interface {
 	Delete(context.Context, string) error
 }
 
+type IKitchenStorage interface {
+	GetQueue(ctx context.Context, branchId string) (*models.KitchenQueueResponse, error)
+	UpdateOrderStatus(ctx context.Context, branchId, orderId, status string) error
+	UpdateItemStatus(ctx context.Context, branchId, itemId, status string) error
+	GetStats(ctx context.Context, request *models.KitchenStatsRequest) (*models.KitchenStatsResponse, error)
+}
+
+type IReviewStorage interface {
+	Create(ctx context.Context, orderId string, review *models.CreateReview) (*models.Review, error)
+	GetByID(ctx context.Context, id string) (*models.Review, error)
+	GetAll(ctx context.Context, request *models.GetAllReviewsRequest) (*models.GetAllReviewsResponse, error)
+	Moderate(ctx context.Context, id, status string) (*models.Review, error)
+	Reply(ctx context.Context, id, reply string) (*models.Review, error)
+}
+
+type ISearchStorage interface {
+	Search(ctx context.Context, request *models.SearchRequest) (*models.SearchResponse, error)
+}
+
+type IImageStorage interface {
+	Create(ctx context.Context, image *models.ProductImage, maxImages int) (*models.ProductImage, error)
+	GetByID(ctx context.Context, id string) (*models.ProductImage, error)
+	Reorder(ctx context.Context, productId, comboId string, imageIds []string) ([]models.ProductImage, error)
+	Delete(ctx context.Context, id string) (*models.ProductImage, error)
+}
+
+type IAnalyticsStorage interface {
+	Revenue(ctx context.Context, req *models.AnalyticsRequest) (*models.RevenueReport, error)
+	TopProducts(ctx context.Context, req *models.AnalyticsRequest) (*models.TopProductsReport, error)
+	Branches(ctx context.Context, req *models.AnalyticsRequest) (*models.BranchPerformanceReport, error)
+	Hourly(ctx context.Context, req *models.AnalyticsRequest) (*models.HourlyReport, error)
+	Customers(ctx context.Context, req *models.AnalyticsRequest) (*models.CustomerReport, error)
+}
+
+type IExportStorage interface {
+	Orders(ctx context.Context, req *models.ExportRequest, fn func(*models.OrderExportRow) error) error
+	Payments(ctx context.Context, req *models.ExportRequest, fn func(*models.PaymentExportRow) error) error
+	CourierEarnings(ctx context.Context, req *models.ExportRequest, fn func(*models.CourierEarningExportRow) error) error
+}
+
+type ICatalogStorage interface {
+	Import(ctx context.Context, rows []models.CatalogRow, dryRun bool) (*models.CatalogImportResult, error)
+	Export(ctx context.Context, fn func(*models.CatalogRow) error) error
+}
+
+type ICourierPayStorage interface {
+	GetRates(ctx context.Context) ([]models.CourierRate, error)
+	SetRate(ctx context.Context, req *models.SetCourierRate) (*models.CourierRate, error)
+	Earnings(ctx context.Context, req *models.CourierEarningsRequest) (*models.CourierEarningsResponse, error)
+	Cash(ctx context.Context, courierId string) (*models.CourierCashResponse, error)
+	Reconcile(ctx context.Context, courierId string, req *models.ReconcileCashRequest) (*models.CourierReconciliation, error)
+	GetReconciliations(ctx context.Context, req *models.GetCourierReconciliationsRequest) (*models.GetCourierReconciliationsResponse, error)
+	CreatePayouts(ctx context.Context, req *models.CreatePayoutsRequest) ([]models.CourierPayout, error)
+	GetPayouts(ctx context.Context, req *models.GetCourierPayoutsRequest) (*models.GetCourierPayoutsResponse, error)
+	MarkPayoutPaid(ctx context.Context, id, reference string) (*models.CourierPayout, error)
+}
+
+type ITipStorage interface {
+	Create(ctx context.Context, orderId string, req *models.CreateTipRequest) (*models.OrderTip, error)
+}
+
+type IDeviceTokenStorage interface {
+	Register(ctx context.Context, userId string, req *models.RegisterDeviceRequest) (*models.DeviceToken, error)
+	Unregister(ctx context.Context, userId, token string) error
+	GetByUser(ctx context.Context, userId string) (*models.GetDeviceTokensResponse, error)
+	Prune(ctx context.Context, tokens []string) error
+}
+
+type IWebhookStorage interface {
+	CreateSubscription(ctx context.Context, req *models.CreateWebhookSubscription) (*models.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id string) (*models.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context, page, limit uint64) (*models.GetAllWebhookSubscriptionsResponse, error)
+	UpdateSubscription(ctx context.Context, id string, req *models.UpdateWebhookSubscription) (*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id string) error
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, d *models.WebhookDelivery, nextAttemptAt time.Time) error
+	Deliveries(ctx context.Context, req *models.GetWebhookDeliveriesRequest) (*models.GetWebhookDeliveriesResponse, error)
+	Redeliver(ctx context.Context, id string) (*models.WebhookDelivery, error)
+}
+
+type IJobStorage interface {
+	Enqueue(ctx context.Context, job *models.EnqueueJob) (string, error)
+	Claim(ctx context.Context, types []string, lease time.Duration) (*models.Job, error)
+	Complete(ctx context.Context, job *models.Job) error
+	Retry(ctx context.Context, job *models.Job, lastError string, runAt time.Time) error
+	Fail(ctx context.Context, job *models.Job, lastError string) error
+	Requeue(ctx context.Context, id string) (*models.Job, error)
+	GetByID(ctx context.Context, id string) (*models.Job, error)
+	GetAll(ctx context.Context, req *models.GetAllJobsRequest) (*models.GetAllJobsResponse, error)
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
 type IRedisStorage interface {
 	SetX(ctx context.Context, key string, value interface{}, duration time.Duration) error
 	Get(ctx context.Context, key string) (interface{}, error)